@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"github.com/zhufuyi/sponge/pkg/app"
+	"github.com/zhufuyi/sponge/pkg/gocron"
 	"github.com/zhufuyi/sponge/pkg/tracer"
 
+	"weaving_net/internal/cache"
 	"weaving_net/internal/config"
 	"weaving_net/internal/model"
 )
@@ -20,13 +22,26 @@ func Close(servers []app.IServer) []app.Close {
 		closes = append(closes, s.Stop)
 	}
 
+	// close scheduled tasks
+	if isTasksRunning {
+		closes = append(closes, func() error {
+			gocron.Stop()
+			return nil
+		})
+	}
+
 	// close database
 	closes = append(closes, func() error {
 		return model.CloseDB()
 	})
 
 	// close redis
-	if config.Get().App.CacheType == "redis" {
+	if config.Get().App.CacheType == "tiered" {
+		closes = append(closes, func() error {
+			return cache.CloseInvalidation()
+		})
+	}
+	if config.Get().App.CacheType == "redis" || config.Get().App.CacheType == "tiered" {
 		closes = append(closes, func() error {
 			return model.CloseRedis()
 		})
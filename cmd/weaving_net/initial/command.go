@@ -0,0 +1,88 @@
+package initial
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/config"
+	"weaving_net/internal/model"
+	"weaving_net/internal/task"
+)
+
+// the timeout of a command
+const commandTimeout = 10 * time.Minute
+
+const commandUsage = `usage: weaving_net [-c config] <command> [options]
+
+commands:
+  cache warmup [-n 100]           preload the n most viewed profiles and the records of the users to the cache
+  cache verify [-n 100] [-repair] compare n random cached values of each table with database,
+                                  and delete the divergent ones from the cache if -repair is set
+`
+
+// IsCommand whether the arguments after the flags are a command, the servers are not started for a command,
+// it must be called after the flags are parsed by InitApp.
+func IsCommand() bool {
+	return flag.NArg() > 0
+}
+
+// RunCommand run the command in the arguments after the flags instead of the servers, the result is printed
+// as JSON, the resources are released after it finishes, the exit code of the process is returned.
+func RunCommand() int {
+	defer closeCommand()
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	result, err := runCommand(ctx, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+func runCommand(ctx context.Context, args []string) (interface{}, error) {
+	if len(args) < 2 || args[0] != "cache" {
+		return nil, fmt.Errorf("unknown command %q\n%s", args, commandUsage)
+	}
+
+	fs := flag.NewFlagSet("cache "+args[1], flag.ContinueOnError)
+	n := fs.Int("n", 0, "the number of the profiles to warm up or the cached values of each table to verify, default is 100")
+	repair := fs.Bool("repair", false, "delete the divergent cached values, only for verify")
+	err := fs.Parse(args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := task.NewCacheJobs()
+	switch args[1] {
+	case "warmup":
+		return jobs.Warmup(ctx, *n)
+	case "verify":
+		return jobs.Verify(ctx, *n, *repair), nil
+	}
+	return nil, fmt.Errorf("unknown command %q\n%s", args, commandUsage)
+}
+
+// release the database and the redis of a command
+func closeCommand() {
+	_ = model.CloseDB()
+	if config.Get().App.CacheType == "tiered" {
+		_ = cache.CloseInvalidation()
+	}
+	if config.Get().App.CacheType == "redis" || config.Get().App.CacheType == "tiered" {
+		_ = model.CloseRedis()
+	}
+}
@@ -7,24 +7,31 @@ import (
 	"flag"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/jinzhu/copier"
 
 	"github.com/zhufuyi/sponge/pkg/conf"
+	"github.com/zhufuyi/sponge/pkg/gocron"
 	"github.com/zhufuyi/sponge/pkg/logger"
 	"github.com/zhufuyi/sponge/pkg/nacoscli"
 	"github.com/zhufuyi/sponge/pkg/stat"
 	"github.com/zhufuyi/sponge/pkg/tracer"
 
 	"weaving_net/configs"
+	"weaving_net/internal/cache"
 	"weaving_net/internal/config"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/handler"
 	"weaving_net/internal/model"
+	"weaving_net/internal/task"
 )
 
 var (
 	version            string
 	configFile         string
 	enableConfigCenter bool
+	isTasksRunning     bool // whether the scheduled tasks are started, they are stopped in Close
 )
 
 // InitApp initial app configuration
@@ -48,13 +55,68 @@ func InitApp() {
 	if err != nil {
 		panic(err)
 	}
-	logger.Debug(config.Show())
+	logger.Debug(config.Show(`"secret"`))
 	logger.Info("init logger succeeded")
 
 	// initializing database
 	model.InitDB()
 	logger.Infof("init %s succeeded", cfg.Database.Driver)
 	model.InitCache(cfg.App.CacheType)
+	cache.SetOptions(&cfg.Cache)
+	err = handler.SetCursorSecret(cfg.Cursor.Secret)
+	if err != nil {
+		panic(err)
+	}
+	if model.IsMongodb() {
+		dao.SetRevisionDB(model.GetDB())
+	}
+	var outboxPublisher dao.OutboxPublisher
+	if cfg.Outbox.Enable && !model.IsMongodb() {
+		outboxPublisher = dao.NewRedisOutboxPublisher(model.GetRedisCli(), cfg.Outbox.Channel)
+		dao.SetOutboxPublisher(outboxPublisher)
+	}
+
+	// a command does not start the servers, the tasks and the statistics, see RunCommand
+	if IsCommand() {
+		return
+	}
+
+	// migrating the schema and the data that the gorm tags can not express
+	err = model.Migrate(model.GetDB())
+	if err != nil {
+		panic(err)
+	}
+	logger.Info("migrate database succeeded")
+	task.RunMigrateProficiency(10 * time.Minute)
+
+	// initializing scheduled tasks
+	tasks := []*gocron.Task{}
+	if cfg.Trash.RetentionDays > 0 {
+		tasks = append(tasks, task.NewPurgeTrashTask(cfg.Trash.PurgeSpec, cfg.Trash.RetentionDays))
+	}
+	if cfg.SkillCatalog.NormalizeSpec != "" {
+		tasks = append(tasks, task.NewNormalizeSkillsTask(cfg.SkillCatalog.NormalizeSpec))
+	}
+	if outboxPublisher != nil {
+		tasks = append(tasks, task.NewOutboxRelayTask(cfg.Outbox.RelaySpec, cfg.Outbox.RetentionDays, outboxPublisher))
+	}
+	if len(tasks) > 0 {
+		err = gocron.Init(gocron.WithLog(logger.Get()))
+		if err != nil {
+			panic(err)
+		}
+		err = gocron.Run(tasks...)
+		if err != nil {
+			panic(err)
+		}
+		isTasksRunning = true
+		logger.Info("init scheduled tasks succeeded")
+	}
+
+	// warming up the cache
+	if cfg.Cache.WarmupSize > 0 {
+		task.RunWarmup(cfg.Cache.WarmupSize, time.Minute)
+	}
 
 	// initializing tracing
 	if cfg.App.EnableTrace {
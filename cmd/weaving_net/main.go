@@ -2,6 +2,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/zhufuyi/sponge/pkg/app"
 
 	"weaving_net/cmd/weaving_net/initial"
@@ -18,6 +20,10 @@ import (
 // @description Type Bearer your-jwt-token to Value
 func main() {
 	initial.InitApp()
+	if initial.IsCommand() {
+		os.Exit(initial.RunCommand())
+	}
+
 	services := initial.CreateServices()
 	closes := initial.Close(services)
 
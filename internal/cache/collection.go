@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"weaving_net/internal/model"
+)
+
+// CollectionCache cache interface of all the records of each user of a table, the records of a user are cached
+// as one value, so it must be deleted when any record of the user is changed.
+type CollectionCache[T any] interface {
+	SetCollection(ctx context.Context, userID int, data []*T, duration time.Duration) error
+	GetCollection(ctx context.Context, userID int) ([]*T, error)
+	GetCollectionStale(ctx context.Context, userID int) ([]*T, bool, error)
+	DelCollections(ctx context.Context, userIDs ...int) error
+	LockCollection(ctx context.Context, userID int) (func(), error)
+	SampleCollections(ctx context.Context, n int) ([]int, error)
+}
+
+// UserEntityCache cache interface of a table with user id, the records are cached by id and by user
+type UserEntityCache[T any] interface {
+	EntityCache[T]
+	CollectionCache[T]
+}
+
+// userEntityCache define a cache struct of the records of a table with user id, the records of the users
+// share the cache and the options of the records.
+type userEntityCache[T any] struct {
+	*entityCache[T]
+}
+
+// NewUserEntityCache new a cache of the records of the table name by id and by user, see NewEntityCache,
+// if the cache type is not memory, redis or tiered, nil is returned, i.e. the cache is not used.
+func NewUserEntityCache[T any](cacheType *model.CacheType, name string, id func(data *T) uint64) UserEntityCache[T] {
+	c, ok := NewEntityCache(cacheType, name, id).(*entityCache[T])
+	if !ok {
+		return nil // no cache
+	}
+	return &userEntityCache[T]{entityCache: c}
+}
+
+// GetCollectionKey cache key of the records of a user
+func (c *userEntityCache[T]) GetCollectionKey(userID int) string {
+	return c.opts.KeyPrefix + "user:" + strconv.Itoa(userID)
+}
+
+// SetCollection write the records of a user to cache, an empty slice is cached too, if duration is 0, the
+// records expire after the expiration time of the options, and they are kept for the stale time of the options.
+func (c *userEntityCache[T]) SetCollection(ctx context.Context, userID int, data []*T, duration time.Duration) error {
+	if data == nil {
+		data = []*T{}
+	}
+	cacheKey := c.GetCollectionKey(userID)
+	err := setEntry(ctx, c.cache, &c.opts, cacheKey, data, c.expireTime(duration))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCollection get the records of a user from cache, the stale records are returned too
+func (c *userEntityCache[T]) GetCollection(ctx context.Context, userID int) ([]*T, error) {
+	data, _, err := c.GetCollectionStale(ctx, userID)
+	return data, err
+}
+
+// GetCollectionStale get the records of a user from cache and whether they are stale
+func (c *userEntityCache[T]) GetCollectionStale(ctx context.Context, userID int) ([]*T, bool, error) {
+	cacheKey := c.GetCollectionKey(userID)
+	data, stale, err := getEntry[[]*T](ctx, c.cache, &c.opts, cacheKey)
+	if err != nil {
+		return nil, false, err
+	}
+	if data == nil {
+		data = []*T{}
+	}
+	return data, stale, nil
+}
+
+// DelCollections delete the records of the users from cache
+func (c *userEntityCache[T]) DelCollections(ctx context.Context, userIDs ...int) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, c.GetCollectionKey(userID))
+	}
+	err := c.cache.Del(ctx, keys...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LockCollection lock the records of a user in redis so that only one instance reloads them, see Lock
+func (c *userEntityCache[T]) LockCollection(ctx context.Context, userID int) (func(), error) {
+	return lock(ctx, c.rdb, &c.opts, c.GetCollectionKey(userID))
+}
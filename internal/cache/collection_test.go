@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/model"
+)
+
+func Test_userEntityCache_Collection(t *testing.T) {
+	record := &model.Skills{UserID: 1, SkillName: "go"}
+	record.ID = 1
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(record.ID): record})
+	defer c.Close()
+	skillsCache := NewSkillsCache(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+
+	err := skillsCache.SetCollection(c.Ctx, 1, []*model.Skills{record}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, c.RedisClient.TTL(c.Ctx, "skills:user:1").Val())
+	got, err := skillsCache.GetCollection(c.Ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, record.SkillName, got[0].SkillName)
+
+	// the empty records are cached too
+	err = skillsCache.SetCollection(c.Ctx, 2, nil, time.Minute)
+	assert.NoError(t, err)
+	got, err = skillsCache.GetCollection(c.Ctx, 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+	assert.Empty(t, got)
+
+	// the records of a user do not conflict with the record of the same id
+	_, err = skillsCache.Get(c.Ctx, 1)
+	assert.ErrorIs(t, err, model.ErrCacheNotFound)
+
+	err = skillsCache.DelCollections(c.Ctx, 1, 2)
+	assert.NoError(t, err)
+	for _, userID := range []int{1, 2} {
+		_, err = skillsCache.GetCollection(c.Ctx, userID)
+		assert.ErrorIs(t, err, model.ErrCacheNotFound)
+	}
+	assert.NoError(t, skillsCache.DelCollections(c.Ctx))
+}
+
+func TestNewUserEntityCache(t *testing.T) {
+	skillsCache := NewSkillsCache(&model.CacheType{CType: "memory"})
+	assert.NotNil(t, skillsCache)
+	err := skillsCache.SetCollection(context.Background(), 1, []*model.Skills{}, 0)
+	assert.NoError(t, err)
+
+	// no cache
+	assert.Nil(t, NewSkillsCache(&model.CacheType{}))
+}
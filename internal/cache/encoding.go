@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"github.com/golang/snappy"
+	"github.com/vmihailenco/msgpack"
+
+	"github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/encoding"
+)
+
+// the encodings of the cached records
+const (
+	EncodingJSON          = "json"
+	EncodingMsgPack       = "msgpack"
+	EncodingMsgPackSnappy = "msgpackSnappy"
+)
+
+// NewEncoding new an encoding by name, json is the default, msgpack is smaller and faster, and msgpackSnappy
+// compresses msgpack by snappy for the large records.
+func NewEncoding(name string) encoding.Encoding {
+	switch name {
+	case EncodingMsgPack:
+		return encoding.MsgPackEncoding{}
+	case EncodingMsgPackSnappy:
+		return MsgPackSnappyEncoding{}
+	}
+	return encoding.JSONEncoding{}
+}
+
+// MsgPackSnappyEncoding msgpack format and snappy compression
+type MsgPackSnappyEncoding struct{}
+
+// Marshal serialization
+func (s MsgPackSnappyEncoding) Marshal(v interface{}) ([]byte, error) {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, b), nil
+}
+
+// Unmarshal deserialization
+func (s MsgPackSnappyEncoding) Unmarshal(data []byte, value interface{}) error {
+	b, err := snappy.Decode(nil, data)
+	if err != nil {
+		return err
+	}
+	return msgpack.Unmarshal(b, value)
+}
+
+// notFound the value of the placeholder of a not found record, it is set with the expiration time of the
+// placeholders of the table instead of the global cache.DefaultNotFoundExpireTime.
+type notFound struct{}
+
+// placeholderEncoding marshal notFound to the placeholder that the caches recognize, the others are marshaled
+// by the encoding.
+type placeholderEncoding struct {
+	encoding.Encoding
+}
+
+func (e placeholderEncoding) Marshal(v interface{}) ([]byte, error) {
+	if _, ok := v.(*notFound); ok {
+		return []byte(cache.NotFoundPlaceholder), nil
+	}
+	return e.Encoding.Marshal(v)
+}
@@ -0,0 +1,261 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/config"
+	"weaving_net/internal/model"
+)
+
+// EntityCache cache interface of the records of a table, T is the model of the table, e.g. model.Users
+type EntityCache[T any] interface {
+	Set(ctx context.Context, id uint64, data *T, duration time.Duration) error
+	Get(ctx context.Context, id uint64) (*T, error)
+	GetStale(ctx context.Context, id uint64) (*T, bool, error)
+	MultiGet(ctx context.Context, ids []uint64) (map[uint64]*T, error)
+	MultiGetStale(ctx context.Context, ids []uint64) (map[uint64]*T, []uint64, error)
+	MultiSet(ctx context.Context, data []*T, duration time.Duration) error
+	Del(ctx context.Context, id uint64) error
+	SetCacheWithNotFound(ctx context.Context, id uint64) error
+	Lock(ctx context.Context, id uint64) (func(), error)
+	Sample(ctx context.Context, n int) ([]uint64, error)
+}
+
+// Options the settings of the cache of a table
+type Options struct {
+	KeyPrefix          string        // prefix of the cache keys, must end with a colon
+	ExpireTime         time.Duration // expiration time of the records if the duration of Set is 0
+	NotFoundExpireTime time.Duration // expiration time of the placeholders of the not found records
+	LocalExpireTime    time.Duration // expiration time of the records in the local cache of the tiered cache
+	Encoding           string        // encoding of the records, see NewEncoding
+
+	// StaleTime the expired records are kept for the stale time, they are still returned while one request
+	// reloads them (stale-while-revalidate), 0 means the records are deleted when they expire.
+	StaleTime time.Duration
+	// LockTime the expiration time of the lock in redis that only one instance reloads a record while the
+	// others wait for it or return the stale record, 0 means the lock is not used.
+	LockTime time.Duration
+}
+
+var (
+	// the options of the tables that are not set by SetOptions
+	defaultOptions = Options{
+		ExpireTime:         5 * time.Minute,
+		NotFoundExpireTime: cache.DefaultNotFoundExpireTime,
+		LocalExpireTime:    time.Minute,
+		Encoding:           EncodingJSON,
+	}
+	// the options of each table, the key is the table name
+	entityOptions = map[string]Options{}
+)
+
+// SetOptions set the options of the caches from the configuration, the zero settings of a table are the default
+// settings, it must be called before the caches and the views are created.
+func SetOptions(cfg *config.Cache) {
+	defaultOptions = mergeOptions(defaultOptions, cfg.Default)
+	entityOptions = map[string]Options{}
+	for name, entity := range cfg.Entities {
+		entityOptions[name] = mergeOptions(defaultOptions, entity)
+	}
+	viewSampleRate = math.Min(cfg.ViewSampleRate, 1)
+}
+
+func mergeOptions(opts Options, cfg config.EntityCache) Options {
+	if cfg.KeyPrefix != "" {
+		opts.KeyPrefix = cfg.KeyPrefix
+	}
+	if cfg.ExpireTime > 0 {
+		opts.ExpireTime = time.Duration(cfg.ExpireTime) * time.Second
+	}
+	if cfg.NotFoundExpireTime > 0 {
+		opts.NotFoundExpireTime = time.Duration(cfg.NotFoundExpireTime) * time.Second
+	}
+	if cfg.LocalExpireTime > 0 {
+		opts.LocalExpireTime = time.Duration(cfg.LocalExpireTime) * time.Second
+	}
+	if cfg.Encoding != "" {
+		opts.Encoding = cfg.Encoding
+	}
+	if cfg.StaleTime > 0 {
+		opts.StaleTime = time.Duration(cfg.StaleTime) * time.Second
+	}
+	if cfg.LockTime > 0 {
+		opts.LockTime = time.Duration(cfg.LockTime) * time.Second
+	}
+	return opts
+}
+
+// GetOptions get the options of the cache of a table, the key prefix is the table name with a colon by default
+func GetOptions(name string) Options {
+	opts, ok := entityOptions[name]
+	if !ok {
+		opts = defaultOptions
+	}
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = name + ":"
+	}
+	return opts
+}
+
+// entityCache define a cache struct of the records of a table
+type entityCache[T any] struct {
+	cache cache.Cache
+	rdb   *redis.Client // the redis of the locks, nil if the cache is in memory
+	opts  Options
+	id    func(data *T) uint64
+}
+
+// NewEntityCache new a cache of the records of the table name with its options, id is the id of a record,
+// if the cache type is not memory, redis or tiered, nil is returned, i.e. the cache is not used.
+func NewEntityCache[T any](cacheType *model.CacheType, name string, id func(data *T) uint64) EntityCache[T] {
+	opts := GetOptions(name)
+	enc := placeholderEncoding{NewEncoding(opts.Encoding)}
+	cachePrefix := ""
+	newObject := func() interface{} { return new(entry[*T]) }
+
+	cType := strings.ToLower(cacheType.CType)
+	switch cType {
+	case "redis":
+		c := cache.NewRedisCache(cacheType.Rdb, cachePrefix, enc, newObject)
+		return &entityCache[T]{cache: c, rdb: cacheType.Rdb, opts: opts, id: id}
+	case "memory":
+		c := cache.NewMemoryCache(cachePrefix, enc, newObject)
+		return &entityCache[T]{cache: c, opts: opts, id: id}
+	case "tiered":
+		c := newTieredCache(name, cacheType.Rdb, enc, opts.LocalExpireTime, newObject)
+		return &entityCache[T]{cache: c, rdb: cacheType.Rdb, opts: opts, id: id}
+	}
+
+	return nil // no cache
+}
+
+// GetCacheKey cache key
+func (c *entityCache[T]) GetCacheKey(id uint64) string {
+	return c.opts.KeyPrefix + utils.Uint64ToStr(id)
+}
+
+func (c *entityCache[T]) expireTime(duration time.Duration) time.Duration {
+	if duration == 0 {
+		return c.opts.ExpireTime
+	}
+	return duration
+}
+
+// Set write to cache, if duration is 0, the record expires after the expiration time of the options,
+// and it is kept for the stale time of the options after it expires.
+func (c *entityCache[T]) Set(ctx context.Context, id uint64, data *T, duration time.Duration) error {
+	if data == nil || id == 0 {
+		return nil
+	}
+	cacheKey := c.GetCacheKey(id)
+	err := setEntry(ctx, c.cache, &c.opts, cacheKey, data, c.expireTime(duration))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get cache value, the stale record is returned too
+func (c *entityCache[T]) Get(ctx context.Context, id uint64) (*T, error) {
+	data, _, err := c.GetStale(ctx, id)
+	return data, err
+}
+
+// GetStale get cache value and whether it is stale, i.e. it has expired and should be reloaded
+func (c *entityCache[T]) GetStale(ctx context.Context, id uint64) (*T, bool, error) {
+	cacheKey := c.GetCacheKey(id)
+	data, stale, err := getEntry[*T](ctx, c.cache, &c.opts, cacheKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, stale, nil
+}
+
+// MultiSet multiple set cache, if duration is 0, the records expire after the expiration time of the options
+func (c *entityCache[T]) MultiSet(ctx context.Context, data []*T, duration time.Duration) error {
+	expiration := c.expireTime(duration)
+	expireAt := time.Now().Add(expiration).UnixMilli()
+	valMap := make(map[string]interface{})
+	for _, v := range data {
+		cacheKey := c.GetCacheKey(c.id(v))
+		valMap[cacheKey] = &entry[*T]{Value: v, ExpireAt: expireAt}
+	}
+
+	err := c.cache.MultiSet(ctx, valMap, expiration+c.opts.StaleTime)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MultiGet multiple get cache, return key in map is id value, the stale records are returned too
+func (c *entityCache[T]) MultiGet(ctx context.Context, ids []uint64) (map[uint64]*T, error) {
+	retMap, _, err := c.MultiGetStale(ctx, ids)
+	return retMap, err
+}
+
+// MultiGetStale multiple get cache and the ids of the stale records, i.e. the records that have expired
+// and should be reloaded, see GetStale.
+func (c *entityCache[T]) MultiGetStale(ctx context.Context, ids []uint64) (map[uint64]*T, []uint64, error) {
+	var keys []string
+	for _, v := range ids {
+		cacheKey := c.GetCacheKey(v)
+		keys = append(keys, cacheKey)
+	}
+
+	itemMap := make(map[string]*entry[*T])
+	err := c.cache.MultiGet(ctx, keys, itemMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retMap := make(map[uint64]*T)
+	var staleIDs []uint64
+	for _, id := range ids {
+		val, ok := itemMap[c.GetCacheKey(id)]
+		if ok && val.ExpireAt != 0 && val.Value != nil {
+			retMap[id] = val.Value
+			if val.isStale(&c.opts) {
+				staleIDs = append(staleIDs, id)
+			}
+		}
+	}
+
+	return retMap, staleIDs, nil
+}
+
+// Del delete cache
+func (c *entityCache[T]) Del(ctx context.Context, id uint64) error {
+	cacheKey := c.GetCacheKey(id)
+	err := c.cache.Del(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetCacheWithNotFound set empty cache, it expires after the not found expiration time of the options
+func (c *entityCache[T]) SetCacheWithNotFound(ctx context.Context, id uint64) error {
+	cacheKey := c.GetCacheKey(id)
+	err := c.cache.Set(ctx, cacheKey, &notFound{}, c.opts.NotFoundExpireTime)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Lock lock the record in redis so that only one instance reloads it, model.ErrCacheLocked is returned if
+// another instance holds the lock, the returned function unlocks it. the lock is always got if the lock
+// time of the options is 0.
+func (c *entityCache[T]) Lock(ctx context.Context, id uint64) (func(), error) {
+	return lock(ctx, c.rdb, &c.opts, c.GetCacheKey(id))
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/config"
+	"weaving_net/internal/model"
+)
+
+func TestSetOptions(t *testing.T) {
+	defer func(opts Options, entities map[string]Options) {
+		defaultOptions, entityOptions = opts, entities
+	}(defaultOptions, entityOptions)
+
+	SetOptions(&config.Cache{
+		Default: config.EntityCache{ExpireTime: 60},
+		Entities: map[string]config.EntityCache{
+			"skills": {KeyPrefix: "s:", NotFoundExpireTime: 30, Encoding: EncodingMsgPackSnappy, StaleTime: 600, LockTime: 5},
+		},
+	})
+
+	assert.Equal(t, Options{
+		KeyPrefix:          "users:",
+		ExpireTime:         time.Minute,
+		NotFoundExpireTime: cache.DefaultNotFoundExpireTime,
+		LocalExpireTime:    time.Minute,
+		Encoding:           EncodingJSON,
+	}, GetOptions("users"))
+	assert.Equal(t, Options{
+		KeyPrefix:          "s:",
+		ExpireTime:         time.Minute,
+		NotFoundExpireTime: 30 * time.Second,
+		LocalExpireTime:    time.Minute,
+		Encoding:           EncodingMsgPackSnappy,
+		StaleTime:          10 * time.Minute,
+		LockTime:           5 * time.Second,
+	}, GetOptions("skills"))
+}
+
+func TestNewEncoding(t *testing.T) {
+	record := &model.Skills{SkillName: "go", Proficiency: 3}
+	record.ID = 1
+	record.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+
+	for _, name := range []string{EncodingJSON, EncodingMsgPack, EncodingMsgPackSnappy} {
+		enc := NewEncoding(name)
+		data, err := enc.Marshal(record)
+		assert.NoError(t, err, name)
+		got := &model.Skills{}
+		err = enc.Unmarshal(data, got)
+		assert.NoError(t, err, name)
+		assert.Equal(t, record.SkillName, got.SkillName, name)
+		assert.True(t, record.CreatedAt.Equal(got.CreatedAt), name)
+	}
+}
+
+func TestEntityCache_options(t *testing.T) {
+	defer func(entities map[string]Options) { entityOptions = entities }(entityOptions)
+	entityOptions = map[string]Options{
+		"skills": {
+			KeyPrefix:          "skill:",
+			ExpireTime:         time.Minute,
+			NotFoundExpireTime: 30 * time.Second,
+			Encoding:           EncodingMsgPackSnappy,
+		},
+	}
+
+	record := &model.Skills{SkillName: "go"}
+	record.ID = 1
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(record.ID): record})
+	defer c.Close()
+	skillsCache := NewSkillsCache(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+
+	// the records expire after the expiration time of the options if the duration is 0
+	err := skillsCache.Set(c.Ctx, record.ID, record, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, c.RedisClient.TTL(c.Ctx, "skill:1").Val())
+	got, err := skillsCache.Get(c.Ctx, record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, record.SkillName, got.SkillName)
+
+	// the placeholders expire after the not found expiration time of the options
+	err = skillsCache.SetCacheWithNotFound(c.Ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, c.RedisClient.TTL(c.Ctx, "skill:2").Val())
+	_, err = skillsCache.Get(c.Ctx, 2)
+	assert.ErrorIs(t, err, cache.ErrPlaceholder)
+}
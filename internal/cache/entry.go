@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/krand"
+
+	"weaving_net/internal/model"
+)
+
+// entry the cached value with the time it expires, the value is kept in the cache for the stale time of
+// the options after it expires, so that the stale value can be returned while it is reloaded.
+type entry[V any] struct {
+	Value    V     `json:"value"`
+	ExpireAt int64 `json:"expireAt"` // unix milliseconds
+}
+
+// set the value with its expiration time to the cache, it is kept for the stale time after it expires
+func setEntry[V any](ctx context.Context, c cache.Cache, opts *Options, key string, value V, expiration time.Duration) error {
+	e := &entry[V]{Value: value, ExpireAt: time.Now().Add(expiration).UnixMilli()}
+	return c.Set(ctx, key, e, expiration+opts.StaleTime)
+}
+
+// get the value from the cache and whether it is stale, the value is never stale if the stale time of the
+// options is 0, the entries of the old format without the expiration time are treated as not found.
+func getEntry[V any](ctx context.Context, c cache.Cache, opts *Options, key string) (V, bool, error) {
+	var e *entry[V]
+	err := c.Get(ctx, key, &e)
+	if err != nil {
+		var zero V
+		return zero, false, err
+	}
+	if e == nil || e.ExpireAt == 0 {
+		var zero V
+		return zero, false, model.ErrCacheNotFound
+	}
+	return e.Value, e.isStale(opts), nil
+}
+
+// isStale whether the value has expired and should be reloaded, it is never stale if the stale time is 0
+func (e *entry[V]) isStale(opts *Options) bool {
+	return opts.StaleTime > 0 && time.Now().UnixMilli() >= e.ExpireAt
+}
+
+// unlock a lock only if it is still held by the token, it may have expired and been taken by another instance
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// lock the key in redis for the lock time of the options, so that only one instance loads the value of the
+// key, model.ErrCacheLocked is returned if another instance holds the lock. if there is no redis or the lock
+// time is 0, the lock is always got, the loads of the same key in one instance are merged by singleflight.
+func lock(ctx context.Context, rdb *redis.Client, opts *Options, key string) (func(), error) {
+	if rdb == nil || opts.LockTime <= 0 {
+		return func() {}, nil
+	}
+
+	lockKey := key + ":lock"
+	token := krand.String(krand.R_All, 16)
+	ok, err := rdb.SetNX(ctx, lockKey, token, opts.LockTime).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, model.ErrCacheLocked
+	}
+	return func() {
+		_ = unlockScript.Run(context.Background(), rdb, []string{lockKey}, token).Err()
+	}, nil
+}
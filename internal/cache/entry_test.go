@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/model"
+)
+
+func newStaleUsersCache(opts Options) (*gotest.Cache, UsersCache) {
+	entityOptions = map[string]Options{"users": opts}
+	record := &model.Users{FirstName: "foo"}
+	record.ID = 1
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(record.ID): record})
+	return c, NewUsersCache(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+}
+
+func Test_entityCache_GetStale(t *testing.T) {
+	defer func(entities map[string]Options) { entityOptions = entities }(entityOptions)
+	c, usersCache := newStaleUsersCache(Options{ExpireTime: time.Minute, StaleTime: time.Hour})
+	defer c.Close()
+	record := c.TestDataSlice[0].(*model.Users)
+
+	// the record is kept for the stale time after it expires
+	err := usersCache.Set(c.Ctx, record.ID, record, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour+time.Millisecond, c.RedisClient.PTTL(c.Ctx, "users:1").Val())
+	time.Sleep(2 * time.Millisecond)
+	got, stale, err := usersCache.GetStale(c.Ctx, record.ID)
+	assert.NoError(t, err)
+	assert.True(t, stale)
+	assert.Equal(t, record.FirstName, got.FirstName)
+
+	err = usersCache.Set(c.Ctx, record.ID, record, 0)
+	assert.NoError(t, err)
+	_, stale, err = usersCache.GetStale(c.Ctx, record.ID)
+	assert.NoError(t, err)
+	assert.False(t, stale)
+
+	// the stale records are got by MultiGet too
+	err = usersCache.MultiSet(c.Ctx, []*model.Users{record}, time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	items, err := usersCache.MultiGet(c.Ctx, []uint64{record.ID})
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	items, staleIDs, err := usersCache.MultiGetStale(c.Ctx, []uint64{record.ID, 2})
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, []uint64{record.ID}, staleIDs)
+
+	// the record of the old format is not found
+	c.RedisClient.Set(c.Ctx, "users:2", `{"id":2}`, time.Minute)
+	_, err = usersCache.Get(c.Ctx, 2)
+	assert.ErrorIs(t, err, model.ErrCacheNotFound)
+}
+
+func Test_entityCache_Lock(t *testing.T) {
+	defer func(entities map[string]Options) { entityOptions = entities }(entityOptions)
+	c, usersCache := newStaleUsersCache(Options{ExpireTime: time.Minute, LockTime: 10 * time.Second})
+	defer c.Close()
+
+	unlock, err := usersCache.Lock(c.Ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, c.RedisClient.TTL(c.Ctx, "users:1:lock").Val())
+
+	// another instance can not get the lock until it is unlocked
+	_, err = usersCache.Lock(c.Ctx, 1)
+	assert.ErrorIs(t, err, model.ErrCacheLocked)
+	unlock()
+	unlock2, err := usersCache.Lock(c.Ctx, 1)
+	assert.NoError(t, err)
+
+	// the expired lock taken by another instance is not unlocked
+	c.RedisClient.Set(c.Ctx, "users:1:lock", "other", time.Minute)
+	unlock2()
+	assert.Equal(t, "other", c.RedisClient.Get(c.Ctx, "users:1:lock").Val())
+
+	// the lock is not used if the lock time is 0
+	entityOptions = map[string]Options{}
+	usersCache = NewUsersCache(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+	for i := 0; i < 2; i++ {
+		_, err = usersCache.Lock(c.Ctx, 2)
+		assert.NoError(t, err)
+	}
+}
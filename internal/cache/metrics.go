@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// the hits of the reads of each tier of the tiered caches, the not found placeholders are hits too
+	cacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "the number of the cache hits of each table and tier",
+	}, []string{"cache", "tier"})
+
+	// the misses of the reads of each tier of the tiered caches
+	cacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "the number of the cache misses of each table and tier",
+	}, []string{"cache", "tier"})
+)
+
+func init() {
+	// the default registry is exposed by the metrics middleware
+	prometheus.MustRegister(cacheHits, cacheMisses)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrSampleNotSupported the cached keys can only be sampled in redis
+var ErrSampleNotSupported = errors.New("sampling the cache is only supported by redis")
+
+// Sample get the ids of n random cached records, the not found placeholders are included, all the keys of
+// the table are scanned, so it is only used by the tools, e.g. the verification of the cache.
+func (c *entityCache[T]) Sample(ctx context.Context, n int) ([]uint64, error) {
+	suffixes, err := sampleKeys(ctx, c.rdb, c.opts.KeyPrefix, n, func(suffix string) bool {
+		_, err := strconv.ParseUint(suffix, 10, 64)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		id, _ := strconv.ParseUint(suffix, 10, 64)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SampleCollections get the users of n random cached records of the users, see Sample
+func (c *userEntityCache[T]) SampleCollections(ctx context.Context, n int) ([]int, error) {
+	suffixes, err := sampleKeys(ctx, c.rdb, c.opts.KeyPrefix+"user:", n, func(suffix string) bool {
+		_, err := strconv.Atoi(suffix)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]int, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		userID, _ := strconv.Atoi(suffix)
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+// scan the keys with the prefix and keep n random ones of them by reservoir sampling, the suffixes of the
+// sampled keys after the prefix are returned, the keys whose suffixes are not valid are skipped, e.g. the locks.
+func sampleKeys(ctx context.Context, rdb *redis.Client, prefix string, n int, valid func(suffix string) bool) ([]string, error) {
+	if rdb == nil {
+		return nil, ErrSampleNotSupported
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	samples := make([]string, 0, n)
+	seen := 0
+	iter := rdb.Scan(ctx, 0, prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		suffix := strings.TrimPrefix(iter.Val(), prefix)
+		if !valid(suffix) {
+			continue
+		}
+		seen++
+		if len(samples) < n {
+			samples = append(samples, suffix)
+			continue
+		}
+		// the key replaces a random sample with the probability n/seen
+		if i := rand.Intn(seen); i < n { //nolint
+			samples[i] = suffix
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/config"
+	"weaving_net/internal/model"
+)
+
+func Test_entityCache_Sample(t *testing.T) {
+	record := &model.Skills{UserID: 1}
+	record.ID = 1
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(record.ID): record})
+	defer c.Close()
+	skillsCache := NewSkillsCache(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+
+	for id := uint64(1); id <= 5; id++ {
+		assert.NoError(t, skillsCache.Set(c.Ctx, id, record, 0))
+	}
+	assert.NoError(t, skillsCache.SetCacheWithNotFound(c.Ctx, 6))
+	assert.NoError(t, skillsCache.SetCollection(c.Ctx, 1, []*model.Skills{record}, 0))
+	unlock, err := skillsCache.Lock(c.Ctx, 1)
+	assert.NoError(t, err)
+	defer unlock()
+
+	// the records of the users and the locks are not sampled
+	ids, err := skillsCache.Sample(c.Ctx, 10)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{1, 2, 3, 4, 5, 6}, ids)
+	ids, err = skillsCache.Sample(c.Ctx, 3)
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+	assert.Subset(t, []uint64{1, 2, 3, 4, 5, 6}, ids)
+	ids, err = skillsCache.Sample(c.Ctx, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	userIDs, err := skillsCache.SampleCollections(c.Ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, userIDs)
+
+	// only redis is sampled
+	memoryCache := NewSkillsCache(&model.CacheType{CType: "memory"})
+	_, err = memoryCache.Sample(c.Ctx, 10)
+	assert.ErrorIs(t, err, ErrSampleNotSupported)
+	_, err = memoryCache.SampleCollections(c.Ctx, 10)
+	assert.ErrorIs(t, err, ErrSampleNotSupported)
+}
+
+func TestNewViews(t *testing.T) {
+	defer func(entities map[string]Options, rate float64) {
+		entityOptions, viewSampleRate = entities, rate
+	}(entityOptions, viewSampleRate)
+	c := gotest.NewCache(map[string]interface{}{"1": &model.Users{}})
+	defer c.Close()
+
+	// the views are not counted by default
+	views := NewViews(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+	assert.NoError(t, views.Incr(c.Ctx, 1))
+	assert.Equal(t, int64(0), c.RedisClient.Exists(c.Ctx, ViewsKey).Val())
+
+	// a sampled view counts for 1/rate views
+	SetOptions(&config.Cache{ViewSampleRate: 0.5})
+	views = NewViews(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, views.Incr(c.Ctx, 4))
+	}
+	score := c.RedisClient.ZScore(c.Ctx, ViewsKey, "4").Val()
+	assert.True(t, score > 0 && int(score)%2 == 0, score)
+	c.RedisClient.Del(c.Ctx, ViewsKey)
+
+	SetOptions(&config.Cache{ViewSampleRate: 1})
+	views = NewViews(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+
+	for _, userID := range []uint64{1, 2, 2, 3, 3, 3} {
+		assert.NoError(t, views.Incr(c.Ctx, userID))
+	}
+	userIDs, err := views.Top(c.Ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 2}, userIDs)
+	userIDs, err = views.Top(c.Ctx, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, userIDs)
+
+	// the views are not counted without redis
+	views = NewViews(&model.CacheType{CType: "memory"})
+	assert.NoError(t, views.Incr(c.Ctx, 1))
+	userIDs, err = views.Top(c.Ctx, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, userIDs)
+}
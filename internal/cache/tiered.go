@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/encoding"
+	"github.com/zhufuyi/sponge/pkg/logger"
+)
+
+// InvalidationChannel the redis channel that the tiered caches of all the instances publish the deleted keys to,
+// the local caches of the other instances delete the keys when they receive them.
+const InvalidationChannel = "weaving_net:cache:invalidation"
+
+// the tiers of the tiered cache, the labels of the metrics
+const (
+	tierLocal = "local"
+	tierRedis = "redis"
+)
+
+var (
+	// the local cache shared by the tiered caches of all the tables, the keys are prefixed by the tables
+	localCache cache.Cache
+	localOnce  sync.Once
+
+	invalidation *redis.PubSub
+)
+
+// getLocalCache get the local cache, the first call subscribes the invalidation channel of rdb
+func getLocalCache(rdb *redis.Client) cache.Cache {
+	localOnce.Do(func() {
+		// the records are decoded by the reads, so the local cache can use the fast encoding of its own
+		localCache = cache.NewMemoryCache("", placeholderEncoding{encoding.MsgPackEncoding{}}, nil)
+		invalidation = rdb.Subscribe(context.Background(), InvalidationChannel)
+		go func(ch <-chan *redis.Message) {
+			for msg := range ch {
+				_ = localCache.Del(context.Background(), msg.Payload)
+			}
+		}(invalidation.Channel())
+	})
+	return localCache
+}
+
+// CloseInvalidation unsubscribe the invalidation channel, it is called before redis is closed
+func CloseInvalidation() error {
+	if invalidation == nil {
+		return nil
+	}
+	return invalidation.Close()
+}
+
+// tieredCache a local cache in front of redis, the reads get from the local cache first, then redis, the
+// records got from redis are written to the local cache with a shorter expiration time, the deletes are
+// published to the other instances so that they delete their local records too.
+type tieredCache struct {
+	name            string // the table name, the label of the metrics
+	local           cache.Cache
+	remote          cache.Cache
+	rdb             *redis.Client
+	localExpireTime time.Duration
+	newObject       func() interface{}
+}
+
+// newTieredCache new a tiered cache of a table
+func newTieredCache(name string, rdb *redis.Client, enc encoding.Encoding, localExpireTime time.Duration, newObject func() interface{}) cache.Cache {
+	return &tieredCache{
+		name:            name,
+		local:           getLocalCache(rdb),
+		remote:          cache.NewRedisCache(rdb, "", enc, newObject),
+		rdb:             rdb,
+		localExpireTime: localExpireTime,
+		newObject:       newObject,
+	}
+}
+
+// the expiration time of a local record is not longer than the local expiration time, because the
+// invalidation message may be lost
+func (c *tieredCache) localExpiration(expiration time.Duration) time.Duration {
+	if expiration <= 0 || expiration > c.localExpireTime {
+		return c.localExpireTime
+	}
+	return expiration
+}
+
+// Set write to redis and the local cache
+func (c *tieredCache) Set(ctx context.Context, key string, val interface{}, expiration time.Duration) error {
+	err := c.remote.Set(ctx, key, val, expiration)
+	if err != nil {
+		return err
+	}
+	_ = c.local.Set(ctx, key, val, c.localExpiration(expiration))
+	return nil
+}
+
+// Get get from the local cache, then redis
+func (c *tieredCache) Get(ctx context.Context, key string, val interface{}) error {
+	err := c.local.Get(ctx, key, val)
+	if err == nil || errors.Is(err, cache.ErrPlaceholder) {
+		cacheHits.WithLabelValues(c.name, tierLocal).Inc()
+		return err
+	}
+	cacheMisses.WithLabelValues(c.name, tierLocal).Inc()
+
+	err = c.remote.Get(ctx, key, val)
+	switch {
+	case err == nil:
+		cacheHits.WithLabelValues(c.name, tierRedis).Inc()
+		_ = c.local.Set(ctx, key, val, c.localExpireTime)
+	case errors.Is(err, cache.ErrPlaceholder):
+		cacheHits.WithLabelValues(c.name, tierRedis).Inc()
+		_ = c.local.Set(ctx, key, &notFound{}, c.localExpireTime)
+	case errors.Is(err, cache.CacheNotFound):
+		cacheMisses.WithLabelValues(c.name, tierRedis).Inc()
+	}
+	return err
+}
+
+// MultiSet multiple set to redis and the local cache
+func (c *tieredCache) MultiSet(ctx context.Context, valMap map[string]interface{}, expiration time.Duration) error {
+	err := c.remote.MultiSet(ctx, valMap, expiration)
+	if err != nil {
+		return err
+	}
+	_ = c.local.MultiSet(ctx, valMap, c.localExpiration(expiration))
+	return nil
+}
+
+// MultiGet multiple get from the local cache, then the missed keys from redis, the keys of the not found
+// placeholders are not in the value map.
+func (c *tieredCache) MultiGet(ctx context.Context, keys []string, value interface{}) error {
+	valueMap := reflect.ValueOf(value)
+	var missedKeys []string
+	for _, key := range keys {
+		object := c.newObject()
+		err := c.local.Get(ctx, key, object)
+		if err == nil {
+			cacheHits.WithLabelValues(c.name, tierLocal).Inc()
+			valueMap.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(object))
+			continue
+		}
+		if errors.Is(err, cache.ErrPlaceholder) {
+			cacheHits.WithLabelValues(c.name, tierLocal).Inc()
+			continue
+		}
+		cacheMisses.WithLabelValues(c.name, tierLocal).Inc()
+		missedKeys = append(missedKeys, key)
+	}
+	if len(missedKeys) == 0 {
+		return nil
+	}
+
+	err := c.remote.MultiGet(ctx, missedKeys, value)
+	if err != nil {
+		return err
+	}
+	for _, key := range missedKeys {
+		val := valueMap.MapIndex(reflect.ValueOf(key))
+		if !val.IsValid() {
+			cacheMisses.WithLabelValues(c.name, tierRedis).Inc()
+			continue
+		}
+		cacheHits.WithLabelValues(c.name, tierRedis).Inc()
+		_ = c.local.Set(ctx, key, val.Interface(), c.localExpireTime)
+	}
+	return nil
+}
+
+// Del delete from redis and the local cache, and publish the keys to the other instances
+func (c *tieredCache) Del(ctx context.Context, keys ...string) error {
+	err := c.remote.Del(ctx, keys...)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		_ = c.local.Del(ctx, key)
+		err = c.rdb.Publish(ctx, InvalidationChannel, key).Err()
+		if err != nil {
+			logger.Warn("publish cache invalidation error", logger.Err(err), logger.String("key", key))
+		}
+	}
+	return nil
+}
+
+// SetCacheWithNotFound set the not found placeholder to redis and the local cache
+func (c *tieredCache) SetCacheWithNotFound(ctx context.Context, key string) error {
+	return c.Set(ctx, key, &notFound{}, cache.DefaultNotFoundExpireTime)
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/model"
+)
+
+func newTieredUsersCache() (*gotest.Cache, UsersCache) {
+	// every test has its own redis, so the local cache and the subscription are new too
+	_ = CloseInvalidation()
+	localOnce = sync.Once{}
+
+	record := &model.Users{FirstName: "foo"}
+	record.ID = 1
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(record.ID): record})
+	return c, NewUsersCache(&model.CacheType{CType: "tiered", Rdb: c.RedisClient})
+}
+
+// wait for the buffered writes of the local cache
+func waitLocal() {
+	time.Sleep(20 * time.Millisecond)
+}
+
+func Test_tieredCache_Get(t *testing.T) {
+	c, usersCache := newTieredUsersCache()
+	defer c.Close()
+	record := c.TestDataSlice[0].(*model.Users)
+	localHits := testutil.ToFloat64(cacheHits.WithLabelValues("users", tierLocal))
+	redisHits := testutil.ToFloat64(cacheHits.WithLabelValues("users", tierRedis))
+
+	err := usersCache.Set(c.Ctx, record.ID, record, time.Hour)
+	assert.NoError(t, err)
+	waitLocal()
+
+	// the record is got from the local cache though it is deleted from redis
+	c.RedisClient.Del(c.Ctx, "users:1")
+	got, err := usersCache.Get(c.Ctx, record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, record.FirstName, got.FirstName)
+	assert.Equal(t, localHits+1, testutil.ToFloat64(cacheHits.WithLabelValues("users", tierLocal)))
+
+	// the record got from redis is written to the local cache
+	err = usersCache.Set(c.Ctx, 2, record, time.Hour)
+	assert.NoError(t, err)
+	_ = localCache.Del(c.Ctx, "users:2")
+	_, err = usersCache.Get(c.Ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, redisHits+1, testutil.ToFloat64(cacheHits.WithLabelValues("users", tierRedis)))
+	waitLocal()
+	c.RedisClient.Del(c.Ctx, "users:2")
+	_, err = usersCache.Get(c.Ctx, 2)
+	assert.NoError(t, err)
+
+	// not found placeholder
+	err = usersCache.SetCacheWithNotFound(c.Ctx, 3)
+	assert.NoError(t, err)
+	_, err = usersCache.Get(c.Ctx, 3)
+	assert.ErrorIs(t, err, cache.ErrPlaceholder)
+
+	// miss of all the tiers
+	redisMisses := testutil.ToFloat64(cacheMisses.WithLabelValues("users", tierRedis))
+	_, err = usersCache.Get(c.Ctx, 4)
+	assert.ErrorIs(t, err, model.ErrCacheNotFound)
+	assert.Equal(t, redisMisses+1, testutil.ToFloat64(cacheMisses.WithLabelValues("users", tierRedis)))
+}
+
+func Test_tieredCache_MultiGet(t *testing.T) {
+	c, usersCache := newTieredUsersCache()
+	defer c.Close()
+	record := c.TestDataSlice[0].(*model.Users)
+
+	err := usersCache.MultiSet(c.Ctx, []*model.Users{record}, time.Hour)
+	assert.NoError(t, err)
+	waitLocal()
+	err = usersCache.SetCacheWithNotFound(c.Ctx, 3)
+	assert.NoError(t, err)
+
+	got, err := usersCache.MultiGet(c.Ctx, []uint64{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, record.FirstName, got[1].FirstName)
+}
+
+func Test_tieredCache_invalidation(t *testing.T) {
+	c, usersCache := newTieredUsersCache()
+	defer c.Close()
+	record := c.TestDataSlice[0].(*model.Users)
+
+	err := usersCache.Set(c.Ctx, record.ID, record, time.Hour)
+	assert.NoError(t, err)
+	waitLocal()
+
+	// another instance deleted the record
+	c.RedisClient.Del(c.Ctx, "users:1")
+	c.RedisClient.Publish(c.Ctx, InvalidationChannel, "users:1")
+	assert.Eventually(t, func() bool {
+		_, err = usersCache.Get(c.Ctx, record.ID)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// the deleted keys are published
+	err = usersCache.Set(c.Ctx, record.ID, record, time.Hour)
+	assert.NoError(t, err)
+	sub := c.RedisClient.Subscribe(c.Ctx, InvalidationChannel)
+	defer sub.Close()
+	_, err = sub.Receive(c.Ctx)
+	assert.NoError(t, err)
+	err = usersCache.Del(c.Ctx, record.ID)
+	assert.NoError(t, err)
+	msg, err := sub.ReceiveMessage(c.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "users:1", msg.Payload)
+}
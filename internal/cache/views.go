@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/model"
+)
+
+// ViewsKey the sorted set in redis of the view counts of the user profiles
+const ViewsKey = "weaving_net:users:views"
+
+// the rate of the views that are counted, it is set by SetOptions, 0 means the views are not counted
+var viewSampleRate float64
+
+// Views the view counts of the user profiles, the most viewed profiles are preloaded by the cache warmup
+type Views interface {
+	Incr(ctx context.Context, userID uint64) error
+	Top(ctx context.Context, n int) ([]uint64, error)
+}
+
+// NewViews new the view counts in the redis of the cache type, if there is no redis, e.g. the cache is in
+// memory, the views are not counted and there are no most viewed profiles.
+func NewViews(cacheType *model.CacheType) Views {
+	if cacheType.Rdb == nil {
+		return noViews{}
+	}
+	return &redisViews{rdb: cacheType.Rdb, sampleRate: viewSampleRate}
+}
+
+type redisViews struct {
+	rdb        *redis.Client
+	sampleRate float64
+}
+
+// Incr count a view of the profile of the user, only the sampled views are written to redis, each of them
+// counts for 1/sampleRate views, so that the counts are about the same with fewer writes.
+func (v *redisViews) Incr(ctx context.Context, userID uint64) error {
+	if v.sampleRate <= 0 || rand.Float64() >= v.sampleRate {
+		return nil
+	}
+	return v.rdb.ZIncrBy(ctx, ViewsKey, 1/v.sampleRate, utils.Uint64ToStr(userID)).Err()
+}
+
+// Top get the n most viewed users, the most viewed is first
+func (v *redisViews) Top(ctx context.Context, n int) ([]uint64, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	members, err := v.rdb.ZRevRange(ctx, ViewsKey, 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint64, 0, len(members))
+	for _, member := range members {
+		if userID := utils.StrToUint64(member); userID > 0 {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+type noViews struct{}
+
+func (noViews) Incr(context.Context, uint64) error { return nil }
+
+func (noViews) Top(context.Context, int) ([]uint64, error) { return nil, nil }
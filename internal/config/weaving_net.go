@@ -29,23 +29,54 @@ func Set(conf *Config) {
 }
 
 type Config struct {
-	App        App          `yaml:"app" json:"app"`
-	Consul     Consul       `yaml:"consul" json:"consul"`
-	Database   Database     `yaml:"database" json:"database"`
-	Etcd       Etcd         `yaml:"etcd" json:"etcd"`
-	Grpc       Grpc         `yaml:"grpc" json:"grpc"`
-	GrpcClient []GrpcClient `yaml:"grpcClient" json:"grpcClient"`
-	HTTP       HTTP         `yaml:"http" json:"http"`
-	Jaeger     Jaeger       `yaml:"jaeger" json:"jaeger"`
-	Logger     Logger       `yaml:"logger" json:"logger"`
-	NacosRd    NacosRd      `yaml:"nacosRd" json:"nacosRd"`
-	Redis      Redis        `yaml:"redis" json:"redis"`
+	Admin        Admin        `yaml:"admin" json:"admin"`
+	App          App          `yaml:"app" json:"app"`
+	Cache        Cache        `yaml:"cache" json:"cache"`
+	Consul       Consul       `yaml:"consul" json:"consul"`
+	Cursor       Cursor       `yaml:"cursor" json:"cursor"`
+	Database     Database     `yaml:"database" json:"database"`
+	Etcd         Etcd         `yaml:"etcd" json:"etcd"`
+	Grpc         Grpc         `yaml:"grpc" json:"grpc"`
+	GrpcClient   []GrpcClient `yaml:"grpcClient" json:"grpcClient"`
+	HTTP         HTTP         `yaml:"http" json:"http"`
+	Jaeger       Jaeger       `yaml:"jaeger" json:"jaeger"`
+	Logger       Logger       `yaml:"logger" json:"logger"`
+	NacosRd      NacosRd      `yaml:"nacosRd" json:"nacosRd"`
+	Outbox       Outbox       `yaml:"outbox" json:"outbox"`
+	Redis        Redis        `yaml:"redis" json:"redis"`
+	SkillCatalog SkillCatalog `yaml:"skillCatalog" json:"skillCatalog"`
+	Trash        Trash        `yaml:"trash" json:"trash"`
+}
+
+type Admin struct {
+	UIDs []string `yaml:"uids" json:"uids"`
+}
+
+type Cache struct {
+	Default        EntityCache            `yaml:"default" json:"default"`
+	Entities       map[string]EntityCache `yaml:"entities" json:"entities"`
+	ViewSampleRate float64                `yaml:"viewSampleRate" json:"viewSampleRate"`
+	WarmupSize     int                    `yaml:"warmupSize" json:"warmupSize"`
+}
+
+type EntityCache struct {
+	Encoding           string `yaml:"encoding" json:"encoding"`
+	ExpireTime         int    `yaml:"expireTime" json:"expireTime"`
+	KeyPrefix          string `yaml:"keyPrefix" json:"keyPrefix"`
+	LocalExpireTime    int    `yaml:"localExpireTime" json:"localExpireTime"`
+	LockTime           int    `yaml:"lockTime" json:"lockTime"`
+	NotFoundExpireTime int    `yaml:"notFoundExpireTime" json:"notFoundExpireTime"`
+	StaleTime          int    `yaml:"staleTime" json:"staleTime"`
 }
 
 type Consul struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+type Cursor struct {
+	Secret string `yaml:"secret" json:"secret"`
+}
+
 type Etcd struct {
 	Addrs []string `yaml:"addrs" json:"addrs"`
 }
@@ -112,13 +143,15 @@ type Sqlite struct {
 }
 
 type Mysql struct {
-	ConnMaxLifetime int      `yaml:"connMaxLifetime" json:"connMaxLifetime"`
-	Dsn             string   `yaml:"dsn" json:"dsn"`
-	EnableLog       bool     `yaml:"enableLog" json:"enableLog"`
-	MastersDsn      []string `yaml:"mastersDsn" json:"mastersDsn"`
-	MaxIdleConns    int      `yaml:"maxIdleConns" json:"maxIdleConns"`
-	MaxOpenConns    int      `yaml:"maxOpenConns" json:"maxOpenConns"`
-	SlavesDsn       []string `yaml:"slavesDsn" json:"slavesDsn"`
+	ConnMaxLifetime      int      `yaml:"connMaxLifetime" json:"connMaxLifetime"`
+	Dsn                  string   `yaml:"dsn" json:"dsn"`
+	EnableLog            bool     `yaml:"enableLog" json:"enableLog"`
+	MastersDsn           []string `yaml:"mastersDsn" json:"mastersDsn"`
+	MaxIdleConns         int      `yaml:"maxIdleConns" json:"maxIdleConns"`
+	MaxOpenConns         int      `yaml:"maxOpenConns" json:"maxOpenConns"`
+	ReadYourWrites       bool     `yaml:"readYourWrites" json:"readYourWrites"`
+	ReplicaCheckInterval int      `yaml:"replicaCheckInterval" json:"replicaCheckInterval"`
+	SlavesDsn            []string `yaml:"slavesDsn" json:"slavesDsn"`
 }
 
 type Postgresql struct {
@@ -136,6 +169,22 @@ type Redis struct {
 	WriteTimeout int    `yaml:"writeTimeout" json:"writeTimeout"`
 }
 
+type SkillCatalog struct {
+	NormalizeSpec string `yaml:"normalizeSpec" json:"normalizeSpec"`
+}
+
+type Trash struct {
+	PurgeSpec     string `yaml:"purgeSpec" json:"purgeSpec"`
+	RetentionDays int    `yaml:"retentionDays" json:"retentionDays"`
+}
+
+type Outbox struct {
+	Channel       string `yaml:"channel" json:"channel"`
+	Enable        bool   `yaml:"enable" json:"enable"`
+	RelaySpec     string `yaml:"relaySpec" json:"relaySpec"`
+	RetentionDays int    `yaml:"retentionDays" json:"retentionDays"`
+}
+
 type Database struct {
 	Driver     string  `yaml:"driver" json:"driver"`
 	Mongodb    Mongodb `yaml:"mongodb" json:"mongodb"`
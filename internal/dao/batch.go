@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var errBatchItemFailed = errors.New("batch item failed")
+
+// runBatch run fn for each of the n items in one transaction, the error of each item is returned in the same order.
+// if isAtomic is true, the whole transaction is rolled back when any item fails (all-or-nothing), otherwise
+// only the failed item is rolled back to its savepoint and the rest are committed (best-effort).
+// err is returned only if the transaction itself fails. the transaction is a unit of work, or a nested transaction
+// of the unit of work of ctx, the cache invalidations and the outbox events of the items that are rolled back
+// are discarded, see UnitOfWork.
+func runBatch(ctx context.Context, db *gorm.DB, n int, isAtomic bool, fn func(ctx context.Context, tx *gorm.DB, i int) error) ([]error, error) {
+	errs := make([]error, n)
+	run := func(ctx context.Context, tx *gorm.DB) error {
+		for i := 0; i < n; i++ {
+			if isAtomic {
+				if errs[i] = fn(ctx, tx, i); errs[i] != nil {
+					return errBatchItemFailed
+				}
+				continue
+			}
+
+			savePoint := fmt.Sprintf("batch_item_%d", i)
+			if err := tx.SavePoint(savePoint).Error; err != nil {
+				return err
+			}
+			discard := discardAfter(ctx)
+			if errs[i] = fn(ctx, tx, i); errs[i] != nil {
+				if err := tx.RollbackTo(savePoint).Error; err != nil {
+					return err
+				}
+				discard()
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if isInUnitOfWork(ctx) {
+		// db is the transaction of the unit of work
+		discard := discardAfter(ctx)
+		err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return run(ctx, tx)
+		})
+		if err != nil {
+			discard()
+		}
+	} else {
+		err = NewUnitOfWork(db).Do(ctx, func(ctx context.Context) error {
+			return run(ctx, TxFromContext(ctx))
+		})
+	}
+	if errors.Is(err, errBatchItemFailed) {
+		err = nil
+	}
+
+	return errs, err
+}
@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_runBatch(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	itemErr := errors.New("item error")
+	fn := func(ctx context.Context, tx *gorm.DB, i int) error {
+		if i == 1 {
+			return itemErr
+		}
+		return tx.Exec("UPDATE users SET name = ?", i).Error
+	}
+
+	// atomic, the batch is rolled back at the first failed item
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE users .*").WithArgs(0).WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectRollback()
+	errs, err := runBatch(d.Ctx, d.DB, 3, true, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, itemErr, nil}, errs)
+
+	// best effort, only the failed item is rolled back to its savepoint
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("SAVEPOINT batch_item_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectExec("UPDATE users .*").WithArgs(0).WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectExec("SAVEPOINT batch_item_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectExec("SAVEPOINT batch_item_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectExec("UPDATE users .*").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+	errs, err = runBatch(d.Ctx, d.DB, 3, false, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, itemErr, nil}, errs)
+
+	// transaction error
+	d.SQLMock.ExpectBegin().WillReturnError(errors.New("begin error"))
+	_, err = runBatch(d.Ctx, d.DB, 3, true, fn)
+	assert.Error(t, err)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+}
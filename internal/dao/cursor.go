@@ -0,0 +1,253 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+)
+
+// DefaultCursorSort the default sort of the keyset pages, the latest is first
+const DefaultCursorSort = "-id"
+
+// ErrInvalidCursor the cursor does not match the sort of the table
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorParams the parameters of a keyset page.
+//
+// the page starts after the row whose sort column values are Values, or ends before it if IsPrev is true,
+// the first page is requested without Values. the sort is the same as query.Params, id is always added as the
+// last sort column so that the order is unique, e.g. "-is_current,-end_date" is sorted by "-is_current,-end_date,-id".
+// the columns are the same filters as query.Params, the filters are the extra conditions the same as GetByColumns,
+// the sort columns are always selected with the columns of SelectColumns in the filters.
+type CursorParams struct {
+	Sort    string
+	Columns []query.Column
+	Filters []clause.Expression
+	Limit   int
+	Values  []json.RawMessage
+	IsPrev  bool
+}
+
+type keysetColumn struct {
+	field  *schema.Field
+	isDesc bool
+}
+
+// KeysetSort the sort columns of a keyset page with id as the last one, the columns must exist in the table
+func KeysetSort(table interface{}, sort string) (string, error) {
+	columns, err := parseKeysetSort(table, sort)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column.isDesc {
+			names = append(names, "-"+column.field.DBName)
+		} else {
+			names = append(names, column.field.DBName)
+		}
+	}
+	return strings.Join(names, ","), nil
+}
+
+// CursorValues the values of the sort columns of a record, which is the position of a keyset page
+func CursorValues(ctx context.Context, record interface{}, sort string) ([]json.RawMessage, error) {
+	columns, err := parseKeysetSort(record, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(record))
+	values := make([]json.RawMessage, 0, len(columns))
+	for _, column := range columns {
+		value, _ := column.field.ValueOf(ctx, rv)
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, data)
+	}
+	return values, nil
+}
+
+// find a keyset page of the table of dest by db, which carries the context of the query, e.g. the replica routing.
+// dest is a pointer to a slice of records, the records are always in the order of the sort, hasMore reports
+// whether there are more records in the paging direction.
+func findByCursor(db *gorm.DB, dest interface{}, params *CursorParams) (bool, error) {
+	columns, err := parseKeysetSort(dest, params.Sort)
+	if err != nil {
+		return false, err
+	}
+
+	if len(params.Columns) > 0 {
+		queryStr, args, err := (&query.Params{Columns: params.Columns}).ConvertToGormConditions()
+		if err != nil {
+			return false, errors.New("query params error: " + err.Error())
+		}
+		db = db.Where(queryStr, args...)
+	}
+	sortColumns := []string{}
+	for _, column := range columns {
+		sortColumns = append(sortColumns, column.field.DBName)
+	}
+	db = db.Scopes(filterScope(params.Filters), selectScope(params.Filters, sortColumns...))
+	if params.Values != nil {
+		queryStr, args, err := keysetConditions(columns, params.Values, params.IsPrev)
+		if err != nil {
+			return false, err
+		}
+		db = db.Where(queryStr, args...)
+	}
+
+	limit := query.NewPage(0, params.Limit, "").Size()
+	err = db.Order(keysetOrder(columns, params.IsPrev)).Limit(limit + 1).Find(dest).Error
+	if err != nil {
+		return false, err
+	}
+
+	records := reflect.ValueOf(dest).Elem()
+	hasMore := records.Len() > limit
+	if hasMore {
+		records.Set(records.Slice(0, limit))
+	}
+	if params.IsPrev {
+		swap := reflect.Swapper(records.Interface())
+		for i, j := 0, records.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	return hasMore, nil
+}
+
+func parseKeysetSort(table interface{}, sort string) ([]keysetColumn, error) {
+	sch, err := schema.Parse(table, schemaCache, schema.NamingStrategy{SingularTable: true})
+	if err != nil {
+		return nil, err
+	}
+
+	sort = strings.ReplaceAll(sort, " ", "")
+	if sort == "" {
+		sort = DefaultCursorSort
+	}
+	columns := []keysetColumn{}
+	hasID := false
+	for _, name := range strings.Split(sort, ",") {
+		column := keysetColumn{}
+		if strings.HasPrefix(name, "-") {
+			column.isDesc = true
+			name = name[1:]
+		}
+		column.field = sch.LookUpField(name)
+		if column.field == nil || column.field.DBName != name {
+			return nil, fmt.Errorf("unknown sort column '%s'", name)
+		}
+		if hasID {
+			break // the columns after id do not change the order
+		}
+		hasID = name == "id"
+		columns = append(columns, column)
+	}
+	if !hasID {
+		columns = append(columns, keysetColumn{field: sch.LookUpField("id"), isDesc: columns[len(columns)-1].isDesc})
+	}
+	return columns, nil
+}
+
+// the order of the page, it is reversed to page backwards. postgresql sorts null as the largest value,
+// so the reversed order is exactly the reverse of the order.
+func keysetOrder(columns []keysetColumn, isPrev bool) string {
+	orders := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column.isDesc != isPrev {
+			orders = append(orders, column.field.DBName+" DESC")
+		} else {
+			orders = append(orders, column.field.DBName+" ASC")
+		}
+	}
+	return strings.Join(orders, ", ")
+}
+
+// the rows after the cursor in the order of the page, for the sort (a, -b, id) it is
+//
+//	a > va OR (a = va AND b < vb) OR (a = va AND b = vb AND id > vid)
+//
+// with null as the largest value of the nullable columns.
+func keysetConditions(columns []keysetColumn, rawValues []json.RawMessage, isPrev bool) (string, []interface{}, error) {
+	values, err := keysetValues(columns, rawValues)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ors := make([]string, 0, len(columns))
+	args := []interface{}{}
+	for i, column := range columns {
+		ands := make([]string, 0, i+1)
+		orArgs := []interface{}{}
+		for j := 0; j < i; j++ {
+			name := columns[j].field.DBName
+			if values[j] == nil {
+				ands = append(ands, name+" IS NULL")
+			} else {
+				ands = append(ands, name+" = ?")
+				orArgs = append(orArgs, values[j])
+			}
+		}
+
+		name := column.field.DBName
+		if column.isDesc != isPrev {
+			if values[i] == nil {
+				ands = append(ands, name+" IS NOT NULL")
+			} else {
+				ands = append(ands, name+" < ?")
+				orArgs = append(orArgs, values[i])
+			}
+		} else {
+			if values[i] == nil {
+				continue // nothing is larger than null
+			}
+			if column.field.PrimaryKey || column.field.NotNull {
+				ands = append(ands, name+" > ?")
+			} else {
+				ands = append(ands, "("+name+" > ? OR "+name+" IS NULL)")
+			}
+			orArgs = append(orArgs, values[i])
+		}
+
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		args = append(args, orArgs...)
+	}
+	if len(ors) == 0 {
+		return "1 = 0", nil, nil
+	}
+	return strings.Join(ors, " OR "), args, nil
+}
+
+// the values of the sort columns in a cursor, a null value is nil
+func keysetValues(columns []keysetColumn, rawValues []json.RawMessage) ([]interface{}, error) {
+	if len(rawValues) != len(columns) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if bytes.Equal(bytes.TrimSpace(rawValues[i]), []byte("null")) {
+			continue
+		}
+		value := reflect.New(column.field.FieldType)
+		err := json.Unmarshal(rawValues[i], value.Interface())
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
@@ -0,0 +1,135 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+
+	"weaving_net/internal/model"
+)
+
+func TestKeysetSort(t *testing.T) {
+	sort, err := KeysetSort(&model.Workexperiences{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "-id", sort)
+
+	sort, err = KeysetSort(&model.Workexperiences{}, "-is_current, -end_date")
+	assert.NoError(t, err)
+	assert.Equal(t, "-is_current,-end_date,-id", sort)
+
+	sort, err = KeysetSort(&[]*model.Workexperiences{}, "company,id,title")
+	assert.NoError(t, err)
+	assert.Equal(t, "company,id", sort)
+
+	_, err = KeysetSort(&model.Workexperiences{}, "salary")
+	assert.Error(t, err)
+	_, err = KeysetSort(&model.Workexperiences{}, "startDate")
+	assert.Error(t, err)
+}
+
+func TestCursorValues(t *testing.T) {
+	record := &model.Workexperiences{Company: "weaving", IsCurrent: true}
+	record.ID = 3
+
+	values, err := CursorValues(context.Background(), record, "-is_current,-end_date,company,-id")
+	assert.NoError(t, err)
+	assert.Equal(t, []json.RawMessage{json.RawMessage("true"), json.RawMessage("null"), json.RawMessage(`"weaving"`), json.RawMessage("3")}, values)
+
+	_, err = CursorValues(context.Background(), record, "unknown")
+	assert.Error(t, err)
+}
+
+func Test_keysetConditions(t *testing.T) {
+	columns, err := parseKeysetSort(&model.Workexperiences{}, "company,-end_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "company ASC, end_date DESC, id DESC", keysetOrder(columns, false))
+	assert.Equal(t, "company DESC, end_date ASC, id ASC", keysetOrder(columns, true))
+
+	endDate := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	data, _ := json.Marshal(endDate)
+	values := []json.RawMessage{json.RawMessage(`"weaving"`), data, json.RawMessage("3")}
+
+	queryStr, args, err := keysetConditions(columns, values, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "(company > ?) OR (company = ? AND end_date < ?) OR (company = ? AND end_date = ? AND id < ?)", queryStr)
+	assert.Equal(t, []interface{}{"weaving", "weaving", &endDate, "weaving", &endDate, uint64(3)}, args)
+
+	queryStr, _, err = keysetConditions(columns, values, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "(company < ?) OR (company = ? AND (end_date > ? OR end_date IS NULL)) OR (company = ? AND end_date = ? AND id > ?)", queryStr)
+
+	// null value
+	values[1] = json.RawMessage("null")
+	queryStr, args, err = keysetConditions(columns, values, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "(company > ?) OR (company = ? AND end_date IS NOT NULL) OR (company = ? AND end_date IS NULL AND id < ?)", queryStr)
+	assert.Equal(t, []interface{}{"weaving", "weaving", "weaving", uint64(3)}, args)
+
+	// nothing after the last null
+	columns, _ = parseKeysetSort(&model.Workexperiences{}, "end_date,id")
+	queryStr, _, err = keysetConditions(columns, []json.RawMessage{json.RawMessage("null"), json.RawMessage("null")}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "1 = 0", queryStr)
+
+	// invalid values
+	_, _, err = keysetConditions(columns, []json.RawMessage{json.RawMessage("3")}, false)
+	assert.Error(t, err)
+	_, _, err = keysetConditions(columns, []json.RawMessage{json.RawMessage("null"), json.RawMessage(`"3"`)}, false)
+	assert.Error(t, err)
+}
+
+func Test_findByCursor(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "company"}).
+		AddRow(3, "c").AddRow(4, "b").AddRow(5, "a")
+	d.SQLMock.ExpectQuery("SELECT .* WHERE user_id = \\? AND \\(id > \\?\\) AND `is_current` = \\? .* ORDER BY id ASC LIMIT 3").
+		WithArgs(1, uint64(2), true).
+		WillReturnRows(rows)
+
+	records := []*model.Workexperiences{}
+	hasMore, err := findByCursor(d.DB.WithContext(d.Ctx), &records, &CursorParams{
+		Sort:    "-id",
+		Columns: []query.Column{{Name: "user_id", Value: 1}},
+		Filters: []clause.Expression{clause.Eq{Column: clause.Column{Name: "is_current"}, Value: true}},
+		Limit:   2,
+		Values:  []json.RawMessage{json.RawMessage("2")},
+		IsPrev:  true,
+	})
+	assert.NoError(t, err)
+	assert.True(t, hasMore)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, uint64(4), records[0].ID)
+		assert.Equal(t, uint64(3), records[1].ID)
+	}
+
+	// the sort columns are selected with the selected columns
+	rows = sqlmock.NewRows([]string{"id", "title", "company"}).AddRow(1, "go", "a")
+	d.SQLMock.ExpectQuery("SELECT `id`,`title`,`company` FROM `workexperiences` WHERE `is_current` = \\? .* ORDER BY company ASC, id ASC").
+		WithArgs(true).
+		WillReturnRows(rows)
+	_, err = findByCursor(d.DB.WithContext(d.Ctx), &records, &CursorParams{
+		Sort:    "company",
+		Filters: []clause.Expression{clause.Eq{Column: clause.Column{Name: "is_current"}, Value: true}, SelectColumns("id", "title")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "go", records[0].Title)
+
+	// error test
+	_, err = findByCursor(d.DB.WithContext(d.Ctx), &records, &CursorParams{Sort: "unknown"})
+	assert.Error(t, err)
+	_, err = findByCursor(d.DB.WithContext(d.Ctx), &records, &CursorParams{Columns: []query.Column{{}}})
+	assert.Error(t, err)
+	_, err = findByCursor(d.DB.WithContext(d.Ctx), &records, &CursorParams{Values: []json.RawMessage{}})
+	assert.Error(t, err)
+}
@@ -2,16 +2,10 @@ package dao
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"time"
 
-	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 
-	cacheBase "github.com/zhufuyi/sponge/pkg/cache"
 	"github.com/zhufuyi/sponge/pkg/ggorm/query"
-	"github.com/zhufuyi/sponge/pkg/utils"
 
 	"weaving_net/internal/cache"
 	"weaving_net/internal/model"
@@ -21,383 +15,88 @@ var _ EducationsDao = (*educationsDao)(nil)
 
 // EducationsDao defining the dao interface
 type EducationsDao interface {
-	Create(ctx context.Context, table *model.Educations) error
-	DeleteByID(ctx context.Context, id uint64) error
-	DeleteByIDs(ctx context.Context, ids []uint64) error
-	UpdateByID(ctx context.Context, table *model.Educations) error
-	GetByID(ctx context.Context, id uint64) (*model.Educations, error)
-	GetByCondition(ctx context.Context, condition *query.Conditions) (*model.Educations, error)
-	GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Educations, error)
-	GetByLastID(ctx context.Context, lastID uint64, limit int, sort string) ([]*model.Educations, error)
-	GetByColumns(ctx context.Context, params *query.Params) ([]*model.Educations, int64, error)
+	Repository[model.Educations]
 
-	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Educations) (uint64, error)
-	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Educations) error
+	GetByUserID(ctx context.Context, userID int) ([]*model.Educations, error)
+	ListByUserID(ctx context.Context, userID int) ([]*model.Educations, error)
+	VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error)
+	Reorder(ctx context.Context, userID int, ids []uint64) error
 }
 
 type educationsDao struct {
-	db    *gorm.DB
-	cache cache.EducationsCache // if nil, the cache is not used.
-	sfg   *singleflight.Group   // if cache is nil, the sfg is not used.
+	*repository[model.Educations]
 }
 
-// NewEducationsDao creating the dao interface
-func NewEducationsDao(db *gorm.DB, xCache cache.EducationsCache) EducationsDao {
-	if xCache == nil {
-		return &educationsDao{db: db}
-	}
-	return &educationsDao{
-		db:    db,
-		cache: xCache,
-		sfg:   new(singleflight.Group),
-	}
-}
-
-func (d *educationsDao) deleteCache(ctx context.Context, id uint64) error {
-	if d.cache != nil {
-		return d.cache.Del(ctx, id)
-	}
-	return nil
-}
-
-// Create a record, insert the record and the id value is written back to the table
-func (d *educationsDao) Create(ctx context.Context, table *model.Educations) error {
-	return d.db.WithContext(ctx).Create(table).Error
-}
-
-// DeleteByID delete a record by id
-func (d *educationsDao) DeleteByID(ctx context.Context, id uint64) error {
-	err := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Educations{}).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	_ = d.deleteCache(ctx, id)
-
-	return nil
-}
-
-// DeleteByIDs delete records by batch id
-func (d *educationsDao) DeleteByIDs(ctx context.Context, ids []uint64) error {
-	err := d.db.WithContext(ctx).Where("id IN (?)", ids).Delete(&model.Educations{}).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	for _, id := range ids {
-		_ = d.deleteCache(ctx, id)
-	}
-
-	return nil
-}
-
-// UpdateByID update a record by id
-func (d *educationsDao) UpdateByID(ctx context.Context, table *model.Educations) error {
-	err := d.updateDataByID(ctx, d.db, table)
+var educationsMapper = &Mapper[model.Educations]{
+	ID:      func(table *model.Educations) *uint64 { return &table.ID },
+	Version: func(table *model.Educations) *int { return &table.Version },
+	Updates: func(table *model.Educations) map[string]interface{} {
+		update := map[string]interface{}{}
 
-	// delete cache
-	_ = d.deleteCache(ctx, table.ID)
-
-	return err
-}
-
-func (d *educationsDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Educations) error {
-	if table.ID < 1 {
-		return errors.New("id cannot be 0")
-	}
-
-	update := map[string]interface{}{}
-
-	if table.UserID != 0 {
-		update["user_id"] = table.UserID
-	}
-	if table.School != "" {
-		update["school"] = table.School
-	}
-	if table.Degree != "" {
-		update["degree"] = table.Degree
-	}
-	if table.FieldOfStudy != "" {
-		update["field_of_study"] = table.FieldOfStudy
-	}
-	if table.StartDate.IsZero() == false {
-		update["start_date"] = table.StartDate
-	}
-	if table.EndDate.IsZero() == false {
-		update["end_date"] = table.EndDate
-	}
-	if table.Gpa != "" {
-		update["gpa"] = table.Gpa
-	}
-	if table.Activities != "" {
-		update["activities"] = table.Activities
-	}
-
-	return db.WithContext(ctx).Model(table).Updates(update).Error
-}
-
-// GetByID get a record by id
-func (d *educationsDao) GetByID(ctx context.Context, id uint64) (*model.Educations, error) {
-	// no cache
-	if d.cache == nil {
-		record := &model.Educations{}
-		err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
-		return record, err
-	}
-
-	// get from cache or database
-	record, err := d.cache.Get(ctx, id)
-	if err == nil {
-		return record, nil
-	}
-
-	if errors.Is(err, model.ErrCacheNotFound) {
-		// for the same id, prevent high concurrent simultaneous access to database
-		val, err, _ := d.sfg.Do(utils.Uint64ToStr(id), func() (interface{}, error) { //nolint
-			table := &model.Educations{}
-			err = d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
-			if err != nil {
-				// if data is empty, set not found cache to prevent cache penetration, default expiration time 10 minutes
-				if errors.Is(err, model.ErrRecordNotFound) {
-					err = d.cache.SetCacheWithNotFound(ctx, id)
-					if err != nil {
-						return nil, err
-					}
-					return nil, model.ErrRecordNotFound
-				}
-				return nil, err
-			}
-			// set cache
-			err = d.cache.Set(ctx, id, table, cache.EducationsExpireTime)
-			if err != nil {
-				return nil, fmt.Errorf("cache.Set error: %v, id=%d", err, id)
-			}
-			return table, nil
-		})
-		if err != nil {
-			return nil, err
+		if table.UserID != 0 {
+			update["user_id"] = table.UserID
 		}
-		table, ok := val.(*model.Educations)
-		if !ok {
-			return nil, model.ErrRecordNotFound
+		if table.School != "" {
+			update["school"] = table.School
 		}
-		return table, nil
-	} else if errors.Is(err, cacheBase.ErrPlaceholder) {
-		return nil, model.ErrRecordNotFound
-	}
-
-	// fail fast, if cache error return, don't request to db
-	return nil, err
-}
-
-// GetByCondition get a record by condition
-// query conditions:
-//
-//	name: column name
-//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
-//	value: column value, if exp=in, multiple values are separated by commas
-//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
-//
-// example: find a male aged 20
-//
-//	condition = &query.Conditions{
-//	    Columns: []query.Column{
-//		{
-//			Name:    "age",
-//			Value:   20,
-//		},
-//		{
-//			Name:  "gender",
-//			Value: "male",
-//		},
-//	}
-func (d *educationsDao) GetByCondition(ctx context.Context, c *query.Conditions) (*model.Educations, error) {
-	queryStr, args, err := c.ConvertToGorm()
-	if err != nil {
-		return nil, err
-	}
-
-	table := &model.Educations{}
-	err = d.db.WithContext(ctx).Where(queryStr, args...).First(table).Error
-	if err != nil {
-		return nil, err
-	}
-
-	return table, nil
-}
-
-// GetByIDs get records by batch id
-func (d *educationsDao) GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Educations, error) {
-	// no cache
-	if d.cache == nil {
-		var records []*model.Educations
-		err := d.db.WithContext(ctx).Where("id IN (?)", ids).Find(&records).Error
-		if err != nil {
-			return nil, err
+		if table.Degree != "" {
+			update["degree"] = table.Degree
 		}
-		itemMap := make(map[uint64]*model.Educations)
-		for _, record := range records {
-			itemMap[record.ID] = record
+		if table.FieldOfStudy != "" {
+			update["field_of_study"] = table.FieldOfStudy
 		}
-		return itemMap, nil
-	}
-
-	// get form cache or database
-	itemMap, err := d.cache.MultiGet(ctx, ids)
-	if err != nil {
-		return nil, err
-	}
-
-	var missedIDs []uint64
-	for _, id := range ids {
-		_, ok := itemMap[id]
-		if !ok {
-			missedIDs = append(missedIDs, id)
-			continue
+		if table.StartDate.IsZero() == false {
+			update["start_date"] = table.StartDate
 		}
-	}
-
-	// get missed data
-	if len(missedIDs) > 0 {
-		// find the id of an active placeholder, i.e. an id that does not exist in database
-		var realMissedIDs []uint64
-		for _, id := range missedIDs {
-			_, err = d.cache.Get(ctx, id)
-			if errors.Is(err, cacheBase.ErrPlaceholder) {
-				continue
-			}
-			realMissedIDs = append(realMissedIDs, id)
+		if table.EndDate != nil {
+			update["end_date"] = table.EndDate
 		}
-
-		if len(realMissedIDs) > 0 {
-			var missedData []*model.Educations
-			err = d.db.WithContext(ctx).Where("id IN (?)", realMissedIDs).Find(&missedData).Error
-			if err != nil {
-				return nil, err
-			}
-
-			if len(missedData) > 0 {
-				for _, data := range missedData {
-					itemMap[data.ID] = data
-				}
-				err = d.cache.MultiSet(ctx, missedData, cache.EducationsExpireTime)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				for _, id := range realMissedIDs {
-					_ = d.cache.SetCacheWithNotFound(ctx, id)
-				}
-			}
+		if table.IsCurrent {
+			update["is_current"] = table.IsCurrent
+		}
+		if table.Gpa != "" {
+			update["gpa"] = table.Gpa
+		}
+		if table.Activities != "" {
+			update["activities"] = table.Activities
+		}
+		if table.Position != 0 {
+			update["position"] = table.Position
 		}
-	}
 
-	return itemMap, nil
+		return update
+	},
+	Position:    func(table *model.Educations) (*int, int) { return &table.Position, table.UserID },
+	UserID:      func(table *model.Educations) int { return table.UserID },
+	OwnerColumn: "user_id",
 }
 
-// GetByLastID get paging records by last id and limit
-func (d *educationsDao) GetByLastID(ctx context.Context, lastID uint64, limit int, sort string) ([]*model.Educations, error) {
-	page := query.NewPage(0, limit, sort)
+// NewEducationsDao creating the dao interface
+func NewEducationsDao(db *gorm.DB, xCache cache.EducationsCache) EducationsDao {
+	return &educationsDao{repository: newRepository[model.Educations](db, xCache, educationsMapper)}
+}
 
+// GetByUserID get all records of a user sorted by position, the records with the same position are sorted with the current one first
+func (d *educationsDao) GetByUserID(ctx context.Context, userID int) ([]*model.Educations, error) {
 	records := []*model.Educations{}
-	err := d.db.WithContext(ctx).Order(page.Sort()).Limit(page.Size()).Where("id < ?", lastID).Find(&records).Error
+	err := d.dbOf(ctx).Where("user_id = ?", userID).Order(query.NewPage(0, 0, SortPositionCurrentFirst).Sort()).Find(&records).Error
 	if err != nil {
 		return nil, err
 	}
 	return records, nil
 }
 
-// GetByColumns get paging records by column information,
-// Note: query performance degrades when table rows are very large because of the use of offset.
-//
-// params includes paging parameters and query parameters
-// paging parameters (required):
-//
-//	page: page number, starting from 0
-//	size: lines per page
-//	sort: sort fields, default is id backwards, you can add - sign before the field to indicate reverse order, no - sign to indicate ascending order, multiple fields separated by comma
-//
-// query parameters (not required):
-//
-//	name: column name
-//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
-//	value: column value, if exp=in, multiple values are separated by commas
-//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
-//
-// example: search for a male over 20 years of age
-//
-//	params = &query.Params{
-//	    Page: 0,
-//	    Size: 20,
-//	    Columns: []query.Column{
-//		{
-//			Name:    "age",
-//			Exp: ">",
-//			Value:   20,
-//		},
-//		{
-//			Name:  "gender",
-//			Value: "male",
-//		},
-//	}
-func (d *educationsDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.Educations, int64, error) {
-	queryStr, args, err := params.ConvertToGormConditions()
-	if err != nil {
-		return nil, 0, errors.New("query params error: " + err.Error())
-	}
-
-	var total int64
-	if params.Sort != "ignore count" { // determine if count is required
-		err = d.db.WithContext(ctx).Model(&model.Educations{}).Select([]string{"id"}).Where(queryStr, args...).Count(&total).Error
-		if err != nil {
-			return nil, 0, err
-		}
-		if total == 0 {
-			return nil, total, nil
-		}
-	}
-
-	records := []*model.Educations{}
-	order, limit, offset := params.ConvertToPage()
-	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return records, total, err
-}
-
-// CreateByTx create a record in the database using the provided transaction
-func (d *educationsDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Educations) (uint64, error) {
-	err := tx.WithContext(ctx).Create(table).Error
-	return table.ID, err
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *educationsDao) ListByUserID(ctx context.Context, userID int) ([]*model.Educations, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
 }
 
-// DeleteByTx delete a record by id in the database using the provided transaction
-func (d *educationsDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error {
-	update := map[string]interface{}{
-		"deleted_at": time.Now(),
-	}
-	err := tx.WithContext(ctx).Model(&model.Educations{}).Where("id = ?", id).Updates(update).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	_ = d.deleteCache(ctx, id)
-
-	return nil
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *educationsDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
 }
 
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *educationsDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Educations) error {
-	err := d.updateDataByID(ctx, tx, table)
-
-	// delete cache
-	_ = d.deleteCache(ctx, table.ID)
-
-	return err
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *educationsDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
 }
@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SelectColumns select only the columns of the records, it is passed with the filters of a list, all the columns
+// are selected if columns is empty.
+func SelectColumns(columns ...string) clause.Expression {
+	selectClause := clause.Select{}
+	for _, column := range columns {
+		selectClause.Columns = append(selectClause.Columns, clause.Column{Name: column})
+	}
+	return selectClause
+}
+
+// filterScope the scope of the filter conditions of a list, they are and-ed with the other conditions
+func filterScope(filters []clause.Expression) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		conditions := []clause.Expression{}
+		for _, filter := range filters {
+			if _, ok := filter.(clause.Select); !ok {
+				conditions = append(conditions, filter)
+			}
+		}
+		if len(conditions) == 0 {
+			return db
+		}
+		return db.Where(clause.And(conditions...))
+	}
+}
+
+// selectScope the scope of the columns selected by SelectColumns in the filters, the required columns are
+// selected with them, e.g. the sort columns of a keyset page. it is not used by count.
+func selectScope(filters []clause.Expression, required ...string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		columns := []clause.Column{}
+		for _, filter := range filters {
+			if selectClause, ok := filter.(clause.Select); ok {
+				columns = append(columns, selectClause.Columns...)
+			}
+		}
+		if len(columns) == 0 {
+			return db
+		}
+		for _, name := range required {
+			if !hasColumn(columns, name) {
+				columns = append(columns, clause.Column{Name: name})
+			}
+		}
+		return db.Clauses(clause.Select{Columns: columns})
+	}
+}
+
+func hasColumn(columns []clause.Column, name string) bool {
+	for _, column := range columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+var (
+	schemaCache = &sync.Map{}
+	// the fields of ggorm.Model, the version and the assessment result can not be changed by the client
+	immutableColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true,
+		"verified_level": true, "verified_at": true}
+)
+
+// MergePatchToColumns convert a JSON merge patch (RFC 7396) document to the columns to be updated,
+// the keys of the document are the json field names of the table, an explicit null clears the field
+// and an omitted field is left alone.
+//
+// example: clear the end date and change the title of a work experience
+//
+//	columns, err := MergePatchToColumns(&model.Workexperiences{}, []byte(`{"endDate":null,"title":"CTO"}`))
+//	// columns = map[string]interface{}{"end_date": nil, "title": "CTO"}
+func MergePatchToColumns(table interface{}, patch []byte) (map[string]interface{}, error) {
+	sch, err := schema.Parse(table, schemaCache, schema.NamingStrategy{SingularTable: true})
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]json.RawMessage{}
+	err = json.Unmarshal(patch, &values)
+	if err != nil {
+		return nil, fmt.Errorf("merge patch must be a json object: %v", err)
+	}
+
+	columns := make(map[string]interface{}, len(values))
+	for name, raw := range values {
+		field := lookUpJSONField(sch, name)
+		if field == nil || field.DBName == "" || immutableColumns[field.DBName] {
+			return nil, fmt.Errorf("field '%s' does not exist or cannot be changed", name)
+		}
+
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			if field.NotNull {
+				return nil, fmt.Errorf("field '%s' cannot be null", name)
+			}
+			columns[field.DBName] = nil
+			continue
+		}
+
+		value := reflect.New(field.FieldType)
+		err = json.Unmarshal(raw, value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of field '%s': %v", name, err)
+		}
+		columns[field.DBName] = value.Elem().Interface()
+	}
+
+	return columns, nil
+}
+
+// replaceColumns convert all the fields of a table except the immutable ones to the columns to be updated,
+// zero values are kept, so that the record is fully replaced. the position is an exception, it is only
+// changed when it is not 0, the order of the records is usually changed by Reorder.
+func replaceColumns(ctx context.Context, table interface{}) (map[string]interface{}, error) {
+	sch, err := schema.Parse(table, schemaCache, schema.NamingStrategy{SingularTable: true})
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(table))
+	columns := make(map[string]interface{}, len(sch.DBNames))
+	for _, dbName := range sch.DBNames {
+		if immutableColumns[dbName] {
+			continue
+		}
+		value, isZero := sch.FieldsByDBName[dbName].ValueOf(ctx, rv)
+		if isZero && dbName == "position" {
+			continue
+		}
+		columns[dbName] = value
+	}
+
+	return columns, nil
+}
+
+func lookUpJSONField(sch *schema.Schema, name string) *schema.Field {
+	for _, field := range sch.Fields {
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == name {
+			return field
+		}
+	}
+	return nil
+}
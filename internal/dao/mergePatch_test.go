@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"weaving_net/internal/model"
+)
+
+func TestMergePatchToColumns(t *testing.T) {
+	columns, err := MergePatchToColumns(&model.Workexperiences{}, []byte(`{"endDate":null,"location":"","title":"CTO","startDate":"2020-01-02T00:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"end_date":   nil,
+		"location":   "",
+		"title":      "CTO",
+		"start_date": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+	}, columns)
+
+	// empty patch
+	columns, err = MergePatchToColumns(&model.Educations{}, []byte(`{}`))
+	assert.NoError(t, err)
+	assert.Empty(t, columns)
+
+	// not null field error
+	_, err = MergePatchToColumns(&model.Workexperiences{}, []byte(`{"company":null}`))
+	assert.Error(t, err)
+
+	// unknown field error
+	_, err = MergePatchToColumns(&model.Workexperiences{}, []byte(`{"salary":100}`))
+	assert.Error(t, err)
+
+	// immutable field error
+	_, err = MergePatchToColumns(&model.Workexperiences{}, []byte(`{"id":2}`))
+	assert.Error(t, err)
+	_, err = MergePatchToColumns(&model.Workexperiences{}, []byte(`{"version":2}`))
+	assert.Error(t, err)
+
+	// invalid value error
+	_, err = MergePatchToColumns(&model.Workexperiences{}, []byte(`{"userId":"abc"}`))
+	assert.Error(t, err)
+
+	// not an object error
+	_, err = MergePatchToColumns(&model.Workexperiences{}, []byte(`[1,2]`))
+	assert.Error(t, err)
+}
+
+func Test_replaceColumns(t *testing.T) {
+	table := &model.Users{FirstName: "foo", Version: 3}
+	table.ID = 1
+	columns, err := replaceColumns(context.Background(), table)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"first_name":          "foo",
+		"last_name":           "",
+		"profile_picture_url": "",
+		"about":               "",
+	}, columns)
+
+	// the position is kept if it is omitted
+	record := &model.Skills{UserID: 1, SkillName: "go"}
+	columns, err = replaceColumns(context.Background(), record)
+	assert.NoError(t, err)
+	assert.NotContains(t, columns, "position")
+	record.Position = 2
+	columns, err = replaceColumns(context.Background(), record)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, columns["position"])
+}
@@ -0,0 +1,182 @@
+package dao
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// the layouts of the time values of the filters in strings, as they are accepted by postgresql
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+func parseSchema(table interface{}) (*schema.Schema, error) {
+	return schema.Parse(table, schemaCache, schema.NamingStrategy{SingularTable: true})
+}
+
+// encode a record to a document of mongodb, the keys are the column names of the table
+func encodeDocument(ctx context.Context, sch *schema.Schema, table interface{}) bson.M {
+	rv := reflect.Indirect(reflect.ValueOf(table))
+	doc := make(bson.M, len(sch.DBNames))
+	for _, dbName := range sch.DBNames {
+		value, _ := sch.FieldsByDBName[dbName].ValueOf(ctx, rv)
+		doc[dbName] = toBSON(value)
+	}
+	return doc
+}
+
+// decode a document of mongodb to a record, the fields of the columns that are not in the document are not changed
+func decodeDocument(ctx context.Context, sch *schema.Schema, doc bson.M, table interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(table))
+	for name, value := range doc {
+		field := sch.FieldsByDBName[name]
+		if field == nil {
+			continue
+		}
+		err := field.Set(ctx, rv, fromBSON(value))
+		if err != nil {
+			return fmt.Errorf("decode column '%s' error: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// convert a value of a field to the value stored in mongodb, the null values are nil, the integers are int64
+// because mongodb has no unsigned integer, e.g. the ids.
+func toBSON(value interface{}) interface{} {
+	if valuer, ok := value.(driver.Valuer); ok {
+		value, _ = valuer.Value()
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return toBSON(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	}
+	return value
+}
+
+// convert a value decoded from mongodb to the value that a field can be set with
+func fromBSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return v.Time()
+	case int32:
+		return int64(v)
+	}
+	return value
+}
+
+// the integer of a number decoded from mongodb, ok is false if the value is not a number, e.g. nil
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func toInt64s(ids []uint64) []int64 {
+	values := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, int64(id))
+	}
+	return values
+}
+
+// convert a value of a filter or of a column to be updated to the type of the field and then to the value stored
+// in mongodb, e.g. the string "3" of an int column, as postgresql converts the values in sql.
+func coerceValue(field *schema.Field, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	fieldType := field.FieldType
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	str, isString := value.(string)
+
+	switch {
+	case fieldType == timeType || fieldType == deletedAtType:
+		if isString {
+			for _, layout := range timeLayouts {
+				if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("invalid time '%s' of column '%s'", str, field.DBName)
+		}
+	case !isString:
+	case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Uint64:
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer '%s' of column '%s'", str, field.DBName)
+		}
+		return n, nil
+	case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' of column '%s'", str, field.DBName)
+		}
+		return n, nil
+	case fieldType.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool '%s' of column '%s'", str, field.DBName)
+		}
+		return b, nil
+	}
+
+	if fieldType.Kind() == reflect.String && !isString {
+		return fmt.Sprint(toBSON(value)), nil
+	}
+	return toBSON(value), nil
+}
+
+// coerce the values of the columns, an error is returned if a column is not in the table
+func coerceColumns(sch *schema.Schema, columns map[string]interface{}) (bson.M, error) {
+	values := make(bson.M, len(columns))
+	for name, value := range columns {
+		field := sch.FieldsByDBName[name]
+		if field == nil {
+			return nil, fmt.Errorf("unknown column '%s'", name)
+		}
+		v, err := coerceValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// whether the column can be null in the documents, only the pointers and deleted_at are stored as null
+func isNullable(field *schema.Field) bool {
+	return field.FieldType.Kind() == reflect.Ptr || field.FieldType == deletedAtType
+}
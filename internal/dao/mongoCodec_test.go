@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm"
+
+	"weaving_net/internal/model"
+)
+
+func Test_encodeDocument(t *testing.T) {
+	ctx := context.Background()
+	sch, err := parseSchema(&model.Workexperiences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startDate := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	record := &model.Workexperiences{UserID: 1, Company: "weaving", StartDate: startDate, IsCurrent: true, Position: 2, Version: 3}
+	record.ID = 5
+
+	doc := encodeDocument(ctx, sch, record)
+	assert.Equal(t, int64(5), doc["id"])
+	assert.Equal(t, int64(1), doc["user_id"])
+	assert.Nil(t, doc["end_date"])
+	assert.Nil(t, doc["deleted_at"])
+
+	// round trip through bson as the documents are stored
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := bson.M{}
+	err = bson.Unmarshal(data, &stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := &model.Workexperiences{}
+	err = decodeDocument(ctx, sch, stored, actual)
+	assert.NoError(t, err)
+	assert.Equal(t, record.ID, actual.ID)
+	assert.Equal(t, record.Company, actual.Company)
+	assert.True(t, startDate.Equal(actual.StartDate))
+	assert.Nil(t, actual.EndDate)
+	assert.Equal(t, record.IsCurrent, actual.IsCurrent)
+	assert.Equal(t, record.Version, actual.Version)
+	assert.False(t, actual.DeletedAt.Valid)
+
+	stored["deleted_at"] = stored["start_date"]
+	err = decodeDocument(ctx, sch, stored, actual)
+	assert.NoError(t, err)
+	assert.Equal(t, gorm.DeletedAt{Time: actual.StartDate, Valid: true}, actual.DeletedAt)
+}
+
+func Test_coerceColumns(t *testing.T) {
+	sch, err := parseSchema(&model.Skills{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := coerceColumns(sch, map[string]interface{}{
+		"proficiency": "3",
+		"catalog_id":  uint64(7),
+		"skill_name":  12,
+		"verified_at": "2020-01-02",
+		"user_id":     nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"proficiency": int64(3),
+		"catalog_id":  int64(7),
+		"skill_name":  "12",
+		"verified_at": time.Date(2020, 1, 2, 0, 0, 0, 0, time.Local),
+		"user_id":     nil,
+	}, values)
+
+	_, err = coerceColumns(sch, map[string]interface{}{"salary": 1})
+	assert.Error(t, err)
+	_, err = coerceColumns(sch, map[string]interface{}{"proficiency": "high"})
+	assert.Error(t, err)
+	_, err = coerceColumns(sch, map[string]interface{}{"verified_at": "yesterday"})
+	assert.Error(t, err)
+}
+
+func Test_resetMongoVerification(t *testing.T) {
+	record := bson.M{"catalog_id": int64(1), "verified_level": int64(3)}
+
+	columns := bson.M{"catalog_id": int64(1)}
+	resetMongoVerification(record, columns)
+	assert.Equal(t, bson.M{"catalog_id": int64(1)}, columns)
+
+	columns = bson.M{"catalog_id": int64(2)}
+	resetMongoVerification(record, columns)
+	assert.Equal(t, bson.M{"catalog_id": int64(2), "verified_level": int64(0), "verified_at": nil}, columns)
+
+	columns = bson.M{"skill_name": "go"}
+	resetMongoVerification(record, columns)
+	assert.Equal(t, bson.M{"skill_name": "go"}, columns)
+}
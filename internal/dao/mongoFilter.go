@@ -0,0 +1,371 @@
+package dao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+)
+
+// the sql of the expressions of the filters that are converted to mongodb, see handler filterSpec
+const (
+	sqlBetween = "? BETWEEN ? AND ?"
+	sqlILike   = "? ILIKE ?"
+)
+
+// the operators and the logical types of query.Column, the same as ConvertToGormConditions
+var (
+	columnExps = map[string]string{
+		query.Eq: "$eq", query.Neq: "$ne", query.Gt: "$gt", query.Gte: "$gte", query.Lt: "$lt", query.Lte: "$lte",
+		query.Like: query.Like, query.In: "$in",
+		"=": "$eq", "!=": "$ne", ">": "$gt", ">=": "$gte", "<": "$lt", "<=": "$lte",
+	}
+	columnLogics = map[string]bool{query.AND: false, "&": false, "&&": false, query.OR: true, "|": true, "||": true}
+)
+
+// and the filters of mongodb, the empty ones are ignored
+func andFilter(filters ...bson.M) bson.M {
+	ands := make([]bson.M, 0, len(filters))
+	for _, filter := range filters {
+		if len(filter) > 0 {
+			ands = append(ands, filter)
+		}
+	}
+	switch len(ands) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return ands[0]
+	}
+	return bson.M{"$and": ands}
+}
+
+// convert the filters of a list to a filter of mongodb, they are and-ed, SelectColumns is not a filter
+func mongoFilters(sch *schema.Schema, filters []clause.Expression) (bson.M, error) {
+	ands := make([]bson.M, 0, len(filters))
+	for _, filter := range filters {
+		if _, ok := filter.(clause.Select); ok {
+			continue
+		}
+		m, err := mongoExpression(sch, filter)
+		if err != nil {
+			return nil, err
+		}
+		ands = append(ands, m)
+	}
+	return andFilter(ands...), nil
+}
+
+// convert a condition of gorm to a filter of mongodb, the null values are compared as sql, e.g. a <> 1 does not
+// match null. only the conditions built by the filters of the handlers are supported.
+func mongoExpression(sch *schema.Schema, expr clause.Expression) (bson.M, error) {
+	switch e := expr.(type) {
+	case clause.AndConditions:
+		return mongoLogic(sch, "", e.Exprs)
+	case clause.OrConditions:
+		return mongoLogic(sch, "$or", e.Exprs)
+	case clause.Where:
+		return mongoLogic(sch, "", e.Exprs)
+	case clause.Eq:
+		if reflect.ValueOf(e.Value).Kind() == reflect.Slice {
+			return mongoCompare(sch, e.Column, "$in", e.Value)
+		}
+		return mongoCompare(sch, e.Column, "$eq", e.Value)
+	case clause.Neq:
+		return mongoCompare(sch, e.Column, "$ne", e.Value)
+	case clause.Gt:
+		return mongoCompare(sch, e.Column, "$gt", e.Value)
+	case clause.Gte:
+		return mongoCompare(sch, e.Column, "$gte", e.Value)
+	case clause.Lt:
+		return mongoCompare(sch, e.Column, "$lt", e.Value)
+	case clause.Lte:
+		return mongoCompare(sch, e.Column, "$lte", e.Value)
+	case clause.IN:
+		return mongoCompare(sch, e.Column, "$in", e.Values)
+	case clause.Like:
+		return mongoCompare(sch, e.Column, query.Like, e.Value)
+	case clause.Expr:
+		if e.SQL == sqlBetween && len(e.Vars) == 3 {
+			lower, err := mongoCompare(sch, e.Vars[0], "$gte", e.Vars[1])
+			if err != nil {
+				return nil, err
+			}
+			upper, err := mongoCompare(sch, e.Vars[0], "$lte", e.Vars[2])
+			if err != nil {
+				return nil, err
+			}
+			return andFilter(lower, upper), nil
+		}
+		if e.SQL == sqlILike && len(e.Vars) == 2 {
+			return mongoCompare(sch, e.Vars[0], "$regex", e.Vars[1])
+		}
+		return nil, fmt.Errorf("unsupported filter '%s' in mongodb", e.SQL)
+	}
+	return nil, fmt.Errorf("unsupported filter %T in mongodb", expr)
+}
+
+func mongoLogic(sch *schema.Schema, op string, exprs []clause.Expression) (bson.M, error) {
+	filters := make([]bson.M, 0, len(exprs))
+	for _, expr := range exprs {
+		filter, err := mongoExpression(sch, expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	if op == "" || len(filters) < 2 {
+		return andFilter(filters...), nil
+	}
+	return bson.M{op: filters}, nil
+}
+
+// the filter of a column compared with a value by op, the operator of mongodb, or like for the pattern of sql
+// like, or $regex for the pattern of ilike.
+func mongoCompare(sch *schema.Schema, column interface{}, op string, value interface{}) (bson.M, error) {
+	var name string
+	switch c := column.(type) {
+	case clause.Column:
+		name = c.Name
+	case string:
+		name = c
+	}
+	field := sch.FieldsByDBName[name]
+	if field == nil {
+		return nil, fmt.Errorf("unknown column '%s'", name)
+	}
+
+	switch op {
+	case query.Like:
+		return bson.M{name: primitive.Regex{Pattern: likeRegex(fmt.Sprint(value)), Options: "s"}}, nil
+	case "$regex":
+		return bson.M{name: primitive.Regex{Pattern: likeRegex(fmt.Sprint(value)), Options: "is"}}, nil
+	case "$in":
+		rv := reflect.ValueOf(value)
+		values := make(bson.A, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			v, err := coerceValue(field, rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return bson.M{name: bson.M{"$in": values}}, nil
+	}
+
+	v, err := coerceValue(field, value)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case op == "$eq":
+		return bson.M{name: v}, nil
+	case op == "$ne" && v != nil:
+		// null is not different from any value in sql
+		return bson.M{name: bson.M{"$nin": bson.A{v, nil}}}, nil
+	}
+	return bson.M{name: bson.M{op: v}}, nil
+}
+
+// convert the pattern of sql like to a regular expression, % matches any characters, _ matches one character,
+// and a backslash escapes the next character.
+func likeRegex(pattern string) string {
+	b := strings.Builder{}
+	b.WriteString("^")
+	isEscaped := false
+	for _, r := range pattern {
+		switch {
+		case isEscaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			isEscaped = false
+		case r == '\\':
+			isEscaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// convert the columns of query.Params to a filter of mongodb, the columns are validated the same as
+// ConvertToGormConditions, and the and-ed columns take precedence over the or-ed ones as sql.
+func mongoConditions(sch *schema.Schema, columns []query.Column) (bson.M, error) {
+	ors := []bson.M{}
+	ands := []bson.M{}
+	for i, column := range columns {
+		if column.Name == "" {
+			return nil, errors.New("field 'name' cannot be empty")
+		}
+		if column.Value == nil {
+			return nil, errors.New("field 'value' cannot be nil")
+		}
+		exp := strings.ToLower(column.Exp)
+		if exp == "" {
+			exp = query.Eq
+		}
+		op, ok := columnExps[exp]
+		if !ok {
+			return nil, fmt.Errorf("unknown exp type '%s'", column.Exp)
+		}
+		logic := strings.ToLower(column.Logic)
+		if logic == "" {
+			logic = query.AND
+		}
+		isOr, ok := columnLogics[logic]
+		if !ok {
+			return nil, fmt.Errorf("unknown logic type '%s'", column.Logic)
+		}
+
+		value := column.Value
+		switch op {
+		case query.Like:
+			value = fmt.Sprintf("%%%v%%", value)
+		case "$in":
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid value type '%s'", value)
+			}
+			value = strings.Split(str, ",")
+		}
+		filter, err := mongoCompare(sch, column.Name, op, value)
+		if err != nil {
+			return nil, err
+		}
+
+		ands = append(ands, filter)
+		if isOr && i < len(columns)-1 { // ignore the logical type of the last column
+			ors = append(ors, andFilter(ands...))
+			ands = []bson.M{}
+		}
+	}
+	ors = append(ors, andFilter(ands...))
+
+	if len(ors) == 1 {
+		return ors[0], nil
+	}
+	return bson.M{"$or": ors}, nil
+}
+
+// parse the sort of query.Params, e.g. "-is_current,position", the default is the latest first
+func parseMongoSort(sch *schema.Schema, sort string) ([]keysetColumn, error) {
+	sort = strings.ReplaceAll(sort, " ", "")
+	if sort == "" {
+		sort = DefaultCursorSort
+	}
+	columns := []keysetColumn{}
+	for _, name := range strings.Split(sort, ",") {
+		column := keysetColumn{}
+		if strings.HasPrefix(name, "-") && len(name) > 1 {
+			column.isDesc = true
+			name = name[1:]
+		}
+		column.field = sch.FieldsByDBName[name]
+		if column.field == nil {
+			return nil, fmt.Errorf("unknown sort column '%s'", name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// the stages that sort the records by the columns, they are reversed if isReversed is true. null is sorted as
+// the largest value like postgresql, mongodb sorts it as the smallest one.
+func mongoSort(columns []keysetColumn, isReversed bool) []bson.M {
+	nulls := bson.M{}
+	sort := bson.D{}
+	for _, column := range columns {
+		direction := 1
+		if column.isDesc != isReversed {
+			direction = -1
+		}
+		name := column.field.DBName
+		if isNullable(column.field) {
+			isNull := "_null_" + name
+			nulls[isNull] = bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$" + name, nil}}, nil}}, 1, 0}}
+			sort = append(sort, bson.E{Key: isNull, Value: direction})
+		}
+		sort = append(sort, bson.E{Key: name, Value: direction})
+	}
+
+	if len(nulls) == 0 {
+		return []bson.M{{"$sort": sort}}
+	}
+	return []bson.M{{"$addFields": nulls}, {"$sort": sort}}
+}
+
+// the filter of the records after the cursor in the order of the page, the same as keysetConditions
+func mongoKeysetConditions(columns []keysetColumn, rawValues []json.RawMessage, isPrev bool) (bson.M, error) {
+	values, err := keysetValues(columns, rawValues)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		values[i] = toBSON(value)
+	}
+
+	ors := make([]bson.M, 0, len(columns))
+	for i, column := range columns {
+		ands := make([]bson.M, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, bson.M{columns[j].field.DBName: values[j]})
+		}
+
+		name := column.field.DBName
+		if column.isDesc != isPrev {
+			if values[i] == nil {
+				ands = append(ands, bson.M{name: bson.M{"$ne": nil}})
+			} else {
+				ands = append(ands, bson.M{name: bson.M{"$lt": values[i]}})
+			}
+		} else {
+			if values[i] == nil {
+				continue // nothing is larger than null
+			}
+			if isNullable(column.field) {
+				ands = append(ands, bson.M{"$or": bson.A{bson.M{name: bson.M{"$gt": values[i]}}, bson.M{name: nil}}})
+			} else {
+				ands = append(ands, bson.M{name: bson.M{"$gt": values[i]}})
+			}
+		}
+
+		ors = append(ors, andFilter(ands...))
+	}
+	if len(ors) == 0 {
+		return bson.M{"$expr": false}, nil
+	}
+	return bson.M{"$or": ors}, nil
+}
+
+// the projection of the columns of SelectColumns in the filters with the required columns, nil if all the
+// columns are selected.
+func mongoProjection(filters []clause.Expression, required ...string) bson.M {
+	projection := bson.M{}
+	for _, filter := range filters {
+		if selectClause, ok := filter.(clause.Select); ok {
+			for _, column := range selectClause.Columns {
+				projection[column.Name] = 1
+			}
+		}
+	}
+	if len(projection) == 0 {
+		return nil
+	}
+	for _, name := range required {
+		projection[name] = 1
+	}
+	return projection
+}
@@ -0,0 +1,152 @@
+package dao
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm/clause"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+
+	"weaving_net/internal/model"
+)
+
+func Test_likeRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{pattern: "%go%", match: []string{"go", "golang", "a go\nb"}, noMatch: []string{"g-o"}},
+		{pattern: "g_", match: []string{"go"}, noMatch: []string{"g", "goo"}},
+		{pattern: `100\%`, match: []string{"100%"}, noMatch: []string{"1000"}},
+		{pattern: "a.b*", match: []string{"a.b*"}, noMatch: []string{"axbb"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			re := regexp.MustCompile("(?s)" + likeRegex(tt.pattern))
+			for _, s := range tt.match {
+				assert.True(t, re.MatchString(s), s)
+			}
+			for _, s := range tt.noMatch {
+				assert.False(t, re.MatchString(s), s)
+			}
+		})
+	}
+}
+
+func Test_mongoFilters(t *testing.T) {
+	sch, err := parseSchema(&model.Workexperiences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := mongoFilters(sch, []clause.Expression{
+		clause.Eq{Column: clause.Column{Name: "is_current"}, Value: true},
+		clause.Neq{Column: clause.Column{Name: "end_date"}, Value: nil},
+		clause.Or(
+			clause.Neq{Column: clause.Column{Name: "company"}, Value: "weaving"},
+			clause.IN{Column: clause.Column{Name: "user_id"}, Values: []interface{}{"1", 2}},
+		),
+		clause.Expr{SQL: sqlILike, Vars: []interface{}{clause.Column{Name: "title"}, "%dev%"}},
+		clause.Expr{SQL: sqlBetween, Vars: []interface{}{clause.Column{Name: "start_date"}, "2020-01-01", "2021-01-01"}},
+		clause.Select{Columns: []clause.Column{{Name: "company"}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"is_current": true},
+		{"end_date": bson.M{"$ne": nil}},
+		{"$or": []bson.M{
+			{"company": bson.M{"$nin": bson.A{"weaving", nil}}},
+			{"user_id": bson.M{"$in": bson.A{int64(1), int64(2)}}},
+		}},
+		{"title": primitive.Regex{Pattern: "^.*dev.*$", Options: "is"}},
+		{"$and": []bson.M{
+			{"start_date": bson.M{"$gte": time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)}},
+			{"start_date": bson.M{"$lte": time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local)}},
+		}},
+	}}, filter)
+
+	_, err = mongoFilters(sch, []clause.Expression{clause.Eq{Column: clause.Column{Name: "salary"}, Value: 1}})
+	assert.Error(t, err)
+	_, err = mongoFilters(sch, []clause.Expression{clause.Eq{Column: clause.Column{Name: "user_id"}, Value: "one"}})
+	assert.Error(t, err)
+}
+
+func Test_mongoConditions(t *testing.T) {
+	sch, err := parseSchema(&model.Skills{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := mongoConditions(sch, []query.Column{
+		{Name: "skill_name", Exp: query.Like, Value: "go", Logic: query.OR},
+		{Name: "proficiency", Exp: query.Gte, Value: "3"},
+		{Name: "user_id", Exp: query.In, Value: "1,2"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": []bson.M{
+		{"skill_name": primitive.Regex{Pattern: "^.*go.*$", Options: "s"}},
+		{"$and": []bson.M{
+			{"proficiency": bson.M{"$gte": int64(3)}},
+			{"user_id": bson.M{"$in": bson.A{int64(1), int64(2)}}},
+		}},
+	}}, filter)
+
+	_, err = mongoConditions(sch, []query.Column{{Name: "skill_name", Exp: "regexp", Value: "go"}})
+	assert.Error(t, err)
+	_, err = mongoConditions(sch, []query.Column{{Name: "skill_name", Value: "go", Logic: "xor"}})
+	assert.Error(t, err)
+	_, err = mongoConditions(sch, []query.Column{{Name: "skill_name"}})
+	assert.Error(t, err)
+}
+
+func Test_mongoSort(t *testing.T) {
+	sch, err := parseSchema(&model.Workexperiences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := parseMongoSort(sch, "position, -end_date")
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "position", Value: 1}, {Key: "_null_end_date", Value: -1}, {Key: "end_date", Value: -1}},
+		mongoSort(columns, false)[1]["$sort"])
+	assert.Equal(t, bson.D{{Key: "position", Value: -1}, {Key: "_null_end_date", Value: 1}, {Key: "end_date", Value: 1}},
+		mongoSort(columns, true)[1]["$sort"])
+
+	columns, err = parseMongoSort(sch, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"$sort": bson.D{{Key: "id", Value: -1}}}}, mongoSort(columns, false))
+
+	_, err = parseMongoSort(sch, "salary")
+	assert.Error(t, err)
+}
+
+func Test_mongoKeysetConditions(t *testing.T) {
+	columns, err := parseKeysetSort(&model.Workexperiences{}, "company,-end_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := mongoKeysetConditions(columns, []json.RawMessage{json.RawMessage(`"weaving"`), json.RawMessage("null"), json.RawMessage("3")}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": []bson.M{
+		{"company": bson.M{"$gt": "weaving"}},
+		{"$and": []bson.M{{"company": "weaving"}, {"end_date": bson.M{"$ne": nil}}}},
+		{"$and": []bson.M{{"company": "weaving"}, {"end_date": nil}, {"id": bson.M{"$lt": int64(3)}}}},
+	}}, filter)
+
+	_, err = mongoKeysetConditions(columns, []json.RawMessage{json.RawMessage(`"weaving"`)}, false)
+	assert.Error(t, err)
+}
+
+func Test_mongoProjection(t *testing.T) {
+	assert.Nil(t, mongoProjection(nil, "id"))
+	assert.Equal(t, bson.M{"company": 1, "id": 1},
+		mongoProjection([]clause.Expression{clause.Select{Columns: []clause.Column{{Name: "company"}}}}, "id"))
+}
@@ -0,0 +1,312 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/model"
+)
+
+var (
+	_ UsersDao             = (*mongoUsersDao)(nil)
+	_ EducationsDao        = (*mongoEducationsDao)(nil)
+	_ ProjectsDao          = (*mongoProjectsDao)(nil)
+	_ SkillsDao            = (*mongoSkillsDao)(nil)
+	_ UserIntroductionsDao = (*mongoUserIntroductionsDao)(nil)
+	_ WorkexperiencesDao   = (*mongoWorkexperiencesDao)(nil)
+)
+
+type mongoUsersDao struct {
+	*mongoRepository[model.Users]
+}
+
+// NewMongoUsersDao creating the dao interface of the users in mongodb
+func NewMongoUsersDao(db *mongo.Database, xCache cache.UsersCache) UsersDao {
+	return &mongoUsersDao{mongoRepository: newMongoRepository[model.Users](db, xCache, usersMapper, mongoUserSection)}
+}
+
+type mongoEducationsDao struct {
+	*mongoRepository[model.Educations]
+}
+
+// NewMongoEducationsDao creating the dao interface of the educations in mongodb
+func NewMongoEducationsDao(db *mongo.Database, xCache cache.EducationsCache) EducationsDao {
+	return &mongoEducationsDao{mongoRepository: newMongoRepository[model.Educations](db, xCache, educationsMapper, "educations")}
+}
+
+// GetByUserID get all records of a user sorted by position, the records with the same position are sorted with the current one first
+func (d *mongoEducationsDao) GetByUserID(ctx context.Context, userID int) ([]*model.Educations, error) {
+	return d.findByUsers(ctx, []int{userID}, SortPositionCurrentFirst)
+}
+
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *mongoEducationsDao) ListByUserID(ctx context.Context, userID int) ([]*model.Educations, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
+}
+
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *mongoEducationsDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
+}
+
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *mongoEducationsDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
+}
+
+type mongoProjectsDao struct {
+	*mongoRepository[model.Projects]
+}
+
+// NewMongoProjectsDao creating the dao interface of the projects in mongodb
+func NewMongoProjectsDao(db *mongo.Database, xCache cache.ProjectsCache) ProjectsDao {
+	return &mongoProjectsDao{mongoRepository: newMongoRepository[model.Projects](db, xCache, projectsMapper, "projects")}
+}
+
+// GetByUserID get all records of a user sorted by position
+func (d *mongoProjectsDao) GetByUserID(ctx context.Context, userID int) ([]*model.Projects, error) {
+	return d.findByUsers(ctx, []int{userID}, SortPosition)
+}
+
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *mongoProjectsDao) ListByUserID(ctx context.Context, userID int) ([]*model.Projects, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
+}
+
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *mongoProjectsDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
+}
+
+// GetByUserIDs get all records of the users in one query, the records of each user are sorted by position
+func (d *mongoProjectsDao) GetByUserIDs(ctx context.Context, userIDs []int) ([]*model.Projects, error) {
+	if len(userIDs) == 0 {
+		return []*model.Projects{}, nil
+	}
+	return d.findByUsers(ctx, userIDs, SortPosition)
+}
+
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *mongoProjectsDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
+}
+
+type mongoSkillsDao struct {
+	*mongoRepository[model.Skills]
+}
+
+// NewMongoSkillsDao creating the dao interface of the skills in mongodb
+func NewMongoSkillsDao(db *mongo.Database, xCache cache.SkillsCache) SkillsDao {
+	r := newMongoRepository[model.Skills](db, xCache, skillsMapper, "skills")
+	r.columns = resetMongoVerification
+	return &mongoSkillsDao{mongoRepository: r}
+}
+
+// GetByUserID get all records of a user sorted by position
+func (d *mongoSkillsDao) GetByUserID(ctx context.Context, userID int) ([]*model.Skills, error) {
+	return d.findByUsers(ctx, []int{userID}, SortPosition)
+}
+
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *mongoSkillsDao) ListByUserID(ctx context.Context, userID int) ([]*model.Skills, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
+}
+
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *mongoSkillsDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
+}
+
+// GetByUserIDs get all records of the users in one query, the records of each user are sorted by position
+func (d *mongoSkillsDao) GetByUserIDs(ctx context.Context, userIDs []int) ([]*model.Skills, error) {
+	if len(userIDs) == 0 {
+		return []*model.Skills{}, nil
+	}
+	return d.findByUsers(ctx, userIDs, SortPosition)
+}
+
+// GetUnmatched get the records after lastID that are not matched to the skill catalog, sorted by id
+func (d *mongoSkillsDao) GetUnmatched(ctx context.Context, lastID uint64, limit int) ([]*model.Skills, error) {
+	return d.findAfter(ctx, lastID, limit, bson.M{"catalog_id": bson.M{"$in": bson.A{0, nil}}})
+}
+
+// GetUnleveled get the records after lastID that have a legacy proficiency level text but no proficiency on the scale, sorted by id
+func (d *mongoSkillsDao) GetUnleveled(ctx context.Context, lastID uint64, limit int) ([]*model.Skills, error) {
+	return d.findAfter(ctx, lastID, limit, bson.M{"proficiency": 0, "proficiency_level": bson.M{"$nin": bson.A{"", nil}}})
+}
+
+// get the records after lastID matching the filter sorted by id
+func (d *mongoSkillsDao) findAfter(ctx context.Context, lastID uint64, limit int, filter bson.M) ([]*model.Skills, error) {
+	return d.find(ctx, &mongoQuery{
+		filter: andFilter(bson.M{"id": bson.M{"$gt": int64(lastID)}}, filter),
+		sort:   []bson.M{{"$sort": bson.D{{Key: "id", Value: 1}}}},
+		limit:  limit,
+	})
+}
+
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *mongoSkillsDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
+}
+
+// Verify set the verified level of a skill to the higher one of it and level, see skillsDao.Verify. the level is
+// compared with the record that is read, so the patch is retried if the record is changed meanwhile.
+func (d *mongoSkillsDao) Verify(ctx context.Context, id uint64, level int) error {
+	for i := 0; i < mongoRetries; i++ {
+		record, err := d.loadByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		verifiedLevel := level
+		if record.VerifiedLevel > verifiedLevel {
+			verifiedLevel = record.VerifiedLevel
+		}
+		err = d.PatchByID(ctx, id, record.Version, map[string]interface{}{
+			"verified_level": verifiedLevel,
+			"verified_at":    time.Now(),
+		})
+		if !errors.Is(err, model.ErrRecordModified) {
+			return err
+		}
+	}
+	return model.ErrRecordModified
+}
+
+// clear the assessment result if the skill is mapped onto another canonical skill, see resetVerification
+func resetMongoVerification(record bson.M, columns bson.M) {
+	catalogID, ok := columns["catalog_id"]
+	if !ok {
+		return
+	}
+	oldID, isSet := toInt64(record["catalog_id"])
+	newID, _ := toInt64(catalogID)
+	if isSet && oldID == newID {
+		return
+	}
+	columns["verified_level"] = int64(0)
+	columns["verified_at"] = nil
+}
+
+type mongoUserIntroductionsDao struct {
+	*mongoRepository[model.UserIntroductions]
+}
+
+// NewMongoUserIntroductionsDao creating the dao interface of the user introductions in mongodb
+func NewMongoUserIntroductionsDao(db *mongo.Database, xCache cache.UserIntroductionsCache) UserIntroductionsDao {
+	return &mongoUserIntroductionsDao{mongoRepository: newMongoRepository[model.UserIntroductions](db, xCache, userIntroductionsMapper, "user_introductions")}
+}
+
+// GetByUserID get all records of a user sorted by position
+func (d *mongoUserIntroductionsDao) GetByUserID(ctx context.Context, userID int) ([]*model.UserIntroductions, error) {
+	return d.findByUsers(ctx, []int{userID}, SortPosition)
+}
+
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *mongoUserIntroductionsDao) ListByUserID(ctx context.Context, userID int) ([]*model.UserIntroductions, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
+}
+
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *mongoUserIntroductionsDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
+}
+
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *mongoUserIntroductionsDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
+}
+
+type mongoWorkexperiencesDao struct {
+	*mongoRepository[model.Workexperiences]
+}
+
+// NewMongoWorkexperiencesDao creating the dao interface of the work experiences in mongodb
+func NewMongoWorkexperiencesDao(db *mongo.Database, xCache cache.WorkexperiencesCache) WorkexperiencesDao {
+	return &mongoWorkexperiencesDao{mongoRepository: newMongoRepository[model.Workexperiences](db, xCache, workexperiencesMapper, "workexperiences")}
+}
+
+// GetByUserID get all records of a user sorted by position, the records with the same position are sorted with the current one first
+func (d *mongoWorkexperiencesDao) GetByUserID(ctx context.Context, userID int) ([]*model.Workexperiences, error) {
+	return d.findByUsers(ctx, []int{userID}, SortPositionCurrentFirst)
+}
+
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *mongoWorkexperiencesDao) ListByUserID(ctx context.Context, userID int) ([]*model.Workexperiences, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
+}
+
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *mongoWorkexperiencesDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
+}
+
+// HasPrimary determine if the user already has a primary record other than excludeID
+func (d *mongoWorkexperiencesDao) HasPrimary(ctx context.Context, userID int, excludeID uint64) (bool, error) {
+	total, err := d.count(ctx, &mongoQuery{
+		profile: bson.M{"_id": int64(userID)},
+		filter:  bson.M{"user_id": int64(userID), "is_primary": true, "id": bson.M{"$ne": int64(excludeID)}},
+	})
+	if err != nil {
+		return false, err
+	}
+	return total > 0, nil
+}
+
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *mongoWorkexperiencesDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
+}
+
+// NewUsersDaoByDriver creating the dao interface of the users in the database of database.driver,
+// the profiles are stored in mongodb if the driver is mongodb, otherwise in postgresql.
+func NewUsersDaoByDriver(xCache cache.UsersCache) UsersDao {
+	if model.IsMongodb() {
+		return NewMongoUsersDao(model.GetMongoDB(), xCache)
+	}
+	return NewUsersDao(model.GetDB(), xCache)
+}
+
+// NewEducationsDaoByDriver creating the dao interface of the educations in the database of database.driver
+func NewEducationsDaoByDriver(xCache cache.EducationsCache) EducationsDao {
+	if model.IsMongodb() {
+		return NewMongoEducationsDao(model.GetMongoDB(), xCache)
+	}
+	return NewEducationsDao(model.GetDB(), xCache)
+}
+
+// NewProjectsDaoByDriver creating the dao interface of the projects in the database of database.driver
+func NewProjectsDaoByDriver(xCache cache.ProjectsCache) ProjectsDao {
+	if model.IsMongodb() {
+		return NewMongoProjectsDao(model.GetMongoDB(), xCache)
+	}
+	return NewProjectsDao(model.GetDB(), xCache)
+}
+
+// NewSkillsDaoByDriver creating the dao interface of the skills in the database of database.driver
+func NewSkillsDaoByDriver(xCache cache.SkillsCache) SkillsDao {
+	if model.IsMongodb() {
+		return NewMongoSkillsDao(model.GetMongoDB(), xCache)
+	}
+	return NewSkillsDao(model.GetDB(), xCache)
+}
+
+// NewUserIntroductionsDaoByDriver creating the dao interface of the user introductions in the database of database.driver
+func NewUserIntroductionsDaoByDriver(xCache cache.UserIntroductionsCache) UserIntroductionsDao {
+	if model.IsMongodb() {
+		return NewMongoUserIntroductionsDao(model.GetMongoDB(), xCache)
+	}
+	return NewUserIntroductionsDao(model.GetDB(), xCache)
+}
+
+// NewWorkexperiencesDaoByDriver creating the dao interface of the work experiences in the database of database.driver
+func NewWorkexperiencesDaoByDriver(xCache cache.WorkexperiencesCache) WorkexperiencesDao {
+	if model.IsMongodb() {
+		return NewMongoWorkexperiencesDao(model.GetMongoDB(), xCache)
+	}
+	return NewWorkexperiencesDao(model.GetDB(), xCache)
+}
@@ -0,0 +1,1100 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/model"
+)
+
+// the collections of mongodb.
+//
+// the profile of a user is a document of the collection profiles whose _id is the user id, the user is embedded
+// as a document and the records of each child table of the user are embedded as an array named as the table, e.g.
+//
+//	{"_id": 1, "user": {"id": 1, "first_name": "..."}, "skills": [{"id": 3, "user_id": 1, "skill_name": "go"}]}
+//
+// the keys of the embedded records are the column names, so that the filters, the sorts and the columns of the
+// updates are the same as postgresql. the ids of each table are generated by the collection counters.
+const (
+	mongoProfiles    = "profiles"
+	mongoCounters    = "counters"
+	mongoUserSection = "user"
+)
+
+// the times that a record is read and written back again if it is changed by others at the same time
+const mongoRetries = 3
+
+var (
+	mongoSections = []string{mongoUserSection, "educations", "projects", "skills", "user_introductions", "workexperiences"}
+	mongoIndexes  sync.Once
+)
+
+// the indexes of the ids of the embedded records, they are created once when the first dao is created
+func ensureMongoIndexes(db *mongo.Database) {
+	mongoIndexes.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		models := make([]mongo.IndexModel, 0, len(mongoSections))
+		for _, section := range mongoSections {
+			models = append(models, mongo.IndexModel{Keys: bson.D{{Key: section + ".id", Value: 1}}})
+		}
+		_, err := db.Collection(mongoProfiles).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			logger.Warn("create indexes of mongodb error", logger.Err(err))
+		}
+	})
+}
+
+// mongoRepository the Repository of a table in the profile documents of mongodb, the records are cached the same as
+// the gorm repository. the records of a user are written atomically in the document of the user, mongodb must be a
+// replica set for the atomic batches, and the *ByTx methods do not use the gorm transaction, see runMongoBatch.
+type mongoRepository[T any] struct {
+	*recordCache[T]
+	db      *mongo.Database
+	section string // the field of the records in the profile documents, e.g. skills
+	isArray bool   // the records of the child tables are arrays, the user is a document
+
+	// columns optional, adjust the columns of every update and patch with the record before it is updated,
+	// the same as Mapper.Columns that is an expression of sql.
+	columns func(record bson.M, columns bson.M)
+}
+
+// mongoQuery a query of the records of a section
+type mongoQuery struct {
+	profile    bson.M   // the filter of the profile documents, it is applied before the records are unwound
+	filter     bson.M   // the filter of the records
+	isUnscoped bool     // whether the soft deleted records are included
+	sort       []bson.M // the stages of the sort, see mongoSort
+	skip       int
+	limit      int
+	projection bson.M
+}
+
+func newMongoRepository[T any](db *mongo.Database, xCache Cache[T], mapper *Mapper[T], section string) *mongoRepository[T] {
+	ensureMongoIndexes(db)
+	return &mongoRepository[T]{
+		recordCache: newRecordCache(xCache, mapper),
+		db:          db,
+		section:     section,
+		isArray:     section != mongoUserSection,
+	}
+}
+
+func (r *mongoRepository[T]) profiles() *mongo.Collection {
+	return r.db.Collection(mongoProfiles)
+}
+
+// the filter of the profile documents that have a record matching the conditions of the columns
+func (r *mongoRepository[T]) hasRecord(conditions bson.M) bson.M {
+	if r.isArray {
+		return bson.M{r.section: bson.M{"$elemMatch": conditions}}
+	}
+	filter := make(bson.M, len(conditions))
+	for name, condition := range conditions {
+		filter[r.section+"."+name] = condition
+	}
+	return filter
+}
+
+// the filter of the profile document of a user, the records of a user that does not exist can not be pushed to
+// the document, e.g. after the user is purged, the same as the foreign keys of postgresql.
+func profileOf(userID int64) bson.M {
+	return bson.M{"_id": userID, mongoUserSection: bson.M{"$exists": true}}
+}
+
+// the path of the record matched by hasRecord in an update, the positional operator is used for the arrays
+func (r *mongoRepository[T]) matched(column string) string {
+	path := r.section
+	if r.isArray {
+		path += ".$"
+	}
+	if column != "" {
+		path += "." + column
+	}
+	return path
+}
+
+// set the columns of all the records matching the conditions
+func (r *mongoRepository[T]) updateRecords(ctx context.Context, conditions bson.M, columns bson.M) error {
+	if !r.isArray {
+		set := make(bson.M, len(columns))
+		for name, value := range columns {
+			set[r.section+"."+name] = value
+		}
+		_, err := r.profiles().UpdateMany(ctx, r.hasRecord(conditions), bson.M{"$set": set})
+		return err
+	}
+
+	set := make(bson.M, len(columns))
+	for name, value := range columns {
+		set[r.section+".$[r]."+name] = value
+	}
+	arrayFilter := make(bson.M, len(conditions))
+	for name, condition := range conditions {
+		arrayFilter["r."+name] = condition
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{arrayFilter}})
+	_, err := r.profiles().UpdateMany(ctx, r.hasRecord(conditions), bson.M{"$set": set}, opts)
+	return err
+}
+
+// delete the records matching the conditions from the profile documents permanently
+func (r *mongoRepository[T]) purgeRecords(ctx context.Context, conditions bson.M) error {
+	update := bson.M{"$pull": bson.M{r.section: conditions}}
+	if !r.isArray {
+		update = bson.M{"$unset": bson.M{r.section: ""}}
+	}
+	_, err := r.profiles().UpdateMany(ctx, r.hasRecord(conditions), update)
+	return err
+}
+
+// the aggregation of the records of the query, the records are unwound from the profile documents
+func (r *mongoRepository[T]) pipeline(q *mongoQuery) bson.A {
+	stages := bson.A{}
+	if len(q.profile) > 0 {
+		stages = append(stages, bson.M{"$match": q.profile})
+	}
+	stages = append(stages,
+		bson.M{"$unwind": "$" + r.section},
+		bson.M{"$replaceRoot": bson.M{"newRoot": "$" + r.section}},
+	)
+	filter := q.filter
+	if !q.isUnscoped {
+		filter = andFilter(filter, bson.M{"deleted_at": nil})
+	}
+	if len(filter) > 0 {
+		stages = append(stages, bson.M{"$match": filter})
+	}
+	for _, stage := range q.sort {
+		stages = append(stages, stage)
+	}
+	if q.skip > 0 {
+		stages = append(stages, bson.M{"$skip": q.skip})
+	}
+	if q.limit > 0 {
+		stages = append(stages, bson.M{"$limit": q.limit})
+	}
+	if len(q.projection) > 0 {
+		stages = append(stages, bson.M{"$project": q.projection})
+	}
+	return stages
+}
+
+// find the documents of the records
+func (r *mongoRepository[T]) findDocuments(ctx context.Context, q *mongoQuery) ([]bson.M, error) {
+	cursor, err := r.profiles().Aggregate(ctx, r.pipeline(q))
+	if err != nil {
+		return nil, err
+	}
+	docs := []bson.M{}
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *mongoRepository[T]) find(ctx context.Context, q *mongoQuery) ([]*T, error) {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, err
+	}
+	docs, err := r.findDocuments(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*T, 0, len(docs))
+	for _, doc := range docs {
+		record := new(T)
+		err = decodeDocument(ctx, sch, doc, record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// find the first record of the query, model.ErrRecordNotFound is returned if there is none
+func (r *mongoRepository[T]) first(ctx context.Context, q *mongoQuery) (*T, error) {
+	q.limit = 1
+	records, err := r.find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, model.ErrRecordNotFound
+	}
+	return records[0], nil
+}
+
+func (r *mongoRepository[T]) count(ctx context.Context, q *mongoQuery) (int64, error) {
+	pipeline := append(r.pipeline(q), bson.M{"$count": "total"})
+	cursor, err := r.profiles().Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	results := []struct {
+		Total int64 `bson:"total"`
+	}{}
+	err = cursor.All(ctx, &results)
+	if err != nil || len(results) == 0 {
+		return 0, err
+	}
+	return results[0].Total, nil
+}
+
+// the query of a record by id
+func (r *mongoRepository[T]) byID(id uint64) *mongoQuery {
+	return &mongoQuery{
+		profile: bson.M{r.section + ".id": int64(id)},
+		filter:  bson.M{"id": int64(id)},
+	}
+}
+
+// the query of the records of the ids
+func (r *mongoRepository[T]) byIDs(ids []uint64) *mongoQuery {
+	return &mongoQuery{
+		profile: bson.M{r.section + ".id": bson.M{"$in": toInt64s(ids)}},
+		filter:  bson.M{"id": bson.M{"$in": toInt64s(ids)}},
+	}
+}
+
+// get the users of the records that are going to be changed, including the soft deleted ones, so that the
+// records of the users are deleted from the cache after the change.
+func (r *mongoRepository[T]) usersOf(ctx context.Context, ids ...uint64) ([]int, error) {
+	if r.collection == nil || len(ids) == 0 {
+		return nil, nil
+	}
+	values, err := r.profiles().Distinct(ctx, "_id", bson.M{r.section + ".id": bson.M{"$in": toInt64s(ids)}})
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]int, 0, len(values))
+	for _, value := range values {
+		if userID, ok := toInt64(value); ok {
+			userIDs = append(userIDs, int(userID))
+		}
+	}
+	return userIDs, nil
+}
+
+// the next id of the table from the collection counters, like a sequence of postgresql
+func (r *mongoRepository[T]) nextID(ctx context.Context) (uint64, error) {
+	counter := struct {
+		Seq int64 `bson:"seq"`
+	}{}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.db.Collection(mongoCounters).FindOneAndUpdate(ctx, bson.M{"_id": r.section},
+		bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	return uint64(counter.Seq), err
+}
+
+// the position after the last record of the user, see nextPosition
+func (r *mongoRepository[T]) nextPosition(ctx context.Context, userID int) (int, error) {
+	cursor, err := r.profiles().Aggregate(ctx, append(r.pipeline(&mongoQuery{profile: bson.M{"_id": int64(userID)}}),
+		bson.M{"$group": bson.M{"_id": nil, "position": bson.M{"$max": "$position"}}}))
+	if err != nil {
+		return 0, err
+	}
+	results := []bson.M{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return 0, err
+	}
+	position := int64(0)
+	if len(results) > 0 {
+		position, _ = toInt64(results[0]["position"])
+	}
+	return int(position) + 1, nil
+}
+
+// insert a record with a new id, the version, the position and the times are filled the same as gorm. the user
+// is inserted as a new profile document, and the other records are pushed to the document of their user,
+// gorm.ErrForeignKeyViolated is returned if the user does not exist.
+func (r *mongoRepository[T]) insert(ctx context.Context, table *T) error {
+	sch, err := parseSchema(table)
+	if err != nil {
+		return err
+	}
+	if version := r.mapper.Version(table); *version == 0 {
+		*version = 1
+	}
+	if id := r.mapper.ID(table); *id == 0 {
+		*id, err = r.nextID(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	rv := reflect.Indirect(reflect.ValueOf(table))
+	now := time.Now()
+	for _, field := range sch.Fields {
+		if field.AutoCreateTime == 0 && field.AutoUpdateTime == 0 {
+			continue
+		}
+		if _, isZero := field.ValueOf(ctx, rv); isZero {
+			_ = field.Set(ctx, rv, now)
+		}
+	}
+
+	if !r.isArray {
+		doc := bson.M{"_id": int64(*r.mapper.ID(table)), r.section: encodeDocument(ctx, sch, table)}
+		_, err = r.profiles().InsertOne(ctx, doc)
+		if mongo.IsDuplicateKeyError(err) {
+			return gorm.ErrDuplicatedKey
+		}
+		return err
+	}
+
+	userID := r.mapper.UserID(table)
+	if r.mapper.Position != nil {
+		position, _ := r.mapper.Position(table)
+		if *position == 0 {
+			*position, err = r.nextPosition(ctx, userID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	result, err := r.profiles().UpdateOne(ctx, profileOf(int64(userID)),
+		bson.M{"$push": bson.M{r.section: encodeDocument(ctx, sch, table)}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return gorm.ErrForeignKeyViolated
+	}
+	return nil
+}
+
+// Create a record, insert the record and the id value is written back to the table
+func (r *mongoRepository[T]) Create(ctx context.Context, table *T) error {
+	err := r.insert(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	r.deleteCollections(ctx, r.userID(table))
+
+	return nil
+}
+
+// DeleteByID delete a record by id
+func (r *mongoRepository[T]) DeleteByID(ctx context.Context, id uint64) error {
+	return r.DeleteByIDs(ctx, []uint64{id})
+}
+
+// DeleteByIDs delete records by batch id
+func (r *mongoRepository[T]) DeleteByIDs(ctx context.Context, ids []uint64) error {
+	userIDs, err := r.usersOf(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	err = r.updateRecords(ctx, bson.M{"id": bson.M{"$in": toInt64s(ids)}, "deleted_at": nil}, bson.M{"deleted_at": time.Now()})
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+	r.deleteCollections(ctx, userIDs...)
+
+	return nil
+}
+
+// UpdateByID update a record by id
+func (r *mongoRepository[T]) UpdateByID(ctx context.Context, table *T) error {
+	id := *r.mapper.ID(table)
+	if id < 1 {
+		return errors.New("id cannot be 0")
+	}
+	userIDs, err := r.usersOf(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.patch(ctx, id, 0, r.mapper.Updates(table))
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	if userID := r.userID(table); userID != 0 {
+		userIDs = append(userIDs, userID)
+	}
+	r.deleteCollections(ctx, userIDs...)
+
+	return err
+}
+
+// update the columns of a record by id and increase its version, the record is read, changed and written back as
+// a whole if its version has not changed, so that the columns can be adjusted with the record. if version is not
+// 0, model.ErrRecordModified is returned if the record has another version, otherwise the record is read again.
+// model.ErrRecordNotFound is returned if the record does not exist.
+// a record whose user is changed is moved to the document of the new user. the revision of the record is saved
+// after it is updated if its table keeps revisions, see SetRevisionDB.
+func (r *mongoRepository[T]) patch(ctx context.Context, id uint64, version int, columns map[string]interface{}) error {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return err
+	}
+	values, err := coerceColumns(sch, columns)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < mongoRetries; i++ {
+		docs, err := r.findDocuments(ctx, r.byID(id))
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return model.ErrRecordNotFound
+		}
+		record := docs[0]
+		oldVersion, _ := toInt64(record["version"])
+		if version > 0 && oldVersion != int64(version) {
+			return model.ErrRecordModified
+		}
+		oldUserID := record["user_id"]
+		old := new(T)
+		err = decodeDocument(ctx, sch, record, old)
+		if err != nil {
+			return err
+		}
+
+		changes := bson.M{}
+		for name, value := range values {
+			changes[name] = value
+		}
+		if r.columns != nil {
+			r.columns(record, changes)
+		}
+		for name, value := range changes {
+			record[name] = value
+		}
+		record["version"] = oldVersion + 1
+		record["updated_at"] = time.Now()
+
+		conditions := bson.M{"id": int64(id), "version": oldVersion, "deleted_at": nil}
+		newUserID, _ := toInt64(record["user_id"])
+		if oldID, _ := toInt64(oldUserID); r.isArray && newUserID != oldID {
+			isMoved, err := r.move(ctx, conditions, oldID, newUserID, record)
+			if err != nil {
+				return err
+			}
+			if isMoved {
+				saveMongoRevision(ctx, sch, id, old, columns)
+				return nil
+			}
+			continue
+		}
+
+		result, err := r.profiles().UpdateOne(ctx, r.hasRecord(conditions), bson.M{"$set": bson.M{r.matched(""): record}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount > 0 {
+			saveMongoRevision(ctx, sch, id, old, columns)
+			return nil
+		}
+	}
+
+	return model.ErrRecordModified
+}
+
+// move a record matching the conditions to the document of another user, it is pushed to the new document and
+// pulled from the old one in one transaction, false is returned if the old one has changed. the transaction is
+// the one of the unit of work of ctx if ctx is in a unit of work.
+func (r *mongoRepository[T]) move(ctx context.Context, conditions bson.M, from int64, to int64, record bson.M) (bool, error) {
+	isMoved := false
+	err := NewMongoUnitOfWork(r.db).Do(ctx, func(ctx context.Context) error {
+		isMoved = false // the transaction may be retried
+		result, err := r.profiles().UpdateOne(ctx, profileOf(to), bson.M{"$push": bson.M{r.section: record}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return gorm.ErrForeignKeyViolated
+		}
+
+		filter := andFilter(bson.M{"_id": from}, r.hasRecord(conditions))
+		result, err = r.profiles().UpdateOne(ctx, filter, bson.M{"$pull": bson.M{r.section: bson.M{"id": conditions["id"]}}})
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			// pulled back in the same transaction, which may be the one of the unit of work that goes on
+			_, err = r.profiles().UpdateOne(ctx, bson.M{"_id": to},
+				bson.M{"$pull": bson.M{r.section: bson.M{"id": conditions["id"], "version": record["version"]}}})
+			return err
+		}
+		isMoved = true
+		return nil
+	})
+	return isMoved, err
+}
+
+// GetByID get a record by id
+func (r *mongoRepository[T]) GetByID(ctx context.Context, id uint64) (*T, error) {
+	// no cache
+	if r.cache == nil {
+		return r.loadByID(ctx, id)
+	}
+
+	// get from cache or database
+	return r.getByID(ctx, id, r.loadByID)
+}
+
+func (r *mongoRepository[T]) loadByID(ctx context.Context, id uint64) (*T, error) {
+	return r.first(ctx, r.byID(id))
+}
+
+// GetByCondition get a record by condition, see repository.GetByCondition
+func (r *mongoRepository[T]) GetByCondition(ctx context.Context, c *query.Conditions) (*T, error) {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, err
+	}
+	filter, err := mongoConditions(sch, c.Columns)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := parseMongoSort(sch, "id")
+	if err != nil {
+		return nil, err
+	}
+	return r.first(ctx, &mongoQuery{filter: filter, sort: mongoSort(columns, false)})
+}
+
+// GetByIDs get records by batch id
+func (r *mongoRepository[T]) GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*T, error) {
+	load := func(ctx context.Context, ids []uint64) ([]*T, error) {
+		return r.find(ctx, r.byIDs(ids))
+	}
+
+	// no cache
+	if r.cache == nil {
+		records, err := load(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		itemMap := make(map[uint64]*T)
+		for _, record := range records {
+			itemMap[*r.mapper.ID(record)] = record
+		}
+		return itemMap, nil
+	}
+
+	// get form cache or database
+	return r.getByIDs(ctx, ids, load)
+}
+
+// GetByLastID get paging records by last id and limit, see repository.GetByLastID
+func (r *mongoRepository[T]) GetByLastID(ctx context.Context, lastID uint64, limit int, sort string, filters ...clause.Expression) ([]*T, error) {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, err
+	}
+	filter, err := mongoFilters(sch, filters)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := parseMongoSort(sch, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.find(ctx, &mongoQuery{
+		filter:     andFilter(bson.M{"id": bson.M{"$lt": int64(lastID)}}, filter),
+		sort:       mongoSort(columns, false),
+		limit:      query.NewPage(0, limit, sort).Size(),
+		projection: mongoProjection(filters),
+	})
+}
+
+// GetByColumns get paging records by column information, see repository.GetByColumns
+func (r *mongoRepository[T]) GetByColumns(ctx context.Context, params *query.Params, filters ...clause.Expression) ([]*T, int64, error) {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, 0, err
+	}
+	conditions, err := mongoConditions(sch, params.Columns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+	filter, err := mongoFilters(sch, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	q := &mongoQuery{filter: andFilter(conditions, filter)}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		total, err = r.count(ctx, q)
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	columns, err := parseMongoSort(sch, params.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	page := query.NewPage(params.Page, params.Size, params.Sort)
+	q.sort = mongoSort(columns, false)
+	q.skip = page.Offset()
+	q.limit = page.Size()
+	q.projection = mongoProjection(filters)
+	records, err := r.find(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
+
+// GetByCursor get a keyset page of records, see repository.GetByCursor
+func (r *mongoRepository[T]) GetByCursor(ctx context.Context, params *CursorParams) ([]*T, bool, error) {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, false, err
+	}
+	columns, err := parseKeysetSort(new(T), params.Sort)
+	if err != nil {
+		return nil, false, err
+	}
+	conditions, err := mongoConditions(sch, params.Columns)
+	if err != nil {
+		return nil, false, errors.New("query params error: " + err.Error())
+	}
+	filter, err := mongoFilters(sch, params.Filters)
+	if err != nil {
+		return nil, false, err
+	}
+	keyset := bson.M{}
+	if params.Values != nil {
+		keyset, err = mongoKeysetConditions(columns, params.Values, params.IsPrev)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	sortColumns := []string{}
+	for _, column := range columns {
+		sortColumns = append(sortColumns, column.field.DBName)
+	}
+
+	limit := query.NewPage(0, params.Limit, "").Size()
+	records, err := r.find(ctx, &mongoQuery{
+		filter:     andFilter(conditions, filter, keyset),
+		sort:       mongoSort(columns, params.IsPrev),
+		limit:      limit + 1,
+		projection: mongoProjection(params.Filters, sortColumns...),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(records) > limit
+	if hasMore {
+		records = records[:limit]
+	}
+	if params.IsPrev {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+	return records, hasMore, nil
+}
+
+// CreateByTx create a record, tx is not used by mongodb, the record is written in the transaction of ctx if
+// it is a mongo.SessionContext, see runMongoBatch.
+func (r *mongoRepository[T]) CreateByTx(ctx context.Context, _ *gorm.DB, table *T) (uint64, error) {
+	err := r.Create(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	return *r.mapper.ID(table), nil
+}
+
+// DeleteByTx delete a record by id, tx is not used by mongodb, see CreateByTx. model.ErrRecordNotFound is
+// returned if the record does not exist or has been deleted, see repository.DeleteByTx.
+func (r *mongoRepository[T]) DeleteByTx(ctx context.Context, _ *gorm.DB, id uint64) error {
+	total, err := r.count(ctx, r.byID(id))
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return model.ErrRecordNotFound
+	}
+	return r.DeleteByID(ctx, id)
+}
+
+// UpdateByTx update a record by id, tx is not used by mongodb, see CreateByTx
+func (r *mongoRepository[T]) UpdateByTx(ctx context.Context, _ *gorm.DB, table *T) error {
+	return r.UpdateByID(ctx, table)
+}
+
+// CreateBatch create records, the id values are written back to the tables, see runMongoBatch
+func (r *mongoRepository[T]) CreateBatch(ctx context.Context, tables []*T, isAtomic bool) ([]error, error) {
+	return runMongoBatch(ctx, r.db.Client(), len(tables), isAtomic, func(ctx context.Context, i int) error {
+		return r.Create(ctx, tables[i])
+	})
+}
+
+// UpdateBatch replace records by id as ReplaceByID does, see repository.UpdateBatch and runMongoBatch
+func (r *mongoRepository[T]) UpdateBatch(ctx context.Context, tables []*T, isAtomic bool) ([]error, error) {
+	return runMongoBatch(ctx, r.db.Client(), len(tables), isAtomic, func(ctx context.Context, i int) error {
+		return r.ReplaceByID(ctx, tables[i])
+	})
+}
+
+// DeleteByIDAndVersion delete a record by id only when its version has not changed, see repository.DeleteByIDAndVersion
+func (r *mongoRepository[T]) DeleteByIDAndVersion(ctx context.Context, id uint64, version int) error {
+	userIDs, err := r.usersOf(ctx, id)
+	if err != nil {
+		return err
+	}
+	conditions := bson.M{"id": int64(id), "deleted_at": nil}
+	if version > 0 {
+		conditions["version"] = version
+	}
+	result, err := r.profiles().UpdateOne(ctx, r.hasRecord(conditions), bson.M{"$set": bson.M{r.matched("deleted_at"): time.Now()}})
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	r.deleteCollections(ctx, userIDs...)
+
+	if result.ModifiedCount == 0 {
+		total, err := r.count(ctx, r.byID(id))
+		if err != nil {
+			return err
+		}
+		if total == 0 {
+			return model.ErrRecordNotFound
+		}
+		return model.ErrRecordModified
+	}
+	return nil
+}
+
+// ReplaceByID replace all fields of a record by id, see repository.ReplaceByID
+func (r *mongoRepository[T]) ReplaceByID(ctx context.Context, table *T) error {
+	columns, err := replaceColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+	return r.PatchByID(ctx, *r.mapper.ID(table), *r.mapper.Version(table), columns)
+}
+
+// PatchByID update the columns of a record by id and increase its version, see repository.PatchByID
+func (r *mongoRepository[T]) PatchByID(ctx context.Context, id uint64, version int, columns map[string]interface{}) error {
+	if id < 1 {
+		return errors.New("id cannot be 0")
+	}
+
+	userIDs, err := r.usersOf(ctx, id)
+	if err != nil {
+		return err
+	}
+	if userID, ok := columns[r.mapper.OwnerColumn].(int); ok && r.collection != nil {
+		userIDs = append(userIDs, userID)
+	}
+
+	err = r.patch(ctx, id, version, columns)
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	r.deleteCollections(ctx, userIDs...)
+
+	return err
+}
+
+// ListDeleted get paging soft deleted records of a user, the latest deleted is first
+func (r *mongoRepository[T]) ListDeleted(ctx context.Context, userID uint64, page int, size int) ([]*T, int64, error) {
+	q := &mongoQuery{
+		profile:    bson.M{"_id": int64(userID)},
+		filter:     bson.M{r.mapper.OwnerColumn: int64(userID), "deleted_at": bson.M{"$ne": nil}},
+		isUnscoped: true,
+	}
+
+	total, err := r.count(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, total, nil
+	}
+
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, 0, err
+	}
+	columns, err := parseMongoSort(sch, "-deleted_at")
+	if err != nil {
+		return nil, 0, err
+	}
+	p := query.NewPage(page, size, "-deleted_at")
+	q.sort = mongoSort(columns, false)
+	q.skip = p.Offset()
+	q.limit = p.Size()
+	records, err := r.find(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
+
+// RestoreByIDs restore soft deleted records by batch id, see repository.RestoreByIDs
+func (r *mongoRepository[T]) RestoreByIDs(ctx context.Context, ids []uint64) error {
+	conditions := bson.M{"id": bson.M{"$in": toInt64s(ids)}, "deleted_at": bson.M{"$ne": nil}}
+	err := r.checkDeleted(ctx, conditions, ids)
+	if err != nil {
+		return err
+	}
+	userIDs, err := r.usersOf(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	columns := bson.M{"deleted_at": nil}
+	for name, value := range r.mapper.RestoreColumns {
+		columns[name] = value
+	}
+	err = r.updateRecords(ctx, conditions, columns)
+	if err != nil {
+		return err
+	}
+
+	// delete cache, a not found placeholder may have been cached after the records were deleted
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+	r.deleteCollections(ctx, userIDs...)
+
+	return nil
+}
+
+// PurgeByIDs permanently delete soft deleted records by batch id, see repository.PurgeByIDs
+func (r *mongoRepository[T]) PurgeByIDs(ctx context.Context, ids []uint64) error {
+	conditions := bson.M{"id": bson.M{"$in": toInt64s(ids)}, "deleted_at": bson.M{"$ne": nil}}
+	err := r.checkDeleted(ctx, conditions, ids)
+	if err != nil {
+		return err
+	}
+	err = r.purgeRecords(ctx, conditions)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+
+	return nil
+}
+
+// check that all the records of the ids are soft deleted before they are restored or purged, because the
+// number of the changed records is not known after the update of the profile documents, see checkAffected.
+func (r *mongoRepository[T]) checkDeleted(ctx context.Context, conditions bson.M, ids []uint64) error {
+	total, err := r.count(ctx, &mongoQuery{
+		profile:    bson.M{r.section + ".id": bson.M{"$in": toInt64s(ids)}},
+		filter:     conditions,
+		isUnscoped: true,
+	})
+	if err != nil {
+		return err
+	}
+	if total != int64(countDistinct(ids)) {
+		return model.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeletedBefore permanently delete records that were soft deleted before t, return the number of deleted records
+func (r *mongoRepository[T]) PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	conditions := bson.M{"deleted_at": bson.M{"$lt": t}}
+	total, err := r.count(ctx, &mongoQuery{filter: conditions, isUnscoped: true})
+	if err != nil || total == 0 {
+		return 0, err
+	}
+	err = r.purgeRecords(ctx, conditions)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// VerifyCache compare n random cached records with database, see repository.VerifyCache
+func (r *mongoRepository[T]) VerifyCache(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return r.verifyCache(ctx, n, repair, r.loadByID)
+}
+
+// get all records of the users sorted by sort
+func (r *mongoRepository[T]) findByUsers(ctx context.Context, userIDs []int, sort string) ([]*T, error) {
+	sch, err := parseSchema(new(T))
+	if err != nil {
+		return nil, err
+	}
+	columns, err := parseMongoSort(sch, sort)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(userIDs))
+	for _, userID := range userIDs {
+		ids = append(ids, int64(userID))
+	}
+	return r.find(ctx, &mongoQuery{
+		profile: bson.M{"_id": bson.M{"$in": ids}},
+		filter:  bson.M{r.mapper.OwnerColumn: bson.M{"$in": ids}},
+		sort:    mongoSort(columns, false),
+	})
+}
+
+// reorderByUser rewrite the positions of the records of a user in the order of ids in one update of the document
+// of the user, only the changed records are updated, see reorder.
+func (r *mongoRepository[T]) reorderByUser(ctx context.Context, userID int, ids []uint64) error {
+	err := r.reorder(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+	r.deleteCollections(ctx, userID)
+
+	return nil
+}
+
+func (r *mongoRepository[T]) reorder(ctx context.Context, userID int, ids []uint64) error {
+	for i := 0; i < mongoRetries; i++ {
+		records, err := r.findDocuments(ctx, &mongoQuery{profile: bson.M{"_id": int64(userID)}})
+		if err != nil {
+			return err
+		}
+		positions := make(map[int64]int64, len(ids))
+		for i, id := range ids {
+			positions[int64(id)] = int64(i + 1)
+		}
+		if len(positions) != len(ids) || len(records) != len(ids) {
+			return model.ErrInvalidPositions
+		}
+		for _, record := range records {
+			id, _ := toInt64(record["id"])
+			if _, ok := positions[id]; !ok {
+				return model.ErrInvalidPositions
+			}
+		}
+
+		// the versions of all the records are checked in the filter, so that the update is not partly applied
+		filters := []bson.M{{"_id": int64(userID)}}
+		set, inc := bson.M{}, bson.M{}
+		arrayFilters := []interface{}{}
+		for _, record := range records {
+			id, _ := toInt64(record["id"])
+			position, _ := toInt64(record["position"])
+			if positions[id] == position {
+				continue
+			}
+			identifier := "r" + strconv.Itoa(len(arrayFilters))
+			filters = append(filters, r.hasRecord(bson.M{"id": id, "version": record["version"], "deleted_at": nil}))
+			arrayFilters = append(arrayFilters, bson.M{identifier + ".id": id})
+			set[r.section+".$["+identifier+"].position"] = positions[id]
+			inc[r.section+".$["+identifier+"].version"] = 1
+		}
+		if len(arrayFilters) == 0 {
+			return nil
+		}
+
+		opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+		result, err := r.profiles().UpdateOne(ctx, andFilter(filters...), bson.M{"$set": set, "$inc": inc}, opts)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+	}
+	return model.ErrRecordModified
+}
+
+// runMongoBatch run fn for each of the n items, the error of each item is returned in the same order. if isAtomic
+// is true, the items are run in one transaction which is aborted when any item fails (all-or-nothing), mongodb
+// must be a replica set for the transactions, and the cache is invalidated after the transaction is committed.
+// otherwise the items are run one by one (best-effort). err is returned only if the transaction itself fails.
+// if ctx is in a unit of work, the items join the transaction of its session instead, mongodb has no savepoints,
+// so the changes of the items before a failed atomic item can not be rolled back alone, err is returned then
+// and the unit of work must be rolled back.
+func runMongoBatch(ctx context.Context, client *mongo.Client, n int, isAtomic bool, fn func(ctx context.Context, i int) error) ([]error, error) {
+	errs := make([]error, n)
+	if isInUnitOfWork(ctx) {
+		for i := 0; i < n; i++ {
+			discard := discardAfter(ctx)
+			if errs[i] = fn(ctx, i); errs[i] != nil {
+				discard()
+				if isAtomic {
+					return errs, fmt.Errorf("batch item %d failed in the unit of work: %w", i, errs[i])
+				}
+			}
+		}
+		return errs, nil
+	}
+	if !isAtomic {
+		for i := 0; i < n; i++ {
+			errs[i] = fn(ctx, i)
+		}
+		return errs, nil
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+	var uow *unitOfWork
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		for i := range errs {
+			errs[i] = nil // the transaction may be retried
+		}
+		uow = &unitOfWork{} // the cache invalidations are deferred until the transaction is committed
+		ctx := context.WithValue(sc, unitOfWorkKey{}, uow)
+		for i := 0; i < n; i++ {
+			if errs[i] = fn(ctx, i); errs[i] != nil {
+				return nil, errBatchItemFailed
+			}
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errBatchItemFailed) {
+		err = nil
+	} else if err == nil {
+		uow.commit(ctx)
+	}
+
+	return errs, err
+}
@@ -0,0 +1,170 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/model"
+)
+
+// DefaultOutboxChannel the default redis channel that the outbox events are published to
+const DefaultOutboxChannel = "weaving_net:outbox"
+
+var _ OutboxDao = (*outboxDao)(nil)
+
+// OutboxPublisher publish the outbox events, e.g. to a message queue. the events are published at least once,
+// a consumer should skip the events that it has handled by their ids.
+type OutboxPublisher interface {
+	Publish(ctx context.Context, events []*model.OutboxEvents) error
+}
+
+var (
+	outboxMu        sync.RWMutex
+	outboxPublisher OutboxPublisher
+)
+
+// SetOutboxPublisher set the publisher of the outbox events, the *ByTx methods of the daos of postgresql write the
+// events of the changes to the outbox table only if the publisher is set, nil disables the outbox.
+func SetOutboxPublisher(p OutboxPublisher) {
+	outboxMu.Lock()
+	outboxPublisher = p
+	outboxMu.Unlock()
+}
+
+func getOutboxPublisher() OutboxPublisher {
+	outboxMu.RLock()
+	defer outboxMu.RUnlock()
+	return outboxPublisher
+}
+
+type redisOutboxPublisher struct {
+	rdb     *redis.Client
+	channel string
+}
+
+// NewRedisOutboxPublisher creating the publisher of the outbox events to a redis channel, each event is
+// published as json, if channel is empty, DefaultOutboxChannel is used.
+func NewRedisOutboxPublisher(rdb *redis.Client, channel string) OutboxPublisher {
+	if channel == "" {
+		channel = DefaultOutboxChannel
+	}
+	return &redisOutboxPublisher{rdb: rdb, channel: channel}
+}
+
+// Publish the events to the channel in order
+func (p *redisOutboxPublisher) Publish(ctx context.Context, events []*model.OutboxEvents) error {
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		err = p.rdb.Publish(ctx, p.channel, data).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write the event of a change of a record in tx, the event is published after the unit of work of ctx is
+// committed and discarded with it if it is rolled back. if ctx is not in a unit of work, the event is published
+// by the relay task, see OutboxDao. nothing is written if there is no publisher.
+func addOutboxEvent(ctx context.Context, tx *gorm.DB, table string, change string, id uint64, payload interface{}) error {
+	if getOutboxPublisher() == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	event := &model.OutboxEvents{
+		EventType:    table + "." + change,
+		ResourceType: table,
+		ResourceID:   id,
+		Payload:      string(data),
+	}
+	err = tx.WithContext(ctx).Create(event).Error
+	if err != nil {
+		return err
+	}
+
+	if uow := unitOfWorkFromContext(ctx); uow != nil && uow.db != nil {
+		uow.mu.Lock()
+		uow.events = append(uow.events, event)
+		uow.mu.Unlock()
+	}
+	return nil
+}
+
+// publish the events of a committed transaction and mark them as published in db, the events that fail to be
+// published are left to the relay task.
+func publishOutboxEvents(ctx context.Context, db *gorm.DB, events []*model.OutboxEvents) {
+	publisher := getOutboxPublisher()
+	if publisher == nil {
+		return
+	}
+	err := publisher.Publish(ctx, events)
+	if err != nil {
+		logger.Warn("publish outbox events error", logger.Err(err), logger.Int("events", len(events)))
+		return
+	}
+
+	ids := make([]uint64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	err = NewOutboxDao(db).MarkPublished(ctx, ids)
+	if err != nil {
+		logger.Warn("mark outbox events published error", logger.Err(err), logger.Any("ids", ids))
+	}
+}
+
+// OutboxDao the dao of the outbox events that the relay task uses
+type OutboxDao interface {
+	ListUnpublished(ctx context.Context, before time.Time, limit int) ([]*model.OutboxEvents, error)
+	MarkPublished(ctx context.Context, ids []uint64) error
+	PurgePublishedBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type outboxDao struct {
+	db *gorm.DB
+}
+
+// NewOutboxDao creating the dao of the outbox events
+func NewOutboxDao(db *gorm.DB) OutboxDao {
+	return &outboxDao{db: db}
+}
+
+// ListUnpublished get the events created before the time that are not published, the earliest is first
+func (d *outboxDao) ListUnpublished(ctx context.Context, before time.Time, limit int) ([]*model.OutboxEvents, error) {
+	records := []*model.OutboxEvents{}
+	err := d.db.WithContext(ctx).Where("published_at IS NULL AND created_at < ?", before).
+		Order("id ASC").Limit(limit).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// MarkPublished set the published time of the events by batch id
+func (d *outboxDao) MarkPublished(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Model(&model.OutboxEvents{}).Where("id IN (?)", ids).
+		Update("published_at", time.Now()).Error
+}
+
+// PurgePublishedBefore permanently delete the events published before t, return the number of deleted events
+func (d *outboxDao) PurgePublishedBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).Unscoped().Where("published_at < ?", t).Delete(&model.OutboxEvents{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,256 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/model"
+)
+
+// the publisher that keeps the published events
+type fakeOutboxPublisher struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (p *fakeOutboxPublisher) Publish(ctx context.Context, events []*model.OutboxEvents) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, event := range events {
+		p.events = append(p.events, fmt.Sprintf("%s:%d", event.EventType, event.ResourceID))
+	}
+	return nil
+}
+
+func (p *fakeOutboxPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := p.events
+	p.events = nil
+	return events
+}
+
+type outboxTest struct {
+	db        *gorm.DB
+	uow       UnitOfWork
+	skills    SkillsDao
+	cache     cache.SkillsCache
+	publisher *fakeOutboxPublisher
+}
+
+func newOutboxTest(t *testing.T) *outboxTest {
+	dsn := fmt.Sprintf("file:outbox%d?mode=memory&cache=shared", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&model.Skills{}, &model.OutboxEvents{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := &fakeOutboxPublisher{}
+	SetOutboxPublisher(publisher)
+	t.Cleanup(func() {
+		SetOutboxPublisher(nil)
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+
+	xCache := cache.NewSkillsCache(&model.CacheType{CType: "memory"})
+	return &outboxTest{
+		db:        db,
+		uow:       NewUnitOfWork(db),
+		skills:    NewSkillsDao(db, xCache),
+		cache:     xCache,
+		publisher: publisher,
+	}
+}
+
+func (o *outboxTest) createSkill(t *testing.T, name string) *model.Skills {
+	record := &model.Skills{UserID: 1, SkillName: name}
+	err := o.uow.Do(context.Background(), func(ctx context.Context) error {
+		_, err := o.skills.CreateByTx(ctx, TxFromContext(ctx), record)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = o.skills.GetByID(context.Background(), record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the memory cache is set asynchronously
+	assert.Eventually(t, func() bool {
+		_, err := o.cache.Get(context.Background(), record.ID)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	return record
+}
+
+func (o *outboxTest) unpublished(t *testing.T) int64 {
+	var total int64
+	err := o.db.Model(&model.OutboxEvents{}).Where("published_at IS NULL").Count(&total).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return total
+}
+
+func Test_repository_ByTx_outbox(t *testing.T) {
+	o := newOutboxTest(t)
+	ctx := context.Background()
+	foo := o.createSkill(t, "foo")
+	bar := o.createSkill(t, "bar")
+	assert.Equal(t, []string{fmt.Sprintf("skills.created:%d", foo.ID), fmt.Sprintf("skills.created:%d", bar.ID)}, o.publisher.published())
+
+	// rolled back, the cache is kept and no event is written
+	errRollback := errors.New("rollback")
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		err := o.skills.DeleteByTx(ctx, TxFromContext(ctx), foo.ID)
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	_, err = o.cache.Get(ctx, foo.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, o.publisher.published())
+	assert.Equal(t, int64(0), o.unpublished(t))
+
+	// committed, the tx of the unit of work joins it with any context
+	err = o.uow.Do(ctx, func(ctx context.Context) error {
+		tx := TxFromContext(ctx)
+		err := o.skills.UpdateByTx(context.Background(), tx, &model.Skills{Model: bar.Model, SkillName: "baz"})
+		if err != nil {
+			return err
+		}
+		err = o.skills.DeleteByTx(ctx, tx, foo.ID)
+		if err != nil {
+			return err
+		}
+		_, err = o.cache.Get(ctx, foo.ID) // deleted from the cache after commit
+		assert.NoError(t, err)
+		_, err = o.cache.Get(ctx, bar.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, o.publisher.published())
+		return nil
+	})
+	assert.NoError(t, err)
+	_, err = o.cache.Get(ctx, foo.ID)
+	assert.Error(t, err)
+	_, err = o.cache.Get(ctx, bar.ID)
+	assert.Error(t, err)
+	assert.Equal(t, []string{fmt.Sprintf("skills.updated:%d", bar.ID), fmt.Sprintf("skills.deleted:%d", foo.ID)}, o.publisher.published())
+	assert.Equal(t, int64(0), o.unpublished(t))
+
+	// soft deleted by gorm like DeleteByID
+	_, err = o.skills.GetByID(ctx, foo.ID)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+	deleted := &model.Skills{}
+	err = o.db.Unscoped().Where("id = ?", foo.ID).First(deleted).Error
+	assert.NoError(t, err)
+	assert.True(t, deleted.DeletedAt.Valid)
+	assert.False(t, deleted.UpdatedAt.IsZero())
+
+	// deleted again, the unit of work is rolled back and no event is written
+	err = o.uow.Do(ctx, func(ctx context.Context) error {
+		return o.skills.DeleteByTx(ctx, TxFromContext(ctx), foo.ID)
+	})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+	assert.Empty(t, o.publisher.published())
+	assert.Equal(t, int64(0), o.unpublished(t))
+
+	// a transaction that was not opened by a unit of work is rejected, the cache would be invalidated before commit
+	err = o.db.Transaction(func(tx *gorm.DB) error {
+		_, err := o.skills.CreateByTx(ctx, tx, &model.Skills{UserID: 1, SkillName: "qux"})
+		return err
+	})
+	assert.ErrorIs(t, err, ErrTxNotInUnitOfWork)
+	assert.Empty(t, o.publisher.published())
+	assert.Equal(t, int64(0), o.unpublished(t))
+
+	// not in a transaction, the change is committed at once
+	_, err = o.skills.CreateByTx(ctx, o.db, &model.Skills{UserID: 1, SkillName: "qux"})
+	assert.NoError(t, err)
+	assert.Empty(t, o.publisher.published())
+	assert.Equal(t, int64(1), o.unpublished(t))
+	events, err := NewOutboxDao(o.db).ListUnpublished(ctx, time.Now().Add(time.Second), 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "skills", events[0].ResourceType)
+}
+
+func Test_runBatch_outbox(t *testing.T) {
+	o := newOutboxTest(t)
+	ctx := context.Background()
+	foo := o.createSkill(t, "foo")
+	o.publisher.published()
+
+	// the failed item is rolled back to its savepoint, only the other item is published
+	missing := &model.Skills{SkillName: "missing"}
+	missing.ID = foo.ID + 100
+	errs, err := o.skills.UpdateBatch(ctx, []*model.Skills{{Model: foo.Model, SkillName: "bar"}, missing}, false)
+	assert.NoError(t, err)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], model.ErrRecordNotFound)
+	assert.Equal(t, []string{fmt.Sprintf("skills.updated:%d", foo.ID)}, o.publisher.published())
+
+	// atomic, nothing is published if any item fails
+	errs, err = o.skills.CreateBatch(ctx, []*model.Skills{{UserID: 1, SkillName: "baz"}, {UserID: 1, SkillName: "qux"}}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Len(t, o.publisher.published(), 2)
+	errs, err = o.skills.UpdateBatch(ctx, []*model.Skills{{Model: foo.Model, SkillName: "baz"}, missing}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[1], model.ErrRecordNotFound)
+	assert.Empty(t, o.publisher.published())
+	assert.Equal(t, int64(0), o.unpublished(t))
+	record, err := o.skills.GetByID(ctx, foo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", record.SkillName)
+}
+
+func Test_outboxDao(t *testing.T) {
+	o := newOutboxTest(t)
+	ctx := context.Background()
+	SetOutboxPublisher(nil)
+
+	// no publisher, no event
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		_, err := o.skills.CreateByTx(ctx, TxFromContext(ctx), &model.Skills{UserID: 1, SkillName: "foo"})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), o.unpublished(t))
+
+	d := NewOutboxDao(o.db)
+	err = o.db.Create(&model.OutboxEvents{EventType: "skills.created", ResourceType: "skills", ResourceID: 1}).Error
+	assert.NoError(t, err)
+	events, err := d.ListUnpublished(ctx, time.Now().Add(time.Second), 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	events, err = d.ListUnpublished(ctx, time.Now().Add(-time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	err = d.MarkPublished(ctx, []uint64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), o.unpublished(t))
+	rows, err := d.PurgePublishedBefore(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rows)
+	rows, err = d.PurgePublishedBefore(ctx, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+}
@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"weaving_net/internal/model"
+)
+
+// SortPosition sort the records of a user by the position set by the user, the same positions are sorted by id
+const SortPosition = "position,id"
+
+// nextPosition the position after the last record of the user, a new record without position is appended to the end
+func nextPosition(ctx context.Context, db *gorm.DB, table interface{}, userID int) (int, error) {
+	var position int
+	err := db.WithContext(ctx).Model(table).Select("COALESCE(MAX(position), 0)").Where("user_id = ?", userID).Scan(&position).Error
+	return position + 1, err
+}
+
+// reorder rewrite the positions of the records of the user in one transaction, the positions start from 1 in
+// the order of ids, only the changed records are updated. ids must be exactly all the records of the user,
+// otherwise ErrInvalidPositions is returned.
+func reorder(ctx context.Context, db *gorm.DB, table interface{}, userID int, ids []uint64) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		records := []struct {
+			ID       uint64
+			Position int
+		}{}
+		err := tx.Model(table).Select("id, position").Where("user_id = ?", userID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).Find(&records).Error
+		if err != nil {
+			return err
+		}
+		positions := make(map[uint64]int, len(ids))
+		for i, id := range ids {
+			positions[id] = i + 1
+		}
+		if len(positions) != len(ids) || len(records) != len(ids) {
+			return model.ErrInvalidPositions
+		}
+		for _, record := range records {
+			if _, ok := positions[record.ID]; !ok {
+				return model.ErrInvalidPositions
+			}
+		}
+
+		for _, record := range records {
+			position := positions[record.ID]
+			if position == record.Position {
+				continue
+			}
+			err = tx.Model(table).Where("id = ?", record.ID).Updates(map[string]interface{}{
+				"position": position,
+				"version":  gorm.Expr("version + 1"),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/model"
+)
+
+var (
+	// while another instance holds the lock of a value and loads it, the cache is got every interval for
+	// the times, then the value is loaded from database if it is still not in the cache.
+	lockWaitInterval = 20 * time.Millisecond
+	lockWaitTimes    = 25
+)
+
+// readThrough the accesses of a cached value for getCached
+type readThrough[V any] struct {
+	key  string                                     // the key of the value in singleflight
+	get  func(ctx context.Context) (V, bool, error) // get the value and whether it is stale from the cache
+	lock func(ctx context.Context) (func(), error)  // lock the value across the instances, see cache.Options
+	load func(ctx context.Context) (V, error)       // load the value from database and set it to the cache
+}
+
+// getCached get a value from the cache, or load it if it is not in the cache, the loads of the same key are merged
+// by singleflight in one instance, and only the instance that holds the lock of the value loads it.
+// a stale value is returned at once, and it is reloaded in the background (stale-while-revalidate), so that
+// the expiration of a hot value does not make all the instances access database at the same time.
+// the errors of the cache, e.g. the placeholder of the not found record, are returned as they are.
+func getCached[V any](ctx context.Context, sfg *singleflight.Group, rt *readThrough[V]) (V, error) {
+	value, stale, err := rt.get(ctx)
+	if err == nil {
+		if stale {
+			revalidate(sfg, rt)
+		}
+		return value, nil
+	}
+	if !errors.Is(err, model.ErrCacheNotFound) {
+		return value, err
+	}
+
+	// for the same key, prevent high concurrent simultaneous access to database
+	val, err, _ := sfg.Do(rt.key, func() (interface{}, error) {
+		return reload(ctx, rt, true)
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	value, ok := val.(V)
+	if !ok {
+		var zero V
+		return zero, model.ErrRecordNotFound
+	}
+	return value, nil
+}
+
+// revalidate reload a stale value in the background, it is skipped if another instance holds the lock
+func revalidate[V any](sfg *singleflight.Group, rt *readThrough[V]) {
+	go func() {
+		_, err, _ := sfg.Do(rt.key, func() (interface{}, error) { //nolint
+			return reload(context.Background(), rt, false)
+		})
+		if err != nil && !errors.Is(err, model.ErrCacheLocked) {
+			logger.Warn("reload stale cache error", logger.Err(err), logger.String("key", rt.key))
+		}
+	}()
+}
+
+// reload load a value with its lock, if another instance holds the lock and wait is true, the value set to the
+// cache by the instance is returned, otherwise model.ErrCacheLocked is returned. the value is not loaded if it
+// has been set to the cache by another instance before the lock is got.
+func reload[V any](ctx context.Context, rt *readThrough[V], wait bool) (V, error) {
+	unlock, err := rt.lock(ctx)
+	if err == nil {
+		defer unlock()
+		value, stale, err := rt.get(ctx)
+		if err == nil && !stale {
+			return value, nil
+		}
+		return rt.load(ctx)
+	}
+	if !errors.Is(err, model.ErrCacheLocked) || !wait {
+		var zero V
+		return zero, err
+	}
+
+	for i := 0; i < lockWaitTimes; i++ {
+		select {
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		case <-time.After(lockWaitInterval):
+		}
+		value, _, err := rt.get(ctx)
+		if !errors.Is(err, model.ErrCacheNotFound) {
+			return value, err
+		}
+	}
+
+	// the lock may be lost, e.g. the instance is down
+	return rt.load(ctx)
+}
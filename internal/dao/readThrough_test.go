@@ -0,0 +1,169 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/singleflight"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/config"
+	"weaving_net/internal/model"
+)
+
+// a cached value of the other instances, locked is whether another instance holds the lock
+type fakeCached struct {
+	value  atomic.Value
+	stale  atomic.Bool
+	locked atomic.Bool
+	loads  atomic.Int32
+}
+
+func (f *fakeCached) readThrough() *readThrough[string] {
+	return &readThrough[string]{
+		key: "1",
+		get: func(ctx context.Context) (string, bool, error) {
+			value, ok := f.value.Load().(string)
+			if !ok {
+				return "", false, model.ErrCacheNotFound
+			}
+			return value, f.stale.Load(), nil
+		},
+		lock: func(ctx context.Context) (func(), error) {
+			if f.locked.Load() {
+				return nil, model.ErrCacheLocked
+			}
+			return func() {}, nil
+		},
+		load: func(ctx context.Context) (string, error) {
+			f.loads.Add(1)
+			f.value.Store("loaded")
+			f.stale.Store(false)
+			return "loaded", nil
+		},
+	}
+}
+
+func Test_getCached(t *testing.T) {
+	sfg := new(singleflight.Group)
+	ctx := context.Background()
+
+	// not in the cache
+	f := &fakeCached{}
+	value, err := getCached(ctx, sfg, f.readThrough())
+	assert.NoError(t, err)
+	assert.Equal(t, "loaded", value)
+	assert.Equal(t, int32(1), f.loads.Load())
+
+	// the stale value is returned and reloaded in the background
+	f = &fakeCached{}
+	f.value.Store("stale")
+	f.stale.Store(true)
+	value, err = getCached(ctx, sfg, f.readThrough())
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", value)
+	assert.Eventually(t, func() bool { return f.loads.Load() == 1 }, time.Second, time.Millisecond)
+
+	// the stale value is not reloaded if another instance holds the lock
+	f = &fakeCached{}
+	f.value.Store("stale")
+	f.stale.Store(true)
+	f.locked.Store(true)
+	_, err = getCached(ctx, sfg, f.readThrough())
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(0), f.loads.Load())
+
+	// the value set by another instance before the lock is got is not loaded again
+	f = &fakeCached{}
+	rt := f.readThrough()
+	rt.lock = func(ctx context.Context) (func(), error) {
+		f.value.Store("other")
+		return func() {}, nil
+	}
+	value, err = getCached(ctx, sfg, rt)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", value)
+	assert.Equal(t, int32(0), f.loads.Load())
+
+	// the cache error
+	rt = f.readThrough()
+	rt.get = func(ctx context.Context) (string, bool, error) { return "", false, errors.New("cache error") }
+	_, err = getCached(ctx, sfg, rt)
+	assert.Error(t, err)
+}
+
+func Test_getCached_locked(t *testing.T) {
+	defer func(interval time.Duration, times int) {
+		lockWaitInterval, lockWaitTimes = interval, times
+	}(lockWaitInterval, lockWaitTimes)
+	lockWaitInterval, lockWaitTimes = time.Millisecond, 20
+	sfg := new(singleflight.Group)
+	ctx := context.Background()
+
+	// wait for the value loaded by the instance that holds the lock
+	f := &fakeCached{}
+	f.locked.Store(true)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		f.value.Store("other")
+	}()
+	value, err := getCached(ctx, sfg, f.readThrough())
+	assert.NoError(t, err)
+	assert.Equal(t, "other", value)
+	assert.Equal(t, int32(0), f.loads.Load())
+
+	// the value is loaded if the instance does not set it in time
+	f = &fakeCached{}
+	f.locked.Store(true)
+	value, err = getCached(ctx, sfg, f.readThrough())
+	assert.NoError(t, err)
+	assert.Equal(t, "loaded", value)
+	assert.Equal(t, int32(1), f.loads.Load())
+
+	// canceled while waiting
+	f = &fakeCached{}
+	f.locked.Store(true)
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = getCached(ctx, sfg, f.readThrough())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_recordCache_getByIDs_stale(t *testing.T) {
+	cache.SetOptions(&config.Cache{Entities: map[string]config.EntityCache{"users": {StaleTime: 60}}})
+	defer cache.SetOptions(&config.Cache{})
+	usersCache := cache.NewUsersCache(&model.CacheType{CType: "memory"})
+	c := newRecordCache[model.Users](usersCache, usersMapper)
+	ctx := context.Background()
+	record := &model.Users{FirstName: "foo"}
+	record.ID = 1
+	loads := atomic.Int32{}
+	load := func(ctx context.Context, ids []uint64) ([]*model.Users, error) {
+		loads.Add(1)
+		return []*model.Users{record}, nil
+	}
+
+	// the stale record is returned and reloaded in the background
+	err := usersCache.Set(ctx, record.ID, record, time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	records, err := c.getByIDs(ctx, []uint64{record.ID}, load)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+
+	// the reloaded record is not stale
+	assert.Eventually(t, func() bool {
+		_, stale, err := usersCache.GetStale(ctx, record.ID)
+		return err == nil && !stale
+	}, time.Second, time.Millisecond)
+	_, err = c.getByIDs(ctx, []uint64{record.ID}, load)
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), loads.Load())
+}
@@ -0,0 +1,217 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/sync/singleflight"
+
+	cacheBase "github.com/zhufuyi/sponge/pkg/cache"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/model"
+)
+
+// recordCache the cache of the records of a table and of the records of each user, it is shared by the
+// repositories of the databases, the records are loaded by the functions passed by the repository.
+type recordCache[T any] struct {
+	cache      Cache[T]            // if nil, the cache is not used.
+	collection CollectionCache[T]  // if nil, the records of the users are not cached.
+	sfg        *singleflight.Group // if cache is nil, the sfg is not used.
+	mapper     *Mapper[T]
+}
+
+func newRecordCache[T any](xCache Cache[T], mapper *Mapper[T]) *recordCache[T] {
+	if xCache == nil {
+		return &recordCache[T]{mapper: mapper}
+	}
+	c := &recordCache[T]{
+		cache:  xCache,
+		sfg:    new(singleflight.Group),
+		mapper: mapper,
+	}
+	if collection, ok := xCache.(CollectionCache[T]); ok && mapper.UserID != nil {
+		c.collection = collection
+	}
+	return c
+}
+
+// delete a record from the cache, it is deleted after the commit if ctx is in a unit of work
+func (c *recordCache[T]) deleteCache(ctx context.Context, id uint64) error {
+	if c.cache == nil {
+		return nil
+	}
+	if deferUntilCommit(ctx, func(ctx context.Context) { _ = c.cache.Del(ctx, id) }) {
+		return nil
+	}
+	return c.cache.Del(ctx, id)
+}
+
+// the user id of a record, 0 if the records of the users are not cached
+func (c *recordCache[T]) userID(table *T) int {
+	if c.collection == nil {
+		return 0
+	}
+	return c.mapper.UserID(table)
+}
+
+// delete the records of the users from the cache, they are deleted after the commit if ctx is in a unit of work
+func (c *recordCache[T]) deleteCollections(ctx context.Context, userIDs ...int) {
+	if c.collection != nil && len(userIDs) > 0 {
+		AfterCommit(ctx, func(ctx context.Context) { _ = c.collection.DelCollections(ctx, userIDs...) })
+	}
+}
+
+// get a record by id from the cache, or by load from database and set it to the cache, the cache must not be nil.
+// load returns model.ErrRecordNotFound if the record does not exist, then a not found placeholder is cached.
+// the cache is not used in a unit of work, the record is loaded in the transaction.
+func (c *recordCache[T]) getByID(ctx context.Context, id uint64, load func(ctx context.Context, id uint64) (*T, error)) (*T, error) {
+	if isInUnitOfWork(ctx) {
+		return load(ctx, id)
+	}
+
+	record, err := getCached(ctx, c.sfg, c.readThrough(id, load))
+	if errors.Is(err, cacheBase.ErrPlaceholder) {
+		return nil, model.ErrRecordNotFound
+	}
+	return record, err
+}
+
+// the accesses of the cached record of id, load gets the record from database
+func (c *recordCache[T]) readThrough(id uint64, load func(ctx context.Context, id uint64) (*T, error)) *readThrough[*T] {
+	return &readThrough[*T]{
+		key:  utils.Uint64ToStr(id),
+		get:  func(ctx context.Context) (*T, bool, error) { return c.cache.GetStale(ctx, id) },
+		lock: func(ctx context.Context) (func(), error) { return c.cache.Lock(ctx, id) },
+		load: func(ctx context.Context) (*T, error) {
+			table, err := load(ctx, id)
+			if err != nil {
+				// if data is empty, set not found cache to prevent cache penetration
+				if errors.Is(err, model.ErrRecordNotFound) {
+					err = c.cache.SetCacheWithNotFound(ctx, id)
+					if err != nil {
+						return nil, err
+					}
+					return nil, model.ErrRecordNotFound
+				}
+				return nil, err
+			}
+			// set cache
+			err = c.cache.Set(ctx, id, table, 0)
+			if err != nil {
+				return nil, fmt.Errorf("cache.Set error: %v, id=%d", err, id)
+			}
+			return table, nil
+		},
+	}
+}
+
+// get records by batch id from the cache, the missed ones are got by load from database and set to the cache,
+// the stale ones are returned and reloaded in the background one by one as getByID does. the cache must not be
+// nil. the cache is not used in a unit of work, the records are loaded in the transaction.
+func (c *recordCache[T]) getByIDs(ctx context.Context, ids []uint64, load func(ctx context.Context, ids []uint64) ([]*T, error)) (map[uint64]*T, error) {
+	if isInUnitOfWork(ctx) {
+		records, err := load(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		itemMap := make(map[uint64]*T, len(records))
+		for _, record := range records {
+			itemMap[*c.mapper.ID(record)] = record
+		}
+		return itemMap, nil
+	}
+
+	itemMap, staleIDs, err := c.cache.MultiGetStale(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range staleIDs {
+		revalidate(c.sfg, c.readThrough(id, func(ctx context.Context, id uint64) (*T, error) {
+			records, err := load(ctx, []uint64{id})
+			if err != nil {
+				return nil, err
+			}
+			if len(records) == 0 {
+				return nil, model.ErrRecordNotFound
+			}
+			return records[0], nil
+		}))
+	}
+
+	var missedIDs []uint64
+	for _, id := range ids {
+		_, ok := itemMap[id]
+		if !ok {
+			missedIDs = append(missedIDs, id)
+			continue
+		}
+	}
+
+	// get missed data
+	if len(missedIDs) > 0 {
+		// find the id of an active placeholder, i.e. an id that does not exist in database
+		var realMissedIDs []uint64
+		for _, id := range missedIDs {
+			_, err = c.cache.Get(ctx, id)
+			if errors.Is(err, cacheBase.ErrPlaceholder) {
+				continue
+			}
+			realMissedIDs = append(realMissedIDs, id)
+		}
+
+		if len(realMissedIDs) > 0 {
+			missedData, err := load(ctx, realMissedIDs)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(missedData) > 0 {
+				for _, data := range missedData {
+					itemMap[*c.mapper.ID(data)] = data
+				}
+				err = c.cache.MultiSet(ctx, missedData, 0)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				for _, id := range realMissedIDs {
+					_ = c.cache.SetCacheWithNotFound(ctx, id)
+				}
+			}
+		}
+	}
+
+	return itemMap, nil
+}
+
+// listByUser get all records of a user by load, the records are got from the cache first if the records of the
+// users are cached and ctx is not in a unit of work, load is the query of the records of a user, e.g. GetByUserID
+// of the dao.
+func (c *recordCache[T]) listByUser(ctx context.Context, userID int, load func(ctx context.Context, userID int) ([]*T, error)) ([]*T, error) {
+	// no cache, or in a unit of work
+	if c.collection == nil || isInUnitOfWork(ctx) {
+		return load(ctx, userID)
+	}
+
+	// get from cache or database
+	return getCached(ctx, c.sfg, &readThrough[[]*T]{
+		key:  "user:" + strconv.Itoa(userID),
+		get:  func(ctx context.Context) ([]*T, bool, error) { return c.collection.GetCollectionStale(ctx, userID) },
+		lock: func(ctx context.Context) (func(), error) { return c.collection.LockCollection(ctx, userID) },
+		load: func(ctx context.Context) ([]*T, error) {
+			records, err := load(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			// set cache, the empty records are cached too
+			err = c.collection.SetCollection(ctx, userID, records, 0)
+			if err != nil {
+				return nil, fmt.Errorf("cache.SetCollection error: %v, userID=%d", err, userID)
+			}
+			return records, nil
+		},
+	})
+}
@@ -0,0 +1,765 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+
+	"weaving_net/internal/model"
+)
+
+// Repository the dao interface of a table with id, version and soft delete, T is the model of the table,
+// e.g. model.Users, the dao of each table is an instantiation of it with the queries of its own.
+type Repository[T any] interface {
+	Create(ctx context.Context, table *T) error
+	DeleteByID(ctx context.Context, id uint64) error
+	DeleteByIDs(ctx context.Context, ids []uint64) error
+	UpdateByID(ctx context.Context, table *T) error
+	GetByID(ctx context.Context, id uint64) (*T, error)
+	GetByCondition(ctx context.Context, condition *query.Conditions) (*T, error)
+	GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*T, error)
+	GetByLastID(ctx context.Context, lastID uint64, limit int, sort string, filters ...clause.Expression) ([]*T, error)
+	GetByColumns(ctx context.Context, params *query.Params, filters ...clause.Expression) ([]*T, int64, error)
+	GetByCursor(ctx context.Context, params *CursorParams) ([]*T, bool, error)
+	CreateBatch(ctx context.Context, tables []*T, isAtomic bool) ([]error, error)
+	UpdateBatch(ctx context.Context, tables []*T, isAtomic bool) ([]error, error)
+	DeleteByIDAndVersion(ctx context.Context, id uint64, version int) error
+	ReplaceByID(ctx context.Context, table *T) error
+	PatchByID(ctx context.Context, id uint64, version int, columns map[string]interface{}) error
+	ListDeleted(ctx context.Context, userID uint64, page int, size int) ([]*T, int64, error)
+	RestoreByIDs(ctx context.Context, ids []uint64) error
+	PurgeByIDs(ctx context.Context, ids []uint64) error
+	PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error)
+	VerifyCache(ctx context.Context, n int, repair bool) (*CacheReport, error)
+
+	CreateByTx(ctx context.Context, tx *gorm.DB, table *T) (uint64, error)
+	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *T) error
+}
+
+// Cache the cache interface of the records of a Repository, the cache of each table implements it, the records
+// are set with the duration 0 so that they expire after the expiration time configured for the cache.
+type Cache[T any] interface {
+	Set(ctx context.Context, id uint64, data *T, duration time.Duration) error
+	Get(ctx context.Context, id uint64) (*T, error)
+	GetStale(ctx context.Context, id uint64) (*T, bool, error)
+	MultiGet(ctx context.Context, ids []uint64) (map[uint64]*T, error)
+	MultiGetStale(ctx context.Context, ids []uint64) (map[uint64]*T, []uint64, error)
+	MultiSet(ctx context.Context, data []*T, duration time.Duration) error
+	Del(ctx context.Context, id uint64) error
+	SetCacheWithNotFound(ctx context.Context, id uint64) error
+	Lock(ctx context.Context, id uint64) (func(), error)
+	Sample(ctx context.Context, n int) ([]uint64, error)
+}
+
+// CollectionCache the cache interface of all the records of each user of a Repository, if the cache of a table
+// with Mapper.UserID implements it, the records of a user got by listByUser are cached, and they are deleted from
+// the cache when any record of the user is created, changed or deleted.
+type CollectionCache[T any] interface {
+	SetCollection(ctx context.Context, userID int, data []*T, duration time.Duration) error
+	GetCollection(ctx context.Context, userID int) ([]*T, error)
+	GetCollectionStale(ctx context.Context, userID int) ([]*T, bool, error)
+	DelCollections(ctx context.Context, userIDs ...int) error
+	LockCollection(ctx context.Context, userID int) (func(), error)
+	SampleCollections(ctx context.Context, n int) ([]int, error)
+}
+
+// Mapper the parts of a Repository that differ between the tables, the fields of a record are accessed by
+// the functions because they are not the same in every model.
+type Mapper[T any] struct {
+	ID      func(table *T) *uint64 // the id field of a record
+	Version func(table *T) *int    // the version field of a record
+
+	// Updates the columns of the non-zero fields of a record that UpdateByID updates, without id and version
+	Updates func(table *T) map[string]interface{}
+	// Columns optional, adjust the columns of every update and patch, e.g. reset the columns derived from the updated ones
+	Columns func(columns map[string]interface{})
+	// Position optional, the position field and the user id of a record, a new record without position is
+	// appended to the end of the records of its user.
+	Position func(table *T) (*int, int)
+	// UserID optional, the user id of a record, the records of each user are cached if it is set, see CollectionCache
+	UserID func(table *T) int
+	// OwnerColumn the column of the user id that the deleted records are listed by, e.g. user_id
+	OwnerColumn string
+	// RestoreColumns optional, the columns reset when the deleted records are restored, e.g. a unique flag that
+	// another record may have taken while they were deleted
+	RestoreColumns map[string]interface{}
+}
+
+// NewRepository creating the dao interface of a table, if xCache is nil, the cache is not used.
+func NewRepository[T any](db *gorm.DB, xCache Cache[T], mapper *Mapper[T]) Repository[T] {
+	return newRepository(db, xCache, mapper)
+}
+
+type repository[T any] struct {
+	*recordCache[T]
+	db *gorm.DB
+}
+
+func newRepository[T any](db *gorm.DB, xCache Cache[T], mapper *Mapper[T]) *repository[T] {
+	return &repository[T]{recordCache: newRecordCache(xCache, mapper), db: db}
+}
+
+// the db of ctx, it is the transaction of the unit of work if ctx is in one, see UnitOfWork
+func (r *repository[T]) dbOf(ctx context.Context) *gorm.DB {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return r.db.WithContext(ctx)
+}
+
+// the db of the reads that may be a little stale, they are routed to a read replica if there is one, the records
+// loaded into the cache are read from the primary, otherwise a stale record would be cached until it expires.
+// the reads in a unit of work are in its transaction.
+func (r *repository[T]) replicaDB(ctx context.Context) *gorm.DB {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return r.db.WithContext(model.WithReplica(ctx))
+}
+
+// the name of the table of T, e.g. the resource type of the outbox events
+func (r *repository[T]) tableName(db *gorm.DB) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return ""
+	}
+	return stmt.Schema.Table
+}
+
+// get the users of the records that are going to be changed, including the soft deleted ones, so that the
+// records of the users are deleted from the cache after the change.
+func (r *repository[T]) usersOf(ctx context.Context, db *gorm.DB, ids ...uint64) ([]int, error) {
+	if r.collection == nil || len(ids) == 0 {
+		return nil, nil
+	}
+	userIDs := []int{}
+	err := db.WithContext(ctx).Unscoped().Model(new(T)).Where("id IN (?)", ids).
+		Distinct().Pluck(r.mapper.OwnerColumn, &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// fill the version and the position of a new record
+func (r *repository[T]) beforeCreate(ctx context.Context, db *gorm.DB, table *T) error {
+	if version := r.mapper.Version(table); *version == 0 {
+		*version = 1
+	}
+	if r.mapper.Position == nil {
+		return nil
+	}
+	position, userID := r.mapper.Position(table)
+	if *position == 0 {
+		next, err := nextPosition(ctx, db, new(T), userID)
+		if err != nil {
+			return err
+		}
+		*position = next
+	}
+	return nil
+}
+
+// Create a record, insert the record and the id value is written back to the table
+func (r *repository[T]) Create(ctx context.Context, table *T) error {
+	err := r.beforeCreate(ctx, r.dbOf(ctx), table)
+	if err != nil {
+		return err
+	}
+	err = r.dbOf(ctx).Create(table).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	r.deleteCollections(ctx, r.userID(table))
+
+	return nil
+}
+
+// DeleteByID delete a record by id
+func (r *repository[T]) DeleteByID(ctx context.Context, id uint64) error {
+	userIDs, err := r.usersOf(ctx, r.dbOf(ctx), id)
+	if err != nil {
+		return err
+	}
+	err = r.dbOf(ctx).Where("id = ?", id).Delete(new(T)).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	r.deleteCollections(ctx, userIDs...)
+
+	return nil
+}
+
+// DeleteByIDs delete records by batch id
+func (r *repository[T]) DeleteByIDs(ctx context.Context, ids []uint64) error {
+	userIDs, err := r.usersOf(ctx, r.dbOf(ctx), ids...)
+	if err != nil {
+		return err
+	}
+	err = r.dbOf(ctx).Where("id IN (?)", ids).Delete(new(T)).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+	r.deleteCollections(ctx, userIDs...)
+
+	return nil
+}
+
+// UpdateByID update a record by id
+func (r *repository[T]) UpdateByID(ctx context.Context, table *T) error {
+	return r.updateByID(ctx, r.dbOf(ctx), table)
+}
+
+// update a record by id and delete the cache, the records of both the old and the new user of the record
+// are deleted from the cache, the user id is not updated if it is 0.
+func (r *repository[T]) updateByID(ctx context.Context, db *gorm.DB, table *T) error {
+	id := *r.mapper.ID(table)
+	if id < 1 {
+		return errors.New("id cannot be 0")
+	}
+	userIDs, err := r.usersOf(ctx, db, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.updateDataByID(ctx, db, table)
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	if userID := r.userID(table); userID != 0 {
+		userIDs = append(userIDs, userID)
+	}
+	r.deleteCollections(ctx, userIDs...)
+
+	return err
+}
+
+func (r *repository[T]) updateDataByID(ctx context.Context, db *gorm.DB, table *T) error {
+	update := r.mapper.Updates(table)
+
+	update["version"] = gorm.Expr("version + 1")
+	if r.mapper.Columns != nil {
+		r.mapper.Columns(update)
+	}
+
+	result := db.WithContext(ctx).Model(table).Updates(update)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetByID get a record by id
+func (r *repository[T]) GetByID(ctx context.Context, id uint64) (*T, error) {
+	// no cache
+	if r.cache == nil {
+		record := new(T)
+		err := r.replicaDB(ctx).Where("id = ?", id).First(record).Error
+		return record, err
+	}
+
+	// get from cache or database
+	return r.getByID(ctx, id, r.loadByID)
+}
+
+// get a record by id from database for the cache
+func (r *repository[T]) loadByID(ctx context.Context, id uint64) (*T, error) {
+	table := new(T)
+	err := r.dbOf(ctx).Where("id = ?", id).First(table).Error
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// GetByCondition get a record by condition
+// query conditions:
+//
+//	name: column name
+//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
+//	value: column value, if exp=in, multiple values are separated by commas
+//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
+//
+// example: find a male aged 20
+//
+//	condition = &query.Conditions{
+//	    Columns: []query.Column{
+//		{
+//			Name:    "age",
+//			Value:   20,
+//		},
+//		{
+//			Name:  "gender",
+//			Value: "male",
+//		},
+//	}
+func (r *repository[T]) GetByCondition(ctx context.Context, c *query.Conditions) (*T, error) {
+	queryStr, args, err := c.ConvertToGorm()
+	if err != nil {
+		return nil, err
+	}
+
+	table := new(T)
+	err = r.dbOf(ctx).Where(queryStr, args...).First(table).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// GetByIDs get records by batch id
+func (r *repository[T]) GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*T, error) {
+	// no cache
+	if r.cache == nil {
+		var records []*T
+		err := r.replicaDB(ctx).Where("id IN (?)", ids).Find(&records).Error
+		if err != nil {
+			return nil, err
+		}
+		itemMap := make(map[uint64]*T)
+		for _, record := range records {
+			itemMap[*r.mapper.ID(record)] = record
+		}
+		return itemMap, nil
+	}
+
+	// get form cache or database
+	return r.getByIDs(ctx, ids, func(ctx context.Context, ids []uint64) ([]*T, error) {
+		var records []*T
+		err := r.dbOf(ctx).Where("id IN (?)", ids).Find(&records).Error
+		return records, err
+	})
+}
+
+// GetByLastID get paging records by last id and limit, the sort only orders the page, use GetByCursor to page by other columns
+// filters are the extra conditions of the records, SelectColumns in filters selects only its columns.
+func (r *repository[T]) GetByLastID(ctx context.Context, lastID uint64, limit int, sort string, filters ...clause.Expression) ([]*T, error) {
+	page := query.NewPage(0, limit, sort)
+
+	records := []*T{}
+	err := r.replicaDB(ctx).Order(page.Sort()).Limit(page.Size()).Where("id < ?", lastID).Scopes(filterScope(filters), selectScope(filters)).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetByCursor get a keyset page of records, hasMore reports whether there are more records in the paging direction,
+// the performance does not degrade with the page number because offset is not used.
+func (r *repository[T]) GetByCursor(ctx context.Context, params *CursorParams) ([]*T, bool, error) {
+	records := []*T{}
+	hasMore, err := findByCursor(r.replicaDB(ctx), &records, params)
+	if err != nil {
+		return nil, false, err
+	}
+	return records, hasMore, nil
+}
+
+// GetByColumns get paging records by column information,
+// Note: query performance degrades when table rows are very large because of the use of offset.
+//
+// params includes paging parameters and query parameters
+// paging parameters (required):
+//
+//	page: page number, starting from 0
+//	size: lines per page
+//	sort: sort fields, default is id backwards, you can add - sign before the field to indicate reverse order, no - sign to indicate ascending order, multiple fields separated by comma
+//
+// query parameters (not required):
+//
+//	name: column name
+//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
+//	value: column value, if exp=in, multiple values are separated by commas
+//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
+//
+// example: search for a male over 20 years of age
+//
+//	params = &query.Params{
+//	    Page: 0,
+//	    Size: 20,
+//	    Columns: []query.Column{
+//		{
+//			Name:    "age",
+//			Exp: ">",
+//			Value:   20,
+//		},
+//		{
+//			Name:  "gender",
+//			Value: "male",
+//		},
+//	}
+//
+// filters are the extra conditions with the operators that query parameters do not support, e.g. between and is null.
+// SelectColumns in filters selects only its columns of the records, the count is not affected.
+func (r *repository[T]) GetByColumns(ctx context.Context, params *query.Params, filters ...clause.Expression) ([]*T, int64, error) {
+	queryStr, args, err := params.ConvertToGormConditions()
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		err = r.replicaDB(ctx).Model(new(T)).Select([]string{"id"}).Where(queryStr, args...).Scopes(filterScope(filters)).Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	records := []*T{}
+	order, limit, offset := params.ConvertToPage()
+	err = r.replicaDB(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Scopes(filterScope(filters), selectScope(filters)).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, err
+}
+
+// CreateByTx create a record in the database using the provided transaction, the cache is invalidated and the
+// outbox event is published after the unit of work of tx is committed, tx should be got by TxFromContext,
+// ErrTxNotInUnitOfWork is returned if it is a transaction that was not opened by a unit of work.
+func (r *repository[T]) CreateByTx(ctx context.Context, tx *gorm.DB, table *T) (uint64, error) {
+	ctx, err := txContext(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	err = r.beforeCreate(ctx, tx, table)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.WithContext(ctx).Create(table).Error
+	if err != nil {
+		return 0, err
+	}
+	id := *r.mapper.ID(table)
+	err = addOutboxEvent(ctx, tx, r.tableName(tx), model.OutboxCreated, id, table)
+	if err != nil {
+		return 0, err
+	}
+
+	// delete cache
+	r.deleteCollections(ctx, r.userID(table))
+
+	return id, nil
+}
+
+// DeleteByTx soft delete a record by id in the database using the provided transaction, see CreateByTx.
+// model.ErrRecordNotFound is returned if the record does not exist or has been deleted.
+func (r *repository[T]) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error {
+	ctx, err := txContext(ctx, tx)
+	if err != nil {
+		return err
+	}
+	userIDs, err := r.usersOf(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	result := tx.WithContext(ctx).Where("id = ?", id).Delete(new(T))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+	err = addOutboxEvent(ctx, tx, r.tableName(tx), model.OutboxDeleted, id, map[string]uint64{"id": id})
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	r.deleteCollections(ctx, userIDs...)
+
+	return nil
+}
+
+// UpdateByTx update a record by id in the database using the provided transaction, zero value fields are not
+// updated, see CreateByTx
+func (r *repository[T]) UpdateByTx(ctx context.Context, tx *gorm.DB, table *T) error {
+	ctx, err := txContext(ctx, tx)
+	if err != nil {
+		return err
+	}
+	err = r.updateByID(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	return addOutboxEvent(ctx, tx, r.tableName(tx), model.OutboxUpdated, *r.mapper.ID(table), r.mapper.Updates(table))
+}
+
+// CreateBatch create records in one transaction by CreateByTx, the id values are written back to the tables,
+// see runBatch for the errors and the meaning of isAtomic.
+func (r *repository[T]) CreateBatch(ctx context.Context, tables []*T, isAtomic bool) ([]error, error) {
+	return runBatch(ctx, r.dbOf(ctx), len(tables), isAtomic, func(ctx context.Context, tx *gorm.DB, i int) error {
+		_, err := r.CreateByTx(ctx, tx, tables[i])
+		return err
+	})
+}
+
+// UpdateBatch replace records by id in one transaction as ReplaceByID does, the version of each table is checked,
+// the error of an item whose record has been modified is model.ErrRecordModified, see runBatch for the errors
+// and the meaning of isAtomic.
+func (r *repository[T]) UpdateBatch(ctx context.Context, tables []*T, isAtomic bool) ([]error, error) {
+	return runBatch(ctx, r.dbOf(ctx), len(tables), isAtomic, func(ctx context.Context, tx *gorm.DB, i int) error {
+		return r.replaceByTx(ctx, tx, tables[i])
+	})
+}
+
+// replaceByTx replace a record by id using the provided transaction and add the outbox event of the change
+func (r *repository[T]) replaceByTx(ctx context.Context, tx *gorm.DB, table *T) error {
+	columns, err := replaceColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+	payload := make(map[string]interface{}, len(columns))
+	for k, v := range columns {
+		payload[k] = v
+	}
+
+	id := *r.mapper.ID(table)
+	err = r.patch(ctx, tx, id, *r.mapper.Version(table), columns)
+	if err != nil {
+		return err
+	}
+	return addOutboxEvent(ctx, tx, r.tableName(tx), model.OutboxUpdated, id, payload)
+}
+
+// DeleteByIDAndVersion delete a record by id only when its version has not changed, if version is 0, the version
+// is not checked. if no record is deleted, model.ErrRecordNotFound is returned if the record does not exist,
+// otherwise model.ErrRecordModified.
+func (r *repository[T]) DeleteByIDAndVersion(ctx context.Context, id uint64, version int) error {
+	userIDs, err := r.usersOf(ctx, r.dbOf(ctx), id)
+	if err != nil {
+		return err
+	}
+	db := r.dbOf(ctx).Where("id = ?", id)
+	if version > 0 {
+		db = db.Where("version = ?", version)
+	}
+	result := db.Delete(new(T))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	r.deleteCollections(ctx, userIDs...)
+
+	if result.RowsAffected == 0 {
+		return r.missError(r.dbOf(ctx), id, version)
+	}
+	return nil
+}
+
+// ReplaceByID replace all fields of a record by id, zero values are written too, if the version of table is not 0,
+// the record is only replaced when its version has not changed, see PatchByID for the errors.
+func (r *repository[T]) ReplaceByID(ctx context.Context, table *T) error {
+	columns, err := replaceColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+	return r.PatchByID(ctx, *r.mapper.ID(table), *r.mapper.Version(table), columns)
+}
+
+// PatchByID update the columns of a record by id and increase its version, the columns are usually converted
+// from a JSON merge patch by MergePatchToColumns, a nil value sets the column to null. if version is not 0,
+// the record is only updated when its version has not changed, otherwise model.ErrRecordModified is returned,
+// model.ErrRecordNotFound is returned if the record does not exist.
+func (r *repository[T]) PatchByID(ctx context.Context, id uint64, version int, columns map[string]interface{}) error {
+	return r.patch(ctx, r.dbOf(ctx), id, version, columns)
+}
+
+// patch update the columns of a record using db, see PatchByID
+func (r *repository[T]) patch(ctx context.Context, db *gorm.DB, id uint64, version int, columns map[string]interface{}) error {
+	if id < 1 {
+		return errors.New("id cannot be 0")
+	}
+
+	userIDs, err := r.usersOf(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	if userID, ok := columns[r.mapper.OwnerColumn].(int); ok && r.collection != nil {
+		userIDs = append(userIDs, userID)
+	}
+
+	table := new(T)
+	*r.mapper.ID(table) = id
+	update := db.Model(table)
+	if version > 0 {
+		update = update.Where("version = ?", version)
+		columns["version"] = version + 1
+	} else {
+		columns["version"] = gorm.Expr("version + 1")
+	}
+	if r.mapper.Columns != nil {
+		r.mapper.Columns(columns)
+	}
+	result := update.Updates(columns)
+
+	// delete cache
+	_ = r.deleteCache(ctx, id)
+	r.deleteCollections(ctx, userIDs...)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missError(db, id, version)
+	}
+	return nil
+}
+
+// the error of a change of a record by id and version that affects no record, model.ErrRecordNotFound if the record
+// does not exist, otherwise model.ErrRecordModified because its version has changed.
+func (r *repository[T]) missError(db *gorm.DB, id uint64, version int) error {
+	if version == 0 {
+		return model.ErrRecordNotFound
+	}
+	var total int64
+	err := db.Model(new(T)).Where("id = ?", id).Count(&total).Error
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return model.ErrRecordNotFound
+	}
+	return model.ErrRecordModified
+}
+
+// ListDeleted get paging soft deleted records of a user, the latest deleted is first
+func (r *repository[T]) ListDeleted(ctx context.Context, userID uint64, page int, size int) ([]*T, int64, error) {
+	queryStr := r.mapper.OwnerColumn + " = ? AND deleted_at IS NOT NULL"
+
+	var total int64
+	err := r.dbOf(ctx).Unscoped().Model(new(T)).Where(queryStr, userID).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, total, nil
+	}
+
+	p := query.NewPage(page, size, "-deleted_at")
+	records := []*T{}
+	err = r.dbOf(ctx).Unscoped().Order(p.Sort()).Limit(p.Size()).Offset(p.Offset()).Where(queryStr, userID).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
+
+// RestoreByIDs restore soft deleted records by batch id, if any of the records does not exist or is not soft deleted,
+// nothing is restored and model.ErrRecordNotFound is returned. the columns of Mapper.RestoreColumns are reset.
+func (r *repository[T]) RestoreByIDs(ctx context.Context, ids []uint64) error {
+	userIDs, err := r.usersOf(ctx, r.dbOf(ctx), ids...)
+	if err != nil {
+		return err
+	}
+	columns := map[string]interface{}{"deleted_at": nil}
+	for name, value := range r.mapper.RestoreColumns {
+		columns[name] = value
+	}
+	err = r.dbOf(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(new(T)).
+			Where("id IN (?) AND deleted_at IS NOT NULL", ids).Updates(columns)
+		return checkAffected(result, ids)
+	})
+	if err != nil {
+		return err
+	}
+
+	// delete cache, a not found placeholder may have been cached after the records were deleted
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+	r.deleteCollections(ctx, userIDs...)
+
+	return nil
+}
+
+// PurgeByIDs permanently delete soft deleted records by batch id, if any of the records does not exist or is not
+// soft deleted, nothing is deleted and model.ErrRecordNotFound is returned.
+func (r *repository[T]) PurgeByIDs(ctx context.Context, ids []uint64) error {
+	err := r.dbOf(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id IN (?) AND deleted_at IS NOT NULL", ids).Delete(new(T))
+		return checkAffected(result, ids)
+	})
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+
+	return nil
+}
+
+// PurgeDeletedBefore permanently delete records that were soft deleted before t, return the number of deleted records
+func (r *repository[T]) PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := r.dbOf(ctx).Unscoped().Where("deleted_at < ?", t).Delete(new(T))
+	return result.RowsAffected, result.Error
+}
+
+// reorderByUser rewrite the positions of the records of a user in the order of ids atomically, see reorder
+func (r *repository[T]) reorderByUser(ctx context.Context, userID int, ids []uint64) error {
+	err := reorder(ctx, r.dbOf(ctx), new(T), userID, ids)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, id := range ids {
+		_ = r.deleteCache(ctx, id)
+	}
+	r.deleteCollections(ctx, userID)
+
+	return nil
+}
+
+// check that a change by batch id affects all the records of the ids, model.ErrRecordNotFound is returned
+// if any of them is not affected, so that the transaction of the change is rolled back.
+func checkAffected(result *gorm.DB, ids []uint64) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(countDistinct(ids)) {
+		return model.ErrRecordNotFound
+	}
+	return nil
+}
+
+// the number of the distinct ids
+func countDistinct(ids []uint64) int {
+	seen := make(map[uint64]struct{}, len(ids))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+	return len(seen)
+}
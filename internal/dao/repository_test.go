@@ -0,0 +1,179 @@
+package dao
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/model"
+)
+
+func newSkillsRepository() *gotest.Dao {
+	testData := &model.Skills{}
+	testData.ID = 1
+	testData.UserID = 1
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao without cache
+	d := gotest.NewDao(nil, testData)
+	d.IDao = NewRepository[model.Skills](d.DB, nil, skillsMapper)
+
+	return d
+}
+
+func TestRepository_Create(t *testing.T) {
+	d := newSkillsRepository()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), 0\\) FROM `skills` WHERE user_id = \\?").
+		WithArgs(testData.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(Repository[model.Skills]).Create(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, testData.Position)
+	assert.Equal(t, 1, testData.Version)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_UpdateByID(t *testing.T) {
+	d := newSkillsRepository()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+	testData.CatalogID = 2
+
+	// the columns of the mapper are adjusted in the same update
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE `skills` SET .*`verified_at`=CASE WHEN catalog_id = \\? THEN verified_at ELSE NULL END.*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(Repository[model.Skills]).UpdateByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// zero id error
+	err = d.IDao.(Repository[model.Skills]).UpdateByID(d.Ctx, &model.Skills{})
+	assert.Error(t, err)
+}
+
+func TestRepository_ListDeleted(t *testing.T) {
+	d := newSkillsRepository()
+	defer d.Close()
+
+	// the deleted records are listed by the owner column of the mapper
+	d.SQLMock.ExpectQuery("SELECT count\\(\\*\\) FROM `skills` WHERE user_id = \\? AND deleted_at IS NOT NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	records, total, err := d.IDao.(Repository[model.Skills]).ListDeleted(d.Ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), total)
+	assert.Len(t, records, 0)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_RestoreByIDs(t *testing.T) {
+	d := newSkillsRepository()
+	defer d.Close()
+
+	// one of the records is not in the trash, nothing is restored
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE `skills` SET .*deleted_at IS NOT NULL").
+		WithArgs(nil, d.AnyTime, 1, 2, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectRollback()
+
+	err := d.IDao.(Repository[model.Skills]).RestoreByIDs(d.Ctx, []uint64{1, 2, 2})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_PurgeByIDs(t *testing.T) {
+	d := newSkillsRepository()
+	defer d.Close()
+
+	// the duplicate ids are counted once
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE FROM `skills` .*deleted_at IS NOT NULL").
+		WithArgs(1, 2, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(Repository[model.Skills]).PurgeByIDs(d.Ctx, []uint64{1, 2, 2})
+	assert.NoError(t, err)
+
+	// the record is not in the trash, nothing is deleted
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE FROM `skills` .*").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectRollback()
+
+	err = d.IDao.(Repository[model.Skills]).PurgeByIDs(d.Ctx, []uint64{3})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_GetByCursor_replica(t *testing.T) {
+	d := newSkillsRepository()
+	defer d.Close()
+	replicaSQL, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicaMock.ExpectPing() // dbresolver opens the replica
+	p := model.NewReplicaPlugin([]*sql.DB{replicaSQL}, time.Hour)
+	defer p.Close()
+	err = d.DB.Use(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the keyset pages are read from the replica
+	replicaMock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	records, hasMore, err := d.IDao.(Repository[model.Skills]).GetByCursor(d.Ctx, &CursorParams{Sort: "id", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.False(t, hasMore)
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+}
@@ -0,0 +1,110 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/model"
+)
+
+var _ RevisionsDao = (*revisionsDao)(nil)
+
+// RevisionsDao defining the dao interface
+type RevisionsDao interface {
+	GetByVersion(ctx context.Context, resourceType string, resourceID uint64, version int) (*model.Revisions, error)
+	ListByResource(ctx context.Context, resourceType string, resourceID uint64, page int, size int) ([]*model.Revisions, int64, error)
+}
+
+var (
+	revisionMu sync.RWMutex
+	revisionDB *gorm.DB
+)
+
+// SetRevisionDB set the db of the revisions of the profiles in mongodb, the daos of mongodb save the revisions of
+// the updated records to it as model.NewRevisionPlugin does for postgresql, nil disables the revisions.
+func SetRevisionDB(db *gorm.DB) {
+	revisionMu.Lock()
+	revisionDB = db
+	revisionMu.Unlock()
+}
+
+func getRevisionDB() *gorm.DB {
+	revisionMu.RLock()
+	defer revisionMu.RUnlock()
+	return revisionDB
+}
+
+// save the revision of a record of mongodb after it is updated, old is the record before the update. the revision
+// is saved after the unit of work of ctx is committed, and the update can not be rolled back in postgresql, so the
+// error is only logged.
+func saveMongoRevision(ctx context.Context, sch *schema.Schema, id uint64, old interface{}, columns map[string]interface{}) {
+	db := getRevisionDB()
+	if db == nil || !model.IsRevisionTable(sch.Table) {
+		return
+	}
+	AfterCommit(ctx, func(ctx context.Context) {
+		err := model.SaveRevision(ctx, db, sch, id, old, columns)
+		if err != nil {
+			logger.Warn("save revision error", logger.Err(err), logger.String("table", sch.Table), logger.Uint64("id", id))
+		}
+	})
+}
+
+// revisions are written by model.NewRevisionPlugin in the same transaction as the update, or after the update in
+// mongodb, see SetRevisionDB. they are never changed after they are written, so there is no cache and no write method.
+type revisionsDao struct {
+	db *gorm.DB
+}
+
+// NewRevisionsDao creating the dao interface
+func NewRevisionsDao(db *gorm.DB) RevisionsDao {
+	return &revisionsDao{db: db}
+}
+
+// GetByVersion get a revision of the resource by version
+func (d *revisionsDao) GetByVersion(ctx context.Context, resourceType string, resourceID uint64, version int) (*model.Revisions, error) {
+	if version < 1 {
+		return nil, errors.New("version cannot be less than 1")
+	}
+
+	record := &model.Revisions{}
+	err := d.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ? AND version = ?", resourceType, resourceID, version).
+		First(record).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// ListByResource get paging revisions of the resource, the latest version is first
+func (d *revisionsDao) ListByResource(ctx context.Context, resourceType string, resourceID uint64, page int, size int) ([]*model.Revisions, int64, error) {
+	queryStr := "resource_type = ? AND resource_id = ?"
+	args := []interface{}{resourceType, resourceID}
+
+	var total int64
+	err := d.db.WithContext(ctx).Model(&model.Revisions{}).Where(queryStr, args...).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, total, nil
+	}
+
+	p := query.NewPage(page, size, "-version")
+	records := []*model.Revisions{}
+	err = d.db.WithContext(ctx).Order(p.Sort()).Limit(p.Size()).Offset(p.Offset()).Where(queryStr, args...).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
@@ -0,0 +1,154 @@
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/model"
+)
+
+func newRevisionsDao() *gotest.Dao {
+	testData := &model.Revisions{}
+	testData.ID = 1
+	testData.ResourceType = "workexperiences"
+	testData.ResourceID = 1
+	testData.Version = 1
+	testData.Snapshot = `{"company":"foo"}`
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao
+	d := gotest.NewDao(nil, testData)
+	d.IDao = NewRevisionsDao(d.DB)
+
+	return d
+}
+
+func Test_revisionsDao_GetByVersion(t *testing.T) {
+	d := newRevisionsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Revisions)
+
+	rows := sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "version", "snapshot"}).
+		AddRow(testData.ID, testData.ResourceType, testData.ResourceID, testData.Version, testData.Snapshot)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ResourceType, testData.ResourceID, testData.Version).
+		WillReturnRows(rows)
+
+	record, err := d.IDao.(RevisionsDao).GetByVersion(d.Ctx, testData.ResourceType, testData.ResourceID, testData.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testData.Snapshot, record.Snapshot)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// invalid version error
+	_, err = d.IDao.(RevisionsDao).GetByVersion(d.Ctx, testData.ResourceType, testData.ResourceID, 0)
+	assert.Error(t, err)
+
+	// notfound error
+	_, err = d.IDao.(RevisionsDao).GetByVersion(d.Ctx, testData.ResourceType, testData.ResourceID, 2)
+	assert.Error(t, err)
+}
+
+func Test_revisionsDao_ListByResource(t *testing.T) {
+	d := newRevisionsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Revisions)
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(testData.ResourceType, testData.ResourceID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "version", "snapshot"}).
+		AddRow(testData.ID, testData.ResourceType, testData.ResourceID, testData.Version, testData.Snapshot)
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	records, total, err := d.IDao.(RevisionsDao).ListByResource(d.Ctx, testData.ResourceType, testData.ResourceID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, records, 1)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty result
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	records, total, err = d.IDao.(RevisionsDao).ListByResource(d.Ctx, testData.ResourceType, 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, records)
+}
+
+func Test_revisionPlugin(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+	testData.Company = "bar"
+
+	err := d.DB.Use(model.NewRevisionPlugin())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT .* FOR UPDATE").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "company"}).AddRow(testData.ID, 1, "foo"))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WithArgs("workexperiences", testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.AnyTime, d.AnyTime, nil, "workexperiences", testData.ID, 1, 3, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(WorkexperiencesDao).UpdateByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the version is stale, no revision is saved
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT .* FOR UPDATE").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "company"}).AddRow(testData.ID, 1, "foo"))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	err = d.IDao.(WorkexperiencesDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{"company": "baz"})
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
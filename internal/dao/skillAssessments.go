@@ -0,0 +1,140 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"weaving_net/internal/model"
+)
+
+var _ SkillAssessmentsDao = (*skillAssessmentsDao)(nil)
+
+// SkillAssessmentsDao defining the dao interface
+type SkillAssessmentsDao interface {
+	Create(ctx context.Context, table *model.SkillAssessments, questions []*model.SkillAssessmentQuestions) error
+	GetByID(ctx context.Context, id uint64) (*model.SkillAssessments, error)
+	GetByCatalogID(ctx context.Context, catalogID uint64) ([]*model.SkillAssessments, error)
+	GetQuestions(ctx context.Context, assessmentID uint64) ([]*model.SkillAssessmentQuestions, error)
+	CreateAttempt(ctx context.Context, assessment *model.SkillAssessments, attempt *model.SkillAssessmentAttempts) (time.Time, error)
+}
+
+// the assessments are maintained by the administrator, so there is no cache. the skills are read and verified by
+// the skills dao, because they may be in mongodb while the assessments are always in postgresql.
+type skillAssessmentsDao struct {
+	db        *gorm.DB
+	skillsDao SkillsDao
+}
+
+// NewSkillAssessmentsDao creating the dao interface, skillsDao is the dao of the skills in the database of
+// database.driver, see NewSkillsDaoByDriver
+func NewSkillAssessmentsDao(db *gorm.DB, skillsDao SkillsDao) SkillAssessmentsDao {
+	return &skillAssessmentsDao{db: db, skillsDao: skillsDao}
+}
+
+// Create an assessment with its questions in one transaction, the questions are kept in the order of the slice
+func (d *skillAssessmentsDao) Create(ctx context.Context, table *model.SkillAssessments, questions []*model.SkillAssessmentQuestions) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(table).Error
+		if err != nil {
+			return err
+		}
+		if len(questions) == 0 {
+			return nil
+		}
+		for i, question := range questions {
+			question.AssessmentID = table.ID
+			question.Position = i + 1
+		}
+		return tx.Create(&questions).Error
+	})
+}
+
+// GetByID get an assessment by id
+func (d *skillAssessmentsDao) GetByID(ctx context.Context, id uint64) (*model.SkillAssessments, error) {
+	record := &model.SkillAssessments{}
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetByCatalogID get the assessments of a canonical skill sorted by id
+func (d *skillAssessmentsDao) GetByCatalogID(ctx context.Context, catalogID uint64) ([]*model.SkillAssessments, error) {
+	records := []*model.SkillAssessments{}
+	err := d.db.WithContext(ctx).Where("catalog_id = ?", catalogID).Order("id ASC").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetQuestions get the questions of an assessment in order, the answers are included
+func (d *skillAssessmentsDao) GetQuestions(ctx context.Context, assessmentID uint64) ([]*model.SkillAssessmentQuestions, error) {
+	records := []*model.SkillAssessmentQuestions{}
+	err := d.db.WithContext(ctx).Where("assessment_id = ?", assessmentID).Order(SortPosition).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// CreateAttempt save a scored attempt in one transaction, the assessment row is locked so that concurrent submits
+// can not bypass the cooldown. if the last attempt of the user is within the cooldown of the assessment,
+// model.ErrAssessmentCooldown is returned with the time when it can be retaken. if the attempt passed, the
+// skill is verified with the higher one of its verified level and the attempt level, the verification is in the
+// transaction if the skills are in postgresql, otherwise the attempt is rolled back if the skill can not be
+// verified. ErrRecordNotFound is returned if the skill does not belong to the user or is not the canonical skill
+// of the assessment.
+func (d *skillAssessmentsDao) CreateAttempt(ctx context.Context, assessment *model.SkillAssessments, attempt *model.SkillAssessmentAttempts) (time.Time, error) {
+	var retryAt time.Time
+	attempt.AssessmentID = assessment.ID
+	err := NewUnitOfWork(d.db).Do(ctx, func(ctx context.Context) error {
+		tx := TxFromContext(ctx)
+		err := tx.Model(&model.SkillAssessments{}).Select("id").Where("id = ?", assessment.ID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.SkillAssessments{}).Error
+		if err != nil {
+			return err
+		}
+
+		skill, err := d.skillsDao.GetByID(ctx, attempt.SkillID)
+		if err != nil {
+			return err
+		}
+		if skill.UserID != attempt.UserID || skill.CatalogID != assessment.CatalogID {
+			return model.ErrRecordNotFound
+		}
+
+		last := &model.SkillAssessmentAttempts{}
+		err = tx.Where("assessment_id = ? AND user_id = ?", assessment.ID, attempt.UserID).Order("id DESC").First(last).Error
+		if err == nil {
+			retryAt = last.CreatedAt.Add(time.Duration(assessment.CooldownHours) * time.Hour)
+			if time.Now().Before(retryAt) {
+				return model.ErrAssessmentCooldown
+			}
+		} else if !errors.Is(err, model.ErrRecordNotFound) {
+			return err
+		}
+
+		err = tx.Create(attempt).Error
+		if err != nil {
+			return err
+		}
+		if !attempt.Passed {
+			return nil
+		}
+		return d.skillsDao.Verify(ctx, attempt.SkillID, attempt.Level)
+	})
+	if err != nil {
+		if errors.Is(err, model.ErrAssessmentCooldown) {
+			return retryAt, err
+		}
+		return time.Time{}, err
+	}
+
+	return time.Time{}, nil
+}
@@ -0,0 +1,186 @@
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/model"
+)
+
+func newSkillAssessmentsDao() *gotest.Dao {
+	testData := &model.SkillAssessments{}
+	testData.ID = 1
+	testData.CatalogID = 2
+	testData.Title = "Go basics"
+	testData.PassScore = 60
+	testData.CooldownHours = 24
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): &model.Skills{}})
+	c.ICache = cache.NewSkillsCache(&model.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = NewSkillAssessmentsDao(d.DB, NewSkillsDao(d.DB, c.ICache.(cache.SkillsCache)))
+
+	return d
+}
+
+func Test_skillAssessmentsDao_Create(t *testing.T) {
+	d := newSkillAssessmentsDao()
+	defer d.Close()
+	testData := &model.SkillAssessments{CatalogID: 2, Title: "Go basics", PassScore: 60}
+	questions := []*model.SkillAssessmentQuestions{
+		{Content: "q1", Options: `["a","b"]`, Answer: 1},
+		{Content: "q2", Options: `["a","b"]`, Answer: 0},
+	}
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO `skill_assessments`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectExec("INSERT INTO `skill_assessment_questions`").
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillAssessmentsDao).Create(d.Ctx, testData, questions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testData.ID, questions[1].AssessmentID)
+	assert.Equal(t, 2, questions[1].Position)
+
+	// create error test
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectRollback()
+	err = d.IDao.(SkillAssessmentsDao).Create(d.Ctx, &model.SkillAssessments{}, questions)
+	assert.Error(t, err)
+}
+
+func Test_skillAssessmentsDao_GetByID(t *testing.T) {
+	d := newSkillAssessmentsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillAssessments)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id"}).AddRow(testData.ID, testData.CatalogID))
+
+	record, err := d.IDao.(SkillAssessmentsDao).GetByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testData.CatalogID, record.CatalogID)
+
+	// not found test
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = d.IDao.(SkillAssessmentsDao).GetByID(d.Ctx, 2)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
+
+func Test_skillAssessmentsDao_GetByCatalogID(t *testing.T) {
+	d := newSkillAssessmentsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillAssessments)
+
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY id ASC").
+		WithArgs(testData.CatalogID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id"}).AddRow(testData.ID, testData.CatalogID))
+
+	records, err := d.IDao.(SkillAssessmentsDao).GetByCatalogID(d.Ctx, testData.CatalogID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(SkillAssessmentsDao).GetByCatalogID(d.Ctx, 3)
+	assert.Error(t, err)
+}
+
+func Test_skillAssessmentsDao_GetQuestions(t *testing.T) {
+	d := newSkillAssessmentsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillAssessments)
+
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY position,id").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assessment_id", "answer"}).AddRow(1, testData.ID, 1))
+
+	records, err := d.IDao.(SkillAssessmentsDao).GetQuestions(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+	assert.Equal(t, 1, records[0].Answer)
+
+	// err test
+	_, err = d.IDao.(SkillAssessmentsDao).GetQuestions(d.Ctx, 2)
+	assert.Error(t, err)
+}
+
+func Test_skillAssessmentsDao_CreateAttempt(t *testing.T) {
+	d := newSkillAssessmentsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillAssessments)
+	attempt := &model.SkillAssessmentAttempts{UserID: 1, SkillID: 3, Score: 80, Level: 4, Passed: true}
+	expectSkill := func(catalogID uint64) {
+		d.SQLMock.ExpectQuery("SELECT `id` FROM `skill_assessments` .* FOR UPDATE").
+			WithArgs(testData.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+		d.SQLMock.ExpectQuery("SELECT \\* FROM `skills`").
+			WithArgs(attempt.SkillID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "catalog_id"}).AddRow(attempt.SkillID, attempt.UserID, catalogID))
+	}
+
+	// passed, the skill is verified in the transaction
+	d.SQLMock.ExpectBegin()
+	expectSkill(testData.CatalogID)
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_attempts` .* ORDER BY id DESC").
+		WithArgs(testData.ID, attempt.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now().Add(-25*time.Hour)))
+	d.SQLMock.ExpectExec("INSERT INTO `skill_assessment_attempts`").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	d.SQLMock.ExpectQuery("SELECT DISTINCT `user_id` FROM `skills`").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(attempt.UserID))
+	d.SQLMock.ExpectExec("UPDATE `skills` SET .*verified_level`=GREATEST\\(verified_level, \\?\\)").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	d.SQLMock.ExpectCommit()
+
+	_, err := d.IDao.(SkillAssessmentsDao).CreateAttempt(d.Ctx, testData, attempt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testData.ID, attempt.AssessmentID)
+
+	// cooldown error test
+	lastAt := time.Now().Add(-time.Hour)
+	d.SQLMock.ExpectBegin()
+	expectSkill(testData.CatalogID)
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_attempts`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, lastAt))
+	d.SQLMock.ExpectRollback()
+	retryAt, err := d.IDao.(SkillAssessmentsDao).CreateAttempt(d.Ctx, testData, &model.SkillAssessmentAttempts{UserID: 1, SkillID: 3})
+	assert.ErrorIs(t, err, model.ErrAssessmentCooldown)
+	assert.WithinDuration(t, lastAt.Add(24*time.Hour), retryAt, time.Second)
+
+	// the skill is not the canonical skill of the assessment
+	d.SQLMock.ExpectBegin()
+	expectSkill(9)
+	d.SQLMock.ExpectRollback()
+	_, err = d.IDao.(SkillAssessmentsDao).CreateAttempt(d.Ctx, testData, &model.SkillAssessmentAttempts{UserID: 1, SkillID: 3})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
@@ -0,0 +1,268 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"weaving_net/internal/model"
+)
+
+var _ SkillCatalogsDao = (*skillCatalogsDao)(nil)
+
+// SkillCatalogsDao defining the dao interface
+type SkillCatalogsDao interface {
+	Create(ctx context.Context, table *model.SkillCatalogs, synonyms []string) error
+	GetByID(ctx context.Context, id uint64) (*model.SkillCatalogs, error)
+	GetChildren(ctx context.Context, parentID uint64) ([]*model.SkillCatalogs, error)
+	GetSynonyms(ctx context.Context, catalogID uint64) ([]*model.SkillSynonyms, error)
+	AddSynonyms(ctx context.Context, catalogID uint64, names []string) error
+	Match(ctx context.Context, name string) (*model.SkillCatalogs, error)
+	Suggest(ctx context.Context, q string, limit int) ([]*model.SkillCatalogs, error)
+	Normalize(ctx context.Context, record *model.Skills) (bool, error)
+
+	CreateCategory(ctx context.Context, table *model.SkillCategories) error
+	GetCategoryByID(ctx context.Context, id uint64) (*model.SkillCategories, error)
+	ListCategories(ctx context.Context) ([]*model.SkillCategories, error)
+}
+
+// the catalog is small and maintained by the administrator, so there is no cache.
+type skillCatalogsDao struct {
+	db *gorm.DB
+}
+
+// NewSkillCatalogsDao creating the dao interface
+func NewSkillCatalogsDao(db *gorm.DB) SkillCatalogsDao {
+	return &skillCatalogsDao{db: db}
+}
+
+// NormalizeSkillName the key to match a skill name, the letters are lowercase, the spaces, '-' and '_' are removed,
+// e.g. "Go lang" and "golang" are both "golang", the symbols such as "c++", "c#" and "node.js" are kept.
+func NormalizeSkillName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// Create a canonical skill with its synonyms in one transaction, ErrNameExists is returned if the name
+// or any of the synonyms is already used by a skill or a synonym in the catalog.
+func (d *skillCatalogsDao) Create(ctx context.Context, table *model.SkillCatalogs, synonyms []string) error {
+	table.Name = strings.TrimSpace(table.Name)
+	table.NormalizedName = NormalizeSkillName(table.Name)
+	if table.NormalizedName == "" {
+		return errors.New("name cannot be empty")
+	}
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := d.checkNamesNotExist(tx, append([]string{table.Name}, synonyms...))
+		if err != nil {
+			return err
+		}
+		err = tx.Create(table).Error
+		if err != nil {
+			return err
+		}
+		return d.createSynonyms(tx, table.ID, table.NormalizedName, synonyms)
+	})
+}
+
+// GetByID get a canonical skill by id
+func (d *skillCatalogsDao) GetByID(ctx context.Context, id uint64) (*model.SkillCatalogs, error) {
+	record := &model.SkillCatalogs{}
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetChildren get the child skills of a skill sorted by name
+func (d *skillCatalogsDao) GetChildren(ctx context.Context, parentID uint64) ([]*model.SkillCatalogs, error) {
+	records := []*model.SkillCatalogs{}
+	err := d.db.WithContext(ctx).Where("parent_id = ?", parentID).Order("name ASC").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetSynonyms get the synonyms of a skill sorted by name
+func (d *skillCatalogsDao) GetSynonyms(ctx context.Context, catalogID uint64) ([]*model.SkillSynonyms, error) {
+	records := []*model.SkillSynonyms{}
+	err := d.db.WithContext(ctx).Where("catalog_id = ?", catalogID).Order("name ASC").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// AddSynonyms add synonyms to a skill in one transaction, ErrNameExists is returned if any of the names
+// is already used by a skill or a synonym in the catalog.
+func (d *skillCatalogsDao) AddSynonyms(ctx context.Context, catalogID uint64, names []string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := d.checkNamesNotExist(tx, names)
+		if err != nil {
+			return err
+		}
+		return d.createSynonyms(tx, catalogID, "", names)
+	})
+}
+
+// Match get the canonical skill whose name or synonym is the same as name after normalization,
+// ErrRecordNotFound is returned if there is no match.
+func (d *skillCatalogsDao) Match(ctx context.Context, name string) (*model.SkillCatalogs, error) {
+	normalizedName := NormalizeSkillName(name)
+	if normalizedName == "" {
+		return nil, model.ErrRecordNotFound
+	}
+
+	record := &model.SkillCatalogs{}
+	db := d.db.WithContext(ctx)
+	err := db.Where("normalized_name = ?", normalizedName).
+		Or("id IN (?)", db.Model(&model.SkillSynonyms{}).Select("catalog_id").Where("normalized_name = ?", normalizedName)).
+		First(record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Suggest get at most limit canonical skills whose name or synonym starts with q after normalization,
+// the shorter names are first, which makes the exact match first.
+func (d *skillCatalogsDao) Suggest(ctx context.Context, q string, limit int) ([]*model.SkillCatalogs, error) {
+	records := []*model.SkillCatalogs{}
+	prefix := escapeLike(NormalizeSkillName(q)) + "%"
+	if prefix == "%" {
+		return records, nil
+	}
+
+	db := d.db.WithContext(ctx)
+	err := db.Where("normalized_name LIKE ?", prefix).
+		Or("id IN (?)", db.Model(&model.SkillSynonyms{}).Select("catalog_id").Where("normalized_name LIKE ?", prefix)).
+		Order("LENGTH(normalized_name) ASC, name ASC").Limit(limit).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Normalize map the skill to its canonical skill, by catalogId if it is set, otherwise by the skill name, the
+// canonical name, the category and the category name as the skill type are filled in. isMatched is false if
+// there is no match by the skill name, ErrRecordNotFound is returned if the catalogId does not exist.
+func (d *skillCatalogsDao) Normalize(ctx context.Context, record *model.Skills) (bool, error) {
+	var catalog *model.SkillCatalogs
+	var err error
+	if record.CatalogID > 0 {
+		catalog, err = d.GetByID(ctx, record.CatalogID)
+	} else {
+		catalog, err = d.Match(ctx, record.SkillName)
+		if errors.Is(err, model.ErrRecordNotFound) {
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	record.CatalogID = catalog.ID
+	record.SkillName = catalog.Name
+	record.CategoryID = catalog.CategoryID
+	if catalog.CategoryID > 0 {
+		category, err := d.GetCategoryByID(ctx, catalog.CategoryID)
+		if err != nil && !errors.Is(err, model.ErrRecordNotFound) {
+			return false, err
+		}
+		if category != nil {
+			record.SkillType = category.Name
+		}
+	}
+
+	return true, nil
+}
+
+// CreateCategory create a category, ErrNameExists is returned if the name is already used
+func (d *skillCatalogsDao) CreateCategory(ctx context.Context, table *model.SkillCategories) error {
+	var total int64
+	err := d.db.WithContext(ctx).Model(&model.SkillCategories{}).Where("name = ?", table.Name).Count(&total).Error
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		return model.ErrNameExists
+	}
+	return d.db.WithContext(ctx).Create(table).Error
+}
+
+// GetCategoryByID get a category by id
+func (d *skillCatalogsDao) GetCategoryByID(ctx context.Context, id uint64) (*model.SkillCategories, error) {
+	record := &model.SkillCategories{}
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListCategories get all categories sorted by name
+func (d *skillCatalogsDao) ListCategories(ctx context.Context) ([]*model.SkillCategories, error) {
+	records := []*model.SkillCategories{}
+	err := d.db.WithContext(ctx).Order("name ASC").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// the normalized names are unique among all the skills and synonyms of the catalog
+func (d *skillCatalogsDao) checkNamesNotExist(tx *gorm.DB, names []string) error {
+	normalizedNames := make([]string, 0, len(names))
+	for _, name := range names {
+		normalizedNames = append(normalizedNames, NormalizeSkillName(name))
+	}
+
+	var total int64
+	err := tx.Model(&model.SkillCatalogs{}).Where("normalized_name IN ?", normalizedNames).Count(&total).Error
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		err = tx.Model(&model.SkillSynonyms{}).Where("normalized_name IN ?", normalizedNames).Count(&total).Error
+		if err != nil {
+			return err
+		}
+	}
+	if total > 0 {
+		return model.ErrNameExists
+	}
+
+	return nil
+}
+
+// create the synonyms, the empty ones and the ones that are the same as the skill or each other after normalization are skipped
+func (d *skillCatalogsDao) createSynonyms(tx *gorm.DB, catalogID uint64, normalizedName string, names []string) error {
+	seen := map[string]struct{}{normalizedName: {}, "": {}}
+	synonyms := []*model.SkillSynonyms{}
+	for _, name := range names {
+		synonym := &model.SkillSynonyms{CatalogID: catalogID, Name: strings.TrimSpace(name), NormalizedName: NormalizeSkillName(name)}
+		if _, ok := seen[synonym.NormalizedName]; ok {
+			continue
+		}
+		seen[synonym.NormalizedName] = struct{}{}
+		synonyms = append(synonyms, synonym)
+	}
+	if len(synonyms) == 0 {
+		return nil
+	}
+	return tx.Create(&synonyms).Error
+}
+
+// escape the wildcard characters of LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
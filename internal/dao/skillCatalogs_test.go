@@ -0,0 +1,325 @@
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/model"
+)
+
+func newSkillCatalogsDao() *gotest.Dao {
+	testData := &model.SkillCatalogs{}
+	testData.ID = 1
+	testData.Name = "Go"
+	testData.NormalizedName = "go"
+	testData.CategoryID = 2
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao
+	d := gotest.NewDao(nil, testData)
+	d.IDao = NewSkillCatalogsDao(d.DB)
+
+	return d
+}
+
+func TestNormalizeSkillName(t *testing.T) {
+	tests := map[string]string{
+		"Go":        "go",
+		"golang":    "golang",
+		"Go lang":   "golang",
+		" Node.js ": "node.js",
+		"C++":       "c++",
+		"C#":        "c#",
+		"Vue-JS":    "vuejs",
+		"  ":        "",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, NormalizeSkillName(name), name)
+	}
+}
+
+func Test_skillCatalogsDao_Create(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := &model.SkillCatalogs{Name: " Go ", CategoryID: 2}
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WithArgs("go", "golang", "golang").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_synonyms`").
+		WithArgs("go", "golang", "golang").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	d.SQLMock.ExpectExec("INSERT INTO `skill_catalogs`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// the synonyms that are the same after normalization are created once
+	d.SQLMock.ExpectExec("INSERT INTO `skill_synonyms` .* VALUES \\(.*\\)$").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillCatalogsDao).Create(d.Ctx, testData, []string{"golang", "Go lang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Go", testData.Name)
+	assert.Equal(t, "go", testData.NormalizedName)
+
+	// name exists error test
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	d.SQLMock.ExpectRollback()
+	err = d.IDao.(SkillCatalogsDao).Create(d.Ctx, &model.SkillCatalogs{Name: "Go"}, nil)
+	assert.ErrorIs(t, err, model.ErrNameExists)
+
+	// empty name error test
+	err = d.IDao.(SkillCatalogsDao).Create(d.Ctx, &model.SkillCatalogs{Name: " "}, nil)
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsDao_GetByID(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "normalized_name"}).
+		AddRow(testData.ID, testData.Name, testData.NormalizedName)
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	record, err := d.IDao.(SkillCatalogsDao).GetByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testData.Name, record.Name)
+
+	// not found test
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = d.IDao.(SkillCatalogsDao).GetByID(d.Ctx, 2)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
+
+func Test_skillCatalogsDao_GetChildren(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "parent_id"}).
+		AddRow(2, "Gin", testData.ID)
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY name ASC").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillCatalogsDao).GetChildren(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(SkillCatalogsDao).GetChildren(d.Ctx, 2)
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsDao_GetSynonyms(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	rows := sqlmock.NewRows([]string{"id", "catalog_id", "name"}).
+		AddRow(1, testData.ID, "golang")
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY name ASC").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillCatalogsDao).GetSynonyms(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(SkillCatalogsDao).GetSynonyms(d.Ctx, 2)
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsDao_AddSynonyms(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WithArgs("golang").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_synonyms`").
+		WithArgs("golang").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	d.SQLMock.ExpectExec("INSERT INTO `skill_synonyms`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillCatalogsDao).AddSynonyms(d.Ctx, testData.ID, []string{"golang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// name exists error test
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	d.SQLMock.ExpectQuery("SELECT count.* FROM `skill_synonyms`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	d.SQLMock.ExpectRollback()
+	err = d.IDao.(SkillCatalogsDao).AddSynonyms(d.Ctx, testData.ID, []string{"golang"})
+	assert.ErrorIs(t, err, model.ErrNameExists)
+}
+
+func Test_skillCatalogsDao_Match(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "normalized_name"}).
+		AddRow(testData.ID, testData.Name, testData.NormalizedName)
+	d.SQLMock.ExpectQuery("SELECT .* WHERE \\(normalized_name = \\? OR id IN \\(SELECT `catalog_id` FROM `skill_synonyms` .*\\)\\)").
+		WithArgs("golang", "golang").
+		WillReturnRows(rows)
+
+	record, err := d.IDao.(SkillCatalogsDao).Match(d.Ctx, "Go lang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testData.Name, record.Name)
+
+	// empty name test
+	_, err = d.IDao.(SkillCatalogsDao).Match(d.Ctx, " ")
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
+
+func Test_skillCatalogsDao_Suggest(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "normalized_name"}).
+		AddRow(testData.ID, testData.Name, testData.NormalizedName)
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY LENGTH\\(normalized_name\\) ASC, name ASC LIMIT 10").
+		WithArgs("gol%", "gol%").
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillCatalogsDao).Suggest(d.Ctx, "Gol", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// the wildcard is escaped
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(`100\%%`, `100\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	records, err = d.IDao.(SkillCatalogsDao).Suggest(d.Ctx, "100%", 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+
+	// empty q test
+	records, err = d.IDao.(SkillCatalogsDao).Suggest(d.Ctx, " ", 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+}
+
+func Test_skillCatalogsDao_Normalize(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SkillCatalogs)
+
+	// match by name
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs("golang", "golang").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_id"}).AddRow(testData.ID, testData.Name, testData.CategoryID))
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_categories`").
+		WithArgs(testData.CategoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(testData.CategoryID, "Programming Languages"))
+
+	record := &model.Skills{SkillName: "golang", SkillType: "language"}
+	isMatched, err := d.IDao.(SkillCatalogsDao).Normalize(d.Ctx, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isMatched)
+	assert.Equal(t, testData.ID, record.CatalogID)
+	assert.Equal(t, "Go", record.SkillName)
+	assert.Equal(t, testData.CategoryID, record.CategoryID)
+	assert.Equal(t, "Programming Languages", record.SkillType)
+
+	// no match by name
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs("cobol", "cobol").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	isMatched, err = d.IDao.(SkillCatalogsDao).Normalize(d.Ctx, &model.Skills{SkillName: "COBOL"})
+	assert.NoError(t, err)
+	assert.False(t, isMatched)
+
+	// catalog id not found
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = d.IDao.(SkillCatalogsDao).Normalize(d.Ctx, &model.Skills{CatalogID: 9})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
+
+func Test_skillCatalogsDao_CreateCategory(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+	testData := &model.SkillCategories{Name: "Programming Languages"}
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(testData.Name).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO `skill_categories`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillCatalogsDao).CreateCategory(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// name exists error test
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(testData.Name).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(SkillCatalogsDao).CreateCategory(d.Ctx, testData)
+	assert.ErrorIs(t, err, model.ErrNameExists)
+}
+
+func Test_skillCatalogsDao_ListCategories(t *testing.T) {
+	d := newSkillCatalogsDao()
+	defer d.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow(1, "Frameworks").
+		AddRow(2, "Programming Languages")
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY name ASC").
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillCatalogsDao).ListCategories(d.Ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 2)
+
+	// err test
+	_, err = d.IDao.(SkillCatalogsDao).ListCategories(d.Ctx)
+	assert.Error(t, err)
+}
@@ -2,16 +2,11 @@ package dao
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"time"
 
-	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 
-	cacheBase "github.com/zhufuyi/sponge/pkg/cache"
 	"github.com/zhufuyi/sponge/pkg/ggorm/query"
-	"github.com/zhufuyi/sponge/pkg/utils"
 
 	"weaving_net/internal/cache"
 	"weaving_net/internal/model"
@@ -21,371 +16,142 @@ var _ SkillsDao = (*skillsDao)(nil)
 
 // SkillsDao defining the dao interface
 type SkillsDao interface {
-	Create(ctx context.Context, table *model.Skills) error
-	DeleteByID(ctx context.Context, id uint64) error
-	DeleteByIDs(ctx context.Context, ids []uint64) error
-	UpdateByID(ctx context.Context, table *model.Skills) error
-	GetByID(ctx context.Context, id uint64) (*model.Skills, error)
-	GetByCondition(ctx context.Context, condition *query.Conditions) (*model.Skills, error)
-	GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Skills, error)
-	GetByLastID(ctx context.Context, lastID uint64, limit int, sort string) ([]*model.Skills, error)
-	GetByColumns(ctx context.Context, params *query.Params) ([]*model.Skills, int64, error)
+	Repository[model.Skills]
 
-	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Skills) (uint64, error)
-	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Skills) error
+	GetByUserID(ctx context.Context, userID int) ([]*model.Skills, error)
+	ListByUserID(ctx context.Context, userID int) ([]*model.Skills, error)
+	VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error)
+	GetByUserIDs(ctx context.Context, userIDs []int) ([]*model.Skills, error)
+	GetUnmatched(ctx context.Context, lastID uint64, limit int) ([]*model.Skills, error)
+	GetUnleveled(ctx context.Context, lastID uint64, limit int) ([]*model.Skills, error)
+	Reorder(ctx context.Context, userID int, ids []uint64) error
+	Verify(ctx context.Context, id uint64, level int) error
 }
 
 type skillsDao struct {
-	db    *gorm.DB
-	cache cache.SkillsCache   // if nil, the cache is not used.
-	sfg   *singleflight.Group // if cache is nil, the sfg is not used.
+	*repository[model.Skills]
 }
 
-// NewSkillsDao creating the dao interface
-func NewSkillsDao(db *gorm.DB, xCache cache.SkillsCache) SkillsDao {
-	if xCache == nil {
-		return &skillsDao{db: db}
-	}
-	return &skillsDao{
-		db:    db,
-		cache: xCache,
-		sfg:   new(singleflight.Group),
-	}
-}
+var skillsMapper = &Mapper[model.Skills]{
+	ID:      func(table *model.Skills) *uint64 { return &table.ID },
+	Version: func(table *model.Skills) *int { return &table.Version },
+	Updates: func(table *model.Skills) map[string]interface{} {
+		update := map[string]interface{}{}
 
-func (d *skillsDao) deleteCache(ctx context.Context, id uint64) error {
-	if d.cache != nil {
-		return d.cache.Del(ctx, id)
-	}
-	return nil
-}
+		if table.UserID != 0 {
+			update["user_id"] = table.UserID
+		}
+		if table.SkillType != "" {
+			update["skill_type"] = table.SkillType
+		}
+		if table.SkillName != "" {
+			update["skill_name"] = table.SkillName
+		}
+		if table.ProficiencyLevel != "" {
+			update["proficiency_level"] = table.ProficiencyLevel
+		}
+		if table.Proficiency != 0 {
+			update["proficiency"] = table.Proficiency
+		}
+		if table.CatalogID != 0 {
+			update["catalog_id"] = table.CatalogID
+		}
+		if table.CategoryID != 0 {
+			update["category_id"] = table.CategoryID
+		}
+		if table.Position != 0 {
+			update["position"] = table.Position
+		}
 
-// Create a record, insert the record and the id value is written back to the table
-func (d *skillsDao) Create(ctx context.Context, table *model.Skills) error {
-	return d.db.WithContext(ctx).Create(table).Error
+		return update
+	},
+	Columns:     resetVerification,
+	Position:    func(table *model.Skills) (*int, int) { return &table.Position, table.UserID },
+	UserID:      func(table *model.Skills) int { return table.UserID },
+	OwnerColumn: "user_id",
 }
 
-// DeleteByID delete a record by id
-func (d *skillsDao) DeleteByID(ctx context.Context, id uint64) error {
-	err := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Skills{}).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	_ = d.deleteCache(ctx, id)
-
-	return nil
+// NewSkillsDao creating the dao interface
+func NewSkillsDao(db *gorm.DB, xCache cache.SkillsCache) SkillsDao {
+	return &skillsDao{repository: newRepository[model.Skills](db, xCache, skillsMapper)}
 }
 
-// DeleteByIDs delete records by batch id
-func (d *skillsDao) DeleteByIDs(ctx context.Context, ids []uint64) error {
-	err := d.db.WithContext(ctx).Where("id IN (?)", ids).Delete(&model.Skills{}).Error
+// GetByUserID get all records of a user sorted by position
+func (d *skillsDao) GetByUserID(ctx context.Context, userID int) ([]*model.Skills, error) {
+	records := []*model.Skills{}
+	err := d.dbOf(ctx).Where("user_id = ?", userID).Order(query.NewPage(0, 0, SortPosition).Sort()).Find(&records).Error
 	if err != nil {
-		return err
-	}
-
-	// delete cache
-	for _, id := range ids {
-		_ = d.deleteCache(ctx, id)
+		return nil, err
 	}
-
-	return nil
+	return records, nil
 }
 
-// UpdateByID update a record by id
-func (d *skillsDao) UpdateByID(ctx context.Context, table *model.Skills) error {
-	err := d.updateDataByID(ctx, d.db, table)
-
-	// delete cache
-	_ = d.deleteCache(ctx, table.ID)
-
-	return err
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *skillsDao) ListByUserID(ctx context.Context, userID int) ([]*model.Skills, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
 }
 
-func (d *skillsDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Skills) error {
-	if table.ID < 1 {
-		return errors.New("id cannot be 0")
-	}
-
-	update := map[string]interface{}{}
-
-	if table.UserID != 0 {
-		update["user_id"] = table.UserID
-	}
-	if table.SkillType != "" {
-		update["skill_type"] = table.SkillType
-	}
-	if table.SkillName != "" {
-		update["skill_name"] = table.SkillName
-	}
-	if table.ProficiencyLevel != "" {
-		update["proficiency_level"] = table.ProficiencyLevel
-	}
-
-	return db.WithContext(ctx).Model(table).Updates(update).Error
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *skillsDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
 }
 
-// GetByID get a record by id
-func (d *skillsDao) GetByID(ctx context.Context, id uint64) (*model.Skills, error) {
-	// no cache
-	if d.cache == nil {
-		record := &model.Skills{}
-		err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
-		return record, err
-	}
-
-	// get from cache or database
-	record, err := d.cache.Get(ctx, id)
-	if err == nil {
-		return record, nil
-	}
-
-	if errors.Is(err, model.ErrCacheNotFound) {
-		// for the same id, prevent high concurrent simultaneous access to database
-		val, err, _ := d.sfg.Do(utils.Uint64ToStr(id), func() (interface{}, error) { //nolint
-			table := &model.Skills{}
-			err = d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
-			if err != nil {
-				// if data is empty, set not found cache to prevent cache penetration, default expiration time 10 minutes
-				if errors.Is(err, model.ErrRecordNotFound) {
-					err = d.cache.SetCacheWithNotFound(ctx, id)
-					if err != nil {
-						return nil, err
-					}
-					return nil, model.ErrRecordNotFound
-				}
-				return nil, err
-			}
-			// set cache
-			err = d.cache.Set(ctx, id, table, cache.SkillsExpireTime)
-			if err != nil {
-				return nil, fmt.Errorf("cache.Set error: %v, id=%d", err, id)
-			}
-			return table, nil
-		})
-		if err != nil {
-			return nil, err
-		}
-		table, ok := val.(*model.Skills)
-		if !ok {
-			return nil, model.ErrRecordNotFound
-		}
-		return table, nil
-	} else if errors.Is(err, cacheBase.ErrPlaceholder) {
-		return nil, model.ErrRecordNotFound
-	}
-
-	// fail fast, if cache error return, don't request to db
-	return nil, err
-}
-
-// GetByCondition get a record by condition
-// query conditions:
-//
-//	name: column name
-//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
-//	value: column value, if exp=in, multiple values are separated by commas
-//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
-//
-// example: find a male aged 20
-//
-//	condition = &query.Conditions{
-//	    Columns: []query.Column{
-//		{
-//			Name:    "age",
-//			Value:   20,
-//		},
-//		{
-//			Name:  "gender",
-//			Value: "male",
-//		},
-//	}
-func (d *skillsDao) GetByCondition(ctx context.Context, c *query.Conditions) (*model.Skills, error) {
-	queryStr, args, err := c.ConvertToGorm()
-	if err != nil {
-		return nil, err
+// GetByUserIDs get all records of the users in one query, the records of each user are sorted by position
+func (d *skillsDao) GetByUserIDs(ctx context.Context, userIDs []int) ([]*model.Skills, error) {
+	records := []*model.Skills{}
+	if len(userIDs) == 0 {
+		return records, nil
 	}
-
-	table := &model.Skills{}
-	err = d.db.WithContext(ctx).Where(queryStr, args...).First(table).Error
+	err := d.dbOf(ctx).Where("user_id IN (?)", userIDs).Order(query.NewPage(0, 0, SortPosition).Sort()).Find(&records).Error
 	if err != nil {
 		return nil, err
 	}
-
-	return table, nil
+	return records, nil
 }
 
-// GetByIDs get records by batch id
-func (d *skillsDao) GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Skills, error) {
-	// no cache
-	if d.cache == nil {
-		var records []*model.Skills
-		err := d.db.WithContext(ctx).Where("id IN (?)", ids).Find(&records).Error
-		if err != nil {
-			return nil, err
-		}
-		itemMap := make(map[uint64]*model.Skills)
-		for _, record := range records {
-			itemMap[record.ID] = record
-		}
-		return itemMap, nil
-	}
-
-	// get form cache or database
-	itemMap, err := d.cache.MultiGet(ctx, ids)
+// GetUnmatched get the records after lastID that are not matched to the skill catalog, sorted by id
+func (d *skillsDao) GetUnmatched(ctx context.Context, lastID uint64, limit int) ([]*model.Skills, error) {
+	records := []*model.Skills{}
+	err := d.dbOf(ctx).Where("id > ? AND (catalog_id = 0 OR catalog_id IS NULL)", lastID).
+		Order("id ASC").Limit(limit).Find(&records).Error
 	if err != nil {
 		return nil, err
 	}
-
-	var missedIDs []uint64
-	for _, id := range ids {
-		_, ok := itemMap[id]
-		if !ok {
-			missedIDs = append(missedIDs, id)
-			continue
-		}
-	}
-
-	// get missed data
-	if len(missedIDs) > 0 {
-		// find the id of an active placeholder, i.e. an id that does not exist in database
-		var realMissedIDs []uint64
-		for _, id := range missedIDs {
-			_, err = d.cache.Get(ctx, id)
-			if errors.Is(err, cacheBase.ErrPlaceholder) {
-				continue
-			}
-			realMissedIDs = append(realMissedIDs, id)
-		}
-
-		if len(realMissedIDs) > 0 {
-			var missedData []*model.Skills
-			err = d.db.WithContext(ctx).Where("id IN (?)", realMissedIDs).Find(&missedData).Error
-			if err != nil {
-				return nil, err
-			}
-
-			if len(missedData) > 0 {
-				for _, data := range missedData {
-					itemMap[data.ID] = data
-				}
-				err = d.cache.MultiSet(ctx, missedData, cache.SkillsExpireTime)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				for _, id := range realMissedIDs {
-					_ = d.cache.SetCacheWithNotFound(ctx, id)
-				}
-			}
-		}
-	}
-
-	return itemMap, nil
+	return records, nil
 }
 
-// GetByLastID get paging records by last id and limit
-func (d *skillsDao) GetByLastID(ctx context.Context, lastID uint64, limit int, sort string) ([]*model.Skills, error) {
-	page := query.NewPage(0, limit, sort)
-
+// GetUnleveled get the records after lastID that have a legacy proficiency level text but no proficiency on the scale, sorted by id
+func (d *skillsDao) GetUnleveled(ctx context.Context, lastID uint64, limit int) ([]*model.Skills, error) {
 	records := []*model.Skills{}
-	err := d.db.WithContext(ctx).Order(page.Sort()).Limit(page.Size()).Where("id < ?", lastID).Find(&records).Error
+	err := d.dbOf(ctx).Where("id > ? AND proficiency = 0 AND proficiency_level <> ''", lastID).
+		Order("id ASC").Limit(limit).Find(&records).Error
 	if err != nil {
 		return nil, err
 	}
 	return records, nil
 }
 
-// GetByColumns get paging records by column information,
-// Note: query performance degrades when table rows are very large because of the use of offset.
-//
-// params includes paging parameters and query parameters
-// paging parameters (required):
-//
-//	page: page number, starting from 0
-//	size: lines per page
-//	sort: sort fields, default is id backwards, you can add - sign before the field to indicate reverse order, no - sign to indicate ascending order, multiple fields separated by comma
-//
-// query parameters (not required):
-//
-//	name: column name
-//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
-//	value: column value, if exp=in, multiple values are separated by commas
-//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
-//
-// example: search for a male over 20 years of age
-//
-//	params = &query.Params{
-//	    Page: 0,
-//	    Size: 20,
-//	    Columns: []query.Column{
-//		{
-//			Name:    "age",
-//			Exp: ">",
-//			Value:   20,
-//		},
-//		{
-//			Name:  "gender",
-//			Value: "male",
-//		},
-//	}
-func (d *skillsDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.Skills, int64, error) {
-	queryStr, args, err := params.ConvertToGormConditions()
-	if err != nil {
-		return nil, 0, errors.New("query params error: " + err.Error())
-	}
-
-	var total int64
-	if params.Sort != "ignore count" { // determine if count is required
-		err = d.db.WithContext(ctx).Model(&model.Skills{}).Select([]string{"id"}).Where(queryStr, args...).Count(&total).Error
-		if err != nil {
-			return nil, 0, err
-		}
-		if total == 0 {
-			return nil, total, nil
-		}
-	}
-
-	records := []*model.Skills{}
-	order, limit, offset := params.ConvertToPage()
-	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return records, total, err
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *skillsDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
 }
 
-// CreateByTx create a record in the database using the provided transaction
-func (d *skillsDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Skills) (uint64, error) {
-	err := tx.WithContext(ctx).Create(table).Error
-	return table.ID, err
+// Verify set the verified level of a skill to the higher one of it and level after an assessment is passed,
+// model.ErrRecordNotFound is returned if the skill does not exist.
+func (d *skillsDao) Verify(ctx context.Context, id uint64, level int) error {
+	return d.PatchByID(ctx, id, 0, map[string]interface{}{
+		"verified_level": gorm.Expr("GREATEST(verified_level, ?)", level),
+		"verified_at":    time.Now(),
+	})
 }
 
-// DeleteByTx delete a record by id in the database using the provided transaction
-func (d *skillsDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error {
-	update := map[string]interface{}{
-		"deleted_at": time.Now(),
+// clear the assessment result in the same update if the skill is mapped onto another canonical skill,
+// the result is kept if the catalog id does not change.
+func resetVerification(columns map[string]interface{}) {
+	catalogID, ok := columns["catalog_id"]
+	if !ok {
+		return
 	}
-	err := tx.WithContext(ctx).Model(&model.Skills{}).Where("id = ?", id).Updates(update).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	_ = d.deleteCache(ctx, id)
-
-	return nil
-}
-
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *skillsDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Skills) error {
-	err := d.updateDataByID(ctx, tx, table)
-
-	// delete cache
-	_ = d.deleteCache(ctx, table.ID)
-
-	return err
+	columns["verified_level"] = gorm.Expr("CASE WHEN catalog_id = ? THEN verified_level ELSE 0 END", catalogID)
+	columns["verified_at"] = gorm.Expr("CASE WHEN catalog_id = ? THEN verified_at ELSE NULL END", catalogID)
 }
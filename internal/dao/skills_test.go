@@ -2,6 +2,8 @@ package dao
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -43,6 +45,8 @@ func Test_skillsDao_Create(t *testing.T) {
 	defer d.Close()
 	testData := d.TestData.(*model.Skills)
 
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("INSERT INTO .*").
 		WithArgs(d.GetAnyArgs(testData)...).
@@ -65,6 +69,8 @@ func Test_skillsDao_DeleteByID(t *testing.T) {
 		Valid: false,
 	}
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -91,6 +97,8 @@ func Test_skillsDao_DeleteByIDs(t *testing.T) {
 		Valid: false,
 	}
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -112,6 +120,8 @@ func Test_skillsDao_UpdateByID(t *testing.T) {
 	defer d.Close()
 	testData := d.TestData.(*model.Skills)
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -129,6 +139,146 @@ func Test_skillsDao_UpdateByID(t *testing.T) {
 
 }
 
+func Test_skillsDao_DeleteByIDAndVersion(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID, 1).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(SkillsDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	err = d.IDao.(SkillsDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// delete error
+	err = d.IDao.(SkillsDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_ReplaceByID(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+	testData.Version = 1
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).ReplaceByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(SkillsDao).ReplaceByID(d.Ctx, testData)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	record := &model.Skills{}
+	record.ID = testData.ID
+	err = d.IDao.(SkillsDao).ReplaceByID(d.Ctx, record)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// zero id error
+	err = d.IDao.(SkillsDao).ReplaceByID(d.Ctx, &model.Skills{})
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_PatchByID(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(2, d.AnyTime, 1, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(SkillsDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{})
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(SkillsDao).PatchByID(d.Ctx, testData.ID, 0, map[string]interface{}{})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// zero id error
+	err = d.IDao.(SkillsDao).PatchByID(d.Ctx, 0, 0, nil)
+	assert.Error(t, err)
+}
+
 func Test_skillsDao_GetByID(t *testing.T) {
 	d := newSkillsDao()
 	defer d.Close()
@@ -255,6 +405,11 @@ func Test_skillsDao_GetByLastID(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// select columns test
+	d.SQLMock.ExpectQuery("SELECT `id`,`created_at` FROM .*").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+	_, err = d.IDao.(SkillsDao).GetByLastID(d.Ctx, 0, 10, "", SelectColumns("id", "created_at"))
+	assert.NoError(t, err)
+
 	// err test
 	_, err = d.IDao.(SkillsDao).GetByLastID(d.Ctx, 0, 10, "unknown-column")
 	assert.Error(t, err)
@@ -304,11 +459,46 @@ func Test_skillsDao_GetByColumns(t *testing.T) {
 	t.Log(err)
 }
 
+func Test_skillsDao_GetByCursor(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt)
+
+	d.SQLMock.ExpectQuery("SELECT .* WHERE \\(id < \\?\\) .* ORDER BY id DESC LIMIT 11").
+		WithArgs(uint64(2)).
+		WillReturnRows(rows)
+
+	records, hasMore, err := d.IDao.(SkillsDao).GetByCursor(d.Ctx, &CursorParams{
+		Sort:   "-id",
+		Limit:  10,
+		Values: []json.RawMessage{json.RawMessage("2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+	assert.False(t, hasMore)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	_, _, err = d.IDao.(SkillsDao).GetByCursor(d.Ctx, &CursorParams{Sort: "unknown-column"})
+	assert.Error(t, err)
+}
+
 func Test_skillsDao_CreateByTx(t *testing.T) {
 	d := newSkillsDao()
 	defer d.Close()
 	testData := d.TestData.(*model.Skills)
 
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("INSERT INTO .*").
 		WithArgs(d.GetAnyArgs(testData)...).
@@ -331,9 +521,11 @@ func Test_skillsDao_DeleteByTx(t *testing.T) {
 		Valid: false,
 	}
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(d.AnyTime, d.AnyTime, testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -341,6 +533,16 @@ func Test_skillsDao_DeleteByTx(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(SkillsDao).DeleteByTx(d.Ctx, d.DB, 100)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
 }
 
 func Test_skillsDao_UpdateByTx(t *testing.T) {
@@ -348,6 +550,8 @@ func Test_skillsDao_UpdateByTx(t *testing.T) {
 	defer d.Close()
 	testData := d.TestData.(*model.Skills)
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -359,3 +563,377 @@ func Test_skillsDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_skillsDao_GetByUserID(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "position"}).
+		AddRow(testData.ID, 1, 1)
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY position ASC, id ASC").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillsDao).GetByUserID(d.Ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(SkillsDao).GetByUserID(d.Ctx, 2)
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_ListByUserID(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "position"}).
+		AddRow(testData.ID, 1, 1)
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillsDao).ListByUserID(d.Ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// the records are got from the cache
+	records, err = d.IDao.(SkillsDao).ListByUserID(d.Ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, testData.ID, records[0].ID)
+
+	// the records of both the old and the new user are deleted from the cache when the user id is changed
+	_, err = d.IDao.(SkillsDao).ListByUserID(d.Ctx, 2)
+	assert.Error(t, err)
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "position"}))
+	_, err = d.IDao.(SkillsDao).ListByUserID(d.Ctx, 2)
+	assert.NoError(t, err)
+	d.SQLMock.ExpectQuery("SELECT DISTINCT `user_id` FROM `skills` WHERE id IN \\(\\?\\)").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+	record := &model.Skills{UserID: 2}
+	record.ID = testData.ID
+	err = d.IDao.(SkillsDao).UpdateByID(d.Ctx, record)
+	assert.NoError(t, err)
+	for _, userID := range []int{1, 2} {
+		d.SQLMock.ExpectQuery("SELECT .*").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "position"}))
+		_, err = d.IDao.(SkillsDao).ListByUserID(d.Ctx, userID)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+}
+
+func Test_skillsDao_GetByUserIDs(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "position"}).
+		AddRow(testData.ID, 1, 1).AddRow(testData.ID+1, 2, 1)
+	d.SQLMock.ExpectQuery("SELECT .* WHERE user_id IN \\(\\?,\\?\\) .* ORDER BY position ASC, id ASC").
+		WithArgs(1, 2).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillsDao).GetByUserIDs(d.Ctx, []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 2)
+
+	records, err = d.IDao.(SkillsDao).GetByUserIDs(d.Ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	// err test
+	_, err = d.IDao.(SkillsDao).GetByUserIDs(d.Ctx, []int{3})
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_GetUnmatched(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	rows := sqlmock.NewRows([]string{"id", "skill_name", "catalog_id"}).
+		AddRow(testData.ID, "golang", 0)
+	d.SQLMock.ExpectQuery("SELECT .* WHERE \\(id > \\? AND \\(catalog_id = 0 OR catalog_id IS NULL\\)\\) .* ORDER BY id ASC LIMIT 10").
+		WithArgs(0).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillsDao).GetUnmatched(d.Ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(SkillsDao).GetUnmatched(d.Ctx, 1, 10)
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_GetUnleveled(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	rows := sqlmock.NewRows([]string{"id", "proficiency_level"}).
+		AddRow(testData.ID, "精通")
+	d.SQLMock.ExpectQuery("SELECT .* WHERE \\(id > \\? AND proficiency = 0 AND proficiency_level <> ''\\) .* ORDER BY id ASC LIMIT 10").
+		WithArgs(0).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(SkillsDao).GetUnleveled(d.Ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(SkillsDao).GetUnleveled(d.Ctx, 1, 10)
+	assert.Error(t, err)
+}
+
+func Test_resetVerification(t *testing.T) {
+	columns := map[string]interface{}{"skill_name": "Go"}
+	resetVerification(columns)
+	assert.Len(t, columns, 1)
+
+	columns["catalog_id"] = uint64(2)
+	resetVerification(columns)
+	assert.Contains(t, columns, "verified_level")
+	assert.Contains(t, columns, "verified_at")
+}
+
+func Test_skillsDao_Reorder(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+
+	// only the changed position is updated
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT id, position .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position"}).AddRow(1, 1).AddRow(2, 1))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(2, d.AnyTime, 2).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).Reorder(d.Ctx, 1, []uint64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not all the records of the user
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT id, position .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position"}).AddRow(1, 1).AddRow(2, 2))
+	d.SQLMock.ExpectRollback()
+	err = d.IDao.(SkillsDao).Reorder(d.Ctx, 1, []uint64{1, 1})
+	assert.ErrorIs(t, err, model.ErrInvalidPositions)
+}
+
+func Test_skillsDao_Verify(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE `skills` SET .*verified_level`=GREATEST\\(verified_level, \\?\\)").
+		WithArgs(d.AnyTime, 4, d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).Verify(d.Ctx, testData.ID, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(SkillsDao).Verify(d.Ctx, 2, 4)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
+
+func Test_skillsDao_CreateBatch(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	errs, err := d.IDao.(SkillsDao).CreateBatch(d.Ctx, []*model.Skills{testData}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []error{nil}, errs)
+
+	// best effort, the failed item is rolled back to its savepoint
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	d.SQLMock.ExpectExec("INSERT INTO .*").WillReturnError(errors.New("insert error"))
+	d.SQLMock.ExpectExec("ROLLBACK TO SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	errs, err = d.IDao.(SkillsDao).CreateBatch(d.Ctx, []*model.Skills{{}}, false)
+	assert.NoError(t, err)
+	assert.Error(t, errs[0])
+}
+
+func Test_skillsDao_UpdateBatch(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+	testData.Version = 1
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	errs, err := d.IDao.(SkillsDao).UpdateBatch(d.Ctx, []*model.Skills{testData}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []error{nil}, errs)
+
+	// atomic, modified error and rollback
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	d.SQLMock.ExpectRollback()
+	errs, err = d.IDao.(SkillsDao).UpdateBatch(d.Ctx, []*model.Skills{testData}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[0], model.ErrRecordModified)
+}
+
+func Test_skillsDao_ListDeleted(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt, time.Now())
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, total, err := d.IDao.(SkillsDao).ListDeleted(d.Ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, records, 1)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty result
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, total, err = d.IDao.(SkillsDao).ListDeleted(d.Ctx, 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func Test_skillsDao_RestoreByIDs(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(nil, d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).RestoreByIDs(d.Ctx, []uint64{testData.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	err = d.IDao.(SkillsDao).RestoreByIDs(d.Ctx, []uint64{111})
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_PurgeByIDs(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Skills)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SkillsDao).PurgeByIDs(d.Ctx, []uint64{testData.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	err = d.IDao.(SkillsDao).PurgeByIDs(d.Ctx, []uint64{111})
+	assert.Error(t, err)
+}
+
+func Test_skillsDao_PurgeDeletedBefore(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(d.AnyTime).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	d.SQLMock.ExpectCommit()
+
+	rows, err := d.IDao.(SkillsDao).PurgeDeletedBefore(d.Ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(3), rows)
+}
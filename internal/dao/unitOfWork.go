@@ -0,0 +1,215 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+
+	"weaving_net/internal/model"
+)
+
+// UnitOfWork run the changes of several tables atomically, the daos called with the context of Do join the
+// transaction of the unit of work, and the cache of the changed records is invalidated after it is committed.
+// the outbox events of the changes of the *ByTx methods are written in the transaction and published after it is
+// committed, see SetOutboxPublisher. the invalidations and the events are discarded if it is rolled back.
+//
+// example: move a project to another user and append it to the projects of the user
+//
+//	err := uow.Do(ctx, func(ctx context.Context) error {
+//		project, err := projectsDao.GetByID(ctx, id) // read in the transaction, not from the cache
+//		if err != nil {
+//			return err
+//		}
+//		project.UserID, project.Position = userID, 0
+//		return projectsDao.UpdateByTx(ctx, dao.TxFromContext(ctx), project)
+//	})
+type UnitOfWork interface {
+	// Do run fn in a transaction, the transaction is committed if fn returns nil, otherwise it is rolled back
+	// and the error of fn is returned. if ctx is already in a unit of work, fn joins it.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type unitOfWorkKey struct{}
+
+// the state of the unit of work of a context, the functions and the outbox events queued in it are run and
+// published after it is committed, they are discarded if it is rolled back.
+type unitOfWork struct {
+	tx *gorm.DB // nil in mongodb, the session is in the context
+	db *gorm.DB // the db that the outbox events are marked as published in, nil in mongodb
+
+	mu          sync.Mutex
+	afterCommit []func(ctx context.Context)
+	events      []*model.OutboxEvents
+}
+
+func unitOfWorkFromContext(ctx context.Context) *unitOfWork {
+	uow, _ := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	return uow
+}
+
+// run the functions queued by AfterCommit in order and publish the outbox events, ctx is the context of Do that
+// is not in the transaction
+func (u *unitOfWork) commit(ctx context.Context) {
+	u.mu.Lock()
+	fns, events := u.afterCommit, u.events
+	u.afterCommit, u.events = nil, nil
+	u.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(ctx)
+	}
+	if len(events) > 0 {
+		publishOutboxEvents(ctx, u.db, events)
+	}
+}
+
+// discardAfter return a function that discards the functions and the outbox events queued in the unit of work of
+// ctx after discardAfter is called, it is called when the changes are rolled back to a savepoint.
+func discardAfter(ctx context.Context) func() {
+	uow := unitOfWorkFromContext(ctx)
+	if uow == nil {
+		return func() {}
+	}
+	uow.mu.Lock()
+	fns, events := len(uow.afterCommit), len(uow.events)
+	uow.mu.Unlock()
+
+	return func() {
+		uow.mu.Lock()
+		uow.afterCommit, uow.events = uow.afterCommit[:fns], uow.events[:events]
+		uow.mu.Unlock()
+	}
+}
+
+// TxFromContext the transaction of the unit of work of ctx, nil if ctx is not in a unit of work or the unit of
+// work is of mongodb, the *ByTx methods of the daos of mongodb use the session of ctx instead.
+func TxFromContext(ctx context.Context) *gorm.DB {
+	if uow := unitOfWorkFromContext(ctx); uow != nil && uow.tx != nil {
+		return uow.tx.WithContext(ctx)
+	}
+	return nil
+}
+
+// AfterCommit run fn after the unit of work of ctx is committed, fn is not run if it is rolled back.
+// fn is run immediately if ctx is not in a unit of work.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if !deferUntilCommit(ctx, fn) {
+		fn(ctx)
+	}
+}
+
+// queue fn to run after the unit of work of ctx is committed, false is returned if ctx is not in a unit of work
+func deferUntilCommit(ctx context.Context, fn func(ctx context.Context)) bool {
+	uow := unitOfWorkFromContext(ctx)
+	if uow == nil {
+		return false
+	}
+	uow.mu.Lock()
+	uow.afterCommit = append(uow.afterCommit, fn)
+	uow.mu.Unlock()
+	return true
+}
+
+// ErrTxNotInUnitOfWork the transaction passed to a *ByTx method was not opened by a unit of work, e.g. by
+// db.Transaction, the cache could only be invalidated before it is committed.
+var ErrTxNotInUnitOfWork = errors.New("transaction is not opened by a unit of work")
+
+// the context of the *ByTx methods, if ctx is not in a unit of work but tx is the transaction of one, e.g. the tx
+// of TxFromContext passed with another context, the changes join the unit of work of tx. a tx that is not in a
+// transaction is committed at once, the cache is invalidated after each change, but a transaction that was not
+// opened by a unit of work is rejected, nothing could invalidate the cache after it is committed.
+func txContext(ctx context.Context, tx *gorm.DB) (context.Context, error) {
+	if isInUnitOfWork(ctx) || tx == nil || tx.Statement == nil {
+		return ctx, nil
+	}
+	if tx.Statement.Context != nil {
+		if uow := unitOfWorkFromContext(tx.Statement.Context); uow != nil {
+			return context.WithValue(ctx, unitOfWorkKey{}, uow), nil
+		}
+	}
+	if _, ok := tx.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return ctx, ErrTxNotInUnitOfWork
+	}
+	return ctx, nil
+}
+
+// whether ctx is in a unit of work, the records are not read from or set to the cache in a unit of work,
+// otherwise the changes that are not committed could be cached.
+func isInUnitOfWork(ctx context.Context) bool {
+	return unitOfWorkFromContext(ctx) != nil
+}
+
+type gormUnitOfWork struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork creating the unit of work of the tables in db
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &gormUnitOfWork{db: db}
+}
+
+// Do run fn in a transaction of db, see UnitOfWork
+func (u *gormUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if isInUnitOfWork(ctx) {
+		return fn(ctx)
+	}
+
+	uow := &unitOfWork{db: u.db}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		uow.tx = tx
+		return fn(context.WithValue(ctx, unitOfWorkKey{}, uow))
+	})
+	if err != nil {
+		return err
+	}
+
+	uow.commit(ctx)
+	return nil
+}
+
+type mongoUnitOfWork struct {
+	client *mongo.Client
+}
+
+// NewMongoUnitOfWork creating the unit of work of the profiles in mongodb, mongodb must be a replica set for the
+// transactions, and the daos of postgresql, e.g. the skill catalog, do not join them.
+func NewMongoUnitOfWork(db *mongo.Database) UnitOfWork {
+	return &mongoUnitOfWork{client: db.Client()}
+}
+
+// Do run fn in a transaction of a session of mongodb, see UnitOfWork. the transaction may be retried on a
+// transient error, so fn may be run more than once.
+func (u *mongoUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if isInUnitOfWork(ctx) {
+		return fn(ctx)
+	}
+
+	session, err := u.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	var uow *unitOfWork
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		uow = &unitOfWork{} // the functions queued by a retried transaction are discarded
+		return nil, fn(context.WithValue(sc, unitOfWorkKey{}, uow))
+	})
+	if err != nil {
+		return err
+	}
+
+	uow.commit(ctx)
+	return nil
+}
+
+// NewUnitOfWorkByDriver creating the unit of work of the profiles in the database of database.driver
+func NewUnitOfWorkByDriver() UnitOfWork {
+	if model.IsMongodb() {
+		return NewMongoUnitOfWork(model.GetMongoDB())
+	}
+	return NewUnitOfWork(model.GetDB())
+}
@@ -0,0 +1,107 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/model"
+)
+
+func newUnitOfWorkTest(t *testing.T) (UnitOfWork, UsersDao) {
+	dsn := fmt.Sprintf("file:uow%d?mode=memory&cache=shared", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&model.Users{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+
+	return NewUnitOfWork(db), NewUsersDao(db, cache.NewUsersCache(&model.CacheType{CType: "memory"}))
+}
+
+func Test_gormUnitOfWork_Do(t *testing.T) {
+	uow, d := newUnitOfWorkTest(t)
+	ctx := context.Background()
+	user := &model.Users{FirstName: "foo", LastName: "test"}
+	err := d.Create(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.GetByID(ctx, user.ID) // cached
+	assert.NoError(t, err)
+
+	// the changes are read in the unit of work, and the cache is invalidated after commit
+	committed := 0
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) { committed++ })
+		err := d.UpdateByTx(ctx, TxFromContext(ctx), &model.Users{Model: user.Model, FirstName: "bar"})
+		if err != nil {
+			return err
+		}
+		record, err := d.GetByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, "bar", record.FirstName)
+		assert.Equal(t, 0, committed)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, committed)
+	record, err := d.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", record.FirstName)
+
+	// the changes and the functions after commit are discarded on rollback
+	errRollback := errors.New("rollback")
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func(ctx context.Context) { committed++ })
+		err := d.UpdateByID(ctx, &model.Users{Model: user.Model, FirstName: "baz"})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	assert.Equal(t, 1, committed)
+	record, err = d.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", record.FirstName)
+
+	// the nested unit of work joins the outer one
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			return d.UpdateByID(ctx, &model.Users{Model: user.Model, FirstName: "qux"})
+		})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	record, err = d.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", record.FirstName)
+}
+
+func TestAfterCommit(t *testing.T) {
+	// not in a unit of work, run immediately
+	called := false
+	AfterCommit(context.Background(), func(ctx context.Context) { called = true })
+	assert.True(t, called)
+	assert.Nil(t, TxFromContext(context.Background()))
+}
@@ -2,6 +2,8 @@ package dao
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -129,6 +131,128 @@ func Test_usersDao_UpdateByID(t *testing.T) {
 
 }
 
+func Test_usersDao_DeleteByIDAndVersion(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID, 1).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(UsersDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(UsersDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	err = d.IDao.(UsersDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// delete error
+	err = d.IDao.(UsersDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.Error(t, err)
+}
+
+func Test_usersDao_ReplaceByID(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+	testData.Version = 1
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(UsersDao).ReplaceByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(UsersDao).ReplaceByID(d.Ctx, testData)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	record := &model.Users{}
+	record.ID = testData.ID
+	err = d.IDao.(UsersDao).ReplaceByID(d.Ctx, record)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// zero id error
+	err = d.IDao.(UsersDao).ReplaceByID(d.Ctx, &model.Users{})
+	assert.Error(t, err)
+}
+
+func Test_usersDao_PatchByID(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(2, d.AnyTime, 1, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(UsersDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(UsersDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{})
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(UsersDao).PatchByID(d.Ctx, testData.ID, 0, map[string]interface{}{})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// zero id error
+	err = d.IDao.(UsersDao).PatchByID(d.Ctx, 0, 0, nil)
+	assert.Error(t, err)
+}
+
 func Test_usersDao_GetByID(t *testing.T) {
 	d := newUsersDao()
 	defer d.Close()
@@ -255,6 +379,11 @@ func Test_usersDao_GetByLastID(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// select columns test
+	d.SQLMock.ExpectQuery("SELECT `id`,`created_at` FROM .*").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+	_, err = d.IDao.(UsersDao).GetByLastID(d.Ctx, 0, 10, "", SelectColumns("id", "created_at"))
+	assert.NoError(t, err)
+
 	// err test
 	_, err = d.IDao.(UsersDao).GetByLastID(d.Ctx, 0, 10, "unknown-column")
 	assert.Error(t, err)
@@ -304,6 +433,39 @@ func Test_usersDao_GetByColumns(t *testing.T) {
 	t.Log(err)
 }
 
+func Test_usersDao_GetByCursor(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt)
+
+	d.SQLMock.ExpectQuery("SELECT .* WHERE \\(id < \\?\\) .* ORDER BY id DESC LIMIT 11").
+		WithArgs(uint64(2)).
+		WillReturnRows(rows)
+
+	records, hasMore, err := d.IDao.(UsersDao).GetByCursor(d.Ctx, &CursorParams{
+		Sort:   "-id",
+		Limit:  10,
+		Values: []json.RawMessage{json.RawMessage("2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+	assert.False(t, hasMore)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	_, _, err = d.IDao.(UsersDao).GetByCursor(d.Ctx, &CursorParams{Sort: "unknown-column"})
+	assert.Error(t, err)
+}
+
 func Test_usersDao_CreateByTx(t *testing.T) {
 	d := newUsersDao()
 	defer d.Close()
@@ -333,7 +495,7 @@ func Test_usersDao_DeleteByTx(t *testing.T) {
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(d.AnyTime, d.AnyTime, testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -341,6 +503,14 @@ func Test_usersDao_DeleteByTx(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// not found error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(UsersDao).DeleteByTx(d.Ctx, d.DB, 100)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
 }
 
 func Test_usersDao_UpdateByTx(t *testing.T) {
@@ -359,3 +529,154 @@ func Test_usersDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_usersDao_CreateBatch(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	errs, err := d.IDao.(UsersDao).CreateBatch(d.Ctx, []*model.Users{testData}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []error{nil}, errs)
+
+	// best effort, the failed item is rolled back to its savepoint
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectExec("INSERT INTO .*").WillReturnError(errors.New("insert error"))
+	d.SQLMock.ExpectExec("ROLLBACK TO SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	errs, err = d.IDao.(UsersDao).CreateBatch(d.Ctx, []*model.Users{{}}, false)
+	assert.NoError(t, err)
+	assert.Error(t, errs[0])
+}
+
+func Test_usersDao_UpdateBatch(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+	testData.Version = 1
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	errs, err := d.IDao.(UsersDao).UpdateBatch(d.Ctx, []*model.Users{testData}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []error{nil}, errs)
+
+	// atomic, modified error and rollback
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	d.SQLMock.ExpectRollback()
+	errs, err = d.IDao.(UsersDao).UpdateBatch(d.Ctx, []*model.Users{testData}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[0], model.ErrRecordModified)
+}
+
+func Test_usersDao_ListDeleted(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt, time.Now())
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, total, err := d.IDao.(UsersDao).ListDeleted(d.Ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, records, 1)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty result
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, total, err = d.IDao.(UsersDao).ListDeleted(d.Ctx, 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func Test_usersDao_RestoreByIDs(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(nil, d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(UsersDao).RestoreByIDs(d.Ctx, []uint64{testData.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	err = d.IDao.(UsersDao).RestoreByIDs(d.Ctx, []uint64{111})
+	assert.Error(t, err)
+}
+
+func Test_usersDao_PurgeByIDs(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Users)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(UsersDao).PurgeByIDs(d.Ctx, []uint64{testData.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	err = d.IDao.(UsersDao).PurgeByIDs(d.Ctx, []uint64{111})
+	assert.Error(t, err)
+}
+
+func Test_usersDao_PurgeDeletedBefore(t *testing.T) {
+	d := newUsersDao()
+	defer d.Close()
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(d.AnyTime).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	d.SQLMock.ExpectCommit()
+
+	rows, err := d.IDao.(UsersDao).PurgeDeletedBefore(d.Ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(3), rows)
+}
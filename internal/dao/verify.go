@@ -0,0 +1,154 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	cacheBase "github.com/zhufuyi/sponge/pkg/cache"
+
+	"weaving_net/internal/model"
+)
+
+// the reasons of the divergences between the cache and database
+const (
+	ReasonPlaceholder = "the record is cached as not found but it exists"
+	ReasonDeleted     = "the record is cached but it is deleted"
+	ReasonModified    = "the cached value is different from database"
+)
+
+// Divergence a cached value that is not the same as database, e.g. a write path does not delete the cache
+type Divergence struct {
+	ID     uint64 `json:"id,omitempty"`     // the id of the record, for the records cached by id
+	UserID int    `json:"userId,omitempty"` // the user id, for the records cached by user
+	Reason string `json:"reason"`
+}
+
+// CacheReport the result of the verification of the cache of a table
+type CacheReport struct {
+	Checked     int           `json:"checked"`     // the number of the sampled cached values
+	Divergences []*Divergence `json:"divergences"` // the cached values that are not the same as database
+	Repaired    bool          `json:"repaired"`    // whether the divergent values are deleted from the cache
+}
+
+// VerifyCache compare n random cached records with database, if repair is true, the divergent records are
+// deleted from the cache so that they are reloaded. a record written while it is verified may be reported,
+// deleting it is harmless. the cache must be redis or tiered, see cache.ErrSampleNotSupported.
+func (r *repository[T]) VerifyCache(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return r.verifyCache(ctx, n, repair, r.loadByID)
+}
+
+// compare n random cached records with the records loaded by load, see VerifyCache
+func (r *recordCache[T]) verifyCache(ctx context.Context, n int, repair bool, load func(ctx context.Context, id uint64) (*T, error)) (*CacheReport, error) {
+	report := &CacheReport{Divergences: []*Divergence{}, Repaired: repair}
+	if r.cache == nil {
+		return report, nil
+	}
+	ids, err := r.cache.Sample(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		cached, err := r.cache.Get(ctx, id)
+		isPlaceholder := errors.Is(err, cacheBase.ErrPlaceholder)
+		if err != nil && !isPlaceholder {
+			if errors.Is(err, model.ErrCacheNotFound) {
+				continue // expired or deleted after it was sampled
+			}
+			return nil, err
+		}
+
+		record, err := load(ctx, id)
+		isFound := err == nil
+		if err != nil && !errors.Is(err, model.ErrRecordNotFound) {
+			return nil, err
+		}
+		report.Checked++
+
+		reason := ""
+		switch {
+		case isPlaceholder && isFound:
+			reason = ReasonPlaceholder
+		case !isPlaceholder && !isFound:
+			reason = ReasonDeleted
+		case !isPlaceholder:
+			isEqual, err := equalJSON(cached, record)
+			if err != nil {
+				return nil, err
+			}
+			if !isEqual {
+				reason = ReasonModified
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		report.Divergences = append(report.Divergences, &Divergence{ID: id, Reason: reason})
+		if repair {
+			_ = r.deleteCache(ctx, id)
+		}
+	}
+
+	return report, nil
+}
+
+// compare the cached records of n random users with the records loaded by load, see VerifyCache
+func (r *recordCache[T]) verifyCollections(ctx context.Context, n int, repair bool, load func(ctx context.Context, userID int) ([]*T, error)) (*CacheReport, error) {
+	report := &CacheReport{Divergences: []*Divergence{}, Repaired: repair}
+	if r.collection == nil {
+		return report, nil
+	}
+	userIDs, err := r.collection.SampleCollections(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range userIDs {
+		cached, err := r.collection.GetCollection(ctx, userID)
+		if err != nil {
+			if errors.Is(err, model.ErrCacheNotFound) {
+				continue // expired or deleted after it was sampled
+			}
+			return nil, err
+		}
+
+		records, err := load(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		report.Checked++
+
+		if records == nil {
+			records = []*T{}
+		}
+		isEqual, err := equalJSON(cached, records)
+		if err != nil {
+			return nil, err
+		}
+		if isEqual {
+			continue
+		}
+
+		report.Divergences = append(report.Divergences, &Divergence{UserID: userID, Reason: ReasonModified})
+		if repair {
+			r.deleteCollections(ctx, userID)
+		}
+	}
+
+	return report, nil
+}
+
+// whether the values are the same in JSON, the cached values are compared as they are returned by the api
+func equalJSON(a interface{}, b interface{}) (bool, error) {
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return string(aData) == string(bData), nil
+}
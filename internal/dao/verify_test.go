@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/model"
+)
+
+func Test_repository_VerifyCache(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	d.SQLMock.MatchExpectationsInOrder(false)
+	iCache := d.Cache.ICache.(cache.SkillsCache)
+
+	newRecord := func(id uint64, name string) *model.Skills {
+		record := &model.Skills{UserID: 1, SkillName: name}
+		record.ID = id
+		return record
+	}
+	newRows := func(record *model.Skills) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "skill_name"}).AddRow(record.ID, record.UserID, record.SkillName)
+	}
+
+	// 1 is the same as database, 2 is modified, 3 is cached as not found but it exists, 4 is deleted
+	for _, record := range []*model.Skills{newRecord(1, "go"), newRecord(2, "go"), newRecord(4, "go")} {
+		assert.NoError(t, iCache.Set(d.Ctx, record.ID, record, 0))
+	}
+	assert.NoError(t, iCache.SetCacheWithNotFound(d.Ctx, 3))
+	d.SQLMock.ExpectQuery("SELECT .*").WithArgs(1).WillReturnRows(newRows(newRecord(1, "go")))
+	d.SQLMock.ExpectQuery("SELECT .*").WithArgs(2).WillReturnRows(newRows(newRecord(2, "rust")))
+	d.SQLMock.ExpectQuery("SELECT .*").WithArgs(3).WillReturnRows(newRows(newRecord(3, "go")))
+	d.SQLMock.ExpectQuery("SELECT .*").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	report, err := d.IDao.(SkillsDao).VerifyCache(d.Ctx, 10, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Checked)
+	assert.True(t, report.Repaired)
+	assert.ElementsMatch(t, []*Divergence{
+		{ID: 2, Reason: ReasonModified},
+		{ID: 3, Reason: ReasonPlaceholder},
+		{ID: 4, Reason: ReasonDeleted},
+	}, report.Divergences)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+
+	// the divergent records are deleted from the cache
+	_, err = iCache.Get(d.Ctx, 1)
+	assert.NoError(t, err)
+	for _, id := range []uint64{2, 3, 4} {
+		_, err = iCache.Get(d.Ctx, id)
+		assert.ErrorIs(t, err, model.ErrCacheNotFound)
+	}
+
+	// no cache
+	report, err = NewSkillsDao(d.DB, nil).VerifyCache(d.Ctx, 10, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Checked)
+
+	// the cache in memory can not be sampled
+	_, err = NewSkillsDao(d.DB, cache.NewSkillsCache(&model.CacheType{CType: "memory"})).VerifyCache(d.Ctx, 10, false)
+	assert.ErrorIs(t, err, cache.ErrSampleNotSupported)
+}
+
+func Test_repository_VerifyCollections(t *testing.T) {
+	d := newSkillsDao()
+	defer d.Close()
+	d.SQLMock.MatchExpectationsInOrder(false)
+	iCache := d.Cache.ICache.(cache.SkillsCache)
+
+	record := &model.Skills{UserID: 1, SkillName: "go"}
+	record.ID = 1
+	assert.NoError(t, iCache.SetCollection(d.Ctx, 1, []*model.Skills{record}, 0))
+	assert.NoError(t, iCache.SetCollection(d.Ctx, 2, nil, 0))
+
+	// the records of user 1 are the same as database, a record of user 2 is created without deleting the cache
+	d.SQLMock.ExpectQuery("SELECT .*").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "skill_name"}).AddRow(1, 1, "go"))
+	d.SQLMock.ExpectQuery("SELECT .*").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "skill_name"}).AddRow(2, 2, "rust"))
+
+	report, err := d.IDao.(SkillsDao).VerifyCollections(d.Ctx, 10, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	assert.Equal(t, []*Divergence{{UserID: 2, Reason: ReasonModified}}, report.Divergences)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+
+	// the divergent records are not deleted without repair
+	_, err = iCache.GetCollection(d.Ctx, 2)
+	assert.NoError(t, err)
+
+	// no cache
+	report, err = NewSkillsDao(d.DB, nil).VerifyCollections(context.Background(), 10, true)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Divergences)
+}
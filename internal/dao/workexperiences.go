@@ -2,16 +2,10 @@ package dao
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"time"
 
-	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 
-	cacheBase "github.com/zhufuyi/sponge/pkg/cache"
 	"github.com/zhufuyi/sponge/pkg/ggorm/query"
-	"github.com/zhufuyi/sponge/pkg/utils"
 
 	"weaving_net/internal/cache"
 	"weaving_net/internal/model"
@@ -21,383 +15,113 @@ var _ WorkexperiencesDao = (*workexperiencesDao)(nil)
 
 // WorkexperiencesDao defining the dao interface
 type WorkexperiencesDao interface {
-	Create(ctx context.Context, table *model.Workexperiences) error
-	DeleteByID(ctx context.Context, id uint64) error
-	DeleteByIDs(ctx context.Context, ids []uint64) error
-	UpdateByID(ctx context.Context, table *model.Workexperiences) error
-	GetByID(ctx context.Context, id uint64) (*model.Workexperiences, error)
-	GetByCondition(ctx context.Context, condition *query.Conditions) (*model.Workexperiences, error)
-	GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Workexperiences, error)
-	GetByLastID(ctx context.Context, lastID uint64, limit int, sort string) ([]*model.Workexperiences, error)
-	GetByColumns(ctx context.Context, params *query.Params) ([]*model.Workexperiences, int64, error)
+	Repository[model.Workexperiences]
 
-	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Workexperiences) (uint64, error)
-	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Workexperiences) error
+	GetByUserID(ctx context.Context, userID int) ([]*model.Workexperiences, error)
+	ListByUserID(ctx context.Context, userID int) ([]*model.Workexperiences, error)
+	VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error)
+	HasPrimary(ctx context.Context, userID int, excludeID uint64) (bool, error)
+	Reorder(ctx context.Context, userID int, ids []uint64) error
 }
 
-type workexperiencesDao struct {
-	db    *gorm.DB
-	cache cache.WorkexperiencesCache // if nil, the cache is not used.
-	sfg   *singleflight.Group        // if cache is nil, the sfg is not used.
-}
-
-// NewWorkexperiencesDao creating the dao interface
-func NewWorkexperiencesDao(db *gorm.DB, xCache cache.WorkexperiencesCache) WorkexperiencesDao {
-	if xCache == nil {
-		return &workexperiencesDao{db: db}
-	}
-	return &workexperiencesDao{
-		db:    db,
-		cache: xCache,
-		sfg:   new(singleflight.Group),
-	}
-}
-
-func (d *workexperiencesDao) deleteCache(ctx context.Context, id uint64) error {
-	if d.cache != nil {
-		return d.cache.Del(ctx, id)
-	}
-	return nil
-}
-
-// Create a record, insert the record and the id value is written back to the table
-func (d *workexperiencesDao) Create(ctx context.Context, table *model.Workexperiences) error {
-	return d.db.WithContext(ctx).Create(table).Error
-}
-
-// DeleteByID delete a record by id
-func (d *workexperiencesDao) DeleteByID(ctx context.Context, id uint64) error {
-	err := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Workexperiences{}).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	_ = d.deleteCache(ctx, id)
-
-	return nil
-}
-
-// DeleteByIDs delete records by batch id
-func (d *workexperiencesDao) DeleteByIDs(ctx context.Context, ids []uint64) error {
-	err := d.db.WithContext(ctx).Where("id IN (?)", ids).Delete(&model.Workexperiences{}).Error
-	if err != nil {
-		return err
-	}
-
-	// delete cache
-	for _, id := range ids {
-		_ = d.deleteCache(ctx, id)
-	}
+// SortCurrentFirst sort the experiences and educations with the current one first, then by end date and start date
+// descending, the null end date of an ongoing record is treated as the latest in postgresql.
+const SortCurrentFirst = "-is_current,-end_date,-start_date"
 
-	return nil
-}
-
-// UpdateByID update a record by id
-func (d *workexperiencesDao) UpdateByID(ctx context.Context, table *model.Workexperiences) error {
-	err := d.updateDataByID(ctx, d.db, table)
-
-	// delete cache
-	_ = d.deleteCache(ctx, table.ID)
-
-	return err
-}
-
-func (d *workexperiencesDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Workexperiences) error {
-	if table.ID < 1 {
-		return errors.New("id cannot be 0")
-	}
-
-	update := map[string]interface{}{}
-
-	if table.UserID != 0 {
-		update["user_id"] = table.UserID
-	}
-	if table.Company != "" {
-		update["company"] = table.Company
-	}
-	if table.Title != "" {
-		update["title"] = table.Title
-	}
-	if table.EmploymentType != "" {
-		update["employment_type"] = table.EmploymentType
-	}
-	if table.JobDescription != "" {
-		update["job_description"] = table.JobDescription
-	}
-	if table.Location != "" {
-		update["location"] = table.Location
-	}
-	if table.StartDate.IsZero() == false {
-		update["start_date"] = table.StartDate
-	}
-	if table.EndDate.IsZero() == false {
-		update["end_date"] = table.EndDate
-	}
+// SortPositionCurrentFirst sort the experiences and educations of a user by position, then SortCurrentFirst
+const SortPositionCurrentFirst = "position," + SortCurrentFirst
 
-	return db.WithContext(ctx).Model(table).Updates(update).Error
+type workexperiencesDao struct {
+	*repository[model.Workexperiences]
 }
 
-// GetByID get a record by id
-func (d *workexperiencesDao) GetByID(ctx context.Context, id uint64) (*model.Workexperiences, error) {
-	// no cache
-	if d.cache == nil {
-		record := &model.Workexperiences{}
-		err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
-		return record, err
-	}
-
-	// get from cache or database
-	record, err := d.cache.Get(ctx, id)
-	if err == nil {
-		return record, nil
-	}
+var workexperiencesMapper = &Mapper[model.Workexperiences]{
+	ID:      func(table *model.Workexperiences) *uint64 { return &table.ID },
+	Version: func(table *model.Workexperiences) *int { return &table.Version },
+	Updates: func(table *model.Workexperiences) map[string]interface{} {
+		update := map[string]interface{}{}
 
-	if errors.Is(err, model.ErrCacheNotFound) {
-		// for the same id, prevent high concurrent simultaneous access to database
-		val, err, _ := d.sfg.Do(utils.Uint64ToStr(id), func() (interface{}, error) { //nolint
-			table := &model.Workexperiences{}
-			err = d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
-			if err != nil {
-				// if data is empty, set not found cache to prevent cache penetration, default expiration time 10 minutes
-				if errors.Is(err, model.ErrRecordNotFound) {
-					err = d.cache.SetCacheWithNotFound(ctx, id)
-					if err != nil {
-						return nil, err
-					}
-					return nil, model.ErrRecordNotFound
-				}
-				return nil, err
-			}
-			// set cache
-			err = d.cache.Set(ctx, id, table, cache.WorkexperiencesExpireTime)
-			if err != nil {
-				return nil, fmt.Errorf("cache.Set error: %v, id=%d", err, id)
-			}
-			return table, nil
-		})
-		if err != nil {
-			return nil, err
+		if table.UserID != 0 {
+			update["user_id"] = table.UserID
 		}
-		table, ok := val.(*model.Workexperiences)
-		if !ok {
-			return nil, model.ErrRecordNotFound
+		if table.Company != "" {
+			update["company"] = table.Company
 		}
-		return table, nil
-	} else if errors.Is(err, cacheBase.ErrPlaceholder) {
-		return nil, model.ErrRecordNotFound
-	}
-
-	// fail fast, if cache error return, don't request to db
-	return nil, err
-}
-
-// GetByCondition get a record by condition
-// query conditions:
-//
-//	name: column name
-//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
-//	value: column value, if exp=in, multiple values are separated by commas
-//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
-//
-// example: find a male aged 20
-//
-//	condition = &query.Conditions{
-//	    Columns: []query.Column{
-//		{
-//			Name:    "age",
-//			Value:   20,
-//		},
-//		{
-//			Name:  "gender",
-//			Value: "male",
-//		},
-//	}
-func (d *workexperiencesDao) GetByCondition(ctx context.Context, c *query.Conditions) (*model.Workexperiences, error) {
-	queryStr, args, err := c.ConvertToGorm()
-	if err != nil {
-		return nil, err
-	}
-
-	table := &model.Workexperiences{}
-	err = d.db.WithContext(ctx).Where(queryStr, args...).First(table).Error
-	if err != nil {
-		return nil, err
-	}
-
-	return table, nil
-}
-
-// GetByIDs get records by batch id
-func (d *workexperiencesDao) GetByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Workexperiences, error) {
-	// no cache
-	if d.cache == nil {
-		var records []*model.Workexperiences
-		err := d.db.WithContext(ctx).Where("id IN (?)", ids).Find(&records).Error
-		if err != nil {
-			return nil, err
+		if table.Title != "" {
+			update["title"] = table.Title
 		}
-		itemMap := make(map[uint64]*model.Workexperiences)
-		for _, record := range records {
-			itemMap[record.ID] = record
+		if table.EmploymentType != "" {
+			update["employment_type"] = table.EmploymentType
 		}
-		return itemMap, nil
-	}
-
-	// get form cache or database
-	itemMap, err := d.cache.MultiGet(ctx, ids)
-	if err != nil {
-		return nil, err
-	}
-
-	var missedIDs []uint64
-	for _, id := range ids {
-		_, ok := itemMap[id]
-		if !ok {
-			missedIDs = append(missedIDs, id)
-			continue
+		if table.JobDescription != "" {
+			update["job_description"] = table.JobDescription
 		}
-	}
-
-	// get missed data
-	if len(missedIDs) > 0 {
-		// find the id of an active placeholder, i.e. an id that does not exist in database
-		var realMissedIDs []uint64
-		for _, id := range missedIDs {
-			_, err = d.cache.Get(ctx, id)
-			if errors.Is(err, cacheBase.ErrPlaceholder) {
-				continue
-			}
-			realMissedIDs = append(realMissedIDs, id)
+		if table.Location != "" {
+			update["location"] = table.Location
 		}
-
-		if len(realMissedIDs) > 0 {
-			var missedData []*model.Workexperiences
-			err = d.db.WithContext(ctx).Where("id IN (?)", realMissedIDs).Find(&missedData).Error
-			if err != nil {
-				return nil, err
-			}
-
-			if len(missedData) > 0 {
-				for _, data := range missedData {
-					itemMap[data.ID] = data
-				}
-				err = d.cache.MultiSet(ctx, missedData, cache.WorkexperiencesExpireTime)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				for _, id := range realMissedIDs {
-					_ = d.cache.SetCacheWithNotFound(ctx, id)
-				}
-			}
+		if table.StartDate.IsZero() == false {
+			update["start_date"] = table.StartDate
+		}
+		if table.EndDate != nil {
+			update["end_date"] = table.EndDate
+		}
+		if table.IsCurrent {
+			update["is_current"] = table.IsCurrent
+		}
+		if table.IsPrimary {
+			update["is_primary"] = table.IsPrimary
+		}
+		if table.Position != 0 {
+			update["position"] = table.Position
 		}
-	}
 
-	return itemMap, nil
+		return update
+	},
+	Position:    func(table *model.Workexperiences) (*int, int) { return &table.Position, table.UserID },
+	UserID:      func(table *model.Workexperiences) int { return table.UserID },
+	OwnerColumn: "user_id",
+	// the user may have set another primary record while it was deleted, see idx_workexperiences_primary
+	RestoreColumns: map[string]interface{}{"is_primary": false},
 }
 
-// GetByLastID get paging records by last id and limit
-func (d *workexperiencesDao) GetByLastID(ctx context.Context, lastID uint64, limit int, sort string) ([]*model.Workexperiences, error) {
-	page := query.NewPage(0, limit, sort)
+// NewWorkexperiencesDao creating the dao interface
+func NewWorkexperiencesDao(db *gorm.DB, xCache cache.WorkexperiencesCache) WorkexperiencesDao {
+	return &workexperiencesDao{repository: newRepository[model.Workexperiences](db, xCache, workexperiencesMapper)}
+}
 
+// GetByUserID get all records of a user sorted by position, the records with the same position are sorted with the current one first
+func (d *workexperiencesDao) GetByUserID(ctx context.Context, userID int) ([]*model.Workexperiences, error) {
 	records := []*model.Workexperiences{}
-	err := d.db.WithContext(ctx).Order(page.Sort()).Limit(page.Size()).Where("id < ?", lastID).Find(&records).Error
+	err := d.dbOf(ctx).Where("user_id = ?", userID).Order(query.NewPage(0, 0, SortPositionCurrentFirst).Sort()).Find(&records).Error
 	if err != nil {
 		return nil, err
 	}
 	return records, nil
 }
 
-// GetByColumns get paging records by column information,
-// Note: query performance degrades when table rows are very large because of the use of offset.
-//
-// params includes paging parameters and query parameters
-// paging parameters (required):
-//
-//	page: page number, starting from 0
-//	size: lines per page
-//	sort: sort fields, default is id backwards, you can add - sign before the field to indicate reverse order, no - sign to indicate ascending order, multiple fields separated by comma
-//
-// query parameters (not required):
-//
-//	name: column name
-//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in
-//	value: column value, if exp=in, multiple values are separated by commas
-//	logic: logical type, defaults to and when value is null, only &(and), ||(or)
-//
-// example: search for a male over 20 years of age
-//
-//	params = &query.Params{
-//	    Page: 0,
-//	    Size: 20,
-//	    Columns: []query.Column{
-//		{
-//			Name:    "age",
-//			Exp: ">",
-//			Value:   20,
-//		},
-//		{
-//			Name:  "gender",
-//			Value: "male",
-//		},
-//	}
-func (d *workexperiencesDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.Workexperiences, int64, error) {
-	queryStr, args, err := params.ConvertToGormConditions()
-	if err != nil {
-		return nil, 0, errors.New("query params error: " + err.Error())
-	}
-
-	var total int64
-	if params.Sort != "ignore count" { // determine if count is required
-		err = d.db.WithContext(ctx).Model(&model.Workexperiences{}).Select([]string{"id"}).Where(queryStr, args...).Count(&total).Error
-		if err != nil {
-			return nil, 0, err
-		}
-		if total == 0 {
-			return nil, total, nil
-		}
-	}
-
-	records := []*model.Workexperiences{}
-	order, limit, offset := params.ConvertToPage()
-	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return records, total, err
+// ListByUserID get all records of a user in the order of GetByUserID, from the cache first
+func (d *workexperiencesDao) ListByUserID(ctx context.Context, userID int) ([]*model.Workexperiences, error) {
+	return d.listByUser(ctx, userID, d.GetByUserID)
 }
 
-// CreateByTx create a record in the database using the provided transaction
-func (d *workexperiencesDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Workexperiences) (uint64, error) {
-	err := tx.WithContext(ctx).Create(table).Error
-	return table.ID, err
+// VerifyCollections compare the cached records of n random users with database, see VerifyCache
+func (d *workexperiencesDao) VerifyCollections(ctx context.Context, n int, repair bool) (*CacheReport, error) {
+	return d.verifyCollections(ctx, n, repair, d.GetByUserID)
 }
 
-// DeleteByTx delete a record by id in the database using the provided transaction
-func (d *workexperiencesDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error {
-	update := map[string]interface{}{
-		"deleted_at": time.Now(),
-	}
-	err := tx.WithContext(ctx).Model(&model.Workexperiences{}).Where("id = ?", id).Updates(update).Error
+// HasPrimary determine if the user already has a primary record other than excludeID, the primary records set by
+// concurrent requests are rejected by the partial unique index idx_workexperiences_primary, see model.Migrate.
+func (d *workexperiencesDao) HasPrimary(ctx context.Context, userID int, excludeID uint64) (bool, error) {
+	var total int64
+	err := d.dbOf(ctx).Model(&model.Workexperiences{}).
+		Where("user_id = ? AND is_primary = ? AND id <> ?", userID, true, excludeID).Count(&total).Error
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	// delete cache
-	_ = d.deleteCache(ctx, id)
-
-	return nil
+	return total > 0, nil
 }
 
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *workexperiencesDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Workexperiences) error {
-	err := d.updateDataByID(ctx, tx, table)
-
-	// delete cache
-	_ = d.deleteCache(ctx, table.ID)
-
-	return err
+// Reorder rewrite the positions of the records of a user in the order of ids atomically, ids must be all the records of the user
+func (d *workexperiencesDao) Reorder(ctx context.Context, userID int, ids []uint64) error {
+	return d.reorderByUser(ctx, userID, ids)
 }
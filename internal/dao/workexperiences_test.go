@@ -2,6 +2,8 @@ package dao
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -43,6 +45,8 @@ func Test_workexperiencesDao_Create(t *testing.T) {
 	defer d.Close()
 	testData := d.TestData.(*model.Workexperiences)
 
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("INSERT INTO .*").
 		WithArgs(d.GetAnyArgs(testData)...).
@@ -65,6 +69,8 @@ func Test_workexperiencesDao_DeleteByID(t *testing.T) {
 		Valid: false,
 	}
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -91,6 +97,8 @@ func Test_workexperiencesDao_DeleteByIDs(t *testing.T) {
 		Valid: false,
 	}
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -112,6 +120,8 @@ func Test_workexperiencesDao_UpdateByID(t *testing.T) {
 	defer d.Close()
 	testData := d.TestData.(*model.Workexperiences)
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -129,6 +139,212 @@ func Test_workexperiencesDao_UpdateByID(t *testing.T) {
 
 }
 
+func Test_workexperiencesDao_GetByUserID(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "is_current"}).
+		AddRow(testData.ID, 1, true)
+	d.SQLMock.ExpectQuery("SELECT .* ORDER BY position ASC, is_current DESC, end_date DESC, start_date DESC").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(WorkexperiencesDao).GetByUserID(d.Ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// err test
+	_, err = d.IDao.(WorkexperiencesDao).GetByUserID(d.Ctx, 2)
+	assert.Error(t, err)
+}
+
+func Test_workexperiencesDao_ListByUserID(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "position"}).
+		AddRow(testData.ID, 1, 1)
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, err := d.IDao.(WorkexperiencesDao).ListByUserID(d.Ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+
+	// the records are got from the cache
+	records, err = d.IDao.(WorkexperiencesDao).ListByUserID(d.Ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, testData.ID, records[0].ID)
+}
+
+func Test_workexperiencesDao_HasPrimary(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(1, true, testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	exists, err := d.IDao.(WorkexperiencesDao).HasPrimary(d.Ctx, 1, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, exists)
+
+	// err test
+	_, err = d.IDao.(WorkexperiencesDao).HasPrimary(d.Ctx, 1, 0)
+	assert.Error(t, err)
+}
+
+func Test_workexperiencesDao_DeleteByIDAndVersion(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID, 1).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(WorkexperiencesDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(WorkexperiencesDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	err = d.IDao.(WorkexperiencesDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// delete error
+	err = d.IDao.(WorkexperiencesDao).DeleteByIDAndVersion(d.Ctx, testData.ID, 1)
+	assert.Error(t, err)
+}
+
+func Test_workexperiencesDao_ReplaceByID(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+	testData.Version = 1
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(WorkexperiencesDao).ReplaceByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(WorkexperiencesDao).ReplaceByID(d.Ctx, testData)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	record := &model.Workexperiences{}
+	record.ID = testData.ID
+	err = d.IDao.(WorkexperiencesDao).ReplaceByID(d.Ctx, record)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// zero id error
+	err = d.IDao.(WorkexperiencesDao).ReplaceByID(d.Ctx, &model.Workexperiences{})
+	assert.Error(t, err)
+}
+
+func Test_workexperiencesDao_PatchByID(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(2, d.AnyTime, 1, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(WorkexperiencesDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// modified error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = d.IDao.(WorkexperiencesDao).PatchByID(d.Ctx, testData.ID, 1, map[string]interface{}{})
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(WorkexperiencesDao).PatchByID(d.Ctx, testData.ID, 0, map[string]interface{}{})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+
+	// zero id error
+	err = d.IDao.(WorkexperiencesDao).PatchByID(d.Ctx, 0, 0, nil)
+	assert.Error(t, err)
+}
+
 func Test_workexperiencesDao_GetByID(t *testing.T) {
 	d := newWorkexperiencesDao()
 	defer d.Close()
@@ -255,6 +471,11 @@ func Test_workexperiencesDao_GetByLastID(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// select columns test
+	d.SQLMock.ExpectQuery("SELECT `id`,`created_at` FROM .*").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+	_, err = d.IDao.(WorkexperiencesDao).GetByLastID(d.Ctx, 0, 10, "", SelectColumns("id", "created_at"))
+	assert.NoError(t, err)
+
 	// err test
 	_, err = d.IDao.(WorkexperiencesDao).GetByLastID(d.Ctx, 0, 10, "unknown-column")
 	assert.Error(t, err)
@@ -304,11 +525,46 @@ func Test_workexperiencesDao_GetByColumns(t *testing.T) {
 	t.Log(err)
 }
 
+func Test_workexperiencesDao_GetByCursor(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt)
+
+	d.SQLMock.ExpectQuery("SELECT .* WHERE \\(id < \\?\\) .* ORDER BY id DESC LIMIT 11").
+		WithArgs(uint64(2)).
+		WillReturnRows(rows)
+
+	records, hasMore, err := d.IDao.(WorkexperiencesDao).GetByCursor(d.Ctx, &CursorParams{
+		Sort:   "-id",
+		Limit:  10,
+		Values: []json.RawMessage{json.RawMessage("2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, records, 1)
+	assert.False(t, hasMore)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	_, _, err = d.IDao.(WorkexperiencesDao).GetByCursor(d.Ctx, &CursorParams{Sort: "unknown-column"})
+	assert.Error(t, err)
+}
+
 func Test_workexperiencesDao_CreateByTx(t *testing.T) {
 	d := newWorkexperiencesDao()
 	defer d.Close()
 	testData := d.TestData.(*model.Workexperiences)
 
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("INSERT INTO .*").
 		WithArgs(d.GetAnyArgs(testData)...).
@@ -331,9 +587,11 @@ func Test_workexperiencesDao_DeleteByTx(t *testing.T) {
 		Valid: false,
 	}
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(d.AnyTime, d.AnyTime, testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -341,6 +599,16 @@ func Test_workexperiencesDao_DeleteByTx(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// not found error
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	err = d.IDao.(WorkexperiencesDao).DeleteByTx(d.Ctx, d.DB, 100)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
 }
 
 func Test_workexperiencesDao_UpdateByTx(t *testing.T) {
@@ -348,6 +616,8 @@ func Test_workexperiencesDao_UpdateByTx(t *testing.T) {
 	defer d.Close()
 	testData := d.TestData.(*model.Workexperiences)
 
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(d.AnyTime, testData.ID).
@@ -359,3 +629,194 @@ func Test_workexperiencesDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_workexperiencesDao_Reorder(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+
+	// only the changed position is updated
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT id, position .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position"}).AddRow(1, 1).AddRow(2, 1))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(2, d.AnyTime, 2).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(WorkexperiencesDao).Reorder(d.Ctx, 1, []uint64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not all the records of the user
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT id, position .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position"}).AddRow(1, 1).AddRow(2, 2))
+	d.SQLMock.ExpectRollback()
+	err = d.IDao.(WorkexperiencesDao).Reorder(d.Ctx, 1, []uint64{1, 1})
+	assert.ErrorIs(t, err, model.ErrInvalidPositions)
+}
+
+func Test_workexperiencesDao_CreateBatch(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	errs, err := d.IDao.(WorkexperiencesDao).CreateBatch(d.Ctx, []*model.Workexperiences{testData}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []error{nil}, errs)
+
+	// best effort, the failed item is rolled back to its savepoint
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	d.SQLMock.ExpectExec("INSERT INTO .*").WillReturnError(errors.New("insert error"))
+	d.SQLMock.ExpectExec("ROLLBACK TO SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	errs, err = d.IDao.(WorkexperiencesDao).CreateBatch(d.Ctx, []*model.Workexperiences{{}}, false)
+	assert.NoError(t, err)
+	assert.Error(t, errs[0])
+}
+
+func Test_workexperiencesDao_UpdateBatch(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+	testData.Version = 1
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	errs, err := d.IDao.(WorkexperiencesDao).UpdateBatch(d.Ctx, []*model.Workexperiences{testData}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []error{nil}, errs)
+
+	// atomic, modified error and rollback
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	d.SQLMock.ExpectRollback()
+	errs, err = d.IDao.(WorkexperiencesDao).UpdateBatch(d.Ctx, []*model.Workexperiences{testData}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[0], model.ErrRecordModified)
+}
+
+func Test_workexperiencesDao_ListDeleted(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt, time.Now())
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	records, total, err := d.IDao.(WorkexperiencesDao).ListDeleted(d.Ctx, 1, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, records, 1)
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty result
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, total, err = d.IDao.(WorkexperiencesDao).ListDeleted(d.Ctx, 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func Test_workexperiencesDao_RestoreByIDs(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	// the restored record is not primary
+	d.SQLMock.ExpectExec("UPDATE .*`is_primary`.*").
+		WithArgs(nil, false, d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(WorkexperiencesDao).RestoreByIDs(d.Ctx, []uint64{testData.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	err = d.IDao.(WorkexperiencesDao).RestoreByIDs(d.Ctx, []uint64{111})
+	assert.Error(t, err)
+}
+
+func Test_workexperiencesDao_PurgeByIDs(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Workexperiences)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(WorkexperiencesDao).PurgeByIDs(d.Ctx, []uint64{testData.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	err = d.IDao.(WorkexperiencesDao).PurgeByIDs(d.Ctx, []uint64{111})
+	assert.Error(t, err)
+}
+
+func Test_workexperiencesDao_PurgeDeletedBefore(t *testing.T) {
+	d := newWorkexperiencesDao()
+	defer d.Close()
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(d.AnyTime).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	d.SQLMock.ExpectCommit()
+
+	rows, err := d.IDao.(WorkexperiencesDao).PurgeDeletedBefore(d.Ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(3), rows)
+}
@@ -0,0 +1,17 @@
+package ecode
+
+import (
+	"github.com/zhufuyi/sponge/pkg/errcode"
+)
+
+// batch business-level http error codes, shared by all resources.
+// the batchNO value range is 1~100, if the same number appears, it will cause a failure to start the service.
+var (
+	batchNO       = 15
+	batchBaseCode = errcode.HCode(batchNO)
+
+	ErrBatchFailed         = errcode.NewError(batchBaseCode+1, "the batch has been rolled back because some items failed")
+	ErrBatchItemFailed     = errcode.NewError(batchBaseCode+2, "failed to process the batch item")
+	ErrBatchItemRolledBack = errcode.NewError(batchBaseCode+3, "the batch item has been rolled back because other items failed")
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -0,0 +1,16 @@
+package ecode
+
+import (
+	"github.com/zhufuyi/sponge/pkg/errcode"
+)
+
+// concurrency business-level http error codes, shared by all resources.
+// the concurrencyNO value range is 1~100, if the same number appears, it will cause a failure to start the service.
+var (
+	concurrencyNO       = 14
+	concurrencyBaseCode = errcode.HCode(concurrencyNO)
+
+	ErrPreconditionFailed   = errcode.NewError(concurrencyBaseCode+1, "the record has been modified by others, please get it again")
+	ErrPreconditionRequired = errcode.NewError(concurrencyBaseCode+2, "the If-Match header is required")
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -20,5 +20,9 @@ var (
 	ErrListByIDsEducations      = errcode.NewError(educationsBaseCode+7, "failed to list by batch ids "+educationsName)
 	ErrListByLastIDEducations   = errcode.NewError(educationsBaseCode+8, "failed to list by last id "+educationsName)
 	ErrListEducations           = errcode.NewError(educationsBaseCode+9, "failed to list of "+educationsName)
+	ErrInvalidDatesEducations   = errcode.NewError(educationsBaseCode+10, "invalid dates of "+educationsName+", the end date of the current one must be empty, otherwise not before the start date")
+	ErrListByUserIDEducations   = errcode.NewError(educationsBaseCode+11, "failed to list by user id "+educationsName)
+	ErrReorderEducations        = errcode.NewError(educationsBaseCode+12, "failed to reorder "+educationsName+", the ids must be all the "+educationsName+" of the user")
+	ErrListByCursorEducations   = errcode.NewError(educationsBaseCode+13, "failed to list by cursor "+educationsName)
 	// error codes are globally unique, adding 1 to the previous error code
 )
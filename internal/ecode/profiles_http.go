@@ -0,0 +1,18 @@
+package ecode
+
+import (
+	"github.com/zhufuyi/sponge/pkg/errcode"
+)
+
+// profiles business-level http error codes.
+// the profilesNO value range is 1~100, if the same number appears, it will cause a failure to start the service.
+var (
+	profilesNO       = 18
+	profilesName     = "profiles"
+	profilesBaseCode = errcode.HCode(profilesNO)
+
+	ErrReplaceProfiles      = errcode.NewError(profilesBaseCode+1, "failed to replace "+profilesName)
+	ErrMoveProjectProfiles  = errcode.NewError(profilesBaseCode+2, "failed to move the project of "+profilesName)
+	ErrUserNotFoundProfiles = errcode.NewError(profilesBaseCode+3, "the user that the project is moved to does not exist")
+	// error codes are globally unique, adding 1 to the previous error code
+)
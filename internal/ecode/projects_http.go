@@ -20,5 +20,8 @@ var (
 	ErrListByIDsProjects      = errcode.NewError(projectsBaseCode+7, "failed to list by batch ids "+projectsName)
 	ErrListByLastIDProjects   = errcode.NewError(projectsBaseCode+8, "failed to list by last id "+projectsName)
 	ErrListProjects           = errcode.NewError(projectsBaseCode+9, "failed to list of "+projectsName)
+	ErrListByUserIDProjects   = errcode.NewError(projectsBaseCode+10, "failed to list by user id "+projectsName)
+	ErrReorderProjects        = errcode.NewError(projectsBaseCode+11, "failed to reorder "+projectsName+", the ids must be all the "+projectsName+" of the user")
+	ErrListByCursorProjects   = errcode.NewError(projectsBaseCode+12, "failed to list by cursor "+projectsName)
 	// error codes are globally unique, adding 1 to the previous error code
 )
@@ -0,0 +1,18 @@
+package ecode
+
+import (
+	"github.com/zhufuyi/sponge/pkg/errcode"
+)
+
+// revisions business-level http error codes.
+// the revisionsNO value range is 1~100, if the same number appears, it will cause a failure to start the service.
+var (
+	revisionsNO       = 13
+	revisionsName     = "revisions"
+	revisionsBaseCode = errcode.HCode(revisionsNO)
+
+	ErrListRevisions    = errcode.NewError(revisionsBaseCode+1, "failed to list of "+revisionsName)
+	ErrDiffRevisions    = errcode.NewError(revisionsBaseCode+2, "failed to diff "+revisionsName)
+	ErrRestoreRevisions = errcode.NewError(revisionsBaseCode+3, "failed to restore "+revisionsName)
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -0,0 +1,22 @@
+package ecode
+
+import (
+	"github.com/zhufuyi/sponge/pkg/errcode"
+)
+
+// skillAssessments business-level http error codes.
+// the skillAssessmentsNO value range is 1~100, if the same number appears, it will cause a failure to start the service.
+var (
+	skillAssessmentsNO       = 17
+	skillAssessmentsName     = "skillAssessments"
+	skillAssessmentsBaseCode = errcode.HCode(skillAssessmentsNO)
+
+	ErrCreateSkillAssessments          = errcode.NewError(skillAssessmentsBaseCode+1, "failed to create "+skillAssessmentsName)
+	ErrGetByIDSkillAssessments         = errcode.NewError(skillAssessmentsBaseCode+2, "failed to get "+skillAssessmentsName+" details")
+	ErrListByCatalogIDSkillAssessments = errcode.NewError(skillAssessmentsBaseCode+3, "failed to list by catalog id "+skillAssessmentsName)
+	ErrSubmitSkillAssessments          = errcode.NewError(skillAssessmentsBaseCode+4, "failed to submit "+skillAssessmentsName)
+	ErrCooldownSkillAssessments        = errcode.NewError(skillAssessmentsBaseCode+5, "the "+skillAssessmentsName+" can not be retaken until the cooldown is over")
+	ErrSkillMismatchSkillAssessments   = errcode.NewError(skillAssessmentsBaseCode+6, "the skill is not the canonical skill of the "+skillAssessmentsName)
+	ErrCatalogNotFoundSkillAssessments = errcode.NewError(skillAssessmentsBaseCode+7, "the skill catalog of "+skillAssessmentsName+" does not exist")
+	// error codes are globally unique, adding 1 to the previous error code
+)
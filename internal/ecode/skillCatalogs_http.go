@@ -0,0 +1,25 @@
+package ecode
+
+import (
+	"github.com/zhufuyi/sponge/pkg/errcode"
+)
+
+// skillCatalogs business-level http error codes.
+// the skillCatalogsNO value range is 1~100, if the same number appears, it will cause a failure to start the service.
+var (
+	skillCatalogsNO       = 16
+	skillCatalogsName     = "skillCatalogs"
+	skillCatalogsBaseCode = errcode.HCode(skillCatalogsNO)
+
+	ErrCreateSkillCatalogs           = errcode.NewError(skillCatalogsBaseCode+1, "failed to create "+skillCatalogsName)
+	ErrGetByIDSkillCatalogs          = errcode.NewError(skillCatalogsBaseCode+2, "failed to get "+skillCatalogsName+" details")
+	ErrAddSynonymsSkillCatalogs      = errcode.NewError(skillCatalogsBaseCode+3, "failed to add synonyms of "+skillCatalogsName)
+	ErrSuggestSkillCatalogs          = errcode.NewError(skillCatalogsBaseCode+4, "failed to suggest "+skillCatalogsName)
+	ErrNameExistsSkillCatalogs       = errcode.NewError(skillCatalogsBaseCode+5, "the name or synonym already exists in "+skillCatalogsName)
+	ErrCategoryNotFoundSkillCatalogs = errcode.NewError(skillCatalogsBaseCode+6, "the category of "+skillCatalogsName+" does not exist")
+	ErrParentNotFoundSkillCatalogs   = errcode.NewError(skillCatalogsBaseCode+7, "the parent of "+skillCatalogsName+" does not exist")
+	ErrCreateSkillCategories         = errcode.NewError(skillCatalogsBaseCode+8, "failed to create skill category")
+	ErrListSkillCategories           = errcode.NewError(skillCatalogsBaseCode+9, "failed to list of skill categories")
+	ErrCategoryExistsSkillCatalogs   = errcode.NewError(skillCatalogsBaseCode+10, "the skill category already exists")
+	// error codes are globally unique, adding 1 to the previous error code
+)
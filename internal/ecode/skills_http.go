@@ -11,14 +11,19 @@ var (
 	skillsName     = "skills"
 	skillsBaseCode = errcode.HCode(skillsNO)
 
-	ErrCreateSkills         = errcode.NewError(skillsBaseCode+1, "failed to create "+skillsName)
-	ErrDeleteByIDSkills     = errcode.NewError(skillsBaseCode+2, "failed to delete "+skillsName)
-	ErrDeleteByIDsSkills    = errcode.NewError(skillsBaseCode+3, "failed to delete by batch ids "+skillsName)
-	ErrUpdateByIDSkills     = errcode.NewError(skillsBaseCode+4, "failed to update "+skillsName)
-	ErrGetByIDSkills        = errcode.NewError(skillsBaseCode+5, "failed to get "+skillsName+" details")
-	ErrGetByConditionSkills = errcode.NewError(skillsBaseCode+6, "failed to get "+skillsName+" details by conditions")
-	ErrListByIDsSkills      = errcode.NewError(skillsBaseCode+7, "failed to list by batch ids "+skillsName)
-	ErrListByLastIDSkills   = errcode.NewError(skillsBaseCode+8, "failed to list by last id "+skillsName)
-	ErrListSkills           = errcode.NewError(skillsBaseCode+9, "failed to list of "+skillsName)
+	ErrCreateSkills             = errcode.NewError(skillsBaseCode+1, "failed to create "+skillsName)
+	ErrDeleteByIDSkills         = errcode.NewError(skillsBaseCode+2, "failed to delete "+skillsName)
+	ErrDeleteByIDsSkills        = errcode.NewError(skillsBaseCode+3, "failed to delete by batch ids "+skillsName)
+	ErrUpdateByIDSkills         = errcode.NewError(skillsBaseCode+4, "failed to update "+skillsName)
+	ErrGetByIDSkills            = errcode.NewError(skillsBaseCode+5, "failed to get "+skillsName+" details")
+	ErrGetByConditionSkills     = errcode.NewError(skillsBaseCode+6, "failed to get "+skillsName+" details by conditions")
+	ErrListByIDsSkills          = errcode.NewError(skillsBaseCode+7, "failed to list by batch ids "+skillsName)
+	ErrListByLastIDSkills       = errcode.NewError(skillsBaseCode+8, "failed to list by last id "+skillsName)
+	ErrListSkills               = errcode.NewError(skillsBaseCode+9, "failed to list of "+skillsName)
+	ErrListByUserIDSkills       = errcode.NewError(skillsBaseCode+10, "failed to list by user id "+skillsName)
+	ErrReorderSkills            = errcode.NewError(skillsBaseCode+11, "failed to reorder "+skillsName+", the ids must be all the "+skillsName+" of the user")
+	ErrCatalogNotFoundSkills    = errcode.NewError(skillsBaseCode+12, "the skill catalog of "+skillsName+" does not exist")
+	ErrInvalidProficiencySkills = errcode.NewError(skillsBaseCode+13, "the proficiency of "+skillsName+" must be 1~5 or a known proficiency level")
+	ErrListByCursorSkills       = errcode.NewError(skillsBaseCode+14, "failed to list by cursor "+skillsName)
 	// error codes are globally unique, adding 1 to the previous error code
 )
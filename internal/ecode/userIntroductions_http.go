@@ -20,5 +20,8 @@ var (
 	ErrListByIDsUserIntroductions      = errcode.NewError(userIntroductionsBaseCode+7, "failed to list by batch ids "+userIntroductionsName)
 	ErrListByLastIDUserIntroductions   = errcode.NewError(userIntroductionsBaseCode+8, "failed to list by last id "+userIntroductionsName)
 	ErrListUserIntroductions           = errcode.NewError(userIntroductionsBaseCode+9, "failed to list of "+userIntroductionsName)
+	ErrListByUserIDUserIntroductions   = errcode.NewError(userIntroductionsBaseCode+10, "failed to list by user id "+userIntroductionsName)
+	ErrReorderUserIntroductions        = errcode.NewError(userIntroductionsBaseCode+11, "failed to reorder "+userIntroductionsName+", the ids must be all the "+userIntroductionsName+" of the user")
+	ErrListByCursorUserIntroductions   = errcode.NewError(userIntroductionsBaseCode+12, "failed to list by cursor "+userIntroductionsName)
 	// error codes are globally unique, adding 1 to the previous error code
 )
@@ -20,5 +20,6 @@ var (
 	ErrListByIDsUsers      = errcode.NewError(usersBaseCode+7, "failed to list by batch ids "+usersName)
 	ErrListByLastIDUsers   = errcode.NewError(usersBaseCode+8, "failed to list by last id "+usersName)
 	ErrListUsers           = errcode.NewError(usersBaseCode+9, "failed to list of "+usersName)
+	ErrListByCursorUsers   = errcode.NewError(usersBaseCode+10, "failed to list by cursor "+usersName)
 	// error codes are globally unique, adding 1 to the previous error code
 )
@@ -20,5 +20,10 @@ var (
 	ErrListByIDsWorkexperiences      = errcode.NewError(workexperiencesBaseCode+7, "failed to list by batch ids "+workexperiencesName)
 	ErrListByLastIDWorkexperiences   = errcode.NewError(workexperiencesBaseCode+8, "failed to list by last id "+workexperiencesName)
 	ErrListWorkexperiences           = errcode.NewError(workexperiencesBaseCode+9, "failed to list of "+workexperiencesName)
+	ErrInvalidDatesWorkexperiences   = errcode.NewError(workexperiencesBaseCode+10, "invalid dates of "+workexperiencesName+", the end date of the current one must be empty, otherwise not before the start date")
+	ErrPrimaryExistsWorkexperiences  = errcode.NewError(workexperiencesBaseCode+11, "the primary "+workexperiencesName+" of the user already exists")
+	ErrListByUserIDWorkexperiences   = errcode.NewError(workexperiencesBaseCode+12, "failed to list by user id "+workexperiencesName)
+	ErrReorderWorkexperiences        = errcode.NewError(workexperiencesBaseCode+13, "failed to reorder "+workexperiencesName+", the ids must be all the "+workexperiencesName+" of the user")
+	ErrListByCursorWorkexperiences   = errcode.NewError(workexperiencesBaseCode+14, "failed to list by cursor "+workexperiencesName)
 	// error codes are globally unique, adding 1 to the previous error code
 )
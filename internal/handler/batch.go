@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/response"
+	"weaving_net/internal/types"
+)
+
+// batchItems the results of the items in a batch request. the invalid items are rejected before
+// accessing the database, the valid ones are passed to the dao in order.
+type batchItems struct {
+	isAtomic bool
+	isCreate bool // the ids of the created items are not valid after rolling back
+	results  []types.BatchItemResult
+	indexes  []int // indexes of the valid items in the request
+}
+
+func newBatchItems(mode string, n int, isCreate bool) *batchItems {
+	items := &batchItems{
+		isAtomic: mode != types.BatchModeBestEffort,
+		isCreate: isCreate,
+		results:  make([]types.BatchItemResult, n),
+	}
+	for i := range items.results {
+		items.results[i] = types.BatchItemResult{Index: i, Code: 0, Msg: "ok"}
+	}
+	return items
+}
+
+// add a valid item, id is the id of the record to be updated, 0 for create
+func (b *batchItems) add(i int, id uint64) {
+	b.results[i].ID = id
+	b.indexes = append(b.indexes, i)
+}
+
+// reject an invalid item
+func (b *batchItems) reject(i int, err *errcode.Error) {
+	b.results[i].Code = err.Code()
+	b.results[i].Msg = err.Msg()
+}
+
+// isAborted in atomic mode, the batch is aborted without accessing the database if any item is invalid
+func (b *batchItems) isAborted() bool {
+	if !b.isAtomic {
+		return false
+	}
+	for _, result := range b.results {
+		if result.Code != 0 {
+			b.rollback()
+			return true
+		}
+	}
+	return false
+}
+
+// done set the results of the valid items by the errors returned by the dao, getID returns the created
+// id of the jth valid item, it is nil for update.
+func (b *batchItems) done(c *gin.Context, errs []error, getID func(j int) uint64) {
+	isFailed := false
+	for j, err := range errs {
+		i := b.indexes[j]
+		if err == nil {
+			if getID != nil {
+				b.results[i].ID = getID(j)
+			}
+			continue
+		}
+
+		isFailed = true
+		e := ecode.ErrBatchItemFailed
+		if errors.Is(err, model.ErrRecordNotFound) {
+			e = ecode.NotFound
+			logger.Warn("batch item not found", logger.Err(err), logger.Int("index", i), middleware.GCtxRequestIDField(c))
+		} else if errors.Is(err, model.ErrRecordModified) {
+			e = ecode.ErrPreconditionFailed
+			logger.Warn("batch item modified", logger.Err(err), logger.Int("index", i), middleware.GCtxRequestIDField(c))
+		} else {
+			logger.Warn("batch item error", logger.Err(err), logger.Int("index", i), middleware.GCtxRequestIDField(c))
+		}
+		b.reject(i, e)
+	}
+
+	if b.isAtomic && isFailed {
+		b.rollback()
+	}
+}
+
+// rollback mark all the successful items as rolled back, the created ids are no longer valid
+func (b *batchItems) rollback() {
+	for i := range b.results {
+		if b.results[i].Code == 0 {
+			b.reject(i, ecode.ErrBatchItemRolledBack)
+			if b.isCreate {
+				b.results[i].ID = 0
+			}
+		}
+	}
+}
+
+// output respond the results, in atomic mode an error is responded if any item failed
+func (b *batchItems) output(c *gin.Context) {
+	succeeded := 0
+	for _, result := range b.results {
+		if result.Code == 0 {
+			succeeded++
+		}
+	}
+	data := gin.H{
+		"results":   b.results,
+		"succeeded": succeeded,
+		"failed":    len(b.results) - succeeded,
+	}
+
+	if b.isAtomic && succeeded < len(b.results) {
+		response.Error(c, ecode.ErrBatchFailed, data)
+		return
+	}
+	response.Success(c, data)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func Test_batchItems(t *testing.T) {
+	c := &gin.Context{}
+	ids := []uint64{11, 12, 13}
+
+	// atomic, the successful items are rolled back and the created ids are cleared
+	items := newBatchItems("", 4, true)
+	assert.True(t, items.isAtomic)
+	items.add(0, 0)
+	items.add(2, 0)
+	items.add(3, 0)
+	items.reject(1, ecode.ErrCreateWorkexperiences)
+	assert.True(t, items.isAborted())
+	assert.Equal(t, ecode.ErrBatchItemRolledBack.Code(), items.results[0].Code)
+	assert.Equal(t, ecode.ErrCreateWorkexperiences.Code(), items.results[1].Code)
+
+	items = newBatchItems(types.BatchModeAtomic, 3, true)
+	for i := 0; i < 3; i++ {
+		items.add(i, 0)
+	}
+	assert.False(t, items.isAborted())
+	items.done(c, []error{nil, model.ErrRecordNotFound, nil}, func(j int) uint64 { return ids[j] })
+	assert.Equal(t, ecode.ErrBatchItemRolledBack.Code(), items.results[0].Code)
+	assert.Equal(t, uint64(0), items.results[0].ID)
+	assert.Equal(t, ecode.NotFound.Code(), items.results[1].Code)
+
+	// best effort, the successful items are kept
+	items = newBatchItems(types.BatchModeBestEffort, 3, false)
+	for i := 0; i < 3; i++ {
+		items.add(i, ids[i])
+	}
+	assert.False(t, items.isAborted())
+	items.done(c, []error{nil, errors.New("error"), nil}, nil)
+	assert.Equal(t, 0, items.results[0].Code)
+	assert.Equal(t, ids[0], items.results[0].ID)
+	assert.Equal(t, ecode.ErrBatchItemFailed.Code(), items.results[1].Code)
+	assert.Equal(t, 0, items.results[2].Code)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/response"
+	"weaving_net/internal/task"
+	"weaving_net/internal/types"
+)
+
+var _ CacheAdminHandler = (*cacheAdminHandler)(nil)
+
+// CacheAdminHandler defining the handler interface
+type CacheAdminHandler interface {
+	Warmup(c *gin.Context)
+	Verify(c *gin.Context)
+}
+
+// cacheJobs the cache jobs used by the admin, see task.CacheJobs
+type cacheJobs interface {
+	Warmup(ctx context.Context, n int) (*task.WarmupReport, error)
+	Verify(ctx context.Context, n int, repair bool) []*task.CacheVerification
+}
+
+type cacheAdminHandler struct {
+	jobs cacheJobs
+}
+
+// NewCacheAdminHandler creating the handler interface
+func NewCacheAdminHandler() CacheAdminHandler {
+	return &cacheAdminHandler{jobs: task.NewCacheJobs()}
+}
+
+// Warmup preload the most viewed profiles to the cache
+// @Summary warm up cache
+// @Description preload the most viewed profiles and the records of the users to the cache, e.g. after a redis flush or a deploy
+// @Tags admin
+// @accept json
+// @Produce json
+// @Param data body types.WarmupCacheRequest true "warmup settings"
+// @Success 200 {object} types.WarmupCacheRespond{}
+// @Router /api/v1/admin/cache/warmup [post]
+// @Security BearerAuth
+func (h *cacheAdminHandler) Warmup(c *gin.Context) {
+	form := &types.WarmupCacheRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	report, err := h.jobs.Warmup(ctx, form.Limit)
+	if err != nil {
+		logger.Error("Warmup error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// Verify compare the cached values with database
+// @Summary verify cache
+// @Description compare random cached values of each table with database, and delete the divergent ones from the cache if repair is true
+// @Tags admin
+// @accept json
+// @Produce json
+// @Param data body types.VerifyCacheRequest true "verification settings"
+// @Success 200 {object} types.VerifyCacheRespond{}
+// @Router /api/v1/admin/cache/verify [post]
+// @Security BearerAuth
+func (h *cacheAdminHandler) Verify(c *gin.Context) {
+	form := &types.VerifyCacheRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	reports := h.jobs.Verify(ctx, form.Sample, form.Repair)
+
+	response.Success(c, gin.H{"reports": reports})
+}
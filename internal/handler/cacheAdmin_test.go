@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+	"weaving_net/internal/task"
+	"weaving_net/internal/types"
+)
+
+type mockCacheJobs struct{}
+
+func (mockCacheJobs) Warmup(ctx context.Context, n int) (*task.WarmupReport, error) {
+	if n > 100 {
+		return nil, errors.New("mock error")
+	}
+	return &task.WarmupReport{Users: n, Collections: map[string]int{"skills": n}}, nil
+}
+
+func (mockCacheJobs) Verify(ctx context.Context, n int, repair bool) []*task.CacheVerification {
+	return []*task.CacheVerification{
+		{Name: "users", CacheReport: &dao.CacheReport{Checked: n, Divergences: []*dao.Divergence{}, Repaired: repair}},
+		{Name: "skills", Error: "mock error"},
+	}
+}
+
+func newCacheAdminHandler() *gotest.Handler {
+	testData := &model.Users{}
+	testData.ID = 1
+
+	d := gotest.NewDao(nil, testData)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &cacheAdminHandler{jobs: mockCacheJobs{}}
+	iHandler := h.IHandler.(CacheAdminHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Warmup",
+			Method:      http.MethodPost,
+			Path:        "/admin/cache/warmup",
+			HandlerFunc: iHandler.Warmup,
+		},
+		{
+			FuncName:    "Verify",
+			Method:      http.MethodPost,
+			Path:        "/admin/cache/verify",
+			HandlerFunc: iHandler.Verify,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_cacheAdminHandler_Warmup(t *testing.T) {
+	h := newCacheAdminHandler()
+	defer h.Close()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("Warmup"), &types.WarmupCacheRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	assert.Equal(t, float64(10), result.Data.(map[string]interface{})["users"])
+
+	// invalid limit error test
+	err = gohttp.Post(result, h.GetRequestURL("Warmup"), &types.WarmupCacheRequest{Limit: -1})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+
+	// warmup error test
+	err = gohttp.Post(result, h.GetRequestURL("Warmup"), &types.WarmupCacheRequest{Limit: 1000})
+	assert.Error(t, err)
+}
+
+func Test_cacheAdminHandler_Verify(t *testing.T) {
+	h := newCacheAdminHandler()
+	defer h.Close()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("Verify"), &types.VerifyCacheRequest{Sample: 10, Repair: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	reports := result.Data.(map[string]interface{})["reports"].([]interface{})
+	assert.Len(t, reports, 2)
+	assert.Equal(t, float64(10), reports[0].(map[string]interface{})["checked"])
+	assert.Equal(t, "mock error", reports[1].(map[string]interface{})["error"])
+
+	// invalid sample error test
+	err = gohttp.Post(result, h.GetRequestURL("Verify"), &types.VerifyCacheRequest{Sample: -1})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
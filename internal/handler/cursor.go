@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/types"
+)
+
+// the default size of a keyset page
+const defaultCursorLimit = 10
+
+// the secret to sign the cursors, it is random until SetCursorSecret is called when the service starts, e.g. in the tests
+var cursorSecret = newCursorSecret()
+
+// the position of a keyset page, it is signed and encoded as an opaque string for the client
+type cursorToken struct {
+	Sort   string            `json:"s"`           // sort with the id tiebreaker
+	Filter string            `json:"f,omitempty"` // digest of the filter columns
+	Values []json.RawMessage `json:"v"`           // values of the sort columns of the row next to the page
+	IsPrev bool              `json:"p,omitempty"` // the page is before the row
+}
+
+// SetCursorSecret set the secret to sign the cursors, all the instances of the service must use the same secret,
+// otherwise a cursor got from one instance is rejected by the others, so an empty secret is an error.
+func SetCursorSecret(secret string) error {
+	if secret == "" {
+		return errors.New("cursor secret is empty, it must be set in the config cursor.secret")
+	}
+	cursorSecret = []byte(secret)
+	return nil
+}
+
+func newCursorSecret() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+}
+
+// newCursorParams convert the request to the parameters of a keyset page, the sort of the first page is used by
+// all the pages, the filter columns must be the same as the first page.
+func newCursorParams(spec *filterSpec, table interface{}, form *types.ListByCursorRequest) (*dao.CursorParams, error) {
+	err := spec.checkColumns(form.Columns)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := spec.filterConditions(form.Filter)
+	if err != nil {
+		return nil, err
+	}
+	params := &dao.CursorParams{Columns: form.Columns, Filters: filters, Limit: form.Limit}
+	if params.Limit == 0 {
+		params.Limit = defaultCursorLimit
+	}
+
+	if form.Cursor == "" {
+		sort, err := spec.checkSort(form.Sort)
+		if err != nil {
+			return nil, err
+		}
+		sort, err = dao.KeysetSort(table, sort)
+		if err != nil {
+			return nil, err
+		}
+		params.Sort = sort
+		return params, nil
+	}
+
+	token, err := decodeCursor(form.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if token.Filter != filterDigest(form) {
+		return nil, errors.New("the filter columns are not the same as the first page")
+	}
+	params.Sort = token.Sort
+	params.Values = token.Values
+	params.IsPrev = token.IsPrev
+	return params, nil
+}
+
+// pageCursors the cursors of the next and previous pages of a keyset page, records is a slice of records in the
+// order of the page, an empty cursor means there is no page in that direction.
+func pageCursors(ctx context.Context, records interface{}, form *types.ListByCursorRequest, params *dao.CursorParams, hasMore bool) (string, string, error) {
+	rv := reflect.ValueOf(records)
+	if rv.Len() == 0 {
+		return "", "", nil
+	}
+
+	hasNext, hasPrev := hasMore, params.Values != nil
+	if params.IsPrev {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	var next, prev string
+	if hasNext {
+		values, err := dao.CursorValues(ctx, rv.Index(rv.Len()-1).Interface(), params.Sort)
+		if err != nil {
+			return "", "", err
+		}
+		next = encodeCursor(&cursorToken{Sort: params.Sort, Filter: filterDigest(form), Values: values})
+	}
+	if hasPrev {
+		values, err := dao.CursorValues(ctx, rv.Index(0).Interface(), params.Sort)
+		if err != nil {
+			return "", "", err
+		}
+		prev = encodeCursor(&cursorToken{Sort: params.Sort, Filter: filterDigest(form), Values: values, IsPrev: true})
+	}
+	return next, prev, nil
+}
+
+func encodeCursor(token *cursorToken) string {
+	data, _ := json.Marshal(token)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + signCursor(payload)
+}
+
+func decodeCursor(cursor string) (*cursorToken, error) {
+	payload, signature, ok := strings.Cut(cursor, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signCursor(payload))) {
+		return nil, dao.ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, dao.ErrInvalidCursor
+	}
+	token := &cursorToken{}
+	err = json.Unmarshal(data, token)
+	if err != nil {
+		return nil, dao.ErrInvalidCursor
+	}
+	return token, nil
+}
+
+func signCursor(payload string) string {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// the digest of the filter columns and the filter, which binds a cursor to the filter of its first page
+func filterDigest(form *types.ListByCursorRequest) string {
+	if len(form.Columns) == 0 && form.Filter == nil {
+		return ""
+	}
+	data, _ := json.Marshal([]interface{}{form.Columns, form.Filter})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func Test_encodeCursor(t *testing.T) {
+	token := &cursorToken{Sort: "-id", Values: []json.RawMessage{json.RawMessage("3")}, IsPrev: true}
+	cursor := encodeCursor(token)
+
+	actual, err := decodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, token, actual)
+
+	// tampered cursor
+	_, err = decodeCursor("x" + cursor)
+	assert.Error(t, err)
+	_, err = decodeCursor(cursor[:len(cursor)-1])
+	assert.Error(t, err)
+	_, err = decodeCursor("unknown")
+	assert.Error(t, err)
+
+	// signed by another secret, the secret can not be empty
+	secret := cursorSecret
+	defer func() { cursorSecret = secret }()
+	err = SetCursorSecret("another secret")
+	assert.NoError(t, err)
+	_, err = decodeCursor(cursor)
+	assert.Error(t, err)
+	err = SetCursorSecret("")
+	assert.Error(t, err)
+}
+
+func Test_newCursorParams(t *testing.T) {
+	form := &types.ListByCursorRequest{Sort: "-is_current,-end_date", Columns: []query.Column{{Name: "user_id", Value: 1}}}
+	params, err := newCursorParams(workexperiencesFilter, &model.Workexperiences{}, form)
+	assert.NoError(t, err)
+	assert.Equal(t, &dao.CursorParams{Sort: "-is_current,-end_date,-id", Columns: form.Columns, Limit: defaultCursorLimit}, params)
+
+	record := &model.Workexperiences{IsCurrent: true}
+	record.ID = 3
+	next, prev, err := pageCursors(context.Background(), []*model.Workexperiences{record}, form, params, true)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, next)
+	assert.Empty(t, prev)
+
+	// the sort of the cursor is used
+	form.Cursor, form.Sort, form.Limit = next, "", 20
+	params, err = newCursorParams(workexperiencesFilter, &model.Workexperiences{}, form)
+	assert.NoError(t, err)
+	assert.Equal(t, "-is_current,-end_date,-id", params.Sort)
+	assert.Equal(t, 20, params.Limit)
+	assert.Equal(t, []json.RawMessage{json.RawMessage("true"), json.RawMessage("null"), json.RawMessage("3")}, params.Values)
+	assert.False(t, params.IsPrev)
+
+	next, prev, err = pageCursors(context.Background(), []*model.Workexperiences{record}, form, params, false)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	params, err = newCursorParams(workexperiencesFilter, &model.Workexperiences{}, &types.ListByCursorRequest{Cursor: prev, Columns: form.Columns})
+	assert.NoError(t, err)
+	assert.True(t, params.IsPrev)
+
+	// the page before has a next page even if it is the first page
+	next, prev, err = pageCursors(context.Background(), []*model.Workexperiences{record}, form, params, false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, next)
+	assert.Empty(t, prev)
+
+	// empty page
+	next, prev, err = pageCursors(context.Background(), []*model.Workexperiences{}, form, params, false)
+	assert.NoError(t, err)
+	assert.Empty(t, next+prev)
+
+	// the filter is changed
+	_, err = newCursorParams(workexperiencesFilter, &model.Workexperiences{}, &types.ListByCursorRequest{Cursor: form.Cursor})
+	assert.Error(t, err)
+
+	// error test
+	_, err = newCursorParams(workexperiencesFilter, &model.Workexperiences{}, &types.ListByCursorRequest{Sort: "unknown"})
+	assert.Error(t, err)
+	_, err = newCursorParams(workexperiencesFilter, &model.Workexperiences{}, &types.ListByCursorRequest{Cursor: "unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+)
+
+// the sql states of the postgresql errors
+const (
+	sqlStateUniqueViolation     = "23505"
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateQueryCanceled       = "57014" // e.g. statement_timeout
+)
+
+// daoError the error code of a dao error, which is responded with its http status:
+// not found is 404, unique violation is 409, foreign key violation is 409 and timeout is 504, the others are 500.
+func daoError(err error) *errcode.Error {
+	var stateErr interface{ SQLState() string }
+	sqlState := ""
+	if errors.As(err, &stateErr) {
+		sqlState = stateErr.SQLState()
+	}
+
+	switch {
+	case errors.Is(err, model.ErrRecordNotFound):
+		return ecode.NotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey) || sqlState == sqlStateUniqueViolation:
+		return ecode.AlreadyExists
+	case errors.Is(err, gorm.ErrForeignKeyViolated) || sqlState == sqlStateForeignKeyViolation:
+		return ecode.FailedPrecondition
+	case errors.Is(err, context.DeadlineExceeded) || sqlState == sqlStateQueryCanceled:
+		return ecode.DeadlineExceeded
+	}
+	return ecode.InternalServerError
+}
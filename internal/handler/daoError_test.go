@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+)
+
+type sqlStateError struct {
+	state string
+}
+
+func (e *sqlStateError) Error() string    { return "sql state " + e.state }
+func (e *sqlStateError) SQLState() string { return e.state }
+
+func Test_daoError(t *testing.T) {
+	assert.Equal(t, ecode.NotFound, daoError(model.ErrRecordNotFound))
+	assert.Equal(t, ecode.AlreadyExists, daoError(gorm.ErrDuplicatedKey))
+	assert.Equal(t, ecode.AlreadyExists, daoError(fmt.Errorf("insert: %w", &sqlStateError{sqlStateUniqueViolation})))
+	assert.Equal(t, ecode.FailedPrecondition, daoError(gorm.ErrForeignKeyViolated))
+	assert.Equal(t, ecode.FailedPrecondition, daoError(&sqlStateError{sqlStateForeignKeyViolation}))
+	assert.Equal(t, ecode.DeadlineExceeded, daoError(context.DeadlineExceeded))
+	assert.Equal(t, ecode.DeadlineExceeded, daoError(&sqlStateError{sqlStateQueryCanceled}))
+	assert.Equal(t, ecode.InternalServerError, daoError(&sqlStateError{"42601"}))
+	assert.Equal(t, ecode.InternalServerError, daoError(errors.New("error")))
+}
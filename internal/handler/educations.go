@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"math"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 
+	"github.com/zhufuyi/sponge/pkg/errcode"
 	"github.com/zhufuyi/sponge/pkg/gin/middleware"
-	"github.com/zhufuyi/sponge/pkg/gin/response"
 	"github.com/zhufuyi/sponge/pkg/logger"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
@@ -16,6 +19,7 @@ import (
 	"weaving_net/internal/dao"
 	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
+	"weaving_net/internal/response"
 	"weaving_net/internal/types"
 )
 
@@ -24,27 +28,51 @@ var _ EducationsHandler = (*educationsHandler)(nil)
 // EducationsHandler defining the handler interface
 type EducationsHandler interface {
 	Create(c *gin.Context)
+	CreateBatch(c *gin.Context)
 	DeleteByID(c *gin.Context)
 	DeleteByIDs(c *gin.Context)
 	UpdateByID(c *gin.Context)
+	UpdateBatch(c *gin.Context)
+	PatchByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	GetByCondition(c *gin.Context)
 	ListByIDs(c *gin.Context)
 	ListByLastID(c *gin.Context)
+	ListByCursor(c *gin.Context)
 	List(c *gin.Context)
+	ListByUserID(c *gin.Context)
+	Reorder(c *gin.Context)
 }
 
+// the columns of educations that the clients can filter and sort by
+var educationsFilter = newFilterSpec(&model.Educations{}, []filterColumn{
+	{name: "user_id", ops: equalOps, sortable: true},
+	{name: "school", ops: textOps, sortable: true},
+	{name: "degree", ops: textOps, sortable: true},
+	{name: "field_of_study", ops: textOps, sortable: true},
+	{name: "start_date", ops: orderedOps, sortable: true},
+	{name: "end_date", ops: orderedOps, sortable: true},
+	{name: "is_current", ops: boolOps, sortable: true},
+	{name: "position", ops: orderedOps, sortable: true},
+})
+
+// the fields of educations that the clients can select
+var educationsFields = newFieldSpec(&model.Educations{}, &types.EducationsObjDetail{}, map[string][]string{
+	"user": {"user_id"},
+})
+
 type educationsHandler struct {
-	iDao dao.EducationsDao
+	iDao     dao.EducationsDao
+	expander *expander
 }
 
 // NewEducationsHandler creating the handler interface
 func NewEducationsHandler() EducationsHandler {
 	return &educationsHandler{
-		iDao: dao.NewEducationsDao(
-			model.GetDB(),
+		iDao: dao.NewEducationsDaoByDriver(
 			cache.NewEducationsCache(model.GetCacheType()),
 		),
+		expander: newExpander(),
 	}
 }
 
@@ -63,7 +91,7 @@ func (h *educationsHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -75,16 +103,70 @@ func (h *educationsHandler) Create(c *gin.Context) {
 	}
 
 	ctx := middleware.WrapCtx(c)
+	if h.isInvalidEducations(ctx, c, educations) {
+		return
+	}
 	err = h.iDao.Create(ctx, educations)
 	if err != nil {
 		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
 	response.Success(c, gin.H{"id": educations.ID})
 }
 
+// CreateBatch create records in batch
+// @Summary create educations in batch
+// @Description submit information to create educations in one transaction, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags educations
+// @accept json
+// @Produce json
+// @Param data body types.CreateEducationssBatchRequest true "educations information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/educations/batch [post]
+// @Security BearerAuth
+func (h *educationsHandler) CreateBatch(c *gin.Context) {
+	form := &types.CreateEducationssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), true)
+	records := make([]*model.Educations, 0, len(form.Items))
+	for i := range form.Items {
+		record := &model.Educations{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrCreateEducations)
+			continue
+		}
+		if e := checkEducations(record); e != nil {
+			items.reject(i, e)
+			continue
+		}
+		items.add(i, 0)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.CreateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("CreateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, func(j int) uint64 { return records[j].ID })
+
+	items.output(c)
+}
+
 // DeleteByID delete a record by id
 // @Summary delete educations
 // @Description delete educations by id
@@ -92,6 +174,7 @@ func (h *educationsHandler) Create(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Success 200 {object} types.DeleteEducationsByIDRespond{}
 // @Router /api/v1/educations/{id} [delete]
 // @Security BearerAuth
@@ -101,12 +184,21 @@ func (h *educationsHandler) DeleteByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err := h.iDao.DeleteByID(ctx, id)
+	err := h.iDao.DeleteByIDAndVersion(ctx, id, version)
 	if err != nil {
-		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("DeleteByIDAndVersion modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("DeleteByIDAndVersion error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -128,7 +220,7 @@ func (h *educationsHandler) DeleteByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -136,7 +228,7 @@ func (h *educationsHandler) DeleteByIDs(c *gin.Context) {
 	err = h.iDao.DeleteByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -145,11 +237,12 @@ func (h *educationsHandler) DeleteByIDs(c *gin.Context) {
 
 // UpdateByID update information by id
 // @Summary update educations
-// @Description update educations information by id
+// @Description replace all educations information by id, fields that are not submitted are reset, the record is only replaced when the If-Match header matches its ETag
 // @Tags educations
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Param data body types.UpdateEducationsByIDRequest true "educations information"
 // @Success 200 {object} types.UpdateEducationsByIDRespond{}
 // @Router /api/v1/educations/{id} [put]
@@ -160,12 +253,16 @@ func (h *educationsHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	form := &types.UpdateEducationsByIDRequest{}
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -176,12 +273,152 @@ func (h *educationsHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.ErrUpdateByIDEducations)
 		return
 	}
+	educations.Version = version
+
+	ctx := middleware.WrapCtx(c)
+	if h.isInvalidEducations(ctx, c, educations) {
+		return
+	}
+	err = h.iDao.ReplaceByID(ctx, educations)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("ReplaceByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("ReplaceByID modified", logger.Err(err), logger.Any("form", form), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("ReplaceByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateBatch update records by id in batch
+// @Summary update educations in batch
+// @Description replace educations by id in one transaction as UpdateByID does, fields that are not submitted are reset, an item fails with the precondition failed code if its version does not match the record, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags educations
+// @accept json
+// @Produce json
+// @Param data body types.UpdateEducationssBatchRequest true "educations information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/educations/batch [put]
+// @Security BearerAuth
+func (h *educationsHandler) UpdateBatch(c *gin.Context) {
+	form := &types.UpdateEducationssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), false)
+	records := make([]*model.Educations, 0, len(form.Items))
+	for i := range form.Items {
+		if form.Items[i].ID == 0 {
+			items.reject(i, ecode.InvalidParams)
+			continue
+		}
+		record := &model.Educations{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrUpdateByIDEducations)
+			continue
+		}
+		if e := checkEducations(record); e != nil {
+			items.reject(i, e)
+			continue
+		}
+		items.add(i, record.ID)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.UpdateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("UpdateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, nil)
+
+	items.output(c)
+}
+
+// PatchByID partial update by id with a JSON merge patch
+// @Summary patch educations
+// @Description partial update educations by id with a JSON merge patch (RFC 7396), an explicit null clears the field and an omitted field is left alone
+// @Tags educations
+// @accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
+// @Param data body types.PatchEducationsByIDRequest true "educations fields to be changed"
+// @Success 200 {object} types.PatchEducationsByIDRespond{}
+// @Router /api/v1/educations/{id} [patch]
+// @Security BearerAuth
+func (h *educationsHandler) PatchByID(c *gin.Context) {
+	_, id, isAbort := getEducationsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		logger.Warn("GetRawData error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	columns, err := dao.MergePatchToColumns(&model.Educations{}, patch)
+	if err != nil {
+		logger.Warn("MergePatchToColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, educations)
+	if isPatchOngoingColumns(columns) {
+		// check the record merged with the patch
+		record, err := h.iDao.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+				response.Out(c, ecode.NotFound)
+			} else {
+				logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+				response.Out(c, daoError(err))
+			}
+			return
+		}
+		_ = json.Unmarshal(patch, record)
+		if h.isInvalidEducations(ctx, c, record) {
+			return
+		}
+	}
+	err = h.iDao.PatchByID(ctx, id, version, columns)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("PatchByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("PatchByID modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("PatchByID error", logger.Err(err), logger.Any("columns", columns), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -195,6 +432,9 @@ func (h *educationsHandler) UpdateByID(c *gin.Context) {
 // @Param id path string true "id"
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the record has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetEducationsByIDRespond{}
 // @Router /api/v1/educations/{id} [get]
 // @Security BearerAuth
@@ -205,15 +445,28 @@ func (h *educationsHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
 	ctx := middleware.WrapCtx(c)
 	educations, err := h.iDao.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -226,7 +479,19 @@ func (h *educationsHandler) GetByID(c *gin.Context) {
 	}
 	data.ID = idStr
 
-	response.Success(c, gin.H{"educations": data})
+	err = h.expander.expandEducations(ctx, []*types.EducationsObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, exp.etag(educations.Version, data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{"educations": fields.project(data)})
 }
 
 // GetByCondition get a record by condition
@@ -236,6 +501,8 @@ func (h *educationsHandler) GetByID(c *gin.Context) {
 // @Param data body types.Conditions true "query condition"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetEducationsByConditionRespond{}
 // @Router /api/v1/educations/condition [post]
 // @Security BearerAuth
@@ -244,13 +511,32 @@ func (h *educationsHandler) GetByCondition(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	err = form.Conditions.CheckValid()
 	if err != nil {
 		logger.Warn("Parameters error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = educationsFilter.checkColumns(form.Conditions.Columns)
+	if err != nil {
+		logger.Warn("checkColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -259,10 +545,10 @@ func (h *educationsHandler) GetByCondition(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByCondition not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByCondition error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -275,7 +561,14 @@ func (h *educationsHandler) GetByCondition(c *gin.Context) {
 	}
 	data.ID = utils.Uint64ToStr(educations.ID)
 
-	response.Success(c, gin.H{"educations": data})
+	err = h.expander.expandEducations(ctx, []*types.EducationsObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{"educations": fields.project(data)})
 }
 
 // ListByIDs list of records by batch id
@@ -285,6 +578,8 @@ func (h *educationsHandler) GetByCondition(c *gin.Context) {
 // @Param data body types.ListEducationssByIDsRequest true "id array"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListEducationssByIDsRespond{}
 // @Router /api/v1/educations/list/ids [post]
 // @Security BearerAuth
@@ -293,7 +588,20 @@ func (h *educationsHandler) ListByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -301,7 +609,7 @@ func (h *educationsHandler) ListByIDs(c *gin.Context) {
 	educationsMap, err := h.iDao.GetByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -317,8 +625,15 @@ func (h *educationsHandler) ListByIDs(c *gin.Context) {
 		}
 	}
 
+	err = h.expander.expandEducations(ctx, educationss, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"educationss": educationss,
+		"educationss": fields.project(educationss),
 	})
 }
 
@@ -331,6 +646,10 @@ func (h *educationsHandler) ListByIDs(c *gin.Context) {
 // @Param lastID query int true "last id, default is MaxInt32" default(0)
 // @Param limit query int false "size in each page" default(10)
 // @Param sort query string false "sort by column name of table, and the "-" sign before column name indicates reverse order" default(-id)
+// @Param q query string false "filter, e.g. startDate >= now-5y and (title ilike go or endDate is null)"
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the list has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListEducationssRespond{}
 // @Router /api/v1/educations/list [get]
 // @Security BearerAuth
@@ -343,13 +662,38 @@ func (h *educationsHandler) ListByLastID(c *gin.Context) {
 	if limit == 0 {
 		limit = 10
 	}
-	sort := c.Query("sort")
+	sort, err := educationsFilter.checkSort(c.Query("sort"))
+	if err != nil {
+		logger.Warn("checkSort error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := educationsFilter.queryConditions(c.Query("q"))
+	if err != nil {
+		logger.Warn("queryConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	educationss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort)
+	educationss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort, filters...)
 	if err != nil {
 		logger.Error("GetByLastID error", logger.Err(err), logger.Uint64("latsID", lastID), logger.Int("limit", limit), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -359,8 +703,98 @@ func (h *educationsHandler) ListByLastID(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandEducations(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, contentETag(data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	response.Success(c, gin.H{
-		"educationss": data,
+		"educationss": fields.project(data),
+	})
+}
+
+// ListByCursor list of records by keyset paging
+// @Summary list of educationss by cursor
+// @Description list of educationss by keyset paging with filter columns, the first page is requested without cursor, the
+// @Description next or previous page is requested with the nextCursor or prevCursor of the last page and the same columns
+// @Tags educations
+// @accept json
+// @Produce json
+// @Param data body types.ListByCursorRequest true "cursor and query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListEducationssByCursorRespond{}
+// @Router /api/v1/educations/list/cursor [post]
+// @Security BearerAuth
+func (h *educationsHandler) ListByCursor(c *gin.Context) {
+	form := &types.ListByCursorRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	params, err := newCursorParams(educationsFilter, &model.Educations{}, form)
+	if err != nil {
+		logger.Warn("newCursorParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	params.Filters = append(params.Filters, fields.selectColumns())
+
+	ctx := middleware.WrapCtx(c)
+	educationss, hasMore, err := h.iDao.GetByCursor(ctx, params)
+	if err != nil {
+		logger.Error("GetByCursor error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	nextCursor, prevCursor, err := pageCursors(ctx, educationss, form, params, hasMore)
+	if err != nil {
+		logger.Error("pageCursors error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListByCursorEducations)
+		return
+	}
+
+	data, err := convertEducationss(educationss)
+	if err != nil {
+		response.Error(c, ecode.ErrListByCursorEducations)
+		return
+	}
+
+	err = h.expander.expandEducations(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"educationss":      fields.project(data),
+		"nextCursor": nextCursor,
+		"prevCursor": prevCursor,
 	})
 }
 
@@ -371,6 +805,8 @@ func (h *educationsHandler) ListByLastID(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param data body types.Params true "query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListEducationssRespond{}
 // @Router /api/v1/educations/list [post]
 // @Security BearerAuth
@@ -379,15 +815,45 @@ func (h *educationsHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = educationsFilter.checkParams(&form.Params)
+	if err != nil {
+		logger.Warn("checkParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := educationsFilter.filterConditions(form.Filter)
+	if err != nil {
+		logger.Warn("filterConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
+	if form.Sort == "" && isFilterByUser(form.Columns) {
+		form.Sort = dao.SortPositionCurrentFirst
+	}
+
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
+
 	ctx := middleware.WrapCtx(c)
-	educationss, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	educationss, total, err := h.iDao.GetByColumns(ctx, &form.Params, filters...)
 	if err != nil {
 		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -397,12 +863,140 @@ func (h *educationsHandler) List(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandEducations(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"educationss": data,
+		"educationss": fields.project(data),
 		"total":        total,
 	})
 }
 
+// ListByUserID list of all records of a user
+// @Summary list of educationss of a user
+// @Description list of all educationss of a user, the current one is first, then sorted by end date descending
+// @Tags educations
+// @Param userId path string true "user id"
+// @Accept json
+// @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListEducationssByUserIDRespond{}
+// @Router /api/v1/educations/user/{userId} [get]
+// @Security BearerAuth
+func (h *educationsHandler) ListByUserID(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	exp, err := parseExpand("educations", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := educationsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.ListByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("GetByUserID error", logger.Err(err), logger.Int("userId", userID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertEducationss(records)
+	if err != nil {
+		response.Error(c, ecode.ErrListByUserIDEducations)
+		return
+	}
+
+	err = h.expander.expandEducations(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"educationss": fields.project(data),
+	})
+}
+
+// Reorder rewrite the positions of all records of a user
+// @Summary reorder educationss of a user
+// @Description rewrite the positions of all educationss of a user atomically in the order of ids, the ids must be all the educationss of the user
+// @Tags educations
+// @accept json
+// @Produce json
+// @Param userId path string true "user id"
+// @Param data body types.ReorderEducationssRequest true "all the ids of the user in the new order"
+// @Success 200 {object} types.ReorderEducationssRespond{}
+// @Router /api/v1/educations/user/{userId}/positions [put]
+// @Security BearerAuth
+func (h *educationsHandler) Reorder(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.ReorderEducationssRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Reorder(ctx, userID, form.IDs)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidPositions) {
+			logger.Warn("Reorder invalid ids", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrReorderEducations)
+		} else {
+			logger.Error("Reorder error", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// isInvalidEducations check the dates of the record, the error is responded if it is invalid
+func (h *educationsHandler) isInvalidEducations(_ context.Context, c *gin.Context, record *model.Educations) bool {
+	if e := checkEducations(record); e != nil {
+		logger.Warn("invalid educations", logger.String("err", e.Msg()), logger.Any("record", record), middleware.GCtxRequestIDField(c))
+		response.Error(c, e)
+		return true
+	}
+
+	return false
+}
+
+// checkEducations check the dates of the record, the business error is returned if it is invalid
+func checkEducations(record *model.Educations) *errcode.Error {
+	if !isValidOngoingDates(record.StartDate, record.EndDate, record.IsCurrent) {
+		return ecode.ErrInvalidDatesEducations
+	}
+	return nil
+}
+
 func getEducationsIDFromPath(c *gin.Context) (string, uint64, bool) {
 	idStr := c.Param("id")
 	id, err := utils.StrToUint64E(idStr)
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -16,6 +17,7 @@ import (
 
 	"weaving_net/internal/cache"
 	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
 	"weaving_net/internal/types"
 )
@@ -40,7 +42,10 @@ func newEducationsHandler() *gotest.Handler {
 
 	// init mock handler
 	h := gotest.NewHandler(d, testData)
-	h.IHandler = &educationsHandler{iDao: d.IDao.(dao.EducationsDao)}
+	h.IHandler = &educationsHandler{
+		iDao:     d.IDao.(dao.EducationsDao),
+		expander: newTestExpander(d.DB),
+	}
 	iHandler := h.IHandler.(EducationsHandler)
 
 	testFns := []gotest.RouterInfo{
@@ -50,6 +55,12 @@ func newEducationsHandler() *gotest.Handler {
 			Path:        "/educations",
 			HandlerFunc: iHandler.Create,
 		},
+		{
+			FuncName:    "CreateBatch",
+			Method:      http.MethodPost,
+			Path:        "/educations/batch",
+			HandlerFunc: iHandler.CreateBatch,
+		},
 		{
 			FuncName:    "DeleteByID",
 			Method:      http.MethodDelete,
@@ -68,6 +79,18 @@ func newEducationsHandler() *gotest.Handler {
 			Path:        "/educations/:id",
 			HandlerFunc: iHandler.UpdateByID,
 		},
+		{
+			FuncName:    "UpdateBatch",
+			Method:      http.MethodPut,
+			Path:        "/educations/batch",
+			HandlerFunc: iHandler.UpdateBatch,
+		},
+		{
+			FuncName:    "PatchByID",
+			Method:      http.MethodPatch,
+			Path:        "/educations/:id",
+			HandlerFunc: iHandler.PatchByID,
+		},
 		{
 			FuncName:    "GetByID",
 			Method:      http.MethodGet,
@@ -92,12 +115,30 @@ func newEducationsHandler() *gotest.Handler {
 			Path:        "/educations/list",
 			HandlerFunc: iHandler.ListByLastID,
 		},
+		{
+			FuncName:    "ListByCursor",
+			Method:      http.MethodPost,
+			Path:        "/educations/list/cursor",
+			HandlerFunc: iHandler.ListByCursor,
+		},
 		{
 			FuncName:    "List",
 			Method:      http.MethodPost,
 			Path:        "/educations/list",
 			HandlerFunc: iHandler.List,
 		},
+		{
+			FuncName:    "ListByUserID",
+			Method:      http.MethodGet,
+			Path:        "/educations/user/:userId",
+			HandlerFunc: iHandler.ListByUserID,
+		},
+		{
+			FuncName:    "Reorder",
+			Method:      http.MethodPut,
+			Path:        "/educations/user/:userId/positions",
+			HandlerFunc: iHandler.Reorder,
+		},
 	}
 
 	h.GoRunHTTPServer(testFns)
@@ -112,6 +153,8 @@ func Test_educationsHandler_Create(t *testing.T) {
 	testData := &types.CreateEducationsRequest{}
 	_ = copier.Copy(testData, h.TestData.(*model.Educations))
 
+	h.MockDao.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
 	h.MockDao.SQLMock.ExpectBegin()
 	args := h.MockDao.GetAnyArgs(h.TestData)
 	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").
@@ -129,19 +172,128 @@ func Test_educationsHandler_Create(t *testing.T) {
 	
 }
 
-func Test_educationsHandler_DeleteByID(t *testing.T) {
+func Test_educationsHandler_ListByUserID(t *testing.T) {
 	h := newEducationsHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.Educations)
 
+	rows := sqlmock.NewRows([]string{"id", "user_id", "start_date", "is_current"}).
+		AddRow(testData.ID, 1, time.Now().AddDate(-1, 0, 0), true)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("ListByUserID", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// invalid user id error test
+	err = gohttp.Get(result, h.GetRequestURL("ListByUserID", "abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// get error test
+	err = gohttp.Get(result, h.GetRequestURL("ListByUserID", 2))
+	assert.Error(t, err)
+}
+
+func Test_educationsHandler_CreateInvalidDates(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+	endDate := time.Now()
+	testData := &types.CreateEducationsRequest{UserID: 1, StartDate: endDate.AddDate(-1, 0, 0), EndDate: &endDate, IsCurrent: true}
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrInvalidDatesEducations.Code(), result.Code)
+
+	// end date before start date
+	testData.IsCurrent = false
+	testData.StartDate = endDate.AddDate(1, 0, 0)
+	err = gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrInvalidDatesEducations.Code(), result.Code)
+
+	// patch error test, the record merged with the patch is checked
+	rows := sqlmock.NewRows([]string{"id", "user_id"}).AddRow(h.TestData.(*model.Educations).ID, 1)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", h.TestData.(*model.Educations).ID),
+		map[string]string{"If-Match": "*"}, map[string]interface{}{"isCurrent": true, "endDate": endDate})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrInvalidDatesEducations.Code(), result.Code)
+}
+
+func Test_educationsHandler_CreateBatch(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+	testData := &types.CreateEducationsRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.Educations))
+
 	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	form := &types.CreateEducationssBatchRequest{Items: []types.CreateEducationsRequest{*testData}}
+	err := gohttp.Post(result, h.GetRequestURL("CreateBatch"), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// best effort, the failed item is reported
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	h.MockDao.SQLMock.ExpectQuery("SELECT COALESCE.*").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").WillReturnError(errors.New("insert error"))
+	h.MockDao.SQLMock.ExpectExec("ROLLBACK TO SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	form.Mode = types.BatchModeBestEffort
+	err = gohttp.Post(result, h.GetRequestURL("CreateBatch"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Code)
+	assert.Equal(t, float64(1), result.Data.(map[string]interface{})["failed"])
+
+	// empty items error test
+	err = gohttp.Post(result, h.GetRequestURL("CreateBatch"), &types.CreateEducationssBatchRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// transaction error test
+	err = gohttp.Post(result, h.GetRequestURL("CreateBatch"), form)
+	assert.Error(t, err)
+}
+
+func Test_educationsHandler_UpdateBatch(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Educations)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
 	result := &gohttp.StdResult{}
-	err := gohttp.Delete(result, h.GetRequestURL("DeleteByID", testData.ID))
+	form := &types.UpdateEducationssBatchRequest{Items: []types.UpdateEducationsBatchItem{{
+		UpdateEducationsByIDRequest: types.UpdateEducationsByIDRequest{ID: testData.ID},
+		Version:                     1,
+	}}}
+	err := gohttp.Put(result, h.GetRequestURL("UpdateBatch"), form)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -149,13 +301,108 @@ func Test_educationsHandler_DeleteByID(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
+	// zero id item, the atomic batch is rolled back without updating
+	form.Items = append(form.Items, types.UpdateEducationsBatchItem{Version: 1})
+	err = gohttp.Put(result, h.GetRequestURL("UpdateBatch"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrBatchFailed.Code(), result.Code)
+	results := result.Data.(map[string]interface{})["results"].([]interface{})
+	assert.Equal(t, float64(ecode.ErrBatchItemRolledBack.Code()), results[0].(map[string]interface{})["code"])
+	assert.Equal(t, float64(ecode.InvalidParams.Code()), results[1].(map[string]interface{})["code"])
+}
+
+func Test_educationsHandler_Reorder(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT id, position .*").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position"}).AddRow(1, 1).AddRow(2, 2))
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Put(result, h.GetRequestURL("Reorder", 1), &types.ReorderEducationssRequest{IDs: []uint64{2, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// not all the records of the user error test
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT id, position .*").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position"}).AddRow(1, 1).AddRow(2, 2))
+	h.MockDao.SQLMock.ExpectRollback()
+	err = gohttp.Put(result, h.GetRequestURL("Reorder", 1), &types.ReorderEducationssRequest{IDs: []uint64{2}})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrReorderEducations.Code(), result.Code)
+
+	// empty ids error test
+	err = gohttp.Put(result, h.GetRequestURL("Reorder", 1), &types.ReorderEducationssRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// reorder error test
+	err = gohttp.Put(result, h.GetRequestURL("Reorder", 1), &types.ReorderEducationssRequest{IDs: []uint64{1}})
+	assert.Error(t, err)
+}
+
+func Test_educationsHandler_DeleteByID(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Educations)
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(h.MockDao.AnyTime, testData.ID, 1). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", testData.ID), ifMatch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
+	}
+
 	// zero id error test
 	err = gohttp.Delete(result, h.GetRequestURL("DeleteByID", 0))
 	assert.NoError(t, err)
 
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", testData.ID), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// modified error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	statusCode, _, err = doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", testData.ID), ifMatch, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+	assert.Equal(t, ecode.ErrPreconditionFailed.Code(), result.Code)
+
 	// delete error test
-	err = gohttp.Delete(result, h.GetRequestURL("DeleteByID", 111))
-	assert.Error(t, err)
+	statusCode, _, _ = doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", 111), ifMatch, nil)
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
 }
 
 func Test_educationsHandler_DeleteByIDs(t *testing.T) {
@@ -163,6 +410,8 @@ func Test_educationsHandler_DeleteByIDs(t *testing.T) {
 	defer h.Close()
 	testData := h.TestData.(*model.Educations)
 
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
 		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
@@ -192,29 +441,120 @@ func Test_educationsHandler_UpdateByID(t *testing.T) {
 	defer h.Close()
 	testData := &types.UpdateEducationsByIDRequest{}
 	_ = copier.Copy(testData, h.TestData.(*model.Educations))
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
 
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
 	result := &gohttp.StdResult{}
-	err := gohttp.Put(result, h.GetRequestURL("UpdateByID", testData.ID), testData)
+	statusCode, _, err := doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", testData.ID), ifMatch, testData)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if result.Code != 0 {
-		t.Fatalf("%+v", result)
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
 	}
 
 	// zero id error test
 	err = gohttp.Put(result, h.GetRequestURL("UpdateByID", 0), testData)
 	assert.NoError(t, err)
 
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", testData.ID), nil, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// modified error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", testData.ID), ifMatch, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+	assert.Equal(t, ecode.ErrPreconditionFailed.Code(), result.Code)
+
 	// update error test
-	err = gohttp.Put(result, h.GetRequestURL("UpdateByID", 111), testData)
-	assert.Error(t, err)
+	statusCode, _, _ = doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", 111), ifMatch, testData)
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
+}
+
+func Test_educationsHandler_PatchByID(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Educations)
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
+	}
+
+	// zero id error test
+	err = gohttp.Patch(result, h.GetRequestURL("PatchByID", 0), map[string]interface{}{})
+	assert.NoError(t, err)
+
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), nil, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// unknown field error test
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{"foo": "bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// immutable field error test
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{"version": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// modified error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	statusCode, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+
+	// not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(111, 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", 111), map[string]string{"If-Match": "*"}, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.NotFound.Code(), result.Code)
+
+	// patch error test
+	statusCode, _, _ = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", 112), ifMatch, map[string]interface{}{})
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
 }
 
 func Test_educationsHandler_GetByID(t *testing.T) {
@@ -238,6 +578,13 @@ func Test_educationsHandler_GetByID(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
+	// not modified test
+	statusCode, etag, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("GetByID", testData.ID),
+		map[string]string{"If-None-Match": versionETag(testData.Version)}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, statusCode)
+	assert.Equal(t, versionETag(testData.Version), etag)
+
 	// zero id error test
 	err = gohttp.Get(result, h.GetRequestURL("GetByID", 0))
 	assert.NoError(t, err)
@@ -339,8 +686,64 @@ func Test_educationsHandler_ListByLastID(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
-	// error test
+	// not modified test
+	for i := 0; i < 2; i++ {
+		rows = sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+			AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt)
+		h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	}
+	_, etag, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("ListByLastID"), nil, map[string]interface{}{"lastID": 0})
+	assert.NoError(t, err)
+	statusCode, _, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("ListByLastID"),
+		map[string]string{"If-None-Match": etag}, map[string]interface{}{"lastID": 0})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, statusCode)
+
+	// fields test
+	rows = sqlmock.NewRows([]string{"id", "updated_at"}).AddRow(testData.ID, testData.UpdatedAt)
+	h.MockDao.SQLMock.ExpectQuery("SELECT `id`,`updated_at` FROM .*").WillReturnRows(rows)
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "fields": "updatedAt"})
+	assert.NoError(t, err)
+	if assert.Equal(t, 0, result.Code) {
+		records := result.Data.(map[string]interface{})["educationss"].([]interface{})
+		assert.Len(t, records[0], 2)
+	}
+
+	// expand test
+	rows = sqlmock.NewRows([]string{"id", "user_id"}).AddRow(testData.ID, 1)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectQuery("SELECT \\* FROM `users` WHERE id IN \\(\\?\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, "foo"))
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "expand": "user", "fields": "userId"})
+	assert.NoError(t, err)
+	if assert.Equal(t, 0, result.Code) {
+		record := result.Data.(map[string]interface{})["educationss"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "foo", record["user"].(map[string]interface{})["firstName"])
+	}
+
+	// invalid expand test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "expand": "user.user"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// unknown field test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "fields": "id,unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// unknown column test
 	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "size": 10, "sort": "unknown-column"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// invalid filter test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "q": "id between 1"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// error test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "size": 10, "sort": "-id"})
 	assert.Error(t, err)
 }
 
@@ -355,7 +758,7 @@ func Test_educationsHandler_List(t *testing.T) {
 	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
 
 	result := &gohttp.StdResult{}
-	err := gohttp.Post(result, h.GetRequestURL("List"), &types.ListEducationssRequest{query.Params{
+	err := gohttp.Post(result, h.GetRequestURL("List"), &types.ListEducationssRequest{Params: query.Params{
 		Page: 0,
 		Size: 10,
 		Sort: "ignore count", // ignore test count
@@ -371,12 +774,65 @@ func Test_educationsHandler_List(t *testing.T) {
 	err = gohttp.Post(result, h.GetRequestURL("List"), nil)
 	assert.NoError(t, err)
 
-	// get error test
-	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListEducationssRequest{query.Params{
+	// unknown column test
+	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListEducationssRequest{Params: query.Params{
 		Page: 0,
 		Size: 10,
 		Sort: "unknown-column",
 	}})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// invalid filter test
+	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListEducationssRequest{
+		Params: query.Params{Page: 0, Size: 10},
+		Filter: &types.Filter{Name: "deleted_at", Exp: "isnull"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// get error test
+	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListEducationssRequest{Params: query.Params{
+		Page: 0,
+		Size: 10,
+		Sort: "-id",
+	}})
+	assert.Error(t, err)
+}
+
+func Test_educationsHandler_ListByCursor(t *testing.T) {
+	h := newEducationsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Educations)
+
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt).
+		AddRow(testData.ID+1, testData.CreatedAt, testData.UpdatedAt)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	assert.NotEmpty(t, result.Data.(map[string]interface{})["nextCursor"])
+
+	// invalid cursor test
+	err = gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{Cursor: "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// unknown column test
+	err = gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{Columns: []query.Column{{Name: "deleted_at", Value: 1}}})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// get error test
+	err = gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{})
 	assert.Error(t, err)
 }
 
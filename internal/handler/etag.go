@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/response"
+)
+
+// versionETag the strong entity tag of a record is its version, e.g. "3"
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// contentETag the weak entity tag of the response data, used by the list endpoints
+func contentETag(data interface{}) string {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	sum := sha1.Sum(content)
+	return `W/"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// isNotModified set the ETag header, and determine if the If-None-Match header of the request matches it,
+// if true, the caller should respond 304 without body.
+func isNotModified(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	ifNoneMatch := c.GetHeader("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		// If-None-Match uses the weak comparison
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// getIfMatchVersion get the version of the record from the If-Match header, "*" matches any version and 0 is returned.
+// the header is required, if it is missing or invalid, the error is responded and isAbort is true.
+func getIfMatchVersion(c *gin.Context) (int, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		logger.Warn("If-Match header is required", middleware.GCtxRequestIDField(c))
+		outputPreconditionError(c, http.StatusPreconditionRequired, ecode.ErrPreconditionRequired)
+		return 0, true
+	}
+	if ifMatch == "*" {
+		return 0, false
+	}
+
+	// If-Match uses the strong comparison, weak tags never match
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(ifMatch, `"`), `"`))
+	if err != nil || version < 1 || versionETag(version) != ifMatch {
+		logger.Warn("If-Match header does not match", logger.String("If-Match", ifMatch), middleware.GCtxRequestIDField(c))
+		outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		return 0, true
+	}
+
+	return version, false
+}
+
+// outputPreconditionError respond the error with its http status code, e.g. 412 and 428
+func outputPreconditionError(c *gin.Context, statusCode int, err *errcode.Error) {
+	response.OutWithStatus(c, statusCode, err)
+}
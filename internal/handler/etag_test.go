@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+
+	"weaving_net/internal/ecode"
+)
+
+// doWithHeader send a request with the header, e.g. If-Match, bind the json body to result,
+// return the http status code and the ETag of the response.
+func doWithHeader(method string, result interface{}, url string, header map[string]string, data interface{}) (int, string, error) {
+	req := &gohttp.Request{}
+	resp, err := req.SetURL(url).SetHeaders(header).Do(method, data)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close() //nolint
+	if resp.StatusCode == http.StatusNotModified {
+		return resp.StatusCode, resp.Header.Get("ETag"), nil
+	}
+
+	return resp.StatusCode, resp.Header.Get("ETag"), resp.BindJSON(result)
+}
+
+func newETagContext(header map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.ReleaseMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range header {
+		c.Request.Header.Set(k, v)
+	}
+	return c, w
+}
+
+func Test_contentETag(t *testing.T) {
+	etag := contentETag([]string{"foo"})
+	assert.Equal(t, etag, contentETag([]string{"foo"}))
+	assert.NotEqual(t, etag, contentETag([]string{"bar"}))
+	assert.Contains(t, etag, `W/"`)
+	assert.Empty(t, contentETag(func() {}))
+}
+
+func Test_isNotModified(t *testing.T) {
+	c, w := newETagContext(nil)
+	assert.False(t, isNotModified(c, versionETag(2)))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	c, _ = newETagContext(map[string]string{"If-None-Match": `"1", "2"`})
+	assert.True(t, isNotModified(c, versionETag(2)))
+
+	c, _ = newETagContext(map[string]string{"If-None-Match": `W/"2"`})
+	assert.True(t, isNotModified(c, versionETag(2)))
+
+	c, _ = newETagContext(map[string]string{"If-None-Match": `"1"`})
+	assert.False(t, isNotModified(c, versionETag(2)))
+
+	c, _ = newETagContext(map[string]string{"If-None-Match": "*"})
+	assert.False(t, isNotModified(c, ""))
+}
+
+func Test_getIfMatchVersion(t *testing.T) {
+	c, _ := newETagContext(map[string]string{"If-Match": `"3"`})
+	version, isAbort := getIfMatchVersion(c)
+	assert.False(t, isAbort)
+	assert.Equal(t, 3, version)
+
+	c, _ = newETagContext(map[string]string{"If-Match": "*"})
+	version, isAbort = getIfMatchVersion(c)
+	assert.False(t, isAbort)
+	assert.Equal(t, 0, version)
+
+	c, w := newETagContext(nil)
+	_, isAbort = getIfMatchVersion(c)
+	assert.True(t, isAbort)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	assert.Contains(t, w.Body.String(), ecode.ErrPreconditionRequired.Msg())
+
+	for _, ifMatch := range []string{`W/"3"`, "3", `"0"`, `"1", "2"`} {
+		c, w = newETagContext(map[string]string{"If-Match": ifMatch})
+		_, isAbort = getIfMatchVersion(c)
+		assert.True(t, isAbort, ifMatch)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, ifMatch)
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+// the maximum depth of the expanded related resources, e.g. skills.user is 2
+const maxExpandDepth = 2
+
+// the related resources that each resource can expand, the related resource of user is users
+var expandable = map[string][]string{
+	"users":             {"skills", "projects"},
+	"educations":        {"user"},
+	"projects":          {"user"},
+	"skills":            {"user"},
+	"userIntroductions": {"user"},
+	"workexperiences":   {"user"},
+}
+
+var expandResources = map[string]string{"user": "users", "skills": "skills", "projects": "projects"}
+
+// expansion the related resources to expand, the key is the name of a related resource and the value is the
+// expansion of the related resource, e.g. skills.user,projects is {"skills": {"user": {}}, "projects": {}}
+type expansion map[string]expansion
+
+// parseExpand parse the comma separated related resources of the query parameter expand, the nested related
+// resources are separated by dots, e.g. skills.user, an empty str expands nothing.
+func parseExpand(resource string, str string) (expansion, error) {
+	exp := expansion{}
+	for _, path := range strings.Split(str, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		names := strings.Split(path, ".")
+		if len(names) > maxExpandDepth {
+			return nil, fmt.Errorf("the expand '%s' is deeper than %d", path, maxExpandDepth)
+		}
+		current, currentResource := exp, resource
+		for _, name := range names {
+			if !hasOp(expandable[currentResource], name) {
+				return nil, fmt.Errorf("'%s' can not be expanded", path)
+			}
+			if current[name] == nil {
+				current[name] = expansion{}
+			}
+			current, currentResource = current[name], expandResources[name]
+		}
+	}
+	return exp, nil
+}
+
+// names the expanded related resources, they are selected with the fields of the response
+func (e expansion) names() []string {
+	names := []string{}
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// etag the entity tag of a record, the record is not modified if the version is not changed unless the related
+// resources are expanded, then the tag is the digest of the data.
+func (e expansion) etag(version int, data interface{}) string {
+	if len(e) == 0 {
+		return versionETag(version)
+	}
+	return contentETag(data)
+}
+
+// expander expand the related resources of the records in batches, every related resource is got by one query
+// for all the records, so that the number of queries does not grow with the number of records.
+type expander struct {
+	usersDao    dao.UsersDao
+	skillsDao   dao.SkillsDao
+	projectsDao dao.ProjectsDao
+}
+
+func newExpander() *expander {
+	return &expander{
+		usersDao:    dao.NewUsersDaoByDriver(cache.NewUsersCache(model.GetCacheType())),
+		skillsDao:   dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType())),
+		projectsDao: dao.NewProjectsDaoByDriver(cache.NewProjectsCache(model.GetCacheType())),
+	}
+}
+
+// expandUsers expand the skills and the projects of the users
+func (x *expander) expandUsers(ctx context.Context, details []*types.UsersObjDetail, exp expansion) error {
+	if len(exp) == 0 || len(details) == 0 {
+		return nil
+	}
+	userIDs := []int{}
+	for _, detail := range details {
+		userIDs = append(userIDs, int(utils.StrToUint64(detail.ID)))
+	}
+
+	if skillsExp, ok := exp["skills"]; ok {
+		records, err := x.skillsDao.GetByUserIDs(ctx, userIDs)
+		if err != nil {
+			return err
+		}
+		skills, err := convertSkillss(records)
+		if err != nil {
+			return err
+		}
+		err = x.expandSkills(ctx, skills, skillsExp)
+		if err != nil {
+			return err
+		}
+		userSkills := map[int][]*types.SkillsObjDetail{}
+		for _, skill := range skills {
+			userSkills[skill.UserID] = append(userSkills[skill.UserID], skill)
+		}
+		for i, detail := range details {
+			detail.Skills = userSkills[userIDs[i]]
+			if detail.Skills == nil {
+				detail.Skills = []*types.SkillsObjDetail{}
+			}
+		}
+	}
+
+	if projectsExp, ok := exp["projects"]; ok {
+		records, err := x.projectsDao.GetByUserIDs(ctx, userIDs)
+		if err != nil {
+			return err
+		}
+		projects, err := convertProjectss(records)
+		if err != nil {
+			return err
+		}
+		err = x.expandProjects(ctx, projects, projectsExp)
+		if err != nil {
+			return err
+		}
+		userProjects := map[int][]*types.ProjectsObjDetail{}
+		for _, project := range projects {
+			userProjects[project.UserID] = append(userProjects[project.UserID], project)
+		}
+		for i, detail := range details {
+			detail.Projects = userProjects[userIDs[i]]
+			if detail.Projects == nil {
+				detail.Projects = []*types.ProjectsObjDetail{}
+			}
+		}
+	}
+
+	return nil
+}
+
+// users get the users of the user ids, the users that do not exist are not in the map
+func (x *expander) users(ctx context.Context, userIDs []int, exp expansion) (map[int]*types.UsersObjDetail, error) {
+	ids, seen := []uint64{}, map[int]bool{}
+	for _, userID := range userIDs {
+		if userID > 0 && !seen[userID] {
+			seen[userID] = true
+			ids = append(ids, uint64(userID))
+		}
+	}
+	userMap := map[int]*types.UsersObjDetail{}
+	if len(ids) == 0 {
+		return userMap, nil
+	}
+
+	records, err := x.usersDao.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	details := []*types.UsersObjDetail{}
+	for _, id := range ids {
+		if record, ok := records[id]; ok {
+			detail, err := convertUsers(record)
+			if err != nil {
+				return nil, err
+			}
+			details = append(details, detail)
+			userMap[int(id)] = detail
+		}
+	}
+	err = x.expandUsers(ctx, details, exp)
+	if err != nil {
+		return nil, err
+	}
+	return userMap, nil
+}
+
+// expandUser expand the user of the records, userIDs are the user ids of the records in order, setUser set the
+// user of the record at the index.
+func (x *expander) expandUser(ctx context.Context, userIDs []int, exp expansion, setUser func(i int, user *types.UsersObjDetail)) error {
+	userExp, ok := exp["user"]
+	if !ok || len(userIDs) == 0 {
+		return nil
+	}
+	users, err := x.users(ctx, userIDs, userExp)
+	if err != nil {
+		return err
+	}
+	for i, userID := range userIDs {
+		setUser(i, users[userID])
+	}
+	return nil
+}
+
+func (x *expander) expandSkills(ctx context.Context, details []*types.SkillsObjDetail, exp expansion) error {
+	userIDs := []int{}
+	for _, detail := range details {
+		userIDs = append(userIDs, detail.UserID)
+	}
+	return x.expandUser(ctx, userIDs, exp, func(i int, user *types.UsersObjDetail) { details[i].User = user })
+}
+
+func (x *expander) expandProjects(ctx context.Context, details []*types.ProjectsObjDetail, exp expansion) error {
+	userIDs := []int{}
+	for _, detail := range details {
+		userIDs = append(userIDs, detail.UserID)
+	}
+	return x.expandUser(ctx, userIDs, exp, func(i int, user *types.UsersObjDetail) { details[i].User = user })
+}
+
+func (x *expander) expandEducations(ctx context.Context, details []*types.EducationsObjDetail, exp expansion) error {
+	userIDs := []int{}
+	for _, detail := range details {
+		userIDs = append(userIDs, detail.UserID)
+	}
+	return x.expandUser(ctx, userIDs, exp, func(i int, user *types.UsersObjDetail) { details[i].User = user })
+}
+
+func (x *expander) expandUserIntroductions(ctx context.Context, details []*types.UserIntroductionsObjDetail, exp expansion) error {
+	userIDs := []int{}
+	for _, detail := range details {
+		userIDs = append(userIDs, detail.UserID)
+	}
+	return x.expandUser(ctx, userIDs, exp, func(i int, user *types.UsersObjDetail) { details[i].User = user })
+}
+
+func (x *expander) expandWorkexperiences(ctx context.Context, details []*types.WorkexperiencesObjDetail, exp expansion) error {
+	userIDs := []int{}
+	for _, detail := range details {
+		userIDs = append(userIDs, detail.UserID)
+	}
+	return x.expandUser(ctx, userIDs, exp, func(i int, user *types.UsersObjDetail) { details[i].User = user })
+}
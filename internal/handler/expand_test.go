@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func newTestExpander(db *gorm.DB) *expander {
+	return &expander{
+		usersDao:    dao.NewUsersDao(db, nil),
+		skillsDao:   dao.NewSkillsDao(db, nil),
+		projectsDao: dao.NewProjectsDao(db, nil),
+	}
+}
+
+func Test_parseExpand(t *testing.T) {
+	exp, err := parseExpand("users", " skills.user, projects,skills ")
+	assert.NoError(t, err)
+	assert.Equal(t, expansion{"skills": {"user": {}}, "projects": {}}, exp)
+	assert.Equal(t, []string{"projects", "skills"}, exp.names())
+
+	exp, err = parseExpand("workexperiences", "")
+	assert.NoError(t, err)
+	assert.Empty(t, exp)
+
+	errExpands := map[string]string{
+		"users":           "user",
+		"skills":          "user.user",
+		"workexperiences": "skills",
+		"projects":        "user.skills.user",
+		"educations":      "user.",
+	}
+	for resource, str := range errExpands {
+		_, err = parseExpand(resource, str)
+		assert.Error(t, err, str)
+	}
+}
+
+func Test_expansion_etag(t *testing.T) {
+	assert.Equal(t, versionETag(2), expansion{}.etag(2, nil))
+	assert.Equal(t, contentETag("data"), expansion{"user": {}}.etag(2, "data"))
+}
+
+func Test_expander_expandUsers(t *testing.T) {
+	d := gotest.NewDao(nil, &model.Users{})
+	defer d.Close()
+	x := newTestExpander(d.DB)
+
+	d.SQLMock.ExpectQuery("SELECT \\* FROM `skills` WHERE user_id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 1).AddRow(2, 1))
+	d.SQLMock.ExpectQuery("SELECT \\* FROM `users` WHERE id IN \\(\\?\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, "foo"))
+
+	details := []*types.UsersObjDetail{{ID: "1"}, {ID: "2"}}
+	err := x.expandUsers(d.Ctx, details, expansion{"skills": {"user": {}}})
+	assert.NoError(t, err)
+	if assert.Len(t, details[0].Skills, 2) {
+		assert.Equal(t, "foo", details[0].Skills[1].User.FirstName)
+	}
+	assert.Equal(t, []*types.SkillsObjDetail{}, details[1].Skills)
+	assert.Nil(t, details[0].Projects)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+
+	// the users that do not exist are not expanded
+	d.SQLMock.ExpectQuery("SELECT \\* FROM `users`").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	workexperiences := []*types.WorkexperiencesObjDetail{{UserID: 3}, {UserID: 0}}
+	err = x.expandWorkexperiences(d.Ctx, workexperiences, expansion{"user": {}})
+	assert.NoError(t, err)
+	assert.Nil(t, workexperiences[0].User)
+
+	// error test
+	err = x.expandUsers(d.Ctx, details, expansion{"projects": {}})
+	assert.Error(t, err)
+	err = x.expandProjects(d.Ctx, []*types.ProjectsObjDetail{{UserID: 1}}, expansion{"user": {}})
+	assert.Error(t, err)
+}
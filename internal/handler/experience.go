@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"weaving_net/internal/model"
+)
+
+// the columns that affect the ongoing semantics of workexperiences and educations, a patch that changes
+// any of them is checked against the record merged with the patch.
+var ongoingColumns = []string{"start_date", "end_date", "is_current", "is_primary", "user_id"}
+
+func isPatchOngoingColumns(columns map[string]interface{}) bool {
+	for _, column := range ongoingColumns {
+		if _, ok := columns[column]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidOngoingDates the end date of a current record must be empty, otherwise it must not be before the start date
+func isValidOngoingDates(startDate time.Time, endDate *time.Time, isCurrent bool) bool {
+	if endDate == nil {
+		return true
+	}
+	if isCurrent {
+		return false
+	}
+	return startDate.IsZero() || !endDate.Before(startDate)
+}
+
+// experienceEnd the end time of an experience, the current one ends now, ok is false if the end is unknown
+func experienceEnd(endDate *time.Time, isCurrent bool, now time.Time) (time.Time, bool) {
+	if endDate != nil {
+		return *endDate, true
+	}
+	if isCurrent {
+		return now, true
+	}
+	return time.Time{}, false
+}
+
+// monthsBetween the number of whole months from start to end
+func monthsBetween(start time.Time, end time.Time) int {
+	if start.IsZero() || end.Before(start) {
+		return 0
+	}
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if end.Day() < start.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// workexperiencesDurationMonths the duration of a work experience in months
+func workexperiencesDurationMonths(record *model.Workexperiences, now time.Time) int {
+	end, ok := experienceEnd(record.EndDate, record.IsCurrent, now)
+	if !ok {
+		return 0
+	}
+	return monthsBetween(record.StartDate, end)
+}
+
+// totalExperienceYears the total years of the work experiences of a user, overlapping periods are counted once,
+// the result is rounded to one decimal place.
+func totalExperienceYears(records []*model.Workexperiences, now time.Time) float64 {
+	type period struct{ start, end time.Time }
+	periods := []period{}
+	for _, record := range records {
+		end, ok := experienceEnd(record.EndDate, record.IsCurrent, now)
+		if !ok || record.StartDate.IsZero() || end.Before(record.StartDate) {
+			continue
+		}
+		periods = append(periods, period{record.StartDate, end})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.Before(periods[j].start) })
+
+	months := 0
+	var merged *period
+	for i := range periods {
+		p := periods[i]
+		if merged != nil && !p.start.After(merged.end) {
+			if p.end.After(merged.end) {
+				merged.end = p.end
+			}
+			continue
+		}
+		if merged != nil {
+			months += monthsBetween(merged.start, merged.end)
+		}
+		merged = &p
+	}
+	if merged != nil {
+		months += monthsBetween(merged.start, merged.end)
+	}
+
+	return math.Round(float64(months)/12*10) / 10
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"weaving_net/internal/model"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func datePtr(year int, month time.Month, day int) *time.Time {
+	t := date(year, month, day)
+	return &t
+}
+
+func Test_isValidOngoingDates(t *testing.T) {
+	assert.True(t, isValidOngoingDates(date(2020, 1, 1), nil, true))
+	assert.True(t, isValidOngoingDates(date(2020, 1, 1), nil, false))
+	assert.True(t, isValidOngoingDates(date(2020, 1, 1), datePtr(2021, 1, 1), false))
+	assert.True(t, isValidOngoingDates(time.Time{}, datePtr(2021, 1, 1), false))
+	assert.False(t, isValidOngoingDates(date(2020, 1, 1), datePtr(2021, 1, 1), true))
+	assert.False(t, isValidOngoingDates(date(2020, 1, 1), datePtr(2019, 1, 1), false))
+}
+
+func Test_isPatchOngoingColumns(t *testing.T) {
+	assert.True(t, isPatchOngoingColumns(map[string]interface{}{"end_date": nil}))
+	assert.False(t, isPatchOngoingColumns(map[string]interface{}{"title": "CTO"}))
+}
+
+func Test_monthsBetween(t *testing.T) {
+	assert.Equal(t, 12, monthsBetween(date(2020, 1, 1), date(2021, 1, 1)))
+	assert.Equal(t, 11, monthsBetween(date(2020, 1, 15), date(2021, 1, 14)))
+	assert.Equal(t, 0, monthsBetween(date(2020, 1, 15), date(2020, 2, 1)))
+	assert.Equal(t, 0, monthsBetween(date(2021, 1, 1), date(2020, 1, 1)))
+	assert.Equal(t, 0, monthsBetween(time.Time{}, date(2020, 1, 1)))
+}
+
+func Test_workexperiencesDurationMonths(t *testing.T) {
+	now := date(2023, 7, 1)
+	assert.Equal(t, 18, workexperiencesDurationMonths(&model.Workexperiences{StartDate: date(2020, 1, 1), EndDate: datePtr(2021, 7, 1)}, now))
+	assert.Equal(t, 6, workexperiencesDurationMonths(&model.Workexperiences{StartDate: date(2023, 1, 1), IsCurrent: true}, now))
+	assert.Equal(t, 0, workexperiencesDurationMonths(&model.Workexperiences{StartDate: date(2023, 1, 1)}, now))
+}
+
+func Test_totalExperienceYears(t *testing.T) {
+	now := date(2023, 1, 1)
+	records := []*model.Workexperiences{
+		{StartDate: date(2021, 1, 1), IsCurrent: true},              // 2021-01 ~ now
+		{StartDate: date(2015, 1, 1), EndDate: datePtr(2018, 1, 1)}, // 3 years
+		{StartDate: date(2016, 1, 1), EndDate: datePtr(2019, 7, 1)}, // overlapped, 1.5 years more
+		{StartDate: date(2020, 1, 1)},                               // unknown end, ignored
+		{StartDate: date(2022, 1, 1), EndDate: datePtr(2021, 1, 1)}, // invalid, ignored
+		{StartDate: date(2021, 6, 1), EndDate: datePtr(2022, 1, 1)}, // inside the current one
+	}
+	assert.Equal(t, 6.5, totalExperienceYears(records, now))
+	assert.Equal(t, 0.0, totalExperienceYears(nil, now))
+}
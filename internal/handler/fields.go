@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"weaving_net/internal/dao"
+)
+
+// fieldSpec the fields of the detail of a table that the clients can select by the query parameter fields,
+// a field is named by its json name and loaded from the column of the same field of the model.
+type fieldSpec struct {
+	columns map[string][]string // field name -> the columns that the field is loaded from
+}
+
+// newFieldSpec the field spec of the detail of a table, computed are the columns of the fields that are computed
+// from the other columns instead of being loaded from the column of the same field.
+func newFieldSpec(table interface{}, detail interface{}, computed map[string][]string) *fieldSpec {
+	sch, err := schema.Parse(table, &sync.Map{}, schema.NamingStrategy{SingularTable: true})
+	if err != nil {
+		panic(err)
+	}
+
+	spec := &fieldSpec{columns: map[string][]string{}}
+	detailType := reflect.TypeOf(detail).Elem()
+	for i := 0; i < detailType.NumField(); i++ {
+		name := jsonName(detailType.Field(i))
+		if name == "" {
+			continue
+		}
+		columns, ok := computed[name]
+		if !ok {
+			field := sch.LookUpField(detailType.Field(i).Name)
+			if field == nil || field.DBName == "" {
+				panic("unknown field " + name + " of " + sch.Table)
+			}
+			columns = []string{field.DBName}
+		}
+		spec.columns[name] = columns
+	}
+	return spec
+}
+
+// fieldSelection the fields selected by the client, a nil selection selects all the fields
+type fieldSelection struct {
+	names   map[string]bool
+	columns []string
+}
+
+// parseFields parse the comma separated field names, e.g. title,company, the id and the expanded related resources
+// are always selected, an empty str selects all the fields.
+func (s *fieldSpec) parseFields(str string, exp expansion) (*fieldSelection, error) {
+	if strings.TrimSpace(str) == "" {
+		return nil, nil
+	}
+
+	fields := &fieldSelection{names: map[string]bool{"id": true}, columns: []string{"id"}}
+	for _, name := range append(strings.Split(str, ","), exp.names()...) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		columns, ok := s.columns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field '%s'", name)
+		}
+		fields.names[name] = true
+		for _, column := range columns {
+			if !containsString(fields.columns, column) {
+				fields.columns = append(fields.columns, column)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// selectColumns the filter that selects only the columns of the fields, all the columns are selected if fields is nil.
+// the records got by id are always loaded with all the columns, so that the partial records are not cached.
+func (f *fieldSelection) selectColumns() clause.Expression {
+	if f == nil {
+		return dao.SelectColumns()
+	}
+	return dao.SelectColumns(f.columns...)
+}
+
+// project keep only the selected fields of a detail or a slice of details, the fields are named by the json names
+func (f *fieldSelection) project(data interface{}) interface{} {
+	if f == nil {
+		return data
+	}
+
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return f.projectDetail(rv)
+	}
+	details := make([]map[string]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		details = append(details, f.projectDetail(rv.Index(i)))
+	}
+	return details
+}
+
+func (f *fieldSelection) projectDetail(rv reflect.Value) map[string]interface{} {
+	rv = reflect.Indirect(rv)
+	detail := map[string]interface{}{}
+	for i := 0; i < rv.NumField(); i++ {
+		if name := jsonName(rv.Type().Field(i)); f.names[name] {
+			detail[name] = rv.Field(i).Interface()
+		}
+	}
+	return detail
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func Test_fieldSpec_parseFields(t *testing.T) {
+	fields, err := workexperiencesFields.parseFields(" title, durationMonths,endDate ,", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "title", "start_date", "end_date", "is_current"}, fields.columns)
+	assert.Equal(t, dao.SelectColumns("id", "title", "start_date", "end_date", "is_current"), fields.selectColumns())
+
+	// the expanded related resources are selected with the fields
+	fields, err = workexperiencesFields.parseFields("title", expansion{"user": {}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "title", "user_id"}, fields.columns)
+	assert.True(t, fields.names["user"])
+
+	fields, err = workexperiencesFields.parseFields("", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+	assert.Equal(t, clause.Select{}, fields.selectColumns())
+
+	for _, str := range []string{"unknown", "title,deletedAt", "job_description"} {
+		_, err = workexperiencesFields.parseFields(str, nil)
+		assert.Error(t, err, str)
+	}
+}
+
+func Test_fieldSelection_project(t *testing.T) {
+	now := time.Now()
+	detail := &types.WorkexperiencesObjDetail{ID: "1", Title: "go", DurationMonths: 3, StartDate: now}
+
+	fields, _ := workexperiencesFields.parseFields("title,durationMonths", nil)
+	expected := map[string]interface{}{"id": "1", "title": "go", "durationMonths": 3}
+	assert.Equal(t, expected, fields.project(detail))
+	assert.Equal(t, []map[string]interface{}{expected}, fields.project([]*types.WorkexperiencesObjDetail{detail}))
+	assert.Equal(t, []map[string]interface{}{}, fields.project([]*types.WorkexperiencesObjDetail{}))
+
+	fields, _ = workexperiencesFields.parseFields("", nil)
+	assert.Equal(t, detail, fields.project(detail))
+}
+
+func Test_newFieldSpec(t *testing.T) {
+	defer func() {
+		assert.NotNil(t, recover())
+	}()
+	_ = newFieldSpec(&model.Users{}, &types.WorkexperiencesObjDetail{}, nil)
+}
@@ -0,0 +1,498 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+
+	"weaving_net/internal/types"
+)
+
+// the operators of the filter which are not supported by query.Column
+const (
+	opILike      = "ilike"
+	opStartsWith = "starts_with"
+	opBetween    = "between"
+	opIsNull     = "isnull"
+	opNotNull    = "notnull"
+)
+
+// the operators that can be used by the filter columns, isnull and notnull can be used by all the nullable columns
+var (
+	equalOps   = []string{query.Eq, query.Neq, query.In}
+	boolOps    = []string{query.Eq, query.Neq}
+	textOps    = []string{query.Eq, query.Neq, query.Like, opILike, opStartsWith, query.In}
+	orderedOps = []string{query.Eq, query.Neq, query.Gt, query.Gte, query.Lt, query.Lte, query.In, opBetween}
+)
+
+// the operators supported by query.Column
+var columnOps = []string{query.Eq, query.Neq, query.Gt, query.Gte, query.Lt, query.Lte, query.Like, query.In}
+
+// the symbols and the other spellings of the operators
+var opAliases = map[string]string{
+	"=":           query.Eq,
+	"!=":          query.Neq,
+	">":           query.Gt,
+	">=":          query.Gte,
+	"<":           query.Lt,
+	"<=":          query.Lte,
+	"is null":     opIsNull,
+	"is not null": opNotNull,
+	"not null":    opNotNull,
+}
+
+// the maximum number of the column conditions of a filter
+const maxFilterConditions = 50
+
+var logics = map[string]bool{"": true, query.AND: true, query.OR: true, "&": true, "&&": true, "|": true, "||": true}
+
+// the layouts of the values of the time columns
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// the time relative to now, e.g. now-5y
+var relativeTimeRegexp = regexp.MustCompile(`^now(?:([+-])(\d{1,6})([yMwdhms]))?$`)
+
+// the sort of GetByColumns which skips counting the records
+const sortIgnoreCount = "ignore count"
+
+// the columns of the model that all the tables can be filtered and sorted by
+var modelFilterColumns = []filterColumn{
+	{name: "id", ops: orderedOps, sortable: true},
+	{name: "created_at", ops: orderedOps, sortable: true},
+	{name: "updated_at", ops: orderedOps, sortable: true},
+}
+
+// filterColumn a column that the clients can filter by
+type filterColumn struct {
+	name     string   // column name of the table
+	ops      []string // allowed operators
+	sortable bool     // the column can be sorted by
+}
+
+type allowedColumn struct {
+	filterColumn
+	field *schema.Field
+}
+
+// filterSpec the columns of a table that the clients can filter and sort by, the other columns are rejected.
+// a column can be named by the json field name or the column name, the values are converted to the type of the field.
+type filterSpec struct {
+	columns map[string]*allowedColumn
+}
+
+// newFilterSpec the filter spec of a table, the columns of the model are always allowed
+func newFilterSpec(table interface{}, columns []filterColumn) *filterSpec {
+	sch, err := schema.Parse(table, &sync.Map{}, schema.NamingStrategy{SingularTable: true})
+	if err != nil {
+		panic(err)
+	}
+
+	spec := &filterSpec{columns: map[string]*allowedColumn{}}
+	for _, column := range append(modelFilterColumns[:len(modelFilterColumns):len(modelFilterColumns)], columns...) {
+		field := sch.LookUpField(column.name)
+		if field == nil || field.DBName != column.name {
+			panic("unknown filter column " + column.name + " of " + sch.Table)
+		}
+		allowed := &allowedColumn{filterColumn: column, field: field}
+		spec.columns[column.name] = allowed
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			spec.columns[name] = allowed
+		}
+	}
+	return spec
+}
+
+// checkParams check the columns and the sort of the params
+func (s *filterSpec) checkParams(params *query.Params) error {
+	err := s.checkColumns(params.Columns)
+	if err != nil {
+		return err
+	}
+	params.Sort, err = s.checkSort(params.Sort)
+	return err
+}
+
+// checkColumns check that the columns are allowed, the names are replaced with the column names, the operators
+// are replaced with their names and the values are converted to the type of the columns.
+func (s *filterSpec) checkColumns(columns []query.Column) error {
+	for i := range columns {
+		column := &columns[i]
+		allowed, ok := s.columns[column.Name]
+		if !ok {
+			return fmt.Errorf("column '%s' can not be filtered", column.Name)
+		}
+
+		exp := normalizeOp(column.Exp)
+		if !hasOp(allowed.ops, exp) {
+			return fmt.Errorf("operator '%s' is not allowed for column '%s'", column.Exp, column.Name)
+		}
+		if !hasOp(columnOps, exp) {
+			return fmt.Errorf("operator '%s' can only be used in filter", column.Exp)
+		}
+		if !logics[strings.ToLower(column.Logic)] {
+			return fmt.Errorf("unknown logic type '%s' of column '%s'", column.Logic, column.Name)
+		}
+
+		value, err := coerceColumnValue(allowed.field, exp, column.Value)
+		if err != nil {
+			return fmt.Errorf("invalid value of column '%s', %v", column.Name, err)
+		}
+		column.Name, column.Exp, column.Value = allowed.name, exp, value
+	}
+	return nil
+}
+
+// checkSort check that the sort columns are allowed, the names are replaced with the column names
+func (s *filterSpec) checkSort(sort string) (string, error) {
+	if sort == sortIgnoreCount {
+		return sort, nil
+	}
+	sort = strings.ReplaceAll(sort, " ", "")
+	if sort == "" {
+		return "", nil
+	}
+
+	names := strings.Split(sort, ",")
+	for i, name := range names {
+		prefix := ""
+		if strings.HasPrefix(name, "-") {
+			prefix, name = "-", name[1:]
+		}
+		allowed, ok := s.columns[name]
+		if !ok || !allowed.sortable {
+			return "", fmt.Errorf("column '%s' can not be sorted", name)
+		}
+		names[i] = prefix + allowed.name
+	}
+	return strings.Join(names, ","), nil
+}
+
+// filterConditions convert the filter to the conditions of gorm, the column conditions are checked the same as
+// checkColumns, the result is empty if filter is nil.
+func (s *filterSpec) filterConditions(filter *types.Filter) ([]clause.Expression, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	count := 0
+	expr, err := s.compileFilter(filter, &count)
+	if err != nil {
+		return nil, err
+	}
+	return []clause.Expression{clause.And(expr)}, nil
+}
+
+// queryConditions the same as filterConditions for the filter of the url query string, see parseFilterQuery for the syntax
+func (s *filterSpec) queryConditions(str string) ([]clause.Expression, error) {
+	filter, err := parseFilterQuery(str)
+	if err != nil {
+		return nil, err
+	}
+	return s.filterConditions(filter)
+}
+
+func (s *filterSpec) compileFilter(filter *types.Filter, count *int) (clause.Expression, error) {
+	if len(filter.And) > 0 || len(filter.Or) > 0 {
+		if filter.Name != "" || (len(filter.And) > 0 && len(filter.Or) > 0) {
+			return nil, fmt.Errorf("a filter is either a column condition or a group of and or or")
+		}
+		children := filter.And
+		if len(filter.Or) > 0 {
+			children = filter.Or
+		}
+		exprs := make([]clause.Expression, 0, len(children))
+		for _, child := range children {
+			if child == nil {
+				return nil, fmt.Errorf("empty filter")
+			}
+			expr, err := s.compileFilter(child, count)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, expr)
+		}
+		if len(exprs) == 1 {
+			return exprs[0], nil // a group of one condition is the condition, gorm joins it with or otherwise
+		}
+		if len(filter.Or) > 0 {
+			return clause.Or(exprs...), nil
+		}
+		return clause.And(exprs...), nil
+	}
+
+	*count++
+	if *count > maxFilterConditions {
+		return nil, fmt.Errorf("a filter has at most %d conditions", maxFilterConditions)
+	}
+	allowed, ok := s.columns[filter.Name]
+	if !ok {
+		return nil, fmt.Errorf("column '%s' can not be filtered", filter.Name)
+	}
+	exp := normalizeOp(filter.Exp)
+	isNullOp := exp == opIsNull || exp == opNotNull
+	if isNullOp && (allowed.field.NotNull || allowed.field.PrimaryKey) || !isNullOp && !hasOp(allowed.ops, exp) {
+		return nil, fmt.Errorf("operator '%s' is not allowed for column '%s'", filter.Exp, filter.Name)
+	}
+
+	column := clause.Column{Name: allowed.name}
+	switch exp {
+	case opIsNull:
+		return clause.Eq{Column: column, Value: nil}, nil
+	case opNotNull:
+		return clause.Neq{Column: column, Value: nil}, nil
+	case query.In, opBetween:
+		values, err := splitValues(filter.Value)
+		if err == nil {
+			values, err = coerceValues(allowed.field.FieldType, values)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of column '%s', %v", filter.Name, err)
+		}
+		if exp == query.In {
+			return clause.IN{Column: column, Values: values}, nil
+		}
+		if len(values) != 2 {
+			return nil, fmt.Errorf("invalid value of column '%s', between must have 2 values", filter.Name)
+		}
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []interface{}{column, values[0], values[1]}}, nil
+	}
+
+	value, err := coerceValue(allowed.field.FieldType, filter.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of column '%s', %v", filter.Name, err)
+	}
+	switch exp {
+	case query.Neq:
+		return clause.Neq{Column: column, Value: value}, nil
+	case query.Gt:
+		return clause.Gt{Column: column, Value: value}, nil
+	case query.Gte:
+		return clause.Gte{Column: column, Value: value}, nil
+	case query.Lt:
+		return clause.Lt{Column: column, Value: value}, nil
+	case query.Lte:
+		return clause.Lte{Column: column, Value: value}, nil
+	case query.Like:
+		return clause.Like{Column: column, Value: "%" + escapeLike(value) + "%"}, nil
+	case opILike:
+		return clause.Expr{SQL: "? ILIKE ?", Vars: []interface{}{column, "%" + escapeLike(value) + "%"}}, nil
+	case opStartsWith:
+		return clause.Like{Column: column, Value: escapeLike(value) + "%"}, nil
+	}
+	return clause.Eq{Column: column, Value: value}, nil
+}
+
+// the name of the operator, the default operator is eq
+func normalizeOp(exp string) string {
+	exp = strings.Join(strings.Fields(strings.ToLower(exp)), " ")
+	if exp == "" {
+		return query.Eq
+	}
+	if name, ok := opAliases[exp]; ok {
+		return name
+	}
+	return exp
+}
+
+// escape the wildcards of like, the value is a string because only the text columns can use like
+func escapeLike(value interface{}) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(fmt.Sprint(value))
+}
+
+func hasOp(ops []string, op string) bool {
+	for _, v := range ops {
+		if v == op {
+			return true
+		}
+	}
+	return false
+}
+
+// the values of the in operator are separated by commas, they are checked one by one and joined again
+func coerceColumnValue(field *schema.Field, exp string, value interface{}) (interface{}, error) {
+	if exp != query.In {
+		return coerceValue(field.FieldType, value)
+	}
+
+	values, err := splitValues(value)
+	if err != nil {
+		return nil, err
+	}
+	coerced, err := coerceValues(field.FieldType, values)
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, 0, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		} else {
+			strs = append(strs, fmt.Sprint(coerced[i]))
+		}
+	}
+	return strings.Join(strs, ","), nil
+}
+
+// the values of in and between are an array or a string separated by commas
+func splitValues(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		values := []interface{}{}
+		for _, s := range strings.Split(v, ",") {
+			values = append(values, strings.TrimSpace(s))
+		}
+		return values, nil
+	case []interface{}:
+		return v, nil
+	}
+	return nil, fmt.Errorf("the values must be an array or separated by commas")
+}
+
+func coerceValues(fieldType reflect.Type, values []interface{}) ([]interface{}, error) {
+	coerced := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		value, err := coerceValue(fieldType, v)
+		if err != nil {
+			return nil, err
+		}
+		coerced = append(coerced, value)
+	}
+	return coerced, nil
+}
+
+// convert the value decoded from json to the type of the field, a number can be written as a string
+func coerceValue(fieldType reflect.Type, value interface{}) (interface{}, error) {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	str, isStr := value.(string)
+
+	if fieldType == reflect.TypeOf(time.Time{}) {
+		if isStr {
+			if t, ok := relativeTime(str, time.Now()); ok {
+				return t, nil
+			}
+			for _, layout := range timeLayouts {
+				t, err := time.Parse(layout, str)
+				if err == nil {
+					return t, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("'%v' is not a time", value)
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64, bool:
+			return fmt.Sprint(v), nil
+		}
+		return nil, fmt.Errorf("'%v' is not a string", value)
+
+	case reflect.Bool:
+		if isStr {
+			b, err := strconv.ParseBool(str)
+			if err == nil {
+				return b, nil
+			}
+		}
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("'%v' is not a bool", value)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isStr {
+			i, err := strconv.ParseInt(str, 10, 64)
+			if err == nil {
+				return i, nil
+			}
+		}
+		f, err := toFloat(value)
+		if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+			return nil, fmt.Errorf("'%v' is not an integer", value)
+		}
+		return int64(f), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isStr {
+			u, err := strconv.ParseUint(str, 10, 64)
+			if err == nil {
+				return u, nil
+			}
+		}
+		f, err := toFloat(value)
+		if err != nil || f != math.Trunc(f) || f < 0 || f > math.MaxUint64 {
+			return nil, fmt.Errorf("'%v' is not an unsigned integer", value)
+		}
+		return uint64(f), nil
+
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%v' is not a number", value)
+		}
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("the column can not be filtered")
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("'%v' is not a number", value)
+}
+
+// the time relative to now, the units are y(year), M(month), w(week), d(day), h(hour), m(minute) and s(second)
+func relativeTime(str string, now time.Time) (time.Time, bool) {
+	matches := relativeTimeRegexp.FindStringSubmatch(str)
+	if matches == nil {
+		return time.Time{}, false
+	}
+	if matches[1] == "" {
+		return now, true
+	}
+
+	n, _ := strconv.Atoi(matches[2])
+	if matches[1] == "-" {
+		n = -n
+	}
+	switch matches[3] {
+	case "y":
+		return now.AddDate(n, 0, 0), true
+	case "M":
+		return now.AddDate(0, n, 0), true
+	case "w":
+		return now.AddDate(0, 0, 7*n), true
+	case "d":
+		return now.AddDate(0, 0, n), true
+	case "h":
+		return now.Add(time.Duration(n) * time.Hour), true
+	case "m":
+		return now.Add(time.Duration(n) * time.Minute), true
+	}
+	return now.Add(time.Duration(n) * time.Second), true
+}
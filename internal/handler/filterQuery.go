@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+
+	"weaving_net/internal/types"
+)
+
+// the maximum length and nesting depth of a filter query
+const (
+	maxFilterQueryLength = 2000
+	maxFilterQueryDepth  = 8
+)
+
+const (
+	tokenWord = iota + 1
+	tokenString
+	tokenSymbol
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type filterToken struct {
+	kind int
+	text string
+}
+
+// parseFilterQuery parse the filter of the url query string, the syntax is
+//
+//	startDate >= now-5y and (company ilike 'weaving net' or title starts_with go) and endDate is null
+//
+// the conditions are joined by and/or with parentheses, and has higher precedence than or. the operators are the
+// same as types.Filter, the values are written as `name in (1, 2, 3)` and `name between 1 and 3` for in and between.
+// a value with spaces, commas, parentheses or quotes is quoted by single or double quotes, \ escapes the next character.
+func parseFilterQuery(str string) (*types.Filter, error) {
+	if len(str) > maxFilterQueryLength {
+		return nil, fmt.Errorf("the filter query is longer than %d", maxFilterQueryLength)
+	}
+	tokens, err := tokenizeFilterQuery(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &filterQueryParser{tokens: tokens}
+	filter, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in the filter query", p.tokens[p.pos].text)
+	}
+	return filter, nil
+}
+
+func tokenizeFilterQuery(str string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	runes := []rune(str)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenLeftParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenRightParen, text: ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{kind: tokenComma, text: ","})
+			i++
+		case r == '\'' || r == '"':
+			quote, sb := r, strings.Builder{}
+			i++
+			for ; i < len(runes) && runes[i] != quote; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string in the filter query")
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, text: sb.String()})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			j := i
+			for j < len(runes) && strings.ContainsRune("=!<>", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: tokenSymbol, text: string(runes[i:j])})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("(),'\"=!<>", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type filterQueryParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterQueryParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *filterQueryParser) next(what string) (filterToken, error) {
+	if p.pos == len(p.tokens) {
+		return filterToken{}, fmt.Errorf("%s is expected at the end of the filter query", what)
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterQueryParser) parseOr(depth int) (*types.Filter, error) {
+	return p.parseGroup(depth, "or", p.parseAnd)
+}
+
+func (p *filterQueryParser) parseAnd(depth int) (*types.Filter, error) {
+	return p.parseGroup(depth, "and", p.parseFactor)
+}
+
+// the operands joined by the keyword, a single operand is not grouped
+func (p *filterQueryParser) parseGroup(depth int, keyword string, parseOperand func(int) (*types.Filter, error)) (*types.Filter, error) {
+	filter, err := parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+	filters := []*types.Filter{filter}
+	for p.peekKeyword(keyword) {
+		p.pos++
+		filter, err = parseOperand(depth)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	if keyword == "or" {
+		return &types.Filter{Or: filters}, nil
+	}
+	return &types.Filter{And: filters}, nil
+}
+
+func (p *filterQueryParser) parseFactor(depth int) (*types.Filter, error) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenLeftParen {
+		if depth == maxFilterQueryDepth {
+			return nil, fmt.Errorf("the filter query is nested deeper than %d", maxFilterQueryDepth)
+		}
+		p.pos++
+		filter, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		token, err := p.next("')'")
+		if err != nil {
+			return nil, err
+		}
+		if token.kind != tokenRightParen {
+			return nil, fmt.Errorf("')' is expected before '%s' in the filter query", token.text)
+		}
+		return filter, nil
+	}
+	return p.parseCondition()
+}
+
+// name operator [value]
+func (p *filterQueryParser) parseCondition() (*types.Filter, error) {
+	token, err := p.next("column name")
+	if err != nil {
+		return nil, err
+	}
+	if token.kind != tokenWord {
+		return nil, fmt.Errorf("column name is expected before '%s' in the filter query", token.text)
+	}
+	filter := &types.Filter{Name: token.text}
+
+	token, err = p.next("operator")
+	if err != nil {
+		return nil, err
+	}
+	if token.kind != tokenSymbol && token.kind != tokenWord {
+		return nil, fmt.Errorf("operator is expected before '%s' in the filter query", token.text)
+	}
+	filter.Exp = strings.ToLower(token.text)
+	if filter.Exp == "is" || filter.Exp == "not" { // is null, is not null and not null
+		for !strings.HasSuffix(filter.Exp, "null") && (p.peekKeyword("not") || p.peekKeyword("null")) {
+			filter.Exp += " " + strings.ToLower(p.tokens[p.pos].text)
+			p.pos++
+		}
+		if exp := normalizeOp(filter.Exp); exp != opIsNull && exp != opNotNull {
+			return nil, fmt.Errorf("unknown operator '%s' in the filter query", filter.Exp)
+		}
+	}
+
+	switch normalizeOp(filter.Exp) {
+	case opIsNull, opNotNull:
+		return filter, nil
+
+	case query.In:
+		token, err = p.next("'('")
+		if err != nil {
+			return nil, err
+		}
+		if token.kind != tokenLeftParen {
+			return nil, fmt.Errorf("'(' is expected before '%s' in the filter query", token.text)
+		}
+		values := []interface{}{}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			token, err = p.next("')'")
+			if err != nil {
+				return nil, err
+			}
+			if token.kind == tokenRightParen {
+				break
+			}
+			if token.kind != tokenComma {
+				return nil, fmt.Errorf("',' or ')' is expected before '%s' in the filter query", token.text)
+			}
+		}
+		filter.Value = values
+		return filter, nil
+
+	case opBetween:
+		low, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekKeyword("and") {
+			return nil, fmt.Errorf("'and' is expected after the lower bound of between in the filter query")
+		}
+		p.pos++
+		high, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		filter.Value = []interface{}{low, high}
+		return filter, nil
+	}
+
+	filter.Value, err = p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func (p *filterQueryParser) parseValue() (interface{}, error) {
+	token, err := p.next("value")
+	if err != nil {
+		return nil, err
+	}
+	if token.kind != tokenWord && token.kind != tokenString {
+		return nil, fmt.Errorf("value is expected before '%s' in the filter query", token.text)
+	}
+	return token.text, nil
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"weaving_net/internal/types"
+)
+
+func Test_parseFilterQuery(t *testing.T) {
+	filter, err := parseFilterQuery(`startDate >= now-5y and (company ilike 'weaving \'net\'' OR title starts_with go) and endDate is null`)
+	assert.NoError(t, err)
+	assert.Equal(t, &types.Filter{And: []*types.Filter{
+		{Name: "startDate", Exp: ">=", Value: "now-5y"},
+		{Or: []*types.Filter{
+			{Name: "company", Exp: "ilike", Value: "weaving 'net'"},
+			{Name: "title", Exp: "starts_with", Value: "go"},
+		}},
+		{Name: "endDate", Exp: "is null"},
+	}}, filter)
+
+	// and has higher precedence than or
+	filter, err = parseFilterQuery(`id in (1, "2,3") or userId=1 and endDate is not null or endDate not null and position between 1 and 3`)
+	assert.NoError(t, err)
+	assert.Equal(t, &types.Filter{Or: []*types.Filter{
+		{Name: "id", Exp: "in", Value: []interface{}{"1", "2,3"}},
+		{And: []*types.Filter{
+			{Name: "userId", Exp: "=", Value: "1"},
+			{Name: "endDate", Exp: "is not null"},
+		}},
+		{And: []*types.Filter{
+			{Name: "endDate", Exp: "not null"},
+			{Name: "position", Exp: "between", Value: []interface{}{"1", "3"}},
+		}},
+	}}, filter)
+
+	filter, err = parseFilterQuery("((id != 1))")
+	assert.NoError(t, err)
+	assert.Equal(t, &types.Filter{Name: "id", Exp: "!=", Value: "1"}, filter)
+
+	filter, err = parseFilterQuery("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+
+	errQueries := []string{
+		"id",
+		"id =",
+		"id = 1 and",
+		"id = 1 or or id = 2",
+		"(id = 1",
+		"id = 1)",
+		"id = (1)",
+		"= 1",
+		"'id' = 1",
+		"id ( 1",
+		"id in 1",
+		"id in (1",
+		"id in (1 2)",
+		"id between 1",
+		"id between 1 or 2",
+		"id is 1",
+		"id is not",
+		"title = 'go",
+		strings.Repeat("(", maxFilterQueryDepth+1) + "id = 1" + strings.Repeat(")", maxFilterQueryDepth+1),
+		strings.Repeat("id = 1 or ", maxFilterQueryLength/10) + "id = 1",
+	}
+	for _, query := range errQueries {
+		_, err = parseFilterQuery(query)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func Test_filterSpec_checkColumns(t *testing.T) {
+	columns := []query.Column{
+		{Name: "userId", Value: "1"},
+		{Name: "company", Exp: "LIKE", Value: "weaving", Logic: "||"},
+		{Name: "startDate", Exp: ">=", Value: "2020-01-02"},
+		{Name: "is_current", Value: true},
+		{Name: "id", Exp: query.In, Value: []interface{}{1.0, "2"}},
+	}
+	err := workexperiencesFilter.checkColumns(columns)
+	assert.NoError(t, err)
+	assert.Equal(t, []query.Column{
+		{Name: "user_id", Exp: query.Eq, Value: int64(1)},
+		{Name: "company", Exp: query.Like, Value: "weaving", Logic: "||"},
+		{Name: "start_date", Exp: query.Gte, Value: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Name: "is_current", Exp: query.Eq, Value: true},
+		{Name: "id", Exp: query.In, Value: "1,2"},
+	}, columns)
+
+	_, _, err = (&query.Params{Columns: columns}).ConvertToGormConditions()
+	assert.NoError(t, err)
+
+	errColumns := []query.Column{
+		{Name: "deleted_at", Value: 1},                  // not allowed column
+		{Name: "userId", Exp: query.Like, Value: "1"},   // not allowed operator
+		{Name: "is_current", Exp: query.Gt, Value: 1},   // not allowed operator
+		{Name: "userId", Value: "one"},                  // not an integer
+		{Name: "userId", Value: 1.5},                    // not an integer
+		{Name: "startDate", Value: "yesterday"},         // not a time
+		{Name: "is_current", Value: "yes"},              // not a bool
+		{Name: "id", Exp: query.In, Value: "1,two"},     // not an integer
+		{Name: "id", Value: -1},                         // not an unsigned integer
+		{Name: "company", Value: "a", Logic: "xor"},     // unknown logic
+		{Name: "company", Value: []interface{}{"a"}},    // not a string
+		{Name: "company", Exp: query.In, Value: 1.0},    // not separated by commas
+		{Name: "company; DROP TABLE users", Value: "a"}, // injection
+	}
+	for _, column := range errColumns {
+		err = workexperiencesFilter.checkColumns([]query.Column{column})
+		assert.Error(t, err, column)
+	}
+}
+
+func Test_filterSpec_checkSort(t *testing.T) {
+	sort, err := skillsFilter.checkSort("-verifiedLevel, skill_name,id")
+	assert.NoError(t, err)
+	assert.Equal(t, "-verified_level,skill_name,id", sort)
+
+	sort, err = skillsFilter.checkSort("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", sort)
+
+	sort, err = skillsFilter.checkSort(sortIgnoreCount)
+	assert.NoError(t, err)
+	assert.Equal(t, sortIgnoreCount, sort)
+
+	_, err = skillsFilter.checkSort("catalogId")
+	assert.Error(t, err)
+	_, err = skillsFilter.checkSort("-deleted_at")
+	assert.Error(t, err)
+	_, err = skillsFilter.checkSort("id;DROP TABLE skills")
+	assert.Error(t, err)
+
+	params := &query.Params{Sort: "-createdAt", Columns: []query.Column{{Name: "userId", Value: 1.0}}}
+	err = skillsFilter.checkParams(params)
+	assert.NoError(t, err)
+	assert.Equal(t, "-created_at", params.Sort)
+	assert.True(t, isFilterByUser(params.Columns))
+
+	err = skillsFilter.checkParams(&query.Params{Columns: []query.Column{{Name: "unknown", Value: 1}}})
+	assert.Error(t, err)
+}
+
+func Test_newFilterSpec(t *testing.T) {
+	defer func() {
+		assert.NotNil(t, recover())
+	}()
+	_ = newFilterSpec(&model.Skills{}, []filterColumn{{name: "unknown", ops: equalOps}})
+}
+
+func Test_filterSpec_filterConditions(t *testing.T) {
+	d := gotest.NewDao(nil, &model.Workexperiences{})
+	defer d.Close()
+	toSQL := func(filters []clause.Expression) string {
+		stmt := d.DB.Session(&gorm.Session{DryRun: true}).Scopes(func(db *gorm.DB) *gorm.DB {
+			for _, filter := range filters {
+				db = db.Where(filter)
+			}
+			return db
+		}).Find(&[]*model.Workexperiences{}).Statement
+		return stmt.SQL.String()
+	}
+
+	filters, err := workexperiencesFilter.filterConditions(&types.Filter{And: []*types.Filter{
+		{Name: "userId", Value: 1.0},
+		{Or: []*types.Filter{
+			{Name: "endDate", Exp: "is null"},
+			{Name: "end_date", Exp: query.Gte, Value: "now-5y"},
+		}},
+		{Name: "company", Exp: opILike, Value: "50%_off"},
+		{Name: "title", Exp: opStartsWith, Value: "go"},
+		{Name: "startDate", Exp: opBetween, Value: []interface{}{"2020-01-01", "2021-01-01"}},
+		{Name: "id", Exp: query.In, Value: "1,2"},
+		{Or: []*types.Filter{{Name: "location", Exp: query.Like, Value: "sh"}}},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `workexperiences` WHERE (`user_id` = ? AND (`end_date` IS NULL OR `end_date` >= ?) AND "+
+		"`company` ILIKE ? AND `title` LIKE ? AND (`start_date` BETWEEN ? AND ?) AND `id` IN (?,?) AND `location` LIKE ?) "+
+		"AND `workexperiences`.`deleted_at` IS NULL", toSQL(filters))
+
+	and := filters[0].(clause.AndConditions)
+	assert.Equal(t, `%50\%\_off%`, and.Exprs[2].(clause.Expr).Vars[1])
+	assert.Equal(t, "go%", and.Exprs[3].(clause.Like).Value)
+
+	filters, err = workexperiencesFilter.filterConditions(&types.Filter{Or: []*types.Filter{
+		{Name: "isCurrent", Value: true},
+		{Name: "endDate", Exp: "notnull"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `workexperiences` WHERE (`is_current` = ? OR `end_date` IS NOT NULL) "+
+		"AND `workexperiences`.`deleted_at` IS NULL", toSQL(filters))
+
+	filters, err = workexperiencesFilter.filterConditions(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, filters)
+
+	tooMany := &types.Filter{}
+	for i := 0; i <= maxFilterConditions; i++ {
+		tooMany.Or = append(tooMany.Or, &types.Filter{Name: "id", Value: i})
+	}
+	errFilters := []*types.Filter{
+		{Name: "deleted_at", Exp: opIsNull},                                     // not allowed column
+		{Name: "company", Exp: opIsNull},                                        // not null column
+		{Name: "userId", Exp: opBetween, Value: []interface{}{1, 2}},            // not allowed operator
+		{Name: "startDate", Exp: opBetween, Value: []interface{}{"now"}},        // one value
+		{Name: "startDate", Exp: query.Gte, Value: "now-5x"},                    // unknown unit
+		{Name: "id", Exp: query.In, Value: 1.0},                                 // not an array
+		{Name: "id", Exp: "~", Value: 1.0},                                      // unknown operator
+		{Name: "id", Value: 1, And: []*types.Filter{{Name: "id", Value: 1}}},    // both condition and group
+		{And: []*types.Filter{{Name: "id", Value: 1}}, Or: []*types.Filter{{}}}, // both and and or
+		{And: []*types.Filter{nil}},                                             // empty filter
+		tooMany,
+	}
+	for _, filter := range errFilters {
+		_, err = workexperiencesFilter.filterConditions(filter)
+		assert.Error(t, err, filter)
+	}
+
+	// the operators of filter can not be used by the columns
+	err = workexperiencesFilter.checkColumns([]query.Column{{Name: "company", Exp: opILike, Value: "a"}})
+	assert.Error(t, err)
+
+	filters, err = workexperiencesFilter.queryConditions("userId = 1 and endDate is null")
+	assert.NoError(t, err)
+	assert.Len(t, filters, 1)
+	filters, err = workexperiencesFilter.queryConditions("")
+	assert.NoError(t, err)
+	assert.Empty(t, filters)
+	_, err = workexperiencesFilter.queryConditions("userId = ")
+	assert.Error(t, err)
+}
+
+func Test_relativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"now":     now,
+		"now-5y":  now.AddDate(-5, 0, 0),
+		"now+1M":  now.AddDate(0, 1, 0),
+		"now-2w":  now.AddDate(0, 0, -14),
+		"now-30d": now.AddDate(0, 0, -30),
+		"now+3h":  now.Add(3 * time.Hour),
+		"now-15m": now.Add(-15 * time.Minute),
+		"now-10s": now.Add(-10 * time.Second),
+	}
+	for str, expected := range tests {
+		actual, ok := relativeTime(str, now)
+		assert.True(t, ok, str)
+		assert.Equal(t, expected, actual, str)
+	}
+
+	for _, str := range []string{"now-5", "now-y", "now*5y", "today", "now-1234567d"} {
+		_, ok := relativeTime(str, now)
+		assert.False(t, ok, str)
+	}
+
+	value, err := coerceValue(reflect.TypeOf(time.Time{}), "now-1d")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -1), value.(time.Time), time.Minute)
+}
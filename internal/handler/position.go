@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+)
+
+// isFilterByUser determine if the list is filtered by a user, the records of a user are sorted by position by default
+func isFilterByUser(columns []query.Column) bool {
+	for _, column := range columns {
+		exp := strings.ToLower(column.Exp)
+		if column.Name == "user_id" && (exp == "" || exp == query.Eq || exp == "=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+)
+
+func Test_isFilterByUser(t *testing.T) {
+	assert.True(t, isFilterByUser([]query.Column{{Name: "user_id", Value: 1}}))
+	assert.True(t, isFilterByUser([]query.Column{{Name: "title", Exp: query.Like, Value: "go"}, {Name: "user_id", Exp: "EQ", Value: 1}}))
+	assert.False(t, isFilterByUser([]query.Column{{Name: "user_id", Exp: query.In, Value: "1,2"}}))
+	assert.False(t, isFilterByUser(nil))
+}
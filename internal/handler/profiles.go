@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/response"
+	"weaving_net/internal/service"
+	"weaving_net/internal/types"
+)
+
+var _ ProfilesHandler = (*profilesHandler)(nil)
+
+// ProfilesHandler defining the handler interface of the operations that change several tables atomically
+type ProfilesHandler interface {
+	Replace(c *gin.Context)
+	MoveProject(c *gin.Context)
+}
+
+type profilesHandler struct {
+	iService   service.ProfilesService
+	catalogDao dao.SkillCatalogsDao
+}
+
+// NewProfilesHandler creating the handler interface
+func NewProfilesHandler() ProfilesHandler {
+	return newProfilesHandlerByService(
+		service.NewProfilesService(),
+		dao.NewSkillCatalogsDao(model.GetDB()),
+	)
+}
+
+func newProfilesHandlerByService(iService service.ProfilesService, catalogDao dao.SkillCatalogsDao) ProfilesHandler {
+	return &profilesHandler{
+		iService:   iService,
+		catalogDao: catalogDao,
+	}
+}
+
+// Replace the user and all the records of the user
+// @Summary replace the profile of a user
+// @Description replace the user and all the educations, projects, skills, introductions and workexperiences of the user in one transaction, the records of the user are deleted and the records of the request are created in their order
+// @Tags users
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the user, * matches any version"
+// @Param data body types.ReplaceProfileRequest true "profile information"
+// @Success 200 {object} types.ReplaceProfileRespond{}
+// @Router /api/v1/users/{id}/profile [put]
+// @Security BearerAuth
+func (h *profilesHandler) Replace(c *gin.Context) {
+	_, id, isAbort := getUsersIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	form := &types.ReplaceProfileRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	profile := &service.Profile{User: &model.Users{}}
+	err = copyProfile(profile, form)
+	if err != nil {
+		response.Error(c, ecode.ErrReplaceProfiles)
+		return
+	}
+	profile.User.ID = id
+	profile.User.Version = version
+
+	ctx := middleware.WrapCtx(c)
+	if e := h.checkProfile(ctx, profile); e != nil {
+		logger.Warn("invalid profile", logger.String("err", e.Msg()), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Error(c, e)
+		return
+	}
+
+	err = h.iService.ReplaceProfile(ctx, profile)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("ReplaceProfile not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("ReplaceProfile modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("ReplaceProfile error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c, profileIDs(profile))
+}
+
+// MoveProject move a project to another user
+// @Summary move a project to another user
+// @Description move the project to the end of the projects of another user in one transaction, the positions of the projects of the previous user are rewritten to close the gap
+// @Tags projects
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the project, * matches any version"
+// @Param data body types.MoveProjectRequest true "the user that the project is moved to"
+// @Success 200 {object} types.MoveProjectRespond{}
+// @Router /api/v1/projects/{id}/move [post]
+// @Security BearerAuth
+func (h *profilesHandler) MoveProject(c *gin.Context) {
+	_, id, isAbort := getProjectsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	form := &types.MoveProjectRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	project, err := h.iService.MoveProject(ctx, id, version, form.UserID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("MoveProject not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("MoveProject modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else if errors.Is(err, service.ErrUserNotFound) {
+			logger.Warn("MoveProject user not found", logger.Err(err), logger.Int("userId", form.UserID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrUserNotFoundProfiles)
+		} else {
+			logger.Error("MoveProject error", logger.Err(err), logger.Any("id", id), logger.Int("userId", form.UserID), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	data, err := convertProjects(project)
+	if err != nil {
+		response.Error(c, ecode.ErrMoveProjectProfiles)
+		return
+	}
+
+	response.Success(c, gin.H{"projects": data})
+}
+
+// checkProfile check the records of the profile as they are checked when they are created one by one, only
+// one workexperience can be the primary position, the stored ones are replaced.
+func (h *profilesHandler) checkProfile(ctx context.Context, profile *service.Profile) *errcode.Error {
+	for _, record := range profile.Educations {
+		if e := checkEducations(record); e != nil {
+			return e
+		}
+	}
+	for _, record := range profile.Skills {
+		if e := checkSkillsByCatalog(ctx, h.catalogDao, record); e != nil {
+			return e
+		}
+	}
+	hasPrimary := false
+	for _, record := range profile.Workexperiences {
+		if !isValidOngoingDates(record.StartDate, record.EndDate, record.IsCurrent) {
+			return ecode.ErrInvalidDatesWorkexperiences
+		}
+		if record.IsPrimary {
+			if hasPrimary {
+				return ecode.ErrPrimaryExistsWorkexperiences
+			}
+			hasPrimary = true
+		}
+	}
+	return nil
+}
+
+// copy the user and the records of the request to the profile
+func copyProfile(profile *service.Profile, form *types.ReplaceProfileRequest) error {
+	err := copier.Copy(profile.User, &form.User)
+	if err == nil {
+		err = copier.Copy(&profile.Educations, form.Educations)
+	}
+	if err == nil {
+		err = copier.Copy(&profile.Projects, form.Projects)
+	}
+	if err == nil {
+		err = copier.Copy(&profile.Skills, form.Skills)
+	}
+	if err == nil {
+		err = copier.Copy(&profile.UserIntroductions, form.UserIntroductions)
+	}
+	if err == nil {
+		err = copier.Copy(&profile.Workexperiences, form.Workexperiences)
+	}
+	return err
+}
+
+// the ids of the created records of the profile in their order
+func profileIDs(profile *service.Profile) *types.ProfileIDs {
+	ids := &types.ProfileIDs{
+		Educations:        make([]uint64, 0, len(profile.Educations)),
+		Projects:          make([]uint64, 0, len(profile.Projects)),
+		Skills:            make([]uint64, 0, len(profile.Skills)),
+		UserIntroductions: make([]uint64, 0, len(profile.UserIntroductions)),
+		Workexperiences:   make([]uint64, 0, len(profile.Workexperiences)),
+	}
+	for _, record := range profile.Educations {
+		ids.Educations = append(ids.Educations, record.ID)
+	}
+	for _, record := range profile.Projects {
+		ids.Projects = append(ids.Projects, record.ID)
+	}
+	for _, record := range profile.Skills {
+		ids.Skills = append(ids.Skills, record.ID)
+	}
+	for _, record := range profile.UserIntroductions {
+		ids.UserIntroductions = append(ids.UserIntroductions, record.ID)
+	}
+	for _, record := range profile.Workexperiences {
+		ids.Workexperiences = append(ids.Workexperiences, record.ID)
+	}
+	return ids
+}
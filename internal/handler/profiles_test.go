@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/service"
+	"weaving_net/internal/types"
+)
+
+// the service of the profiles that returns err, the ids of the created records are their indexes plus 1
+type fakeProfilesService struct {
+	err     error
+	profile *service.Profile
+}
+
+func (s *fakeProfilesService) ReplaceProfile(ctx context.Context, profile *service.Profile) error {
+	s.profile = profile
+	if s.err != nil {
+		return s.err
+	}
+	for i, record := range profile.Projects {
+		record.ID = uint64(i + 1)
+	}
+	return nil
+}
+
+func (s *fakeProfilesService) MoveProject(ctx context.Context, id uint64, version int, userID int) (*model.Projects, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	record := &model.Projects{UserID: userID, Position: 1, Version: version + 1}
+	record.ID = id
+	return record, nil
+}
+
+func newProfilesHandler(s *fakeProfilesService) *gotest.Handler {
+	testData := &model.Users{}
+	testData.ID = 1
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao of the skill catalog
+	d := gotest.NewDao(nil, testData)
+	d.IDao = dao.NewSkillCatalogsDao(d.DB)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = newProfilesHandlerByService(s, d.IDao.(dao.SkillCatalogsDao))
+	iHandler := h.IHandler.(ProfilesHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Replace",
+			Method:      http.MethodPut,
+			Path:        "/users/:id/profile",
+			HandlerFunc: iHandler.Replace,
+		},
+		{
+			FuncName:    "MoveProject",
+			Method:      http.MethodPost,
+			Path:        "/projects/:id/move",
+			HandlerFunc: iHandler.MoveProject,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_profilesHandler_Replace(t *testing.T) {
+	s := &fakeProfilesService{}
+	h := newProfilesHandler(s)
+	defer h.Close()
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
+	testData := &types.ReplaceProfileRequest{
+		User:     types.CreateUsersRequest{FirstName: "foo"},
+		Projects: []types.CreateProjectsRequest{{ProjectName: "a"}, {ProjectName: "b"}},
+	}
+
+	result := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodPut, result, h.GetRequestURL("Replace", 1), ifMatch, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
+	}
+	assert.Equal(t, uint64(1), s.profile.User.ID)
+	assert.Equal(t, 1, s.profile.User.Version)
+	assert.Equal(t, "b", s.profile.Projects[1].ProjectName)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, result.Data.(map[string]interface{})["projects"])
+
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("Replace", 1), nil, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// invalid records error test
+	endDate := time.Now()
+	invalid := &types.ReplaceProfileRequest{
+		User: testData.User,
+		Workexperiences: []types.CreateWorkexperiencesRequest{
+			{StartDate: endDate.AddDate(-1, 0, 0), IsPrimary: true},
+			{StartDate: endDate.AddDate(-2, 0, 0), EndDate: &endDate, IsPrimary: true},
+		},
+	}
+	_, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("Replace", 1), ifMatch, invalid)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrPrimaryExistsWorkexperiences.Code(), result.Code)
+
+	// modified error test
+	s.err = model.ErrRecordModified
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("Replace", 1), ifMatch, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+
+	// not found error test
+	s.err = model.ErrRecordNotFound
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("Replace", 1), ifMatch, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+}
+
+func Test_profilesHandler_MoveProject(t *testing.T) {
+	s := &fakeProfilesService{}
+	h := newProfilesHandler(s)
+	defer h.Close()
+	ifMatch := map[string]string{"If-Match": "*"}
+	testData := &types.MoveProjectRequest{UserID: 2}
+
+	result := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodPost, result, h.GetRequestURL("MoveProject", 1), ifMatch, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
+	}
+	project := result.Data.(map[string]interface{})["projects"].(map[string]interface{})
+	assert.Equal(t, float64(2), project["userId"])
+
+	// invalid user id error test
+	_, _, err = doWithHeader(http.MethodPost, result, h.GetRequestURL("MoveProject", 1), ifMatch, &types.MoveProjectRequest{})
+	assert.NoError(t, err)
+	assert.NotZero(t, result.Code)
+
+	// the user does not exist error test
+	s.err = service.ErrUserNotFound
+	_, _, err = doWithHeader(http.MethodPost, result, h.GetRequestURL("MoveProject", 1), ifMatch, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrUserNotFoundProfiles.Code(), result.Code)
+
+	// modified error test
+	s.err = model.ErrRecordModified
+	statusCode, _, err = doWithHeader(http.MethodPost, result, h.GetRequestURL("MoveProject", 1), ifMatch, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+}
@@ -3,12 +3,12 @@ package handler
 import (
 	"errors"
 	"math"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 
 	"github.com/zhufuyi/sponge/pkg/gin/middleware"
-	"github.com/zhufuyi/sponge/pkg/gin/response"
 	"github.com/zhufuyi/sponge/pkg/logger"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
@@ -16,6 +16,7 @@ import (
 	"weaving_net/internal/dao"
 	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
+	"weaving_net/internal/response"
 	"weaving_net/internal/types"
 )
 
@@ -24,27 +25,47 @@ var _ ProjectsHandler = (*projectsHandler)(nil)
 // ProjectsHandler defining the handler interface
 type ProjectsHandler interface {
 	Create(c *gin.Context)
+	CreateBatch(c *gin.Context)
 	DeleteByID(c *gin.Context)
 	DeleteByIDs(c *gin.Context)
 	UpdateByID(c *gin.Context)
+	UpdateBatch(c *gin.Context)
+	PatchByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	GetByCondition(c *gin.Context)
 	ListByIDs(c *gin.Context)
 	ListByLastID(c *gin.Context)
+	ListByCursor(c *gin.Context)
 	List(c *gin.Context)
+	ListByUserID(c *gin.Context)
+	Reorder(c *gin.Context)
 }
 
+// the columns of projects that the clients can filter and sort by
+var projectsFilter = newFilterSpec(&model.Projects{}, []filterColumn{
+	{name: "user_id", ops: equalOps, sortable: true},
+	{name: "project_name", ops: textOps, sortable: true},
+	{name: "role", ops: textOps, sortable: true},
+	{name: "position", ops: orderedOps, sortable: true},
+})
+
+// the fields of projects that the clients can select
+var projectsFields = newFieldSpec(&model.Projects{}, &types.ProjectsObjDetail{}, map[string][]string{
+	"user": {"user_id"},
+})
+
 type projectsHandler struct {
-	iDao dao.ProjectsDao
+	iDao     dao.ProjectsDao
+	expander *expander
 }
 
 // NewProjectsHandler creating the handler interface
 func NewProjectsHandler() ProjectsHandler {
 	return &projectsHandler{
-		iDao: dao.NewProjectsDao(
-			model.GetDB(),
+		iDao: dao.NewProjectsDaoByDriver(
 			cache.NewProjectsCache(model.GetCacheType()),
 		),
+		expander: newExpander(),
 	}
 }
 
@@ -63,7 +84,7 @@ func (h *projectsHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -78,13 +99,60 @@ func (h *projectsHandler) Create(c *gin.Context) {
 	err = h.iDao.Create(ctx, projects)
 	if err != nil {
 		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
 	response.Success(c, gin.H{"id": projects.ID})
 }
 
+// CreateBatch create records in batch
+// @Summary create projects in batch
+// @Description submit information to create projects in one transaction, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags projects
+// @accept json
+// @Produce json
+// @Param data body types.CreateProjectssBatchRequest true "projects information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/projects/batch [post]
+// @Security BearerAuth
+func (h *projectsHandler) CreateBatch(c *gin.Context) {
+	form := &types.CreateProjectssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), true)
+	records := make([]*model.Projects, 0, len(form.Items))
+	for i := range form.Items {
+		record := &model.Projects{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrCreateProjects)
+			continue
+		}
+		items.add(i, 0)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.CreateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("CreateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, func(j int) uint64 { return records[j].ID })
+
+	items.output(c)
+}
+
 // DeleteByID delete a record by id
 // @Summary delete projects
 // @Description delete projects by id
@@ -92,6 +160,7 @@ func (h *projectsHandler) Create(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Success 200 {object} types.DeleteProjectsByIDRespond{}
 // @Router /api/v1/projects/{id} [delete]
 // @Security BearerAuth
@@ -101,12 +170,21 @@ func (h *projectsHandler) DeleteByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err := h.iDao.DeleteByID(ctx, id)
+	err := h.iDao.DeleteByIDAndVersion(ctx, id, version)
 	if err != nil {
-		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("DeleteByIDAndVersion modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("DeleteByIDAndVersion error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -128,7 +206,7 @@ func (h *projectsHandler) DeleteByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -136,7 +214,7 @@ func (h *projectsHandler) DeleteByIDs(c *gin.Context) {
 	err = h.iDao.DeleteByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -145,11 +223,12 @@ func (h *projectsHandler) DeleteByIDs(c *gin.Context) {
 
 // UpdateByID update information by id
 // @Summary update projects
-// @Description update projects information by id
+// @Description replace all projects information by id, fields that are not submitted are reset, the record is only replaced when the If-Match header matches its ETag
 // @Tags projects
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Param data body types.UpdateProjectsByIDRequest true "projects information"
 // @Success 200 {object} types.UpdateProjectsByIDRespond{}
 // @Router /api/v1/projects/{id} [put]
@@ -160,12 +239,16 @@ func (h *projectsHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	form := &types.UpdateProjectsByIDRequest{}
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -176,12 +259,127 @@ func (h *projectsHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.ErrUpdateByIDProjects)
 		return
 	}
+	projects.Version = version
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, projects)
+	err = h.iDao.ReplaceByID(ctx, projects)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("ReplaceByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("ReplaceByID modified", logger.Err(err), logger.Any("form", form), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("ReplaceByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateBatch update records by id in batch
+// @Summary update projects in batch
+// @Description replace projects by id in one transaction as UpdateByID does, fields that are not submitted are reset, an item fails with the precondition failed code if its version does not match the record, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags projects
+// @accept json
+// @Produce json
+// @Param data body types.UpdateProjectssBatchRequest true "projects information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/projects/batch [put]
+// @Security BearerAuth
+func (h *projectsHandler) UpdateBatch(c *gin.Context) {
+	form := &types.UpdateProjectssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), false)
+	records := make([]*model.Projects, 0, len(form.Items))
+	for i := range form.Items {
+		if form.Items[i].ID == 0 {
+			items.reject(i, ecode.InvalidParams)
+			continue
+		}
+		record := &model.Projects{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrUpdateByIDProjects)
+			continue
+		}
+		items.add(i, record.ID)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.UpdateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("UpdateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, nil)
+
+	items.output(c)
+}
+
+// PatchByID partial update by id with a JSON merge patch
+// @Summary patch projects
+// @Description partial update projects by id with a JSON merge patch (RFC 7396), an explicit null clears the field and an omitted field is left alone
+// @Tags projects
+// @accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
+// @Param data body types.PatchProjectsByIDRequest true "projects fields to be changed"
+// @Success 200 {object} types.PatchProjectsByIDRespond{}
+// @Router /api/v1/projects/{id} [patch]
+// @Security BearerAuth
+func (h *projectsHandler) PatchByID(c *gin.Context) {
+	_, id, isAbort := getProjectsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		logger.Warn("GetRawData error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	columns, err := dao.MergePatchToColumns(&model.Projects{}, patch)
+	if err != nil {
+		logger.Warn("MergePatchToColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.PatchByID(ctx, id, version, columns)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("PatchByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("PatchByID modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("PatchByID error", logger.Err(err), logger.Any("columns", columns), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -195,6 +393,9 @@ func (h *projectsHandler) UpdateByID(c *gin.Context) {
 // @Param id path string true "id"
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the record has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetProjectsByIDRespond{}
 // @Router /api/v1/projects/{id} [get]
 // @Security BearerAuth
@@ -205,15 +406,28 @@ func (h *projectsHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
 	ctx := middleware.WrapCtx(c)
 	projects, err := h.iDao.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -226,7 +440,19 @@ func (h *projectsHandler) GetByID(c *gin.Context) {
 	}
 	data.ID = idStr
 
-	response.Success(c, gin.H{"projects": data})
+	err = h.expander.expandProjects(ctx, []*types.ProjectsObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, exp.etag(projects.Version, data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{"projects": fields.project(data)})
 }
 
 // GetByCondition get a record by condition
@@ -236,6 +462,8 @@ func (h *projectsHandler) GetByID(c *gin.Context) {
 // @Param data body types.Conditions true "query condition"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetProjectsByConditionRespond{}
 // @Router /api/v1/projects/condition [post]
 // @Security BearerAuth
@@ -244,13 +472,32 @@ func (h *projectsHandler) GetByCondition(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	err = form.Conditions.CheckValid()
 	if err != nil {
 		logger.Warn("Parameters error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = projectsFilter.checkColumns(form.Conditions.Columns)
+	if err != nil {
+		logger.Warn("checkColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -259,10 +506,10 @@ func (h *projectsHandler) GetByCondition(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByCondition not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByCondition error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -275,7 +522,14 @@ func (h *projectsHandler) GetByCondition(c *gin.Context) {
 	}
 	data.ID = utils.Uint64ToStr(projects.ID)
 
-	response.Success(c, gin.H{"projects": data})
+	err = h.expander.expandProjects(ctx, []*types.ProjectsObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{"projects": fields.project(data)})
 }
 
 // ListByIDs list of records by batch id
@@ -285,6 +539,8 @@ func (h *projectsHandler) GetByCondition(c *gin.Context) {
 // @Param data body types.ListProjectssByIDsRequest true "id array"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListProjectssByIDsRespond{}
 // @Router /api/v1/projects/list/ids [post]
 // @Security BearerAuth
@@ -293,7 +549,20 @@ func (h *projectsHandler) ListByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -301,7 +570,7 @@ func (h *projectsHandler) ListByIDs(c *gin.Context) {
 	projectsMap, err := h.iDao.GetByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -317,8 +586,15 @@ func (h *projectsHandler) ListByIDs(c *gin.Context) {
 		}
 	}
 
+	err = h.expander.expandProjects(ctx, projectss, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"projectss": projectss,
+		"projectss": fields.project(projectss),
 	})
 }
 
@@ -331,6 +607,10 @@ func (h *projectsHandler) ListByIDs(c *gin.Context) {
 // @Param lastID query int true "last id, default is MaxInt32" default(0)
 // @Param limit query int false "size in each page" default(10)
 // @Param sort query string false "sort by column name of table, and the "-" sign before column name indicates reverse order" default(-id)
+// @Param q query string false "filter, e.g. startDate >= now-5y and (title ilike go or endDate is null)"
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the list has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListProjectssRespond{}
 // @Router /api/v1/projects/list [get]
 // @Security BearerAuth
@@ -343,13 +623,38 @@ func (h *projectsHandler) ListByLastID(c *gin.Context) {
 	if limit == 0 {
 		limit = 10
 	}
-	sort := c.Query("sort")
+	sort, err := projectsFilter.checkSort(c.Query("sort"))
+	if err != nil {
+		logger.Warn("checkSort error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := projectsFilter.queryConditions(c.Query("q"))
+	if err != nil {
+		logger.Warn("queryConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	projectss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort)
+	projectss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort, filters...)
 	if err != nil {
 		logger.Error("GetByLastID error", logger.Err(err), logger.Uint64("latsID", lastID), logger.Int("limit", limit), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -359,8 +664,98 @@ func (h *projectsHandler) ListByLastID(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandProjects(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, contentETag(data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"projectss": fields.project(data),
+	})
+}
+
+// ListByCursor list of records by keyset paging
+// @Summary list of projectss by cursor
+// @Description list of projectss by keyset paging with filter columns, the first page is requested without cursor, the
+// @Description next or previous page is requested with the nextCursor or prevCursor of the last page and the same columns
+// @Tags projects
+// @accept json
+// @Produce json
+// @Param data body types.ListByCursorRequest true "cursor and query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListProjectssByCursorRespond{}
+// @Router /api/v1/projects/list/cursor [post]
+// @Security BearerAuth
+func (h *projectsHandler) ListByCursor(c *gin.Context) {
+	form := &types.ListByCursorRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	params, err := newCursorParams(projectsFilter, &model.Projects{}, form)
+	if err != nil {
+		logger.Warn("newCursorParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	params.Filters = append(params.Filters, fields.selectColumns())
+
+	ctx := middleware.WrapCtx(c)
+	projectss, hasMore, err := h.iDao.GetByCursor(ctx, params)
+	if err != nil {
+		logger.Error("GetByCursor error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	nextCursor, prevCursor, err := pageCursors(ctx, projectss, form, params, hasMore)
+	if err != nil {
+		logger.Error("pageCursors error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListByCursorProjects)
+		return
+	}
+
+	data, err := convertProjectss(projectss)
+	if err != nil {
+		response.Error(c, ecode.ErrListByCursorProjects)
+		return
+	}
+
+	err = h.expander.expandProjects(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"projectss": data,
+		"projectss":      fields.project(data),
+		"nextCursor": nextCursor,
+		"prevCursor": prevCursor,
 	})
 }
 
@@ -371,6 +766,8 @@ func (h *projectsHandler) ListByLastID(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param data body types.Params true "query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListProjectssRespond{}
 // @Router /api/v1/projects/list [post]
 // @Security BearerAuth
@@ -379,15 +776,45 @@ func (h *projectsHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = projectsFilter.checkParams(&form.Params)
+	if err != nil {
+		logger.Warn("checkParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := projectsFilter.filterConditions(form.Filter)
+	if err != nil {
+		logger.Warn("filterConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	if form.Sort == "" && isFilterByUser(form.Columns) {
+		form.Sort = dao.SortPosition
+	}
+
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	projectss, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	projectss, total, err := h.iDao.GetByColumns(ctx, &form.Params, filters...)
 	if err != nil {
 		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -397,12 +824,121 @@ func (h *projectsHandler) List(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandProjects(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"projectss": data,
+		"projectss": fields.project(data),
 		"total":        total,
 	})
 }
 
+// ListByUserID list of all records of a user
+// @Summary list of projectss of a user
+// @Description list of all projectss of a user sorted by position
+// @Tags projects
+// @Param userId path string true "user id"
+// @Accept json
+// @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListProjectssByUserIDRespond{}
+// @Router /api/v1/projects/user/{userId} [get]
+// @Security BearerAuth
+func (h *projectsHandler) ListByUserID(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	exp, err := parseExpand("projects", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := projectsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.ListByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("GetByUserID error", logger.Err(err), logger.Int("userId", userID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertProjectss(records)
+	if err != nil {
+		response.Error(c, ecode.ErrListByUserIDProjects)
+		return
+	}
+
+	err = h.expander.expandProjects(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"projectss": fields.project(data),
+	})
+}
+
+// Reorder rewrite the positions of all records of a user
+// @Summary reorder projectss of a user
+// @Description rewrite the positions of all projectss of a user atomically in the order of ids, the ids must be all the projectss of the user
+// @Tags projects
+// @accept json
+// @Produce json
+// @Param userId path string true "user id"
+// @Param data body types.ReorderProjectssRequest true "all the ids of the user in the new order"
+// @Success 200 {object} types.ReorderProjectssRespond{}
+// @Router /api/v1/projects/user/{userId}/positions [put]
+// @Security BearerAuth
+func (h *projectsHandler) Reorder(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.ReorderProjectssRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Reorder(ctx, userID, form.IDs)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidPositions) {
+			logger.Warn("Reorder invalid ids", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrReorderProjects)
+		} else {
+			logger.Error("Reorder error", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
 func getProjectsIDFromPath(c *gin.Context) (string, uint64, bool) {
 	idStr := c.Param("id")
 	id, err := utils.StrToUint64E(idStr)
@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/response"
+	"weaving_net/internal/types"
+)
+
+var _ RevisionsHandler = (*revisionsHandler)(nil)
+
+// RevisionsHandler defining the handler interface
+type RevisionsHandler interface {
+	List(c *gin.Context)
+	Diff(c *gin.Context)
+	Restore(c *gin.Context)
+}
+
+// revisionResource a resource that keeps revisions, restore applies the snapshot as a merge patch through the
+// resource dao at the current version of the record, so that empty and null values are restored too, the
+// record is checked as an update of it, and the cache is deleted as usual.
+type revisionResource struct {
+	table   string
+	getByID func(ctx context.Context, id uint64) (interface{}, error)
+	restore func(ctx context.Context, id uint64, snapshot []byte) (*errcode.Error, error)
+}
+
+// the dao of a resource that keeps revisions
+type revisionResourceDao[T any] interface {
+	GetByID(ctx context.Context, id uint64) (*T, error)
+	PatchByID(ctx context.Context, id uint64, version int, columns map[string]interface{}) error
+}
+
+// restore the snapshot of a record, check validates the record merged with the snapshot as the update of the
+// resource does, the business error is returned if it is invalid. model.ErrRecordModified is returned if the
+// record is modified after it is read.
+func restoreSnapshot[T any](ctx context.Context, d revisionResourceDao[T], id uint64, snapshot []byte,
+	version func(*T) int, check func(ctx context.Context, record *T) (*errcode.Error, error)) (*errcode.Error, error) {
+	columns, err := dao.MergePatchToColumns(new(T), snapshot)
+	if err != nil {
+		return nil, err
+	}
+	record, err := d.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	currentVersion := version(record)
+	if check != nil {
+		err = json.Unmarshal(snapshot, record)
+		if err != nil {
+			return nil, err
+		}
+		e, err := check(ctx, record)
+		if e != nil || err != nil {
+			return e, err
+		}
+	}
+	return nil, d.PatchByID(ctx, id, currentVersion, columns)
+}
+
+type revisionsHandler struct {
+	iDao      dao.RevisionsDao
+	resources map[string]*revisionResource // key is the resource name in the path
+}
+
+// NewRevisionsHandler creating the handler interface
+func NewRevisionsHandler() RevisionsHandler {
+	return newRevisionsHandlerByDao(
+		dao.NewRevisionsDao(model.GetDB()),
+		dao.NewWorkexperiencesDaoByDriver(cache.NewWorkexperiencesCache(model.GetCacheType())),
+		dao.NewProjectsDaoByDriver(cache.NewProjectsCache(model.GetCacheType())),
+		dao.NewUserIntroductionsDaoByDriver(cache.NewUserIntroductionsCache(model.GetCacheType())),
+		dao.NewUsersDaoByDriver(cache.NewUsersCache(model.GetCacheType())),
+	)
+}
+
+func newRevisionsHandlerByDao(
+	iDao dao.RevisionsDao,
+	workexperiencesDao dao.WorkexperiencesDao,
+	projectsDao dao.ProjectsDao,
+	userIntroductionsDao dao.UserIntroductionsDao,
+	usersDao dao.UsersDao,
+) *revisionsHandler {
+	workexperiences := &workexperiencesHandler{iDao: workexperiencesDao}
+	return &revisionsHandler{
+		iDao: iDao,
+		resources: map[string]*revisionResource{
+			"workexperiences": {
+				table: "workexperiences",
+				getByID: func(ctx context.Context, id uint64) (interface{}, error) {
+					return workexperiencesDao.GetByID(ctx, id)
+				},
+				restore: func(ctx context.Context, id uint64, snapshot []byte) (*errcode.Error, error) {
+					return restoreSnapshot[model.Workexperiences](ctx, workexperiencesDao, id, snapshot,
+						func(record *model.Workexperiences) int { return record.Version }, workexperiences.checkWorkexperiences)
+				},
+			},
+			"projects": {
+				table: "projects",
+				getByID: func(ctx context.Context, id uint64) (interface{}, error) {
+					return projectsDao.GetByID(ctx, id)
+				},
+				restore: func(ctx context.Context, id uint64, snapshot []byte) (*errcode.Error, error) {
+					return restoreSnapshot[model.Projects](ctx, projectsDao, id, snapshot,
+						func(record *model.Projects) int { return record.Version }, nil)
+				},
+			},
+			"userIntroductions": {
+				table: "user_introductions",
+				getByID: func(ctx context.Context, id uint64) (interface{}, error) {
+					return userIntroductionsDao.GetByID(ctx, id)
+				},
+				restore: func(ctx context.Context, id uint64, snapshot []byte) (*errcode.Error, error) {
+					return restoreSnapshot[model.UserIntroductions](ctx, userIntroductionsDao, id, snapshot,
+						func(record *model.UserIntroductions) int { return record.Version }, nil)
+				},
+			},
+			"users": {
+				table: "users",
+				getByID: func(ctx context.Context, id uint64) (interface{}, error) {
+					return usersDao.GetByID(ctx, id)
+				},
+				restore: func(ctx context.Context, id uint64, snapshot []byte) (*errcode.Error, error) {
+					return restoreSnapshot[model.Users](ctx, usersDao, id, snapshot,
+						func(record *model.Users) int { return record.Version }, nil)
+				},
+			},
+		},
+	}
+}
+
+// List of revisions of a resource
+// @Summary list of revisions
+// @Description list of revisions of a resource, the latest version is first
+// @Tags revisions
+// @accept json
+// @Produce json
+// @Param resource path string true "resource name, workexperiences, projects, userIntroductions or users"
+// @Param id path string true "resource id"
+// @Param page query int false "page number, starting from 0" default(0)
+// @Param size query int false "size in each page" default(10)
+// @Success 200 {object} types.ListRevisionsRespond{}
+// @Router /api/v1/revisions/{resource}/{id} [get]
+// @Security BearerAuth
+func (h *revisionsHandler) List(c *gin.Context) {
+	res, id, isAbort := h.getRevisionResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	page := utils.StrToInt(c.Query("page"))
+	size := utils.StrToInt(c.Query("size"))
+	if size == 0 {
+		size = 10
+	}
+
+	ctx := middleware.WrapCtx(c)
+	revisions, total, err := h.iDao.ListByResource(ctx, res.table, id, page, size)
+	if err != nil {
+		logger.Error("ListByResource error", logger.Err(err), logger.String("resource", res.table), logger.Uint64("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertRevisionss(revisions)
+	if err != nil {
+		response.Error(c, ecode.ErrListRevisions)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"revisions": data,
+		"total":     total,
+	})
+}
+
+// Diff two revisions of a resource
+// @Summary diff revisions
+// @Description compare the fields of two revisions of a resource, to=0 means compare with the current data
+// @Tags revisions
+// @accept json
+// @Produce json
+// @Param resource path string true "resource name, workexperiences, projects, userIntroductions or users"
+// @Param id path string true "resource id"
+// @Param from query int true "from version"
+// @Param to query int false "to version, 0 means the current data" default(0)
+// @Success 200 {object} types.DiffRevisionsRespond{}
+// @Router /api/v1/revisions/{resource}/{id}/diff [get]
+// @Security BearerAuth
+func (h *revisionsHandler) Diff(c *gin.Context) {
+	res, id, isAbort := h.getRevisionResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	from := utils.StrToInt(c.Query("from"))
+	to := utils.StrToInt(c.Query("to"))
+	if from < 1 || to < 0 {
+		logger.Warn("invalid versions", logger.Int("from", from), logger.Int("to", to), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	fromSnapshot, err := h.getSnapshot(ctx, res, id, from)
+	if err != nil {
+		h.outputSnapshotError(c, err, res, id, from)
+		return
+	}
+	toSnapshot, err := h.getSnapshot(ctx, res, id, to)
+	if err != nil {
+		h.outputSnapshotError(c, err, res, id, to)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"from":  from,
+		"to":    to,
+		"diffs": diffSnapshots(fromSnapshot, toSnapshot),
+	})
+}
+
+// Restore a revision of a resource
+// @Summary restore revision
+// @Description restore a resource to the data of a revision, the data before restoring is kept as a new revision. the restored data is checked as an update, and 409 is responded if the resource is modified while restoring
+// @Tags revisions
+// @accept json
+// @Produce json
+// @Param resource path string true "resource name, workexperiences, projects, userIntroductions or users"
+// @Param id path string true "resource id"
+// @Param data body types.RestoreRevisionRequest true "version"
+// @Success 200 {object} types.RestoreRevisionRespond{}
+// @Router /api/v1/revisions/{resource}/{id}/restore [post]
+// @Security BearerAuth
+func (h *revisionsHandler) Restore(c *gin.Context) {
+	res, id, isAbort := h.getRevisionResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.RestoreRevisionRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	revision, err := h.iDao.GetByVersion(ctx, res.table, id, form.Version)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("GetByVersion not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByVersion error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	e, err := res.restore(ctx, id, []byte(revision.Snapshot))
+	if e != nil {
+		logger.Warn("invalid restored revision", logger.String("err", e.Msg()), logger.String("resource", res.table), logger.Uint64("id", id), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, e)
+		return
+	}
+	if err != nil {
+		fields := []logger.Field{logger.Err(err), logger.String("resource", res.table), logger.Uint64("id", id), logger.Any("form", form), middleware.GCtxRequestIDField(c)}
+		switch {
+		case errors.Is(err, model.ErrRecordNotFound):
+			logger.Warn("restore not found", fields...)
+			response.Out(c, ecode.NotFound)
+		case errors.Is(err, model.ErrRecordModified):
+			logger.Warn("restore modified", fields...)
+			response.OutWithStatus(c, http.StatusConflict, ecode.ErrPreconditionFailed)
+		case daoError(err) == ecode.AlreadyExists:
+			logger.Warn("restore conflict", fields...)
+			response.Out(c, ecode.AlreadyExists)
+		default:
+			logger.Error("restore error", fields...)
+			response.Error(c, ecode.ErrRestoreRevisions)
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+func (h *revisionsHandler) getRevisionResourceFromPath(c *gin.Context) (*revisionResource, uint64, bool) {
+	name := c.Param("resource")
+	res, ok := h.resources[name]
+	if !ok {
+		logger.Warn("unsupported revision resource", logger.String("resource", name), middleware.GCtxRequestIDField(c))
+		return nil, 0, true
+	}
+
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return nil, 0, true
+	}
+
+	return res, id, false
+}
+
+// get the snapshot of a version, version 0 means the current data
+func (h *revisionsHandler) getSnapshot(ctx context.Context, res *revisionResource, id uint64, version int) (map[string]interface{}, error) {
+	if version == 0 {
+		record, err := res.getByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return model.RevisionSnapshot(res.table, record)
+	}
+
+	revision, err := h.iDao.GetByVersion(ctx, res.table, id, version)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := map[string]interface{}{}
+	err = json.Unmarshal([]byte(revision.Snapshot), &snapshot)
+	return snapshot, err
+}
+
+func (h *revisionsHandler) outputSnapshotError(c *gin.Context, err error, res *revisionResource, id uint64, version int) {
+	fields := []logger.Field{logger.Err(err), logger.String("resource", res.table), logger.Uint64("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c)}
+	if errors.Is(err, model.ErrRecordNotFound) {
+		logger.Warn("get snapshot not found", fields...)
+		response.Out(c, ecode.NotFound)
+		return
+	}
+	logger.Error("get snapshot error", fields...)
+	response.Error(c, ecode.ErrDiffRevisions)
+}
+
+// compare two snapshots field by field, the fields are sorted by name
+func diffSnapshots(from map[string]interface{}, to map[string]interface{}) []*types.RevisionFieldDiff {
+	fields := make([]string, 0, len(from))
+	for field := range from {
+		fields = append(fields, field)
+	}
+	for field := range to {
+		if _, ok := from[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diffs := []*types.RevisionFieldDiff{}
+	for _, field := range fields {
+		if reflect.DeepEqual(from[field], to[field]) {
+			continue
+		}
+		diffs = append(diffs, &types.RevisionFieldDiff{
+			Field: field,
+			From:  from[field],
+			To:    to[field],
+		})
+	}
+
+	return diffs
+}
+
+func convertRevisions(revision *model.Revisions) (*types.RevisionsObjDetail, error) {
+	snapshot := map[string]interface{}{}
+	err := json.Unmarshal([]byte(revision.Snapshot), &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.RevisionsObjDetail{
+		ID:           utils.Uint64ToStr(revision.ID),
+		ResourceType: revision.ResourceType,
+		ResourceID:   revision.ResourceID,
+		UserID:       revision.UserID,
+		Version:      revision.Version,
+		Snapshot:     snapshot,
+		CreatedAt:    revision.CreatedAt,
+	}, nil
+}
+
+func convertRevisionss(fromValues []*model.Revisions) ([]*types.RevisionsObjDetail, error) {
+	toValues := []*types.RevisionsObjDetail{}
+	for _, v := range fromValues {
+		data, err := convertRevisions(v)
+		if err != nil {
+			return nil, err
+		}
+		toValues = append(toValues, data)
+	}
+
+	return toValues, nil
+}
@@ -0,0 +1,363 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+	"github.com/zhufuyi/sponge/pkg/mgo"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func newRevisionsHandler() *gotest.Handler {
+	testData := &model.Revisions{}
+	testData.ID = 1
+	testData.ResourceType = "workexperiences"
+	testData.ResourceID = 1
+	testData.Version = 1
+	testData.Snapshot = `{"company":"foo","title":"engineer"}`
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao, the resources are not cached
+	d := gotest.NewDao(nil, testData)
+	d.IDao = dao.NewRevisionsDao(d.DB)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = newRevisionsHandlerByDao(
+		d.IDao.(dao.RevisionsDao),
+		dao.NewWorkexperiencesDao(d.DB, nil),
+		dao.NewProjectsDao(d.DB, nil),
+		dao.NewUserIntroductionsDao(d.DB, nil),
+		dao.NewUsersDao(d.DB, nil),
+	)
+	iHandler := h.IHandler.(RevisionsHandler)
+
+	h.GoRunHTTPServer(revisionsRouters(iHandler))
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func revisionsRouters(iHandler RevisionsHandler) []gotest.RouterInfo {
+	return []gotest.RouterInfo{
+		{
+			FuncName:    "List",
+			Method:      http.MethodGet,
+			Path:        "/revisions/:resource/:id",
+			HandlerFunc: iHandler.List,
+		},
+		{
+			FuncName:    "Diff",
+			Method:      http.MethodGet,
+			Path:        "/revisions/:resource/:id/diff",
+			HandlerFunc: iHandler.Diff,
+		},
+		{
+			FuncName:    "Restore",
+			Method:      http.MethodPost,
+			Path:        "/revisions/:resource/:id/restore",
+			HandlerFunc: iHandler.Restore,
+		},
+	}
+}
+
+func Test_revisionsHandler_List(t *testing.T) {
+	h := newRevisionsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Revisions)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "version", "snapshot"}).
+		AddRow(testData.ID, testData.ResourceType, testData.ResourceID, testData.Version, testData.Snapshot)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("List", testData.ResourceType, testData.ResourceID), gohttp.KV{"page": 0, "size": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// unsupported resource error test
+	err = gohttp.Get(result, h.GetRequestURL("List", "skills", testData.ResourceID))
+	assert.NoError(t, err)
+	assert.NotZero(t, result.Code)
+
+	// get error test
+	err = gohttp.Get(result, h.GetRequestURL("List", testData.ResourceType, 111))
+	assert.Error(t, err)
+}
+
+func Test_revisionsHandler_Diff(t *testing.T) {
+	h := newRevisionsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Revisions)
+
+	rows := sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "version", "snapshot"}).
+		AddRow(testData.ID, testData.ResourceType, testData.ResourceID, testData.Version, testData.Snapshot)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company", "title"}).AddRow(testData.ResourceID, "bar", "engineer"))
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("Diff", testData.ResourceType, testData.ResourceID), gohttp.KV{"from": 1, "to": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// invalid version error test
+	err = gohttp.Get(result, h.GetRequestURL("Diff", testData.ResourceType, testData.ResourceID), gohttp.KV{"from": 0})
+	assert.NoError(t, err)
+	assert.NotZero(t, result.Code)
+
+	// not found error test
+	err = gohttp.Get(result, h.GetRequestURL("Diff", testData.ResourceType, testData.ResourceID), gohttp.KV{"from": 5})
+	assert.NoError(t, err)
+	assert.NotZero(t, result.Code)
+}
+
+func Test_revisionsHandler_Restore(t *testing.T) {
+	h := newRevisionsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Revisions)
+
+	rows := sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "version", "snapshot"}).
+		AddRow(testData.ID, testData.ResourceType, testData.ResourceID, testData.Version, testData.Snapshot)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	// the record is patched at its current version
+	rows = sqlmock.NewRows([]string{"id", "user_id", "company", "version"}).AddRow(testData.ResourceID, 1, "bar", 3)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ResourceID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("Restore", testData.ResourceType, testData.ResourceID),
+		&types.RestoreRevisionRequest{Version: testData.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// invalid params error test
+	err = gohttp.Post(result, h.GetRequestURL("Restore", testData.ResourceType, testData.ResourceID), nil)
+	assert.NoError(t, err)
+
+	// get error test
+	err = gohttp.Post(result, h.GetRequestURL("Restore", testData.ResourceType, 111),
+		&types.RestoreRevisionRequest{Version: testData.Version})
+	assert.Error(t, err)
+}
+
+func Test_diffSnapshots(t *testing.T) {
+	diffs := diffSnapshots(
+		map[string]interface{}{"company": "foo", "title": "engineer", "location": "beijing"},
+		map[string]interface{}{"company": "bar", "title": "engineer", "endDate": "2023-01-01"},
+	)
+	assert.Equal(t, []*types.RevisionFieldDiff{
+		{Field: "company", From: "foo", To: "bar"},
+		{Field: "endDate", From: nil, To: "2023-01-01"},
+		{Field: "location", From: "beijing", To: nil},
+	}, diffs)
+}
+
+func TestNewRevisionsHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewRevisionsHandler()
+}
+
+type revisionsBackend struct {
+	*gotest.Handler
+	users           dao.UsersDao
+	projects        dao.ProjectsDao
+	workexperiences dao.WorkexperiencesDao
+}
+
+// the handler of the revisions of the profiles in sqlite or in mongodb, the revisions are always in sqlite.
+// the mongodb is the one of the dsn in the environment variable WEAVING_NET_TEST_MONGODB_DSN, see the
+// behavioral tests of dao, it is skipped if the variable is not set, unless the backends are required.
+func newRevisionsBackend(t *testing.T, name string) *revisionsBackend {
+	dsn := fmt.Sprintf("file:revisions%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Use(model.NewRevisionPlugin())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&model.Revisions{}, &model.Users{}, &model.Projects{}, &model.UserIntroductions{}, &model.Workexperiences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+
+	b := &revisionsBackend{}
+	var userIntroductionsDao dao.UserIntroductionsDao
+	if name == "mongodb" {
+		mongoDsn := os.Getenv("WEAVING_NET_TEST_MONGODB_DSN")
+		if mongoDsn == "" {
+			if os.Getenv("WEAVING_NET_TEST_REQUIRE_BACKENDS") != "" {
+				t.Fatal("WEAVING_NET_TEST_MONGODB_DSN is not set, but WEAVING_NET_TEST_REQUIRE_BACKENDS is")
+			}
+			t.Skip("WEAVING_NET_TEST_MONGODB_DSN is not set")
+		}
+		mdb, err := mgo.Init(mongoDsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mongoDB := mdb.Client().Database(fmt.Sprintf("%s_test_%d", mdb.Name(), time.Now().UnixNano()))
+		dao.SetRevisionDB(db)
+		t.Cleanup(func() {
+			dao.SetRevisionDB(nil)
+			_ = mongoDB.Drop(context.Background())
+			_ = mgo.Close(mdb)
+		})
+		b.users = dao.NewMongoUsersDao(mongoDB, nil)
+		b.projects = dao.NewMongoProjectsDao(mongoDB, nil)
+		b.workexperiences = dao.NewMongoWorkexperiencesDao(mongoDB, nil)
+		userIntroductionsDao = dao.NewMongoUserIntroductionsDao(mongoDB, nil)
+	} else {
+		b.users = dao.NewUsersDao(db, nil)
+		b.projects = dao.NewProjectsDao(db, nil)
+		b.workexperiences = dao.NewWorkexperiencesDao(db, nil)
+		userIntroductionsDao = dao.NewUserIntroductionsDao(db, nil)
+	}
+
+	b.Handler = gotest.NewHandler(nil, nil)
+	iHandler := newRevisionsHandlerByDao(dao.NewRevisionsDao(db), b.workexperiences, b.projects, userIntroductionsDao, b.users)
+	b.GoRunHTTPServer(revisionsRouters(iHandler))
+	time.Sleep(time.Millisecond * 200)
+	t.Cleanup(b.Close)
+	return b
+}
+
+func Test_revisionsHandler_backends(t *testing.T) {
+	for _, name := range []string{"sqlite", "mongodb"} {
+		t.Run(name, func(t *testing.T) {
+			b := newRevisionsBackend(t, name)
+			ctx := context.Background()
+			user := &model.Users{FirstName: "Ada", LastName: "Lovelace", About: "mathematician"}
+			err := b.users.Create(ctx, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			project := &model.Projects{UserID: int(user.ID), ProjectName: "loom", Role: "engineer"}
+			err = b.projects.Create(ctx, project)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// every update of a project keeps a revision, only the updates of the about of a user keep one
+			err = b.projects.PatchByID(ctx, project.ID, 0, map[string]interface{}{"project_name": "weaving"})
+			assert.NoError(t, err)
+			err = b.users.PatchByID(ctx, user.ID, 0, map[string]interface{}{"last_name": "Byron"})
+			assert.NoError(t, err)
+			err = b.users.PatchByID(ctx, user.ID, 0, map[string]interface{}{"about": "poet"})
+			assert.NoError(t, err)
+
+			list := &types.ListRevisionsRespond{}
+			err = gohttp.Get(list, b.GetRequestURL("List", "projects", project.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, int64(1), list.Data.Total)
+			if assert.Len(t, list.Data.Revisions, 1) {
+				assert.Equal(t, 1, list.Data.Revisions[0].Version)
+				assert.Equal(t, "loom", list.Data.Revisions[0].Snapshot["projectName"])
+			}
+			list = &types.ListRevisionsRespond{}
+			err = gohttp.Get(list, b.GetRequestURL("List", "users", user.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, int64(1), list.Data.Total)
+			if assert.Len(t, list.Data.Revisions, 1) {
+				assert.Equal(t, map[string]interface{}{"about": "mathematician"}, list.Data.Revisions[0].Snapshot)
+			}
+
+			diff := &types.DiffRevisionsRespond{}
+			err = gohttp.Get(diff, b.GetRequestURL("Diff", "projects", project.ID), gohttp.KV{"from": 1, "to": 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, []types.RevisionFieldDiff{{Field: "projectName", From: "loom", To: "weaving"}}, diff.Data.Diffs)
+
+			// the restore is an update too, the replaced data is kept as the next revision
+			result := &gohttp.StdResult{}
+			err = gohttp.Post(result, b.GetRequestURL("Restore", "projects", project.ID), &types.RestoreRevisionRequest{Version: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Zero(t, result.Code)
+			record, err := b.projects.GetByID(ctx, project.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "loom", record.ProjectName)
+			list = &types.ListRevisionsRespond{}
+			err = gohttp.Get(list, b.GetRequestURL("List", "projects", project.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, int64(2), list.Data.Total)
+			if assert.Len(t, list.Data.Revisions, 2) {
+				assert.Equal(t, 2, list.Data.Revisions[0].Version)
+				assert.Equal(t, "weaving", list.Data.Revisions[0].Snapshot["projectName"])
+			}
+
+			// the restored record is checked as an update, a user has at most one primary workexperience
+			first := &model.Workexperiences{UserID: int(user.ID), Company: "a", IsPrimary: true}
+			err = b.workexperiences.Create(ctx, first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second := &model.Workexperiences{UserID: int(user.ID), Company: "b"}
+			err = b.workexperiences.Create(ctx, second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = b.workexperiences.PatchByID(ctx, first.ID, 0, map[string]interface{}{"is_primary": false})
+			assert.NoError(t, err)
+			err = b.workexperiences.PatchByID(ctx, second.ID, 0, map[string]interface{}{"is_primary": true})
+			assert.NoError(t, err)
+			result = &gohttp.StdResult{}
+			err = gohttp.Post(result, b.GetRequestURL("Restore", "workexperiences", first.ID), &types.RestoreRevisionRequest{Version: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, ecode.ErrPrimaryExistsWorkexperiences.Code(), result.Code)
+			experience, err := b.workexperiences.GetByID(ctx, first.ID)
+			assert.NoError(t, err)
+			assert.False(t, experience.IsPrimary)
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/response"
+	"weaving_net/internal/types"
+)
+
+var _ SkillAssessmentsHandler = (*skillAssessmentsHandler)(nil)
+
+// SkillAssessmentsHandler defining the handler interface
+type SkillAssessmentsHandler interface {
+	Create(c *gin.Context)
+	GetByID(c *gin.Context)
+	ListByCatalogID(c *gin.Context)
+	Submit(c *gin.Context)
+}
+
+type skillAssessmentsHandler struct {
+	iDao       dao.SkillAssessmentsDao
+	skillsDao  dao.SkillsDao
+	catalogDao dao.SkillCatalogsDao
+}
+
+// NewSkillAssessmentsHandler creating the handler interface
+func NewSkillAssessmentsHandler() SkillAssessmentsHandler {
+	skillsDao := dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType()))
+	return &skillAssessmentsHandler{
+		iDao:       dao.NewSkillAssessmentsDao(model.GetDB(), skillsDao),
+		skillsDao:  skillsDao,
+		catalogDao: dao.NewSkillCatalogsDao(model.GetDB()),
+	}
+}
+
+// Create an assessment
+// @Summary create skill assessment
+// @Description create an assessment of a canonical skill with its questions, the answers are never responded to the client
+// @Tags skillAssessments
+// @accept json
+// @Produce json
+// @Param data body types.CreateSkillAssessmentsRequest true "skill assessment information"
+// @Success 200 {object} types.CreateSkillAssessmentsRespond{}
+// @Router /api/v1/skills/assessments [post]
+// @Security BearerAuth
+func (h *skillAssessmentsHandler) Create(c *gin.Context) {
+	form := &types.CreateSkillAssessmentsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	questions := make([]*model.SkillAssessmentQuestions, 0, len(form.Questions))
+	for i, q := range form.Questions {
+		if q.Answer >= len(q.Options) {
+			logger.Warn("answer out of options", logger.Int("index", i), middleware.GCtxRequestIDField(c))
+			response.InvalidParams(c, errors.New("the answer of question "+strconv.Itoa(i)+" is out of its options"))
+			return
+		}
+		options, err := json.Marshal(q.Options)
+		if err != nil {
+			response.Error(c, ecode.ErrCreateSkillAssessments)
+			return
+		}
+		questions = append(questions, &model.SkillAssessmentQuestions{Content: q.Content, Options: string(options), Answer: q.Answer})
+	}
+
+	ctx := middleware.WrapCtx(c)
+	_, err = h.catalogDao.GetByID(ctx, form.CatalogID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("GetByID catalog not found", logger.Err(err), logger.Any("catalogId", form.CatalogID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrCatalogNotFoundSkillAssessments)
+		} else {
+			logger.Error("GetByID catalog error", logger.Err(err), logger.Any("catalogId", form.CatalogID), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	skillAssessments := &model.SkillAssessments{}
+	err = copier.Copy(skillAssessments, form)
+	if err != nil {
+		response.Error(c, ecode.ErrCreateSkillAssessments)
+		return
+	}
+
+	err = h.iDao.Create(ctx, skillAssessments, questions)
+	if err != nil {
+		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{"id": skillAssessments.ID})
+}
+
+// GetByID get an assessment with its questions
+// @Summary get skill assessment detail
+// @Description get an assessment with its questions in order, the answers are not included
+// @Tags skillAssessments
+// @Param id path string true "id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.GetSkillAssessmentsByIDRespond{}
+// @Router /api/v1/skills/assessments/{id} [get]
+// @Security BearerAuth
+func (h *skillAssessmentsHandler) GetByID(c *gin.Context) {
+	_, id, isAbort := getSkillAssessmentsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	skillAssessments, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+	questions, err := h.iDao.GetQuestions(ctx, id)
+	if err != nil {
+		logger.Error("GetQuestions error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertSkillAssessments(skillAssessments)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDSkillAssessments)
+		return
+	}
+	questionsData := make([]*types.SkillAssessmentQuestionsObjDetail, 0, len(questions))
+	for _, question := range questions {
+		detail := &types.SkillAssessmentQuestionsObjDetail{ID: utils.Uint64ToStr(question.ID), Content: question.Content}
+		err = json.Unmarshal([]byte(question.Options), &detail.Options)
+		if err != nil {
+			logger.Error("Unmarshal options error", logger.Err(err), logger.Uint64("questionId", question.ID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrGetByIDSkillAssessments)
+			return
+		}
+		questionsData = append(questionsData, detail)
+	}
+
+	response.Success(c, gin.H{
+		"skillAssessments": data,
+		"questions":        questionsData,
+	})
+}
+
+// ListByCatalogID list of the assessments of a canonical skill
+// @Summary list of skill assessments by catalog id
+// @Description list of the assessments of a canonical skill sorted by id
+// @Tags skillAssessments
+// @Param catalogId path string true "canonical skill id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.ListSkillAssessmentsByCatalogIDRespond{}
+// @Router /api/v1/skills/assessments/catalog/{catalogId} [get]
+// @Security BearerAuth
+func (h *skillAssessmentsHandler) ListByCatalogID(c *gin.Context) {
+	catalogID, err := utils.StrToUint64E(c.Param("catalogId"))
+	if err != nil || catalogID == 0 {
+		logger.Warn("invalid catalogId", logger.String("catalogId", c.Param("catalogId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.GetByCatalogID(ctx, catalogID)
+	if err != nil {
+		logger.Error("GetByCatalogID error", logger.Err(err), logger.Uint64("catalogId", catalogID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data := make([]*types.SkillAssessmentsObjDetail, 0, len(records))
+	for _, record := range records {
+		detail, err := convertSkillAssessments(record)
+		if err != nil {
+			response.Error(c, ecode.ErrListByCatalogIDSkillAssessments)
+			return
+		}
+		data = append(data, detail)
+	}
+
+	response.Success(c, gin.H{
+		"skillAssessmentss": data,
+	})
+}
+
+// Submit the answers of an assessment to verify a skill
+// @Summary submit skill assessment
+// @Description submit the answers of an assessment, the answers are scored by the server and the skill is verified if the score
+// @Description reaches the pass score. the assessment can not be retaken until the cooldown after the last attempt is over.
+// @Tags skillAssessments
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param data body types.SubmitSkillAssessmentsRequest true "answers"
+// @Success 200 {object} types.SubmitSkillAssessmentsRespond{}
+// @Router /api/v1/skills/assessments/{id}/attempts [post]
+// @Security BearerAuth
+func (h *skillAssessmentsHandler) Submit(c *gin.Context) {
+	_, id, isAbort := getSkillAssessmentsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.SubmitSkillAssessmentsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	skillAssessments, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+	skill, err := h.skillsDao.GetByID(ctx, form.SkillID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("GetByID skill not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrSkillMismatchSkillAssessments)
+		} else {
+			logger.Error("GetByID skill error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+	if skill.CatalogID != skillAssessments.CatalogID {
+		logger.Warn("skill mismatch", logger.Uint64("catalogId", skill.CatalogID), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrSkillMismatchSkillAssessments)
+		return
+	}
+
+	questions, err := h.iDao.GetQuestions(ctx, id)
+	if err != nil {
+		logger.Error("GetQuestions error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	score := scoreAssessment(questions, form.Answers)
+	attempt := &model.SkillAssessmentAttempts{
+		UserID:  skill.UserID,
+		SkillID: skill.ID,
+		Score:   score,
+		Level:   proficiencyOfScore(score),
+		Passed:  score >= skillAssessments.PassScore,
+	}
+	retryAt, err := h.iDao.CreateAttempt(ctx, skillAssessments, attempt)
+	if err != nil {
+		if errors.Is(err, model.ErrAssessmentCooldown) {
+			logger.Warn("CreateAttempt in cooldown", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(retryAt).Seconds())+1))
+			response.Error(c, ecode.ErrCooldownSkillAssessments, gin.H{"retryAt": retryAt})
+		} else if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("CreateAttempt skill mismatch", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrSkillMismatchSkillAssessments)
+		} else {
+			logger.Error("CreateAttempt error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"score":     attempt.Score,
+		"level":     attempt.Level,
+		"passed":    attempt.Passed,
+		"passScore": skillAssessments.PassScore,
+	})
+}
+
+// the percentage of the questions answered correctly, the unanswered questions and the answers of
+// the questions not in the assessment are wrong.
+func scoreAssessment(questions []*model.SkillAssessmentQuestions, answers []types.SkillAssessmentAnswer) int {
+	if len(questions) == 0 {
+		return 0
+	}
+
+	chosen := make(map[uint64]int, len(answers))
+	for _, answer := range answers {
+		chosen[answer.QuestionID] = answer.Option
+	}
+	correct := 0
+	for _, question := range questions {
+		if option, ok := chosen[question.ID]; ok && option == question.Answer {
+			correct++
+		}
+	}
+
+	return correct * 100 / len(questions)
+}
+
+// the proficiency level verified by a score
+func proficiencyOfScore(score int) int {
+	switch {
+	case score >= 90:
+		return model.ProficiencyExpert
+	case score >= 75:
+		return model.ProficiencyAdvanced
+	case score >= 60:
+		return model.ProficiencyIntermediate
+	case score >= 40:
+		return model.ProficiencyElementary
+	default:
+		return model.ProficiencyBeginner
+	}
+}
+
+func getSkillAssessmentsIDFromPath(c *gin.Context) (string, uint64, bool) {
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return "", 0, true
+	}
+
+	return idStr, id, false
+}
+
+func convertSkillAssessments(skillAssessments *model.SkillAssessments) (*types.SkillAssessmentsObjDetail, error) {
+	data := &types.SkillAssessmentsObjDetail{}
+	err := copier.Copy(data, skillAssessments)
+	if err != nil {
+		return nil, err
+	}
+	data.ID = utils.Uint64ToStr(skillAssessments.ID)
+	return data, nil
+}
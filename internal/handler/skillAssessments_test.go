@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func newSkillAssessmentsHandler() *gotest.Handler {
+	testData := &model.SkillAssessments{}
+	testData.ID = 1
+	testData.CatalogID = 2
+	testData.Title = "Go basics"
+	testData.PassScore = 60
+	testData.CooldownHours = 24
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{})
+	c.ICache = cache.NewSkillsCache(&model.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	skillsDao := dao.NewSkillsDao(d.DB, c.ICache.(cache.SkillsCache))
+	d.IDao = dao.NewSkillAssessmentsDao(d.DB, skillsDao)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &skillAssessmentsHandler{
+		iDao:       d.IDao.(dao.SkillAssessmentsDao),
+		skillsDao:  skillsDao,
+		catalogDao: dao.NewSkillCatalogsDao(d.DB),
+	}
+	iHandler := h.IHandler.(SkillAssessmentsHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Create",
+			Method:      http.MethodPost,
+			Path:        "/skills/assessments",
+			HandlerFunc: iHandler.Create,
+		},
+		{
+			FuncName:    "ListByCatalogID",
+			Method:      http.MethodGet,
+			Path:        "/skills/assessments/catalog/:catalogId",
+			HandlerFunc: iHandler.ListByCatalogID,
+		},
+		{
+			FuncName:    "GetByID",
+			Method:      http.MethodGet,
+			Path:        "/skills/assessments/:id",
+			HandlerFunc: iHandler.GetByID,
+		},
+		{
+			FuncName:    "Submit",
+			Method:      http.MethodPost,
+			Path:        "/skills/assessments/:id/attempts",
+			HandlerFunc: iHandler.Submit,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_skillAssessmentsHandler_Create(t *testing.T) {
+	h := newSkillAssessmentsHandler()
+	defer h.Close()
+	testData := &types.CreateSkillAssessmentsRequest{
+		CatalogID: 2,
+		Title:     "Go basics",
+		PassScore: 60,
+		Questions: []types.CreateSkillAssessmentQuestionsRequest{
+			{Content: "Which keyword starts a goroutine?", Options: []string{"go", "async"}, Answer: 0},
+		},
+	}
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(testData.CatalogID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(testData.CatalogID, "Go"))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_assessments`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_assessment_questions`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// catalog not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(testData.CatalogID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	err = gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrCatalogNotFoundSkillAssessments.Code(), result.Code)
+
+	// answer out of options error test
+	testData.Questions[0].Answer = 2
+	err = gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// no questions error test
+	testData.Questions = nil
+	err = gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+}
+
+func Test_skillAssessmentsHandler_GetByID(t *testing.T) {
+	h := newSkillAssessmentsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SkillAssessments)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessments`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id"}).AddRow(testData.ID, testData.CatalogID))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_questions`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "content", "options", "answer"}).AddRow(1, "q1", `["a","b"]`, 1))
+
+	result := &types.GetSkillAssessmentsByIDRespond{}
+	err := gohttp.Get(result, h.GetRequestURL("GetByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	assert.Equal(t, []string{"a", "b"}, result.Data.Questions[0].Options)
+
+	// not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessments`").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	stdResult := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodGet, stdResult, h.GetRequestURL("GetByID", 2), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	assert.Equal(t, ecode.NotFound.Code(), stdResult.Code)
+
+	// get error test
+	err = gohttp.Get(stdResult, h.GetRequestURL("GetByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_skillAssessmentsHandler_ListByCatalogID(t *testing.T) {
+	h := newSkillAssessmentsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SkillAssessments)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessments`").
+		WithArgs(testData.CatalogID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id"}).AddRow(testData.ID, testData.CatalogID))
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("ListByCatalogID", testData.CatalogID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero catalog id error test
+	err = gohttp.Get(result, h.GetRequestURL("ListByCatalogID", 0))
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// list error test
+	err = gohttp.Get(result, h.GetRequestURL("ListByCatalogID", testData.CatalogID))
+	assert.Error(t, err)
+}
+
+func Test_skillAssessmentsHandler_Submit(t *testing.T) {
+	h := newSkillAssessmentsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SkillAssessments)
+	form := &types.SubmitSkillAssessmentsRequest{
+		SkillID: 3,
+		Answers: []types.SkillAssessmentAnswer{{QuestionID: 1, Option: 1}, {QuestionID: 2, Option: 0}},
+	}
+	expectGet := func(catalogID uint64) {
+		h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessments`").
+			WithArgs(testData.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id", "pass_score", "cooldown_hours"}).
+				AddRow(testData.ID, testData.CatalogID, testData.PassScore, testData.CooldownHours))
+		h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skills`").
+			WithArgs(form.SkillID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "catalog_id"}).AddRow(form.SkillID, 1, catalogID))
+	}
+
+	expectGet(testData.CatalogID)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_questions`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "answer"}).AddRow(1, 1).AddRow(2, 1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT `id` FROM `skill_assessments` .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skills`").
+		WithArgs(form.SkillID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "catalog_id"}).AddRow(form.SkillID, 1, testData.CatalogID))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_attempts`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_assessment_attempts`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &types.SubmitSkillAssessmentsRespond{}
+	err := gohttp.Post(result, h.GetRequestURL("Submit", testData.ID), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	// one of two answers is right, the skill is not verified
+	assert.Equal(t, 50, result.Data.Score)
+	assert.Equal(t, model.ProficiencyElementary, result.Data.Level)
+	assert.False(t, result.Data.Passed)
+
+	// cooldown error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessments`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id", "pass_score", "cooldown_hours"}).
+			AddRow(testData.ID, testData.CatalogID, testData.PassScore, testData.CooldownHours))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_questions`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "answer"}).AddRow(1, 1).AddRow(2, 1))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT `id` FROM `skill_assessments` .* FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skills`").
+		WithArgs(form.SkillID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "catalog_id"}).AddRow(form.SkillID, 1, testData.CatalogID))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessment_attempts`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	h.MockDao.SQLMock.ExpectRollback()
+	stdResult := &gohttp.StdResult{}
+	err = gohttp.Post(stdResult, h.GetRequestURL("Submit", testData.ID), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrCooldownSkillAssessments.Code(), stdResult.Code)
+
+	// skill mismatch error test, the skill is cached by the first submit
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_assessments`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id"}).AddRow(testData.ID, 9))
+	err = gohttp.Post(stdResult, h.GetRequestURL("Submit", testData.ID), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrSkillMismatchSkillAssessments.Code(), stdResult.Code)
+
+	// empty answers error test
+	err = gohttp.Post(stdResult, h.GetRequestURL("Submit", testData.ID), &types.SubmitSkillAssessmentsRequest{SkillID: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), stdResult.Code)
+}
+
+func Test_scoreAssessment(t *testing.T) {
+	questions := []*model.SkillAssessmentQuestions{{Answer: 0}, {Answer: 1}, {Answer: 2}, {Answer: 3}}
+	for i, question := range questions {
+		question.ID = uint64(i + 1)
+	}
+
+	score := scoreAssessment(questions, []types.SkillAssessmentAnswer{
+		{QuestionID: 1, Option: 0},
+		{QuestionID: 2, Option: 1},
+		{QuestionID: 3, Option: 0},
+		{QuestionID: 9, Option: 3}, // not in the assessment
+	})
+	assert.Equal(t, 50, score)
+	assert.Equal(t, 0, scoreAssessment(nil, nil))
+
+	assert.Equal(t, model.ProficiencyExpert, proficiencyOfScore(100))
+	assert.Equal(t, model.ProficiencyAdvanced, proficiencyOfScore(75))
+	assert.Equal(t, model.ProficiencyIntermediate, proficiencyOfScore(60))
+	assert.Equal(t, model.ProficiencyBeginner, proficiencyOfScore(0))
+}
+
+func TestNewSkillAssessmentsHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewSkillAssessmentsHandler()
+}
@@ -0,0 +1,390 @@
+package handler
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/response"
+	"weaving_net/internal/types"
+)
+
+// the default and max number of suggestions
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+)
+
+var _ SkillCatalogsHandler = (*skillCatalogsHandler)(nil)
+
+// SkillCatalogsHandler defining the handler interface
+type SkillCatalogsHandler interface {
+	Create(c *gin.Context)
+	GetByID(c *gin.Context)
+	AddSynonyms(c *gin.Context)
+	Suggest(c *gin.Context)
+	CreateCategory(c *gin.Context)
+	ListCategories(c *gin.Context)
+}
+
+type skillCatalogsHandler struct {
+	iDao dao.SkillCatalogsDao
+}
+
+// NewSkillCatalogsHandler creating the handler interface
+func NewSkillCatalogsHandler() SkillCatalogsHandler {
+	return &skillCatalogsHandler{
+		iDao: dao.NewSkillCatalogsDao(model.GetDB()),
+	}
+}
+
+// Create a canonical skill
+// @Summary create skill catalog
+// @Description create a canonical skill of the catalog with its category, parent and synonyms, the names are unique after normalization
+// @Tags skillCatalogs
+// @accept json
+// @Produce json
+// @Param data body types.CreateSkillCatalogsRequest true "skill catalog information"
+// @Success 200 {object} types.CreateSkillCatalogsRespond{}
+// @Router /api/v1/skills/catalog [post]
+// @Security BearerAuth
+func (h *skillCatalogsHandler) Create(c *gin.Context) {
+	form := &types.CreateSkillCatalogsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	if dao.NormalizeSkillName(form.Name) == "" {
+		logger.Warn("empty name", logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	if form.CategoryID > 0 {
+		_, err = h.iDao.GetCategoryByID(ctx, form.CategoryID)
+		if err != nil {
+			h.outputReferenceError(c, err, "GetCategoryByID", ecode.ErrCategoryNotFoundSkillCatalogs)
+			return
+		}
+	}
+	if form.ParentID > 0 {
+		_, err = h.iDao.GetByID(ctx, form.ParentID)
+		if err != nil {
+			h.outputReferenceError(c, err, "GetByID parent", ecode.ErrParentNotFoundSkillCatalogs)
+			return
+		}
+	}
+
+	skillCatalogs := &model.SkillCatalogs{}
+	err = copier.Copy(skillCatalogs, form)
+	if err != nil {
+		response.Error(c, ecode.ErrCreateSkillCatalogs)
+		return
+	}
+
+	err = h.iDao.Create(ctx, skillCatalogs, form.Synonyms)
+	if err != nil {
+		if errors.Is(err, model.ErrNameExists) {
+			logger.Warn("Create name exists", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrNameExistsSkillCatalogs)
+		} else {
+			logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c, gin.H{"id": skillCatalogs.ID})
+}
+
+// GetByID get a canonical skill detail
+// @Summary get skill catalog detail
+// @Description get a canonical skill of the catalog with its synonyms and child skills
+// @Tags skillCatalogs
+// @Param id path string true "id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.GetSkillCatalogsByIDRespond{}
+// @Router /api/v1/skills/catalog/{id} [get]
+// @Security BearerAuth
+func (h *skillCatalogsHandler) GetByID(c *gin.Context) {
+	_, id, isAbort := getSkillCatalogsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	skillCatalogs, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	synonyms, err := h.iDao.GetSynonyms(ctx, id)
+	if err != nil {
+		logger.Error("GetSynonyms error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	children, err := h.iDao.GetChildren(ctx, id)
+	if err != nil {
+		logger.Error("GetChildren error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertSkillCatalogs(skillCatalogs)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDSkillCatalogs)
+		return
+	}
+	childrenData, err := convertSkillCatalogss(children)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDSkillCatalogs)
+		return
+	}
+	names := make([]string, 0, len(synonyms))
+	for _, synonym := range synonyms {
+		names = append(names, synonym.Name)
+	}
+
+	response.Success(c, gin.H{
+		"skillCatalogs": data,
+		"synonyms":      names,
+		"children":      childrenData,
+	})
+}
+
+// AddSynonyms add synonyms to a canonical skill
+// @Summary add synonyms of skill catalog
+// @Description add synonyms to a canonical skill of the catalog, the names are unique after normalization
+// @Tags skillCatalogs
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param data body types.AddSkillCatalogsSynonymsRequest true "synonyms"
+// @Success 200 {object} types.AddSkillCatalogsSynonymsRespond{}
+// @Router /api/v1/skills/catalog/{id}/synonyms [post]
+// @Security BearerAuth
+func (h *skillCatalogsHandler) AddSynonyms(c *gin.Context) {
+	_, id, isAbort := getSkillCatalogsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.AddSkillCatalogsSynonymsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	_, err = h.iDao.GetByID(ctx, id)
+	if err != nil {
+		h.outputReferenceError(c, err, "GetByID", ecode.NotFound)
+		return
+	}
+
+	err = h.iDao.AddSynonyms(ctx, id, form.Names)
+	if err != nil {
+		if errors.Is(err, model.ErrNameExists) {
+			logger.Warn("AddSynonyms name exists", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrNameExistsSkillCatalogs)
+		} else {
+			logger.Error("AddSynonyms error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// Suggest autocomplete skill names
+// @Summary suggest skill catalog
+// @Description autocomplete the canonical skills whose name or synonym starts with q, e.g. q=gol suggests Go by its synonym golang
+// @Tags skillCatalogs
+// @Param q query string true "prefix of the skill name"
+// @Param limit query int false "max number of suggestions, default is 10, max is 50"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.SuggestSkillCatalogsRespond{}
+// @Router /api/v1/skills/catalog/suggest [get]
+// @Security BearerAuth
+func (h *skillCatalogsHandler) Suggest(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" || utf8.RuneCountInString(q) > 50 {
+		logger.Warn("invalid q", logger.String("q", q), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	limit := utils.StrToInt(c.Query("limit"))
+	if limit < 1 {
+		limit = defaultSuggestLimit
+	} else if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.Suggest(ctx, q, limit)
+	if err != nil {
+		logger.Error("Suggest error", logger.Err(err), logger.String("q", q), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertSkillCatalogss(records)
+	if err != nil {
+		response.Error(c, ecode.ErrSuggestSkillCatalogs)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"skillCatalogss": data,
+	})
+}
+
+// CreateCategory create a skill category
+// @Summary create skill category
+// @Description create a skill category, the name is unique
+// @Tags skillCatalogs
+// @accept json
+// @Produce json
+// @Param data body types.CreateSkillCategoriesRequest true "skill category information"
+// @Success 200 {object} types.CreateSkillCategoriesRespond{}
+// @Router /api/v1/skills/catalog/categories [post]
+// @Security BearerAuth
+func (h *skillCatalogsHandler) CreateCategory(c *gin.Context) {
+	form := &types.CreateSkillCategoriesRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	skillCategories := &model.SkillCategories{}
+	err = copier.Copy(skillCategories, form)
+	if err != nil {
+		response.Error(c, ecode.ErrCreateSkillCategories)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.CreateCategory(ctx, skillCategories)
+	if err != nil {
+		if errors.Is(err, model.ErrNameExists) {
+			logger.Warn("CreateCategory name exists", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrCategoryExistsSkillCatalogs)
+		} else {
+			logger.Error("CreateCategory error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c, gin.H{"id": skillCategories.ID})
+}
+
+// ListCategories list of all skill categories
+// @Summary list of skill categories
+// @Description list of all skill categories sorted by name
+// @Tags skillCatalogs
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.ListSkillCategoriesRespond{}
+// @Router /api/v1/skills/catalog/categories [get]
+// @Security BearerAuth
+func (h *skillCatalogsHandler) ListCategories(c *gin.Context) {
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.ListCategories(ctx)
+	if err != nil {
+		logger.Error("ListCategories error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data := make([]*types.SkillCategoriesObjDetail, 0, len(records))
+	for _, record := range records {
+		detail := &types.SkillCategoriesObjDetail{}
+		err = copier.Copy(detail, record)
+		if err != nil {
+			response.Error(c, ecode.ErrListSkillCategories)
+			return
+		}
+		detail.ID = utils.Uint64ToStr(record.ID)
+		data = append(data, detail)
+	}
+
+	response.Success(c, gin.H{
+		"skillCategoriess": data,
+	})
+}
+
+// respond the error of getting a referenced record, notFoundErr is responded if it does not exist
+func (h *skillCatalogsHandler) outputReferenceError(c *gin.Context, err error, name string, notFoundErr *errcode.Error) {
+	if errors.Is(err, model.ErrRecordNotFound) {
+		logger.Warn(name+" not found", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, notFoundErr)
+		return
+	}
+	logger.Error(name+" error", logger.Err(err), middleware.GCtxRequestIDField(c))
+	response.Out(c, daoError(err))
+}
+
+func getSkillCatalogsIDFromPath(c *gin.Context) (string, uint64, bool) {
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return "", 0, true
+	}
+
+	return idStr, id, false
+}
+
+func convertSkillCatalogs(skillCatalogs *model.SkillCatalogs) (*types.SkillCatalogsObjDetail, error) {
+	data := &types.SkillCatalogsObjDetail{}
+	err := copier.Copy(data, skillCatalogs)
+	if err != nil {
+		return nil, err
+	}
+	data.ID = utils.Uint64ToStr(skillCatalogs.ID)
+	return data, nil
+}
+
+func convertSkillCatalogss(fromValues []*model.SkillCatalogs) ([]*types.SkillCatalogsObjDetail, error) {
+	toValues := []*types.SkillCatalogsObjDetail{}
+	for _, v := range fromValues {
+		data, err := convertSkillCatalogs(v)
+		if err != nil {
+			return nil, err
+		}
+		toValues = append(toValues, data)
+	}
+
+	return toValues, nil
+}
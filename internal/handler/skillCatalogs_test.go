@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func newSkillCatalogsHandler() *gotest.Handler {
+	testData := &model.SkillCatalogs{}
+	testData.ID = 1
+	testData.Name = "Go"
+	testData.NormalizedName = "go"
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao
+	d := gotest.NewDao(nil, testData)
+	d.IDao = dao.NewSkillCatalogsDao(d.DB)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &skillCatalogsHandler{iDao: d.IDao.(dao.SkillCatalogsDao)}
+	iHandler := h.IHandler.(SkillCatalogsHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Create",
+			Method:      http.MethodPost,
+			Path:        "/skills/catalog",
+			HandlerFunc: iHandler.Create,
+		},
+		{
+			FuncName:    "Suggest",
+			Method:      http.MethodGet,
+			Path:        "/skills/catalog/suggest",
+			HandlerFunc: iHandler.Suggest,
+		},
+		{
+			FuncName:    "CreateCategory",
+			Method:      http.MethodPost,
+			Path:        "/skills/catalog/categories",
+			HandlerFunc: iHandler.CreateCategory,
+		},
+		{
+			FuncName:    "ListCategories",
+			Method:      http.MethodGet,
+			Path:        "/skills/catalog/categories",
+			HandlerFunc: iHandler.ListCategories,
+		},
+		{
+			FuncName:    "GetByID",
+			Method:      http.MethodGet,
+			Path:        "/skills/catalog/:id",
+			HandlerFunc: iHandler.GetByID,
+		},
+		{
+			FuncName:    "AddSynonyms",
+			Method:      http.MethodPost,
+			Path:        "/skills/catalog/:id/synonyms",
+			HandlerFunc: iHandler.AddSynonyms,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_skillCatalogsHandler_Create(t *testing.T) {
+	h := newSkillCatalogsHandler()
+	defer h.Close()
+	testData := &types.CreateSkillCatalogsRequest{Name: "Go", CategoryID: 2, Synonyms: []string{"golang"}}
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_categories`").
+		WithArgs(testData.CategoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(testData.CategoryID, "Programming Languages"))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.* FROM `skill_synonyms`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_catalogs`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_synonyms`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// category not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_categories`").
+		WithArgs(testData.CategoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	err = gohttp.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrCategoryNotFoundSkillCatalogs.Code(), result.Code)
+
+	// parent not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	err = gohttp.Post(result, h.GetRequestURL("Create"), &types.CreateSkillCatalogsRequest{Name: "Gin", ParentID: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrParentNotFoundSkillCatalogs.Code(), result.Code)
+
+	// name exists error test
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectRollback()
+	err = gohttp.Post(result, h.GetRequestURL("Create"), &types.CreateSkillCatalogsRequest{Name: "Go"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrNameExistsSkillCatalogs.Code(), result.Code)
+
+	// empty name error test
+	err = gohttp.Post(result, h.GetRequestURL("Create"), &types.CreateSkillCatalogsRequest{Name: " - "})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// create error test
+	err = gohttp.Post(result, h.GetRequestURL("Create"), &types.CreateSkillCatalogsRequest{Name: "Go"})
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsHandler_GetByID(t *testing.T) {
+	h := newSkillCatalogsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SkillCatalogs)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "normalized_name"}).
+			AddRow(testData.ID, testData.Name, testData.NormalizedName))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_synonyms`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "catalog_id", "name"}).AddRow(1, testData.ID, "golang"))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "Gin", testData.ID))
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("GetByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	statusCode, _, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("GetByID", 2), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	assert.Equal(t, ecode.NotFound.Code(), result.Code)
+
+	// zero id error test
+	err = gohttp.Get(result, h.GetRequestURL("GetByID", 0))
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// get error test
+	err = gohttp.Get(result, h.GetRequestURL("GetByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsHandler_AddSynonyms(t *testing.T) {
+	h := newSkillCatalogsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SkillCatalogs)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(testData.ID, testData.Name))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.* FROM `skill_synonyms`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_synonyms`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	form := &types.AddSkillCatalogsSynonymsRequest{Names: []string{"golang"}}
+	err := gohttp.Post(result, h.GetRequestURL("AddSynonyms", testData.ID), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// name exists error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(testData.ID, testData.Name))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.* FROM `skill_catalogs`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectRollback()
+	err = gohttp.Post(result, h.GetRequestURL("AddSynonyms", testData.ID), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrNameExistsSkillCatalogs.Code(), result.Code)
+
+	// not found error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	err = gohttp.Post(result, h.GetRequestURL("AddSynonyms", 2), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.NotFound.Code(), result.Code)
+
+	// empty names error test
+	err = gohttp.Post(result, h.GetRequestURL("AddSynonyms", testData.ID), &types.AddSkillCatalogsSynonymsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+}
+
+func Test_skillCatalogsHandler_Suggest(t *testing.T) {
+	h := newSkillCatalogsHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SkillCatalogs)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* LIMIT 50").
+		WithArgs("gol%", "gol%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(testData.ID, testData.Name))
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("Suggest"), gohttp.KV{"q": "gol", "limit": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// empty q error test
+	err = gohttp.Get(result, h.GetRequestURL("Suggest"))
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// suggest error test
+	err = gohttp.Get(result, h.GetRequestURL("Suggest"), gohttp.KV{"q": "gol"})
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsHandler_CreateCategory(t *testing.T) {
+	h := newSkillCatalogsHandler()
+	defer h.Close()
+	testData := &types.CreateSkillCategoriesRequest{Name: "Programming Languages"}
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(testData.Name).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO `skill_categories`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("CreateCategory"), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// name exists error test
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(testData.Name).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	err = gohttp.Post(result, h.GetRequestURL("CreateCategory"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrCategoryExistsSkillCatalogs.Code(), result.Code)
+
+	// create error test
+	err = gohttp.Post(result, h.GetRequestURL("CreateCategory"), testData)
+	assert.Error(t, err)
+}
+
+func Test_skillCatalogsHandler_ListCategories(t *testing.T) {
+	h := newSkillCatalogsHandler()
+	defer h.Close()
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .* FROM `skill_categories`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Programming Languages"))
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("ListCategories"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// list error test
+	err = gohttp.Get(result, h.GetRequestURL("ListCategories"))
+	assert.Error(t, err)
+}
+
+func TestNewSkillCatalogsHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewSkillCatalogsHandler()
+}
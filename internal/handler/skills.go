@@ -1,14 +1,16 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"math"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 
+	"github.com/zhufuyi/sponge/pkg/errcode"
 	"github.com/zhufuyi/sponge/pkg/gin/middleware"
-	"github.com/zhufuyi/sponge/pkg/gin/response"
 	"github.com/zhufuyi/sponge/pkg/logger"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
@@ -16,6 +18,7 @@ import (
 	"weaving_net/internal/dao"
 	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
+	"weaving_net/internal/response"
 	"weaving_net/internal/types"
 )
 
@@ -24,27 +27,53 @@ var _ SkillsHandler = (*skillsHandler)(nil)
 // SkillsHandler defining the handler interface
 type SkillsHandler interface {
 	Create(c *gin.Context)
+	CreateBatch(c *gin.Context)
 	DeleteByID(c *gin.Context)
 	DeleteByIDs(c *gin.Context)
 	UpdateByID(c *gin.Context)
+	UpdateBatch(c *gin.Context)
+	PatchByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	GetByCondition(c *gin.Context)
 	ListByIDs(c *gin.Context)
 	ListByLastID(c *gin.Context)
+	ListByCursor(c *gin.Context)
 	List(c *gin.Context)
+	ListByUserID(c *gin.Context)
+	Reorder(c *gin.Context)
 }
 
+// the columns of skills that the clients can filter and sort by
+var skillsFilter = newFilterSpec(&model.Skills{}, []filterColumn{
+	{name: "user_id", ops: equalOps, sortable: true},
+	{name: "skill_type", ops: textOps, sortable: true},
+	{name: "skill_name", ops: textOps, sortable: true},
+	{name: "proficiency", ops: orderedOps, sortable: true},
+	{name: "catalog_id", ops: equalOps},
+	{name: "category_id", ops: equalOps},
+	{name: "verified_level", ops: orderedOps, sortable: true},
+	{name: "position", ops: orderedOps, sortable: true},
+})
+
+// the fields of skills that the clients can select
+var skillsFields = newFieldSpec(&model.Skills{}, &types.SkillsObjDetail{}, map[string][]string{
+	"user": {"user_id"},
+})
+
 type skillsHandler struct {
-	iDao dao.SkillsDao
+	iDao       dao.SkillsDao
+	catalogDao dao.SkillCatalogsDao
+	expander   *expander
 }
 
 // NewSkillsHandler creating the handler interface
 func NewSkillsHandler() SkillsHandler {
 	return &skillsHandler{
-		iDao: dao.NewSkillsDao(
-			model.GetDB(),
+		iDao: dao.NewSkillsDaoByDriver(
 			cache.NewSkillsCache(model.GetCacheType()),
 		),
+		catalogDao: dao.NewSkillCatalogsDao(model.GetDB()),
+		expander:   newExpander(),
 	}
 }
 
@@ -63,7 +92,7 @@ func (h *skillsHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -75,16 +104,70 @@ func (h *skillsHandler) Create(c *gin.Context) {
 	}
 
 	ctx := middleware.WrapCtx(c)
+	if h.isInvalidSkills(ctx, c, skills) {
+		return
+	}
 	err = h.iDao.Create(ctx, skills)
 	if err != nil {
 		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
 	response.Success(c, gin.H{"id": skills.ID})
 }
 
+// CreateBatch create records in batch
+// @Summary create skills in batch
+// @Description submit information to create skills in one transaction, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags skills
+// @accept json
+// @Produce json
+// @Param data body types.CreateSkillssBatchRequest true "skills information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/skills/batch [post]
+// @Security BearerAuth
+func (h *skillsHandler) CreateBatch(c *gin.Context) {
+	form := &types.CreateSkillssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), true)
+	records := make([]*model.Skills, 0, len(form.Items))
+	for i := range form.Items {
+		record := &model.Skills{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrCreateSkills)
+			continue
+		}
+		if e := h.checkSkills(ctx, record); e != nil {
+			items.reject(i, e)
+			continue
+		}
+		items.add(i, 0)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.CreateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("CreateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, func(j int) uint64 { return records[j].ID })
+
+	items.output(c)
+}
+
 // DeleteByID delete a record by id
 // @Summary delete skills
 // @Description delete skills by id
@@ -92,6 +175,7 @@ func (h *skillsHandler) Create(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Success 200 {object} types.DeleteSkillsByIDRespond{}
 // @Router /api/v1/skills/{id} [delete]
 // @Security BearerAuth
@@ -101,12 +185,21 @@ func (h *skillsHandler) DeleteByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err := h.iDao.DeleteByID(ctx, id)
+	err := h.iDao.DeleteByIDAndVersion(ctx, id, version)
 	if err != nil {
-		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("DeleteByIDAndVersion modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("DeleteByIDAndVersion error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -128,7 +221,7 @@ func (h *skillsHandler) DeleteByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -136,7 +229,7 @@ func (h *skillsHandler) DeleteByIDs(c *gin.Context) {
 	err = h.iDao.DeleteByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -145,11 +238,12 @@ func (h *skillsHandler) DeleteByIDs(c *gin.Context) {
 
 // UpdateByID update information by id
 // @Summary update skills
-// @Description update skills information by id
+// @Description replace all skills information by id, fields that are not submitted are reset, the record is only replaced when the If-Match header matches its ETag
 // @Tags skills
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Param data body types.UpdateSkillsByIDRequest true "skills information"
 // @Success 200 {object} types.UpdateSkillsByIDRespond{}
 // @Router /api/v1/skills/{id} [put]
@@ -160,12 +254,16 @@ func (h *skillsHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	form := &types.UpdateSkillsByIDRequest{}
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -176,12 +274,139 @@ func (h *skillsHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.ErrUpdateByIDSkills)
 		return
 	}
+	skills.Version = version
+
+	ctx := middleware.WrapCtx(c)
+	if h.isInvalidSkills(ctx, c, skills) {
+		return
+	}
+	err = h.iDao.ReplaceByID(ctx, skills)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("ReplaceByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("ReplaceByID modified", logger.Err(err), logger.Any("form", form), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("ReplaceByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateBatch update records by id in batch
+// @Summary update skills in batch
+// @Description replace skills by id in one transaction as UpdateByID does, fields that are not submitted are reset, an item fails with the precondition failed code if its version does not match the record, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags skills
+// @accept json
+// @Produce json
+// @Param data body types.UpdateSkillssBatchRequest true "skills information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/skills/batch [put]
+// @Security BearerAuth
+func (h *skillsHandler) UpdateBatch(c *gin.Context) {
+	form := &types.UpdateSkillssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), false)
+	records := make([]*model.Skills, 0, len(form.Items))
+	for i := range form.Items {
+		if form.Items[i].ID == 0 {
+			items.reject(i, ecode.InvalidParams)
+			continue
+		}
+		record := &model.Skills{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrUpdateByIDSkills)
+			continue
+		}
+		if e := h.checkSkills(ctx, record); e != nil {
+			items.reject(i, e)
+			continue
+		}
+		items.add(i, record.ID)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.UpdateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("UpdateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, nil)
+
+	items.output(c)
+}
+
+// PatchByID partial update by id with a JSON merge patch
+// @Summary patch skills
+// @Description partial update skills by id with a JSON merge patch (RFC 7396), an explicit null clears the field and an omitted field is left alone
+// @Tags skills
+// @accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
+// @Param data body types.PatchSkillsByIDRequest true "skills fields to be changed"
+// @Success 200 {object} types.PatchSkillsByIDRespond{}
+// @Router /api/v1/skills/{id} [patch]
+// @Security BearerAuth
+func (h *skillsHandler) PatchByID(c *gin.Context) {
+	_, id, isAbort := getSkillsIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		logger.Warn("GetRawData error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	columns, err := dao.MergePatchToColumns(&model.Skills{}, patch)
+	if err != nil {
+		logger.Warn("MergePatchToColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	if !normalizeProficiencyColumns(columns) {
+		logger.Warn("invalid proficiency", logger.Any("columns", columns), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrInvalidProficiencySkills)
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, skills)
+	err = h.iDao.PatchByID(ctx, id, version, columns)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("PatchByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("PatchByID modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("PatchByID error", logger.Err(err), logger.Any("columns", columns), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -195,6 +420,9 @@ func (h *skillsHandler) UpdateByID(c *gin.Context) {
 // @Param id path string true "id"
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the record has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetSkillsByIDRespond{}
 // @Router /api/v1/skills/{id} [get]
 // @Security BearerAuth
@@ -205,15 +433,28 @@ func (h *skillsHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
 	ctx := middleware.WrapCtx(c)
 	skills, err := h.iDao.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -226,7 +467,19 @@ func (h *skillsHandler) GetByID(c *gin.Context) {
 	}
 	data.ID = idStr
 
-	response.Success(c, gin.H{"skills": data})
+	err = h.expander.expandSkills(ctx, []*types.SkillsObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, exp.etag(skills.Version, data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{"skills": fields.project(data)})
 }
 
 // GetByCondition get a record by condition
@@ -236,6 +489,8 @@ func (h *skillsHandler) GetByID(c *gin.Context) {
 // @Param data body types.Conditions true "query condition"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetSkillsByConditionRespond{}
 // @Router /api/v1/skills/condition [post]
 // @Security BearerAuth
@@ -244,13 +499,32 @@ func (h *skillsHandler) GetByCondition(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	err = form.Conditions.CheckValid()
 	if err != nil {
 		logger.Warn("Parameters error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = skillsFilter.checkColumns(form.Conditions.Columns)
+	if err != nil {
+		logger.Warn("checkColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -259,10 +533,10 @@ func (h *skillsHandler) GetByCondition(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByCondition not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByCondition error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -275,7 +549,14 @@ func (h *skillsHandler) GetByCondition(c *gin.Context) {
 	}
 	data.ID = utils.Uint64ToStr(skills.ID)
 
-	response.Success(c, gin.H{"skills": data})
+	err = h.expander.expandSkills(ctx, []*types.SkillsObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{"skills": fields.project(data)})
 }
 
 // ListByIDs list of records by batch id
@@ -285,6 +566,8 @@ func (h *skillsHandler) GetByCondition(c *gin.Context) {
 // @Param data body types.ListSkillssByIDsRequest true "id array"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListSkillssByIDsRespond{}
 // @Router /api/v1/skills/list/ids [post]
 // @Security BearerAuth
@@ -293,7 +576,20 @@ func (h *skillsHandler) ListByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -301,7 +597,7 @@ func (h *skillsHandler) ListByIDs(c *gin.Context) {
 	skillsMap, err := h.iDao.GetByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -317,8 +613,15 @@ func (h *skillsHandler) ListByIDs(c *gin.Context) {
 		}
 	}
 
+	err = h.expander.expandSkills(ctx, skillss, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"skillss": skillss,
+		"skillss": fields.project(skillss),
 	})
 }
 
@@ -331,6 +634,10 @@ func (h *skillsHandler) ListByIDs(c *gin.Context) {
 // @Param lastID query int true "last id, default is MaxInt32" default(0)
 // @Param limit query int false "size in each page" default(10)
 // @Param sort query string false "sort by column name of table, and the "-" sign before column name indicates reverse order" default(-id)
+// @Param q query string false "filter, e.g. startDate >= now-5y and (title ilike go or endDate is null)"
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the list has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListSkillssRespond{}
 // @Router /api/v1/skills/list [get]
 // @Security BearerAuth
@@ -343,13 +650,38 @@ func (h *skillsHandler) ListByLastID(c *gin.Context) {
 	if limit == 0 {
 		limit = 10
 	}
-	sort := c.Query("sort")
+	sort, err := skillsFilter.checkSort(c.Query("sort"))
+	if err != nil {
+		logger.Warn("checkSort error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := skillsFilter.queryConditions(c.Query("q"))
+	if err != nil {
+		logger.Warn("queryConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	skillss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort)
+	skillss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort, filters...)
 	if err != nil {
 		logger.Error("GetByLastID error", logger.Err(err), logger.Uint64("latsID", lastID), logger.Int("limit", limit), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -359,8 +691,98 @@ func (h *skillsHandler) ListByLastID(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandSkills(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, contentETag(data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	response.Success(c, gin.H{
-		"skillss": data,
+		"skillss": fields.project(data),
+	})
+}
+
+// ListByCursor list of records by keyset paging
+// @Summary list of skillss by cursor
+// @Description list of skillss by keyset paging with filter columns, the first page is requested without cursor, the
+// @Description next or previous page is requested with the nextCursor or prevCursor of the last page and the same columns
+// @Tags skills
+// @accept json
+// @Produce json
+// @Param data body types.ListByCursorRequest true "cursor and query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListSkillssByCursorRespond{}
+// @Router /api/v1/skills/list/cursor [post]
+// @Security BearerAuth
+func (h *skillsHandler) ListByCursor(c *gin.Context) {
+	form := &types.ListByCursorRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	params, err := newCursorParams(skillsFilter, &model.Skills{}, form)
+	if err != nil {
+		logger.Warn("newCursorParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	params.Filters = append(params.Filters, fields.selectColumns())
+
+	ctx := middleware.WrapCtx(c)
+	skillss, hasMore, err := h.iDao.GetByCursor(ctx, params)
+	if err != nil {
+		logger.Error("GetByCursor error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	nextCursor, prevCursor, err := pageCursors(ctx, skillss, form, params, hasMore)
+	if err != nil {
+		logger.Error("pageCursors error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListByCursorSkills)
+		return
+	}
+
+	data, err := convertSkillss(skillss)
+	if err != nil {
+		response.Error(c, ecode.ErrListByCursorSkills)
+		return
+	}
+
+	err = h.expander.expandSkills(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"skillss":      fields.project(data),
+		"nextCursor": nextCursor,
+		"prevCursor": prevCursor,
 	})
 }
 
@@ -371,6 +793,8 @@ func (h *skillsHandler) ListByLastID(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param data body types.Params true "query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListSkillssRespond{}
 // @Router /api/v1/skills/list [post]
 // @Security BearerAuth
@@ -379,15 +803,45 @@ func (h *skillsHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = skillsFilter.checkParams(&form.Params)
+	if err != nil {
+		logger.Warn("checkParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := skillsFilter.filterConditions(form.Filter)
+	if err != nil {
+		logger.Warn("filterConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
+	if form.Sort == "" && isFilterByUser(form.Columns) {
+		form.Sort = dao.SortPosition
+	}
+
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
+
 	ctx := middleware.WrapCtx(c)
-	skillss, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	skillss, total, err := h.iDao.GetByColumns(ctx, &form.Params, filters...)
 	if err != nil {
 		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -397,12 +851,204 @@ func (h *skillsHandler) List(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandSkills(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"skillss": data,
+		"skillss": fields.project(data),
 		"total":        total,
 	})
 }
 
+// ListByUserID list of all records of a user
+// @Summary list of skillss of a user
+// @Description list of all skillss of a user sorted by position
+// @Tags skills
+// @Param userId path string true "user id"
+// @Accept json
+// @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListSkillssByUserIDRespond{}
+// @Router /api/v1/skills/user/{userId} [get]
+// @Security BearerAuth
+func (h *skillsHandler) ListByUserID(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	exp, err := parseExpand("skills", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := skillsFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.ListByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("GetByUserID error", logger.Err(err), logger.Int("userId", userID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertSkillss(records)
+	if err != nil {
+		response.Error(c, ecode.ErrListByUserIDSkills)
+		return
+	}
+
+	err = h.expander.expandSkills(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"skillss": fields.project(data),
+	})
+}
+
+// Reorder rewrite the positions of all records of a user
+// @Summary reorder skillss of a user
+// @Description rewrite the positions of all skillss of a user atomically in the order of ids, the ids must be all the skillss of the user
+// @Tags skills
+// @accept json
+// @Produce json
+// @Param userId path string true "user id"
+// @Param data body types.ReorderSkillssRequest true "all the ids of the user in the new order"
+// @Success 200 {object} types.ReorderSkillssRespond{}
+// @Router /api/v1/skills/user/{userId}/positions [put]
+// @Security BearerAuth
+func (h *skillsHandler) Reorder(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.ReorderSkillssRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Reorder(ctx, userID, form.IDs)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidPositions) {
+			logger.Warn("Reorder invalid ids", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrReorderSkills)
+		} else {
+			logger.Error("Reorder error", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// isInvalidSkills map the skill to the skill catalog and the proficiency scale, the error is responded if the
+// catalogId does not exist or the proficiency is invalid
+func (h *skillsHandler) isInvalidSkills(ctx context.Context, c *gin.Context, record *model.Skills) bool {
+	if e := h.checkSkills(ctx, record); e != nil {
+		logger.Warn("invalid skills", logger.String("err", e.Msg()), logger.Any("record", record), middleware.GCtxRequestIDField(c))
+		response.Error(c, e)
+		return true
+	}
+
+	return false
+}
+
+// checkSkills map the skill to the skill catalog and the proficiency scale, the business error is returned if the
+// catalogId does not exist or the proficiency is invalid. matching by name is best effort, a skill that is not
+// matched is kept as it is and matched later by the normalization task.
+func (h *skillsHandler) checkSkills(ctx context.Context, record *model.Skills) *errcode.Error {
+	return checkSkillsByCatalog(ctx, h.catalogDao, record)
+}
+
+// checkSkillsByCatalog the same as checkSkills with the skill catalog of catalogDao
+func checkSkillsByCatalog(ctx context.Context, catalogDao dao.SkillCatalogsDao, record *model.Skills) *errcode.Error {
+	if !normalizeProficiency(record) {
+		return ecode.ErrInvalidProficiencySkills
+	}
+	if record.CatalogID == 0 && record.SkillName == "" {
+		return nil
+	}
+
+	_, err := catalogDao.Normalize(ctx, record)
+	if err != nil {
+		if record.CatalogID > 0 && errors.Is(err, model.ErrRecordNotFound) {
+			return ecode.ErrCatalogNotFoundSkills
+		}
+		logger.Warn("Normalize error", logger.Err(err), logger.Any("record", record))
+	}
+
+	return nil
+}
+
+// normalizeProficiency map the legacy proficiency level text onto the scale if the proficiency is not set, and
+// keep the name of the proficiency as the text, false is returned if the proficiency is invalid.
+func normalizeProficiency(record *model.Skills) bool {
+	if record.Proficiency == 0 && record.ProficiencyLevel != "" {
+		record.Proficiency = model.ParseProficiency(record.ProficiencyLevel)
+		if record.Proficiency == 0 {
+			return false
+		}
+	}
+	if record.Proficiency == 0 {
+		return true
+	}
+	if !model.IsValidProficiency(record.Proficiency) {
+		return false
+	}
+	record.ProficiencyLevel = model.ProficiencyName(record.Proficiency)
+	return true
+}
+
+// normalizeProficiencyColumns the same as normalizeProficiency for the columns of a merge patch
+func normalizeProficiencyColumns(columns map[string]interface{}) bool {
+	record := &model.Skills{}
+	level, hasLevel := columns["proficiency"]
+	text, hasText := columns["proficiency_level"]
+	if !hasLevel && !hasText {
+		return true
+	}
+	if hasLevel {
+		record.Proficiency, _ = level.(int)
+		if record.Proficiency == 0 {
+			columns["proficiency_level"] = ""
+			return true
+		}
+	} else if text != nil {
+		record.ProficiencyLevel, _ = text.(string)
+	}
+	if !normalizeProficiency(record) {
+		return false
+	}
+	columns["proficiency"] = record.Proficiency
+	columns["proficiency_level"] = record.ProficiencyLevel
+	return true
+}
+
 func getSkillsIDFromPath(c *gin.Context) (string, uint64, bool) {
 	idStr := c.Param("id")
 	id, err := utils.StrToUint64E(idStr)
@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/logger"
+	"github.com/zhufuyi/sponge/pkg/utils"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
+	"weaving_net/internal/model"
+	"weaving_net/internal/response"
+	"weaving_net/internal/types"
+)
+
+var _ TrashHandler = (*trashHandler)(nil)
+
+// TrashHandler defining the handler interface
+type TrashHandler interface {
+	List(c *gin.Context)
+	RestoreByID(c *gin.Context)
+	RestoreByIDs(c *gin.Context)
+	PurgeByID(c *gin.Context)
+	PurgeByIDs(c *gin.Context)
+}
+
+// trashDao the methods of the resource dao used by the trash
+type trashDao interface {
+	RestoreByIDs(ctx context.Context, ids []uint64) error
+	PurgeByIDs(ctx context.Context, ids []uint64) error
+}
+
+type trashResource struct {
+	iDao        trashDao
+	listDeleted func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error)
+}
+
+type trashHandler struct {
+	resources map[string]*trashResource // key is the resource name in the path
+}
+
+// NewTrashHandler creating the handler interface
+func NewTrashHandler() TrashHandler {
+	return newTrashHandlerByDao(
+		dao.NewUsersDaoByDriver(cache.NewUsersCache(model.GetCacheType())),
+		dao.NewEducationsDaoByDriver(cache.NewEducationsCache(model.GetCacheType())),
+		dao.NewProjectsDaoByDriver(cache.NewProjectsCache(model.GetCacheType())),
+		dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType())),
+		dao.NewUserIntroductionsDaoByDriver(cache.NewUserIntroductionsCache(model.GetCacheType())),
+		dao.NewWorkexperiencesDaoByDriver(cache.NewWorkexperiencesCache(model.GetCacheType())),
+	)
+}
+
+func newTrashHandlerByDao(
+	usersDao dao.UsersDao,
+	educationsDao dao.EducationsDao,
+	projectsDao dao.ProjectsDao,
+	skillsDao dao.SkillsDao,
+	userIntroductionsDao dao.UserIntroductionsDao,
+	workexperiencesDao dao.WorkexperiencesDao,
+) *trashHandler {
+	return &trashHandler{
+		resources: map[string]*trashResource{
+			"users": {
+				iDao: usersDao,
+				listDeleted: func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error) {
+					records, total, err := usersDao.ListDeleted(ctx, userID, page, size)
+					if err != nil {
+						return nil, 0, err
+					}
+					data, err := convertUserss(records)
+					return data, total, err
+				},
+			},
+			"educations": {
+				iDao: educationsDao,
+				listDeleted: func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error) {
+					records, total, err := educationsDao.ListDeleted(ctx, userID, page, size)
+					if err != nil {
+						return nil, 0, err
+					}
+					data, err := convertEducationss(records)
+					return data, total, err
+				},
+			},
+			"projects": {
+				iDao: projectsDao,
+				listDeleted: func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error) {
+					records, total, err := projectsDao.ListDeleted(ctx, userID, page, size)
+					if err != nil {
+						return nil, 0, err
+					}
+					data, err := convertProjectss(records)
+					return data, total, err
+				},
+			},
+			"skills": {
+				iDao: skillsDao,
+				listDeleted: func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error) {
+					records, total, err := skillsDao.ListDeleted(ctx, userID, page, size)
+					if err != nil {
+						return nil, 0, err
+					}
+					data, err := convertSkillss(records)
+					return data, total, err
+				},
+			},
+			"userIntroductions": {
+				iDao: userIntroductionsDao,
+				listDeleted: func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error) {
+					records, total, err := userIntroductionsDao.ListDeleted(ctx, userID, page, size)
+					if err != nil {
+						return nil, 0, err
+					}
+					data, err := convertUserIntroductionss(records)
+					return data, total, err
+				},
+			},
+			"workexperiences": {
+				iDao: workexperiencesDao,
+				listDeleted: func(ctx context.Context, userID uint64, page int, size int) (interface{}, int64, error) {
+					records, total, err := workexperiencesDao.ListDeleted(ctx, userID, page, size)
+					if err != nil {
+						return nil, 0, err
+					}
+					data, err := convertWorkexperiencess(records)
+					return data, total, err
+				},
+			},
+		},
+	}
+}
+
+// List of soft deleted records of a user
+// @Summary list of deleted records
+// @Description list of soft deleted records of a user, the latest deleted is first
+// @Tags trash
+// @accept json
+// @Produce json
+// @Param resource path string true "resource name, users, educations, projects, skills, userIntroductions or workexperiences"
+// @Param userId query int true "user id"
+// @Param page query int false "page number, starting from 0" default(0)
+// @Param size query int false "size in each page" default(10)
+// @Success 200 {object} types.ListTrashRespond{}
+// @Router /api/v1/trash/{resource} [get]
+// @Security BearerAuth
+func (h *trashHandler) List(c *gin.Context) {
+	res, isAbort := h.getTrashResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	userID := utils.StrToUint64(c.Query("userId"))
+	if userID == 0 {
+		logger.Warn("userId cannot be empty", middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	page := utils.StrToInt(c.Query("page"))
+	size := utils.StrToInt(c.Query("size"))
+	if size == 0 {
+		size = 10
+	}
+
+	ctx := middleware.WrapCtx(c)
+	data, total, err := res.listDeleted(ctx, userID, page, size)
+	if err != nil {
+		logger.Error("ListDeleted error", logger.Err(err), logger.Uint64("userId", userID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"records": data,
+		"total":   total,
+	})
+}
+
+// RestoreByID restore a deleted record by id
+// @Summary restore deleted record
+// @Description restore a soft deleted record by id, 404 if the record is not in the trash, a restored workexperience is not primary
+// @Tags trash
+// @accept json
+// @Produce json
+// @Param resource path string true "resource name, users, educations, projects, skills, userIntroductions or workexperiences"
+// @Param id path string true "id"
+// @Success 200 {object} types.RestoreTrashRespond{}
+// @Router /api/v1/trash/{resource}/{id}/restore [post]
+// @Security BearerAuth
+func (h *trashHandler) RestoreByID(c *gin.Context) {
+	res, isAbort := h.getTrashResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	_, id, isAbort := getTrashIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := res.iDao.RestoreByIDs(ctx, []uint64{id})
+	if err != nil {
+		logger.Error("RestoreByIDs error", logger.Err(err), logger.Uint64("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c)
+}
+
+// RestoreByIDs restore deleted records by batch id
+// @Summary restore deleted records
+// @Description restore soft deleted records by batch id, if any of the records is not in the trash, nothing is restored and 404 is returned, the restored workexperiences are not primary
+// @Tags trash
+// @Param resource path string true "resource name, users, educations, projects, skills, userIntroductions or workexperiences"
+// @Param data body types.RestoreTrashByIDsRequest true "id array"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.RestoreTrashRespond{}
+// @Router /api/v1/trash/{resource}/restore/ids [post]
+// @Security BearerAuth
+func (h *trashHandler) RestoreByIDs(c *gin.Context) {
+	res, isAbort := h.getTrashResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.RestoreTrashByIDsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = res.iDao.RestoreByIDs(ctx, form.IDs)
+	if err != nil {
+		logger.Error("RestoreByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c)
+}
+
+// PurgeByID permanently delete a deleted record by id
+// @Summary purge deleted record
+// @Description permanently delete a soft deleted record by id, 404 if the record is not in the trash
+// @Tags trash
+// @accept json
+// @Produce json
+// @Param resource path string true "resource name, users, educations, projects, skills, userIntroductions or workexperiences"
+// @Param id path string true "id"
+// @Success 200 {object} types.PurgeTrashRespond{}
+// @Router /api/v1/trash/{resource}/{id} [delete]
+// @Security BearerAuth
+func (h *trashHandler) PurgeByID(c *gin.Context) {
+	res, isAbort := h.getTrashResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	_, id, isAbort := getTrashIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := res.iDao.PurgeByIDs(ctx, []uint64{id})
+	if err != nil {
+		logger.Error("PurgeByIDs error", logger.Err(err), logger.Uint64("id", id), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c)
+}
+
+// PurgeByIDs permanently delete deleted records by batch id
+// @Summary purge deleted records
+// @Description permanently delete soft deleted records by batch id, if any of the records is not in the trash, nothing is deleted and 404 is returned
+// @Tags trash
+// @Param resource path string true "resource name, users, educations, projects, skills, userIntroductions or workexperiences"
+// @Param data body types.PurgeTrashByIDsRequest true "id array"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.PurgeTrashRespond{}
+// @Router /api/v1/trash/{resource}/purge/ids [post]
+// @Security BearerAuth
+func (h *trashHandler) PurgeByIDs(c *gin.Context) {
+	res, isAbort := h.getTrashResourceFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.PurgeTrashByIDsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = res.iDao.PurgeByIDs(ctx, form.IDs)
+	if err != nil {
+		logger.Error("PurgeByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c)
+}
+
+func (h *trashHandler) getTrashResourceFromPath(c *gin.Context) (*trashResource, bool) {
+	name := c.Param("resource")
+	res, ok := h.resources[name]
+	if !ok {
+		logger.Warn("unsupported trash resource", logger.String("resource", name), middleware.GCtxRequestIDField(c))
+		return nil, true
+	}
+	return res, false
+}
+
+func getTrashIDFromPath(c *gin.Context) (string, uint64, bool) {
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return "", 0, true
+	}
+
+	return idStr, id, false
+}
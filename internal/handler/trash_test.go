@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gohttp"
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+	"weaving_net/internal/types"
+)
+
+func newTrashHandler() *gotest.Handler {
+	testData := &model.Skills{}
+	testData.ID = 1
+	testData.UserID = 1
+	testData.CreatedAt = time.Now()
+	testData.UpdatedAt = testData.CreatedAt
+
+	// init mock dao, the resources are not cached
+	d := gotest.NewDao(nil, testData)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = newTrashHandlerByDao(
+		dao.NewUsersDao(d.DB, nil),
+		dao.NewEducationsDao(d.DB, nil),
+		dao.NewProjectsDao(d.DB, nil),
+		dao.NewSkillsDao(d.DB, nil),
+		dao.NewUserIntroductionsDao(d.DB, nil),
+		dao.NewWorkexperiencesDao(d.DB, nil),
+	)
+	iHandler := h.IHandler.(TrashHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "List",
+			Method:      http.MethodGet,
+			Path:        "/trash/:resource",
+			HandlerFunc: iHandler.List,
+		},
+		{
+			FuncName:    "RestoreByID",
+			Method:      http.MethodPost,
+			Path:        "/trash/:resource/:id/restore",
+			HandlerFunc: iHandler.RestoreByID,
+		},
+		{
+			FuncName:    "RestoreByIDs",
+			Method:      http.MethodPost,
+			Path:        "/trash/:resource/restore/ids",
+			HandlerFunc: iHandler.RestoreByIDs,
+		},
+		{
+			FuncName:    "PurgeByID",
+			Method:      http.MethodDelete,
+			Path:        "/trash/:resource/:id",
+			HandlerFunc: iHandler.PurgeByID,
+		},
+		{
+			FuncName:    "PurgeByIDs",
+			Method:      http.MethodPost,
+			Path:        "/trash/:resource/purge/ids",
+			HandlerFunc: iHandler.PurgeByIDs,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_trashHandler_List(t *testing.T) {
+	h := newTrashHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Skills)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"id", "user_id", "created_at", "updated_at", "deleted_at"}).
+		AddRow(testData.ID, testData.UserID, testData.CreatedAt, testData.UpdatedAt, time.Now())
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Get(result, h.GetRequestURL("List", "skills"), gohttp.KV{"userId": testData.UserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// empty user id error test
+	err = gohttp.Get(result, h.GetRequestURL("List", "skills"))
+	assert.NoError(t, err)
+	assert.NotZero(t, result.Code)
+
+	// unsupported resource error test
+	err = gohttp.Get(result, h.GetRequestURL("List", "revisions"), gohttp.KV{"userId": testData.UserID})
+	assert.NoError(t, err)
+	assert.NotZero(t, result.Code)
+
+	// get error test
+	err = gohttp.Get(result, h.GetRequestURL("List", "skills"), gohttp.KV{"userId": 111})
+	assert.Error(t, err)
+}
+
+func Test_trashHandler_RestoreByID(t *testing.T) {
+	h := newTrashHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Skills)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(nil, h.MockDao.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("RestoreByID", "skills", testData.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = gohttp.Post(result, h.GetRequestURL("RestoreByID", "skills", 0), nil)
+	assert.NoError(t, err)
+
+	// restore error test
+	err = gohttp.Post(result, h.GetRequestURL("RestoreByID", "skills", 111), nil)
+	assert.Error(t, err)
+}
+
+func Test_trashHandler_RestoreByIDs(t *testing.T) {
+	h := newTrashHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Skills)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(nil, h.MockDao.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("RestoreByIDs", "skills"), &types.RestoreTrashByIDsRequest{IDs: []uint64{testData.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// empty ids error test
+	err = gohttp.Post(result, h.GetRequestURL("RestoreByIDs", "skills"), nil)
+	assert.NoError(t, err)
+
+	// restore error test
+	err = gohttp.Post(result, h.GetRequestURL("RestoreByIDs", "skills"), &types.RestoreTrashByIDsRequest{IDs: []uint64{111}})
+	assert.Error(t, err)
+}
+
+func Test_trashHandler_PurgeByID(t *testing.T) {
+	h := newTrashHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Skills)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Delete(result, h.GetRequestURL("PurgeByID", "skills", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = gohttp.Delete(result, h.GetRequestURL("PurgeByID", "skills", 0))
+	assert.NoError(t, err)
+
+	// purge error test
+	err = gohttp.Delete(result, h.GetRequestURL("PurgeByID", "skills", 111))
+	assert.Error(t, err)
+}
+
+func Test_trashHandler_PurgeByIDs(t *testing.T) {
+	h := newTrashHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Skills)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("PurgeByIDs", "skills"), &types.PurgeTrashByIDsRequest{IDs: []uint64{testData.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// empty ids error test
+	err = gohttp.Post(result, h.GetRequestURL("PurgeByIDs", "skills"), nil)
+	assert.NoError(t, err)
+
+	// purge error test
+	err = gohttp.Post(result, h.GetRequestURL("PurgeByIDs", "skills"), &types.PurgeTrashByIDsRequest{IDs: []uint64{111}})
+	assert.Error(t, err)
+}
+
+func TestNewTrashHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewTrashHandler()
+}
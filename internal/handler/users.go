@@ -3,12 +3,12 @@ package handler
 import (
 	"errors"
 	"math"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 
 	"github.com/zhufuyi/sponge/pkg/gin/middleware"
-	"github.com/zhufuyi/sponge/pkg/gin/response"
 	"github.com/zhufuyi/sponge/pkg/logger"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
@@ -16,6 +16,7 @@ import (
 	"weaving_net/internal/dao"
 	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
+	"weaving_net/internal/response"
 	"weaving_net/internal/types"
 )
 
@@ -24,27 +25,46 @@ var _ UsersHandler = (*usersHandler)(nil)
 // UsersHandler defining the handler interface
 type UsersHandler interface {
 	Create(c *gin.Context)
+	CreateBatch(c *gin.Context)
 	DeleteByID(c *gin.Context)
 	DeleteByIDs(c *gin.Context)
 	UpdateByID(c *gin.Context)
+	UpdateBatch(c *gin.Context)
+	PatchByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	GetByCondition(c *gin.Context)
 	ListByIDs(c *gin.Context)
 	ListByLastID(c *gin.Context)
+	ListByCursor(c *gin.Context)
 	List(c *gin.Context)
 }
 
+// the columns of users that the clients can filter and sort by
+var usersFilter = newFilterSpec(&model.Users{}, []filterColumn{
+	{name: "first_name", ops: textOps, sortable: true},
+	{name: "last_name", ops: textOps, sortable: true},
+})
+
+// the fields of users that the clients can select
+var usersFields = newFieldSpec(&model.Users{}, &types.UsersObjDetail{}, map[string][]string{
+	"skills":   nil,
+	"projects": nil,
+})
+
 type usersHandler struct {
-	iDao dao.UsersDao
+	iDao     dao.UsersDao
+	expander *expander
+	views    cache.Views // the view counts of the profiles got by id, see task.CacheJobs.Warmup
 }
 
 // NewUsersHandler creating the handler interface
 func NewUsersHandler() UsersHandler {
 	return &usersHandler{
-		iDao: dao.NewUsersDao(
-			model.GetDB(),
+		iDao: dao.NewUsersDaoByDriver(
 			cache.NewUsersCache(model.GetCacheType()),
 		),
+		expander: newExpander(),
+		views:    cache.NewViews(model.GetCacheType()),
 	}
 }
 
@@ -63,7 +83,7 @@ func (h *usersHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -78,13 +98,60 @@ func (h *usersHandler) Create(c *gin.Context) {
 	err = h.iDao.Create(ctx, users)
 	if err != nil {
 		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
 	response.Success(c, gin.H{"id": users.ID})
 }
 
+// CreateBatch create records in batch
+// @Summary create users in batch
+// @Description submit information to create users in one transaction, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags users
+// @accept json
+// @Produce json
+// @Param data body types.CreateUserssBatchRequest true "users information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/users/batch [post]
+// @Security BearerAuth
+func (h *usersHandler) CreateBatch(c *gin.Context) {
+	form := &types.CreateUserssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), true)
+	records := make([]*model.Users, 0, len(form.Items))
+	for i := range form.Items {
+		record := &model.Users{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrCreateUsers)
+			continue
+		}
+		items.add(i, 0)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.CreateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("CreateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, func(j int) uint64 { return records[j].ID })
+
+	items.output(c)
+}
+
 // DeleteByID delete a record by id
 // @Summary delete users
 // @Description delete users by id
@@ -92,6 +159,7 @@ func (h *usersHandler) Create(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Success 200 {object} types.DeleteUsersByIDRespond{}
 // @Router /api/v1/users/{id} [delete]
 // @Security BearerAuth
@@ -101,12 +169,21 @@ func (h *usersHandler) DeleteByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err := h.iDao.DeleteByID(ctx, id)
+	err := h.iDao.DeleteByIDAndVersion(ctx, id, version)
 	if err != nil {
-		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("DeleteByIDAndVersion modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("DeleteByIDAndVersion error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -128,7 +205,7 @@ func (h *usersHandler) DeleteByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -136,7 +213,7 @@ func (h *usersHandler) DeleteByIDs(c *gin.Context) {
 	err = h.iDao.DeleteByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -145,11 +222,12 @@ func (h *usersHandler) DeleteByIDs(c *gin.Context) {
 
 // UpdateByID update information by id
 // @Summary update users
-// @Description update users information by id
+// @Description replace all users information by id, fields that are not submitted are reset, the record is only replaced when the If-Match header matches its ETag
 // @Tags users
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Param data body types.UpdateUsersByIDRequest true "users information"
 // @Success 200 {object} types.UpdateUsersByIDRespond{}
 // @Router /api/v1/users/{id} [put]
@@ -160,12 +238,16 @@ func (h *usersHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	form := &types.UpdateUsersByIDRequest{}
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -176,12 +258,127 @@ func (h *usersHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.ErrUpdateByIDUsers)
 		return
 	}
+	users.Version = version
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, users)
+	err = h.iDao.ReplaceByID(ctx, users)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("ReplaceByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("ReplaceByID modified", logger.Err(err), logger.Any("form", form), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("ReplaceByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateBatch update records by id in batch
+// @Summary update users in batch
+// @Description replace users by id in one transaction as UpdateByID does, fields that are not submitted are reset, an item fails with the precondition failed code if its version does not match the record, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags users
+// @accept json
+// @Produce json
+// @Param data body types.UpdateUserssBatchRequest true "users information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/users/batch [put]
+// @Security BearerAuth
+func (h *usersHandler) UpdateBatch(c *gin.Context) {
+	form := &types.UpdateUserssBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), false)
+	records := make([]*model.Users, 0, len(form.Items))
+	for i := range form.Items {
+		if form.Items[i].ID == 0 {
+			items.reject(i, ecode.InvalidParams)
+			continue
+		}
+		record := &model.Users{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrUpdateByIDUsers)
+			continue
+		}
+		items.add(i, record.ID)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.UpdateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("UpdateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, nil)
+
+	items.output(c)
+}
+
+// PatchByID partial update by id with a JSON merge patch
+// @Summary patch users
+// @Description partial update users by id with a JSON merge patch (RFC 7396), an explicit null clears the field and an omitted field is left alone
+// @Tags users
+// @accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
+// @Param data body types.PatchUsersByIDRequest true "users fields to be changed"
+// @Success 200 {object} types.PatchUsersByIDRespond{}
+// @Router /api/v1/users/{id} [patch]
+// @Security BearerAuth
+func (h *usersHandler) PatchByID(c *gin.Context) {
+	_, id, isAbort := getUsersIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		logger.Warn("GetRawData error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	columns, err := dao.MergePatchToColumns(&model.Users{}, patch)
+	if err != nil {
+		logger.Warn("MergePatchToColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.PatchByID(ctx, id, version, columns)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("PatchByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("PatchByID modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("PatchByID error", logger.Err(err), logger.Any("columns", columns), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -195,6 +392,9 @@ func (h *usersHandler) UpdateByID(c *gin.Context) {
 // @Param id path string true "id"
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the record has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. skills,projects, and skills.user expands the user of each skill"
 // @Success 200 {object} types.GetUsersByIDRespond{}
 // @Router /api/v1/users/{id} [get]
 // @Security BearerAuth
@@ -205,18 +405,35 @@ func (h *usersHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	exp, err := parseExpand("users", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := usersFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
 	ctx := middleware.WrapCtx(c)
 	users, err := h.iDao.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
+	err = h.views.Incr(ctx, id)
+	if err != nil {
+		logger.Warn("views.Incr error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+	}
 
 	data := &types.UsersObjDetail{}
 	err = copier.Copy(data, users)
@@ -226,7 +443,19 @@ func (h *usersHandler) GetByID(c *gin.Context) {
 	}
 	data.ID = idStr
 
-	response.Success(c, gin.H{"users": data})
+	err = h.expander.expandUsers(ctx, []*types.UsersObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, exp.etag(users.Version, data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{"users": fields.project(data)})
 }
 
 // GetByCondition get a record by condition
@@ -236,6 +465,8 @@ func (h *usersHandler) GetByID(c *gin.Context) {
 // @Param data body types.Conditions true "query condition"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. skills,projects, and skills.user expands the user of each skill"
 // @Success 200 {object} types.GetUsersByConditionRespond{}
 // @Router /api/v1/users/condition [post]
 // @Security BearerAuth
@@ -244,13 +475,32 @@ func (h *usersHandler) GetByCondition(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	err = form.Conditions.CheckValid()
 	if err != nil {
 		logger.Warn("Parameters error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = usersFilter.checkColumns(form.Conditions.Columns)
+	if err != nil {
+		logger.Warn("checkColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("users", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := usersFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -259,10 +509,10 @@ func (h *usersHandler) GetByCondition(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByCondition not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByCondition error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -275,7 +525,14 @@ func (h *usersHandler) GetByCondition(c *gin.Context) {
 	}
 	data.ID = utils.Uint64ToStr(users.ID)
 
-	response.Success(c, gin.H{"users": data})
+	err = h.expander.expandUsers(ctx, []*types.UsersObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{"users": fields.project(data)})
 }
 
 // ListByIDs list of records by batch id
@@ -285,6 +542,8 @@ func (h *usersHandler) GetByCondition(c *gin.Context) {
 // @Param data body types.ListUserssByIDsRequest true "id array"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. skills,projects, and skills.user expands the user of each skill"
 // @Success 200 {object} types.ListUserssByIDsRespond{}
 // @Router /api/v1/users/list/ids [post]
 // @Security BearerAuth
@@ -293,7 +552,20 @@ func (h *usersHandler) ListByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("users", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := usersFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -301,7 +573,7 @@ func (h *usersHandler) ListByIDs(c *gin.Context) {
 	usersMap, err := h.iDao.GetByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -317,8 +589,15 @@ func (h *usersHandler) ListByIDs(c *gin.Context) {
 		}
 	}
 
+	err = h.expander.expandUsers(ctx, userss, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"userss": userss,
+		"userss": fields.project(userss),
 	})
 }
 
@@ -331,6 +610,10 @@ func (h *usersHandler) ListByIDs(c *gin.Context) {
 // @Param lastID query int true "last id, default is MaxInt32" default(0)
 // @Param limit query int false "size in each page" default(10)
 // @Param sort query string false "sort by column name of table, and the "-" sign before column name indicates reverse order" default(-id)
+// @Param q query string false "filter, e.g. startDate >= now-5y and (title ilike go or endDate is null)"
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the list has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. skills,projects, and skills.user expands the user of each skill"
 // @Success 200 {object} types.ListUserssRespond{}
 // @Router /api/v1/users/list [get]
 // @Security BearerAuth
@@ -343,13 +626,38 @@ func (h *usersHandler) ListByLastID(c *gin.Context) {
 	if limit == 0 {
 		limit = 10
 	}
-	sort := c.Query("sort")
+	sort, err := usersFilter.checkSort(c.Query("sort"))
+	if err != nil {
+		logger.Warn("checkSort error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := usersFilter.queryConditions(c.Query("q"))
+	if err != nil {
+		logger.Warn("queryConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("users", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := usersFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	userss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort)
+	userss, err := h.iDao.GetByLastID(ctx, lastID, limit, sort, filters...)
 	if err != nil {
 		logger.Error("GetByLastID error", logger.Err(err), logger.Uint64("latsID", lastID), logger.Int("limit", limit), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -359,8 +667,98 @@ func (h *usersHandler) ListByLastID(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandUsers(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, contentETag(data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"userss": fields.project(data),
+	})
+}
+
+// ListByCursor list of records by keyset paging
+// @Summary list of userss by cursor
+// @Description list of userss by keyset paging with filter columns, the first page is requested without cursor, the
+// @Description next or previous page is requested with the nextCursor or prevCursor of the last page and the same columns
+// @Tags users
+// @accept json
+// @Produce json
+// @Param data body types.ListByCursorRequest true "cursor and query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. skills,projects, and skills.user expands the user of each skill"
+// @Success 200 {object} types.ListUserssByCursorRespond{}
+// @Router /api/v1/users/list/cursor [post]
+// @Security BearerAuth
+func (h *usersHandler) ListByCursor(c *gin.Context) {
+	form := &types.ListByCursorRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	params, err := newCursorParams(usersFilter, &model.Users{}, form)
+	if err != nil {
+		logger.Warn("newCursorParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("users", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := usersFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	params.Filters = append(params.Filters, fields.selectColumns())
+
+	ctx := middleware.WrapCtx(c)
+	userss, hasMore, err := h.iDao.GetByCursor(ctx, params)
+	if err != nil {
+		logger.Error("GetByCursor error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	nextCursor, prevCursor, err := pageCursors(ctx, userss, form, params, hasMore)
+	if err != nil {
+		logger.Error("pageCursors error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListByCursorUsers)
+		return
+	}
+
+	data, err := convertUserss(userss)
+	if err != nil {
+		response.Error(c, ecode.ErrListByCursorUsers)
+		return
+	}
+
+	err = h.expander.expandUsers(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"userss": data,
+		"userss":     fields.project(data),
+		"nextCursor": nextCursor,
+		"prevCursor": prevCursor,
 	})
 }
 
@@ -371,6 +769,8 @@ func (h *usersHandler) ListByLastID(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param data body types.Params true "query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. skills,projects, and skills.user expands the user of each skill"
 // @Success 200 {object} types.ListUserssRespond{}
 // @Router /api/v1/users/list [post]
 // @Security BearerAuth
@@ -379,15 +779,41 @@ func (h *usersHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = usersFilter.checkParams(&form.Params)
+	if err != nil {
+		logger.Warn("checkParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := usersFilter.filterConditions(form.Filter)
+	if err != nil {
+		logger.Warn("filterConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
+	exp, err := parseExpand("users", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := usersFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
+
 	ctx := middleware.WrapCtx(c)
-	userss, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	userss, total, err := h.iDao.GetByColumns(ctx, &form.Params, filters...)
 	if err != nil {
 		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -397,8 +823,15 @@ func (h *usersHandler) List(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandUsers(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"userss": data,
+		"userss": fields.project(data),
 		"total":  total,
 	})
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -15,7 +16,9 @@ import (
 	"github.com/zhufuyi/sponge/pkg/utils"
 
 	"weaving_net/internal/cache"
+	"weaving_net/internal/config"
 	"weaving_net/internal/dao"
+	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
 	"weaving_net/internal/types"
 )
@@ -40,7 +43,11 @@ func newUsersHandler() *gotest.Handler {
 
 	// init mock handler
 	h := gotest.NewHandler(d, testData)
-	h.IHandler = &usersHandler{iDao: d.IDao.(dao.UsersDao)}
+	h.IHandler = &usersHandler{
+		iDao:     d.IDao.(dao.UsersDao),
+		expander: newTestExpander(d.DB),
+		views:    cache.NewViews(&model.CacheType{CType: "redis", Rdb: c.RedisClient}),
+	}
 	iHandler := h.IHandler.(UsersHandler)
 
 	testFns := []gotest.RouterInfo{
@@ -50,6 +57,12 @@ func newUsersHandler() *gotest.Handler {
 			Path:        "/users",
 			HandlerFunc: iHandler.Create,
 		},
+		{
+			FuncName:    "CreateBatch",
+			Method:      http.MethodPost,
+			Path:        "/users/batch",
+			HandlerFunc: iHandler.CreateBatch,
+		},
 		{
 			FuncName:    "DeleteByID",
 			Method:      http.MethodDelete,
@@ -68,6 +81,18 @@ func newUsersHandler() *gotest.Handler {
 			Path:        "/users/:id",
 			HandlerFunc: iHandler.UpdateByID,
 		},
+		{
+			FuncName:    "UpdateBatch",
+			Method:      http.MethodPut,
+			Path:        "/users/batch",
+			HandlerFunc: iHandler.UpdateBatch,
+		},
+		{
+			FuncName:    "PatchByID",
+			Method:      http.MethodPatch,
+			Path:        "/users/:id",
+			HandlerFunc: iHandler.PatchByID,
+		},
 		{
 			FuncName:    "GetByID",
 			Method:      http.MethodGet,
@@ -92,6 +117,12 @@ func newUsersHandler() *gotest.Handler {
 			Path:        "/users/list",
 			HandlerFunc: iHandler.ListByLastID,
 		},
+		{
+			FuncName:    "ListByCursor",
+			Method:      http.MethodPost,
+			Path:        "/users/list/cursor",
+			HandlerFunc: iHandler.ListByCursor,
+		},
 		{
 			FuncName:    "List",
 			Method:      http.MethodPost,
@@ -129,19 +160,65 @@ func Test_usersHandler_Create(t *testing.T) {
 
 }
 
-func Test_usersHandler_DeleteByID(t *testing.T) {
+func Test_usersHandler_CreateBatch(t *testing.T) {
+	h := newUsersHandler()
+	defer h.Close()
+	testData := &types.CreateUsersRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.Users))
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	form := &types.CreateUserssBatchRequest{Items: []types.CreateUsersRequest{*testData}}
+	err := gohttp.Post(result, h.GetRequestURL("CreateBatch"), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// best effort, the failed item is reported
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").WillReturnError(errors.New("insert error"))
+	h.MockDao.SQLMock.ExpectExec("ROLLBACK TO SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	form.Mode = types.BatchModeBestEffort
+	err = gohttp.Post(result, h.GetRequestURL("CreateBatch"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Code)
+	assert.Equal(t, float64(1), result.Data.(map[string]interface{})["failed"])
+
+	// empty items error test
+	err = gohttp.Post(result, h.GetRequestURL("CreateBatch"), &types.CreateUserssBatchRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// transaction error test
+	err = gohttp.Post(result, h.GetRequestURL("CreateBatch"), form)
+	assert.Error(t, err)
+}
+
+func Test_usersHandler_UpdateBatch(t *testing.T) {
 	h := newUsersHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.Users)
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
 	result := &gohttp.StdResult{}
-	err := gohttp.Delete(result, h.GetRequestURL("DeleteByID", testData.ID))
+	form := &types.UpdateUserssBatchRequest{Items: []types.UpdateUsersBatchItem{{
+		UpdateUsersByIDRequest: types.UpdateUsersByIDRequest{ID: testData.ID},
+		Version:                1,
+	}}}
+	err := gohttp.Put(result, h.GetRequestURL("UpdateBatch"), form)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -149,13 +226,61 @@ func Test_usersHandler_DeleteByID(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
+	// zero id item, the atomic batch is rolled back without accessing the database
+	form.Items = append(form.Items, types.UpdateUsersBatchItem{Version: 1})
+	err = gohttp.Put(result, h.GetRequestURL("UpdateBatch"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrBatchFailed.Code(), result.Code)
+	results := result.Data.(map[string]interface{})["results"].([]interface{})
+	assert.Equal(t, float64(ecode.ErrBatchItemRolledBack.Code()), results[0].(map[string]interface{})["code"])
+	assert.Equal(t, float64(ecode.InvalidParams.Code()), results[1].(map[string]interface{})["code"])
+}
+
+func Test_usersHandler_DeleteByID(t *testing.T) {
+	h := newUsersHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Users)
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(h.MockDao.AnyTime, testData.ID, 1). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", testData.ID), ifMatch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
+	}
+
 	// zero id error test
 	err = gohttp.Delete(result, h.GetRequestURL("DeleteByID", 0))
 	assert.NoError(t, err)
 
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", testData.ID), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// modified error test
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	statusCode, _, err = doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", testData.ID), ifMatch, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+	assert.Equal(t, ecode.ErrPreconditionFailed.Code(), result.Code)
+
 	// delete error test
-	err = gohttp.Delete(result, h.GetRequestURL("DeleteByID", 111))
-	assert.Error(t, err)
+	statusCode, _, _ = doWithHeader(http.MethodDelete, result, h.GetRequestURL("DeleteByID", 111), ifMatch, nil)
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
 }
 
 func Test_usersHandler_DeleteByIDs(t *testing.T) {
@@ -192,32 +317,115 @@ func Test_usersHandler_UpdateByID(t *testing.T) {
 	defer h.Close()
 	testData := &types.UpdateUsersByIDRequest{}
 	_ = copier.Copy(testData, h.TestData.(*model.Users))
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
 	result := &gohttp.StdResult{}
-	err := gohttp.Put(result, h.GetRequestURL("UpdateByID", testData.ID), testData)
+	statusCode, _, err := doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", testData.ID), ifMatch, testData)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if result.Code != 0 {
-		t.Fatalf("%+v", result)
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
 	}
 
 	// zero id error test
 	err = gohttp.Put(result, h.GetRequestURL("UpdateByID", 0), testData)
 	assert.NoError(t, err)
 
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", testData.ID), nil, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// modified error test
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	statusCode, _, err = doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", testData.ID), ifMatch, testData)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+	assert.Equal(t, ecode.ErrPreconditionFailed.Code(), result.Code)
+
 	// update error test
-	err = gohttp.Put(result, h.GetRequestURL("UpdateByID", 111), testData)
-	assert.Error(t, err)
+	statusCode, _, _ = doWithHeader(http.MethodPut, result, h.GetRequestURL("UpdateByID", 111), ifMatch, testData)
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
+}
+
+func Test_usersHandler_PatchByID(t *testing.T) {
+	h := newUsersHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Users)
+	ifMatch := map[string]string{"If-Match": versionETag(1)}
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &gohttp.StdResult{}
+	statusCode, _, err := doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusOK || result.Code != 0 {
+		t.Fatalf("%d, %+v", statusCode, result)
+	}
+
+	// zero id error test
+	err = gohttp.Patch(result, h.GetRequestURL("PatchByID", 0), map[string]interface{}{})
+	assert.NoError(t, err)
+
+	// missing If-Match error test
+	statusCode, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), nil, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, statusCode)
+
+	// unknown field error test
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{"foo": "bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// immutable field error test
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{"version": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// modified error test
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	statusCode, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", testData.ID), ifMatch, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode)
+
+	// not found error test
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(111, 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	_, _, err = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", 111), map[string]string{"If-Match": "*"}, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.NotFound.Code(), result.Code)
+
+	// patch error test
+	statusCode, _, _ = doWithHeader(http.MethodPatch, result, h.GetRequestURL("PatchByID", 112), ifMatch, map[string]interface{}{})
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
 }
 
 func Test_usersHandler_GetByID(t *testing.T) {
+	cache.SetOptions(&config.Cache{ViewSampleRate: 1})
+	defer cache.SetOptions(&config.Cache{})
 	h := newUsersHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.Users)
@@ -238,6 +446,18 @@ func Test_usersHandler_GetByID(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
+	// not modified test
+	statusCode, etag, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("GetByID", testData.ID),
+		map[string]string{"If-None-Match": versionETag(testData.Version)}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, statusCode)
+	assert.Equal(t, versionETag(testData.Version), etag)
+
+	// the views of the profile are counted
+	score, err := h.MockDao.Cache.RedisClient.ZScore(h.MockDao.Ctx, cache.ViewsKey, "1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), score)
+
 	// zero id error test
 	err = gohttp.Get(result, h.GetRequestURL("GetByID", 0))
 	assert.NoError(t, err)
@@ -339,8 +559,68 @@ func Test_usersHandler_ListByLastID(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
-	// error test
+	// not modified test
+	for i := 0; i < 2; i++ {
+		rows = sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+			AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt)
+		h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	}
+	_, etag, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("ListByLastID"), nil, map[string]interface{}{"lastID": 0})
+	assert.NoError(t, err)
+	statusCode, _, err := doWithHeader(http.MethodGet, result, h.GetRequestURL("ListByLastID"),
+		map[string]string{"If-None-Match": etag}, map[string]interface{}{"lastID": 0})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, statusCode)
+
+	// fields test
+	rows = sqlmock.NewRows([]string{"id", "updated_at"}).AddRow(testData.ID, testData.UpdatedAt)
+	h.MockDao.SQLMock.ExpectQuery("SELECT `id`,`updated_at` FROM .*").WillReturnRows(rows)
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "fields": "updatedAt"})
+	assert.NoError(t, err)
+	if assert.Equal(t, 0, result.Code) {
+		records := result.Data.(map[string]interface{})["userss"].([]interface{})
+		assert.Len(t, records[0], 2)
+	}
+
+	// expand test
+	rows = sqlmock.NewRows([]string{"id"}).AddRow(testData.ID)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectQuery("SELECT \\* FROM `skills` WHERE user_id IN \\(\\?\\)").
+		WithArgs(int(testData.ID)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "skill_name"}).AddRow(1, testData.ID, "go"))
+	h.MockDao.SQLMock.ExpectQuery("SELECT \\* FROM `projects` WHERE user_id IN \\(\\?\\)").
+		WithArgs(int(testData.ID)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "expand": "skills,projects"})
+	assert.NoError(t, err)
+	if assert.Equal(t, 0, result.Code) {
+		record := result.Data.(map[string]interface{})["userss"].([]interface{})[0].(map[string]interface{})
+		assert.Len(t, record["skills"], 1)
+		assert.Nil(t, record["projects"])
+	}
+
+	// invalid expand test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "expand": "user"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// unknown field test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "fields": "id,unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// unknown column test
 	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "size": 10, "sort": "unknown-column"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// invalid filter test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "q": "id between 1"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// error test
+	err = gohttp.Get(result, h.GetRequestURL("ListByLastID"), gohttp.KV{"lastID": 0, "size": 10, "sort": "-id"})
 	assert.Error(t, err)
 }
 
@@ -355,7 +635,7 @@ func Test_usersHandler_List(t *testing.T) {
 	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
 
 	result := &gohttp.StdResult{}
-	err := gohttp.Post(result, h.GetRequestURL("List"), &types.ListUserssRequest{query.Params{
+	err := gohttp.Post(result, h.GetRequestURL("List"), &types.ListUserssRequest{Params: query.Params{
 		Page: 0,
 		Size: 10,
 		Sort: "ignore count", // ignore test count
@@ -371,12 +651,65 @@ func Test_usersHandler_List(t *testing.T) {
 	err = gohttp.Post(result, h.GetRequestURL("List"), nil)
 	assert.NoError(t, err)
 
-	// get error test
-	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListUserssRequest{query.Params{
+	// unknown column test
+	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListUserssRequest{Params: query.Params{
 		Page: 0,
 		Size: 10,
 		Sort: "unknown-column",
 	}})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// invalid filter test
+	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListUserssRequest{
+		Params: query.Params{Page: 0, Size: 10},
+		Filter: &types.Filter{Name: "deleted_at", Exp: "isnull"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// get error test
+	err = gohttp.Post(result, h.GetRequestURL("List"), &types.ListUserssRequest{Params: query.Params{
+		Page: 0,
+		Size: 10,
+		Sort: "-id",
+	}})
+	assert.Error(t, err)
+}
+
+func Test_usersHandler_ListByCursor(t *testing.T) {
+	h := newUsersHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Users)
+
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+		AddRow(testData.ID, testData.CreatedAt, testData.UpdatedAt).
+		AddRow(testData.ID+1, testData.CreatedAt, testData.UpdatedAt)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &gohttp.StdResult{}
+	err := gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	assert.NotEmpty(t, result.Data.(map[string]interface{})["nextCursor"])
+
+	// invalid cursor test
+	err = gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{Cursor: "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// unknown column test
+	err = gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{Columns: []query.Column{{Name: "deleted_at", Value: 1}}})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.InvalidParams.Code(), result.Code)
+
+	// get error test
+	err = gohttp.Post(result, h.GetRequestURL("ListByCursor"), &types.ListByCursorRequest{})
 	assert.Error(t, err)
 }
 
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"math"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 
+	"github.com/zhufuyi/sponge/pkg/errcode"
 	"github.com/zhufuyi/sponge/pkg/gin/middleware"
-	"github.com/zhufuyi/sponge/pkg/gin/response"
 	"github.com/zhufuyi/sponge/pkg/logger"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
@@ -16,6 +20,7 @@ import (
 	"weaving_net/internal/dao"
 	"weaving_net/internal/ecode"
 	"weaving_net/internal/model"
+	"weaving_net/internal/response"
 	"weaving_net/internal/types"
 )
 
@@ -24,27 +29,54 @@ var _ WorkexperiencesHandler = (*workexperiencesHandler)(nil)
 // WorkexperiencesHandler defining the handler interface
 type WorkexperiencesHandler interface {
 	Create(c *gin.Context)
+	CreateBatch(c *gin.Context)
 	DeleteByID(c *gin.Context)
 	DeleteByIDs(c *gin.Context)
 	UpdateByID(c *gin.Context)
+	UpdateBatch(c *gin.Context)
+	PatchByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	GetByCondition(c *gin.Context)
 	ListByIDs(c *gin.Context)
 	ListByLastID(c *gin.Context)
+	ListByCursor(c *gin.Context)
 	List(c *gin.Context)
+	ListByUserID(c *gin.Context)
+	Reorder(c *gin.Context)
 }
 
+// the columns of workexperiences that the clients can filter and sort by
+var workexperiencesFilter = newFilterSpec(&model.Workexperiences{}, []filterColumn{
+	{name: "user_id", ops: equalOps, sortable: true},
+	{name: "company", ops: textOps, sortable: true},
+	{name: "title", ops: textOps, sortable: true},
+	{name: "employment_type", ops: textOps, sortable: true},
+	{name: "location", ops: textOps, sortable: true},
+	{name: "start_date", ops: orderedOps, sortable: true},
+	{name: "end_date", ops: orderedOps, sortable: true},
+	{name: "is_current", ops: boolOps, sortable: true},
+	{name: "is_primary", ops: boolOps, sortable: true},
+	{name: "position", ops: orderedOps, sortable: true},
+})
+
+// the fields of workexperiences that the clients can select
+var workexperiencesFields = newFieldSpec(&model.Workexperiences{}, &types.WorkexperiencesObjDetail{}, map[string][]string{
+	"user":           {"user_id"},
+	"durationMonths": {"start_date", "end_date", "is_current"},
+})
+
 type workexperiencesHandler struct {
-	iDao dao.WorkexperiencesDao
+	iDao     dao.WorkexperiencesDao
+	expander *expander
 }
 
 // NewWorkexperiencesHandler creating the handler interface
 func NewWorkexperiencesHandler() WorkexperiencesHandler {
 	return &workexperiencesHandler{
-		iDao: dao.NewWorkexperiencesDao(
-			model.GetDB(),
+		iDao: dao.NewWorkexperiencesDaoByDriver(
 			cache.NewWorkexperiencesCache(model.GetCacheType()),
 		),
+		expander: newExpander(),
 	}
 }
 
@@ -63,7 +95,7 @@ func (h *workexperiencesHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -75,16 +107,77 @@ func (h *workexperiencesHandler) Create(c *gin.Context) {
 	}
 
 	ctx := middleware.WrapCtx(c)
+	if h.isInvalidWorkexperiences(ctx, c, workexperiences) {
+		return
+	}
 	err = h.iDao.Create(ctx, workexperiences)
 	if err != nil {
 		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
 	response.Success(c, gin.H{"id": workexperiences.ID})
 }
 
+// CreateBatch create records in batch
+// @Summary create workexperiences in batch
+// @Description submit information to create workexperiences in one transaction, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags workexperiences
+// @accept json
+// @Produce json
+// @Param data body types.CreateWorkexperiencessBatchRequest true "workexperiences information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/workexperiences/batch [post]
+// @Security BearerAuth
+func (h *workexperiencesHandler) CreateBatch(c *gin.Context) {
+	form := &types.CreateWorkexperiencessBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), true)
+	records := make([]*model.Workexperiences, 0, len(form.Items))
+	primaryUserIDs := map[int]struct{}{}
+	for i := range form.Items {
+		record := &model.Workexperiences{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrCreateWorkexperiences)
+			continue
+		}
+		e, err := h.checkBatchWorkexperiences(ctx, record, primaryUserIDs)
+		if err != nil {
+			logger.Error("checkBatchWorkexperiences error", logger.Err(err), logger.Int("index", i), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+			return
+		}
+		if e != nil {
+			items.reject(i, e)
+			continue
+		}
+		items.add(i, 0)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.CreateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("CreateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, func(j int) uint64 { return records[j].ID })
+
+	items.output(c)
+}
+
 // DeleteByID delete a record by id
 // @Summary delete workexperiences
 // @Description delete workexperiences by id
@@ -92,6 +185,7 @@ func (h *workexperiencesHandler) Create(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Success 200 {object} types.DeleteWorkexperiencesByIDRespond{}
 // @Router /api/v1/workexperiences/{id} [delete]
 // @Security BearerAuth
@@ -101,12 +195,21 @@ func (h *workexperiencesHandler) DeleteByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err := h.iDao.DeleteByID(ctx, id)
+	err := h.iDao.DeleteByIDAndVersion(ctx, id, version)
 	if err != nil {
-		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("DeleteByIDAndVersion modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("DeleteByIDAndVersion error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -128,7 +231,7 @@ func (h *workexperiencesHandler) DeleteByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -136,7 +239,7 @@ func (h *workexperiencesHandler) DeleteByIDs(c *gin.Context) {
 	err = h.iDao.DeleteByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -145,11 +248,12 @@ func (h *workexperiencesHandler) DeleteByIDs(c *gin.Context) {
 
 // UpdateByID update information by id
 // @Summary update workexperiences
-// @Description update workexperiences information by id
+// @Description replace all workexperiences information by id, fields that are not submitted are reset, the record is only replaced when the If-Match header matches its ETag
 // @Tags workexperiences
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
 // @Param data body types.UpdateWorkexperiencesByIDRequest true "workexperiences information"
 // @Success 200 {object} types.UpdateWorkexperiencesByIDRespond{}
 // @Router /api/v1/workexperiences/{id} [put]
@@ -160,12 +264,16 @@ func (h *workexperiencesHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.InvalidParams)
 		return
 	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
 
 	form := &types.UpdateWorkexperiencesByIDRequest{}
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -176,12 +284,159 @@ func (h *workexperiencesHandler) UpdateByID(c *gin.Context) {
 		response.Error(c, ecode.ErrUpdateByIDWorkexperiences)
 		return
 	}
+	workexperiences.Version = version
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, workexperiences)
+	if h.isInvalidWorkexperiences(ctx, c, workexperiences) {
+		return
+	}
+	err = h.iDao.ReplaceByID(ctx, workexperiences)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("ReplaceByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("ReplaceByID modified", logger.Err(err), logger.Any("form", form), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("ReplaceByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateBatch update records by id in batch
+// @Summary update workexperiences in batch
+// @Description replace workexperiences by id in one transaction as UpdateByID does, fields that are not submitted are reset, an item fails with the precondition failed code if its version does not match the record, in atomic mode all items are rolled back if any item fails, in bestEffort mode the successful items are committed, the result of each item is returned
+// @Tags workexperiences
+// @accept json
+// @Produce json
+// @Param data body types.UpdateWorkexperiencessBatchRequest true "workexperiences information"
+// @Success 200 {object} types.BatchRespond{}
+// @Router /api/v1/workexperiences/batch [put]
+// @Security BearerAuth
+func (h *workexperiencesHandler) UpdateBatch(c *gin.Context) {
+	form := &types.UpdateWorkexperiencessBatchRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	items := newBatchItems(form.Mode, len(form.Items), false)
+	records := make([]*model.Workexperiences, 0, len(form.Items))
+	primaryUserIDs := map[int]struct{}{}
+	for i := range form.Items {
+		if form.Items[i].ID == 0 {
+			items.reject(i, ecode.InvalidParams)
+			continue
+		}
+		record := &model.Workexperiences{}
+		if err = copier.Copy(record, &form.Items[i]); err != nil {
+			items.reject(i, ecode.ErrUpdateByIDWorkexperiences)
+			continue
+		}
+		e, err := h.checkBatchWorkexperiences(ctx, record, primaryUserIDs)
+		if err != nil {
+			logger.Error("checkBatchWorkexperiences error", logger.Err(err), logger.Int("index", i), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+			return
+		}
+		if e != nil {
+			items.reject(i, e)
+			continue
+		}
+		items.add(i, record.ID)
+		records = append(records, record)
+	}
+	if items.isAborted() || len(records) == 0 {
+		items.output(c)
+		return
+	}
+
+	errs, err := h.iDao.UpdateBatch(ctx, records, items.isAtomic)
+	if err != nil {
+		logger.Error("UpdateBatch error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+	items.done(c, errs, nil)
+
+	items.output(c)
+}
+
+// PatchByID partial update by id with a JSON merge patch
+// @Summary patch workexperiences
+// @Description partial update workexperiences by id with a JSON merge patch (RFC 7396), an explicit null clears the field and an omitted field is left alone
+// @Tags workexperiences
+// @accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "the ETag of the record got from GetByID, or *"
+// @Param data body types.PatchWorkexperiencesByIDRequest true "workexperiences fields to be changed"
+// @Success 200 {object} types.PatchWorkexperiencesByIDRespond{}
+// @Router /api/v1/workexperiences/{id} [patch]
+// @Security BearerAuth
+func (h *workexperiencesHandler) PatchByID(c *gin.Context) {
+	_, id, isAbort := getWorkexperiencesIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	version, isAbort := getIfMatchVersion(c)
+	if isAbort {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		logger.Warn("GetRawData error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	columns, err := dao.MergePatchToColumns(&model.Workexperiences{}, patch)
+	if err != nil {
+		logger.Warn("MergePatchToColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	if isPatchOngoingColumns(columns) {
+		// check the record merged with the patch
+		record, err := h.iDao.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+				response.Out(c, ecode.NotFound)
+			} else {
+				logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+				response.Out(c, daoError(err))
+			}
+			return
+		}
+		_ = json.Unmarshal(patch, record)
+		if h.isInvalidWorkexperiences(ctx, c, record) {
+			return
+		}
+	}
+	err = h.iDao.PatchByID(ctx, id, version, columns)
+	if err != nil {
+		if errors.Is(err, model.ErrRecordNotFound) {
+			logger.Warn("PatchByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Out(c, ecode.NotFound)
+		} else if errors.Is(err, model.ErrRecordModified) {
+			logger.Warn("PatchByID modified", logger.Err(err), logger.Any("id", id), logger.Int("version", version), middleware.GCtxRequestIDField(c))
+			outputPreconditionError(c, http.StatusPreconditionFailed, ecode.ErrPreconditionFailed)
+		} else {
+			logger.Error("PatchByID error", logger.Err(err), logger.Any("columns", columns), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
 		return
 	}
 
@@ -195,6 +450,9 @@ func (h *workexperiencesHandler) UpdateByID(c *gin.Context) {
 // @Param id path string true "id"
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the record has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetWorkexperiencesByIDRespond{}
 // @Router /api/v1/workexperiences/{id} [get]
 // @Security BearerAuth
@@ -205,15 +463,28 @@ func (h *workexperiencesHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
 	ctx := middleware.WrapCtx(c)
 	workexperiences, err := h.iDao.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -225,8 +496,21 @@ func (h *workexperiencesHandler) GetByID(c *gin.Context) {
 		return
 	}
 	data.ID = idStr
+	data.DurationMonths = workexperiencesDurationMonths(workexperiences, time.Now())
 
-	response.Success(c, gin.H{"workexperiences": data})
+	err = h.expander.expandWorkexperiences(ctx, []*types.WorkexperiencesObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, exp.etag(workexperiences.Version, data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Success(c, gin.H{"workexperiences": fields.project(data)})
 }
 
 // GetByCondition get a record by condition
@@ -236,6 +520,8 @@ func (h *workexperiencesHandler) GetByID(c *gin.Context) {
 // @Param data body types.Conditions true "query condition"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.GetWorkexperiencesByConditionRespond{}
 // @Router /api/v1/workexperiences/condition [post]
 // @Security BearerAuth
@@ -244,13 +530,32 @@ func (h *workexperiencesHandler) GetByCondition(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
 	err = form.Conditions.CheckValid()
 	if err != nil {
 		logger.Warn("Parameters error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+	err = workexperiencesFilter.checkColumns(form.Conditions.Columns)
+	if err != nil {
+		logger.Warn("checkColumns error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -259,10 +564,10 @@ func (h *workexperiencesHandler) GetByCondition(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, model.ErrRecordNotFound) {
 			logger.Warn("GetByCondition not found", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Error(c, ecode.NotFound)
+			response.Out(c, ecode.NotFound)
 		} else {
 			logger.Error("GetByCondition error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			response.Out(c, daoError(err))
 		}
 		return
 	}
@@ -274,8 +579,16 @@ func (h *workexperiencesHandler) GetByCondition(c *gin.Context) {
 		return
 	}
 	data.ID = utils.Uint64ToStr(workexperiences.ID)
+	data.DurationMonths = workexperiencesDurationMonths(workexperiences, time.Now())
+
+	err = h.expander.expandWorkexperiences(ctx, []*types.WorkexperiencesObjDetail{data}, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
 
-	response.Success(c, gin.H{"workexperiences": data})
+	response.Success(c, gin.H{"workexperiences": fields.project(data)})
 }
 
 // ListByIDs list of records by batch id
@@ -285,6 +598,8 @@ func (h *workexperiencesHandler) GetByCondition(c *gin.Context) {
 // @Param data body types.ListWorkexperiencessByIDsRequest true "id array"
 // @Accept json
 // @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListWorkexperiencessByIDsRespond{}
 // @Router /api/v1/workexperiences/list/ids [post]
 // @Security BearerAuth
@@ -293,7 +608,20 @@ func (h *workexperiencesHandler) ListByIDs(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
 		return
 	}
 
@@ -301,7 +629,7 @@ func (h *workexperiencesHandler) ListByIDs(c *gin.Context) {
 	workexperiencesMap, err := h.iDao.GetByIDs(ctx, form.IDs)
 	if err != nil {
 		logger.Error("GetByIDs error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -317,8 +645,15 @@ func (h *workexperiencesHandler) ListByIDs(c *gin.Context) {
 		}
 	}
 
+	err = h.expander.expandWorkexperiences(ctx, workexperiencess, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"workexperiencess": workexperiencess,
+		"workexperiencess": fields.project(workexperiencess),
 	})
 }
 
@@ -331,6 +666,10 @@ func (h *workexperiencesHandler) ListByIDs(c *gin.Context) {
 // @Param lastID query int true "last id, default is MaxInt32" default(0)
 // @Param limit query int false "size in each page" default(10)
 // @Param sort query string false "sort by column name of table, and the "-" sign before column name indicates reverse order" default(-id)
+// @Param q query string false "filter, e.g. startDate >= now-5y and (title ilike go or endDate is null)"
+// @Param If-None-Match header string false "the ETag got last time, 304 is responded if the list has not been modified"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListWorkexperiencessRespond{}
 // @Router /api/v1/workexperiences/list [get]
 // @Security BearerAuth
@@ -343,13 +682,38 @@ func (h *workexperiencesHandler) ListByLastID(c *gin.Context) {
 	if limit == 0 {
 		limit = 10
 	}
-	sort := c.Query("sort")
+	sort, err := workexperiencesFilter.checkSort(c.Query("sort"))
+	if err != nil {
+		logger.Warn("checkSort error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := workexperiencesFilter.queryConditions(c.Query("q"))
+	if err != nil {
+		logger.Warn("queryConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	workexperiencess, err := h.iDao.GetByLastID(ctx, lastID, limit, sort)
+	workexperiencess, err := h.iDao.GetByLastID(ctx, lastID, limit, sort, filters...)
 	if err != nil {
 		logger.Error("GetByLastID error", logger.Err(err), logger.Uint64("latsID", lastID), logger.Int("limit", limit), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -359,8 +723,98 @@ func (h *workexperiencesHandler) ListByLastID(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandWorkexperiences(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	if isNotModified(c, contentETag(data)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	response.Success(c, gin.H{
-		"workexperiencess": data,
+		"workexperiencess": fields.project(data),
+	})
+}
+
+// ListByCursor list of records by keyset paging
+// @Summary list of workexperiencess by cursor
+// @Description list of workexperiencess by keyset paging with filter columns, the first page is requested without cursor, the
+// @Description next or previous page is requested with the nextCursor or prevCursor of the last page and the same columns
+// @Tags workexperiences
+// @accept json
+// @Produce json
+// @Param data body types.ListByCursorRequest true "cursor and query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListWorkexperiencessByCursorRespond{}
+// @Router /api/v1/workexperiences/list/cursor [post]
+// @Security BearerAuth
+func (h *workexperiencesHandler) ListByCursor(c *gin.Context) {
+	form := &types.ListByCursorRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	params, err := newCursorParams(workexperiencesFilter, &model.Workexperiences{}, form)
+	if err != nil {
+		logger.Warn("newCursorParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	params.Filters = append(params.Filters, fields.selectColumns())
+
+	ctx := middleware.WrapCtx(c)
+	workexperiencess, hasMore, err := h.iDao.GetByCursor(ctx, params)
+	if err != nil {
+		logger.Error("GetByCursor error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	nextCursor, prevCursor, err := pageCursors(ctx, workexperiencess, form, params, hasMore)
+	if err != nil {
+		logger.Error("pageCursors error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListByCursorWorkexperiences)
+		return
+	}
+
+	data, err := convertWorkexperiencess(workexperiencess)
+	if err != nil {
+		response.Error(c, ecode.ErrListByCursorWorkexperiences)
+		return
+	}
+
+	err = h.expander.expandWorkexperiences(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"workexperiencess":      fields.project(data),
+		"nextCursor": nextCursor,
+		"prevCursor": prevCursor,
 	})
 }
 
@@ -371,6 +825,8 @@ func (h *workexperiencesHandler) ListByLastID(c *gin.Context) {
 // @accept json
 // @Produce json
 // @Param data body types.Params true "query parameters"
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
 // @Success 200 {object} types.ListWorkexperiencessRespond{}
 // @Router /api/v1/workexperiences/list [post]
 // @Security BearerAuth
@@ -379,15 +835,45 @@ func (h *workexperiencesHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		response.InvalidParams(c, err)
 		return
 	}
+	err = workexperiencesFilter.checkParams(&form.Params)
+	if err != nil {
+		logger.Warn("checkParams error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters, err := workexperiencesFilter.filterConditions(form.Filter)
+	if err != nil {
+		logger.Warn("filterConditions error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	if form.Sort == "" && isFilterByUser(form.Columns) {
+		form.Sort = dao.SortPositionCurrentFirst
+	}
+
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	filters = append(filters, fields.selectColumns())
 
 	ctx := middleware.WrapCtx(c)
-	workexperiencess, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	workexperiencess, total, err := h.iDao.GetByColumns(ctx, &form.Params, filters...)
 	if err != nil {
 		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		response.Out(c, daoError(err))
 		return
 	}
 
@@ -397,12 +883,171 @@ func (h *workexperiencesHandler) List(c *gin.Context) {
 		return
 	}
 
+	err = h.expander.expandWorkexperiences(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
 	response.Success(c, gin.H{
-		"workexperiencess": data,
+		"workexperiencess": fields.project(data),
 		"total":        total,
 	})
 }
 
+// ListByUserID list of all records of a user
+// @Summary list of workexperiencess of a user
+// @Description list of all workexperiencess of a user, the current position is first, then sorted by end date descending, the total years of experience are counted without the overlapping periods
+// @Tags workexperiences
+// @Param userId path string true "user id"
+// @Accept json
+// @Produce json
+// @Param fields query string false "the comma separated fields of the response, e.g. id,updatedAt, all the fields are returned by default"
+// @Param expand query string false "the related resources to expand, e.g. user"
+// @Success 200 {object} types.ListWorkexperiencessByUserIDRespond{}
+// @Router /api/v1/workexperiences/user/{userId} [get]
+// @Security BearerAuth
+func (h *workexperiencesHandler) ListByUserID(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	exp, err := parseExpand("workexperiences", c.Query("expand"))
+	if err != nil {
+		logger.Warn("parseExpand error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+	fields, err := workexperiencesFields.parseFields(c.Query("fields"), exp)
+	if err != nil {
+		logger.Warn("parseFields error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	records, err := h.iDao.ListByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("GetByUserID error", logger.Err(err), logger.Int("userId", userID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	data, err := convertWorkexperiencess(records)
+	if err != nil {
+		response.Error(c, ecode.ErrListByUserIDWorkexperiences)
+		return
+	}
+
+	err = h.expander.expandWorkexperiences(ctx, data, exp)
+	if err != nil {
+		logger.Error("expand error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return
+	}
+
+	response.Success(c, gin.H{
+		"workexperiencess": fields.project(data),
+		"totalYears":       totalExperienceYears(records, time.Now()),
+	})
+}
+
+// Reorder rewrite the positions of all records of a user
+// @Summary reorder workexperiencess of a user
+// @Description rewrite the positions of all workexperiencess of a user atomically in the order of ids, the ids must be all the workexperiencess of the user
+// @Tags workexperiences
+// @accept json
+// @Produce json
+// @Param userId path string true "user id"
+// @Param data body types.ReorderWorkexperiencessRequest true "all the ids of the user in the new order"
+// @Success 200 {object} types.ReorderWorkexperiencessRespond{}
+// @Router /api/v1/workexperiences/user/{userId}/positions [put]
+// @Security BearerAuth
+func (h *workexperiencesHandler) Reorder(c *gin.Context) {
+	userID := utils.StrToInt(c.Param("userId"))
+	if userID < 1 {
+		logger.Warn("invalid userId", logger.String("userId", c.Param("userId")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.ReorderWorkexperiencessRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.InvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Reorder(ctx, userID, form.IDs)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidPositions) {
+			logger.Warn("Reorder invalid ids", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrReorderWorkexperiences)
+		} else {
+			logger.Error("Reorder error", logger.Err(err), logger.Int("userId", userID), logger.Any("ids", form.IDs), middleware.GCtxRequestIDField(c))
+			response.Out(c, daoError(err))
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// isInvalidWorkexperiences check the dates and the primary position of the record, the error is responded if it is invalid
+func (h *workexperiencesHandler) isInvalidWorkexperiences(ctx context.Context, c *gin.Context, record *model.Workexperiences) bool {
+	e, err := h.checkWorkexperiences(ctx, record)
+	if err != nil {
+		logger.Error("checkWorkexperiences error", logger.Err(err), logger.Int("userId", record.UserID), middleware.GCtxRequestIDField(c))
+		response.Out(c, daoError(err))
+		return true
+	}
+	if e != nil {
+		logger.Warn("invalid workexperiences", logger.String("err", e.Msg()), logger.Any("record", record), middleware.GCtxRequestIDField(c))
+		response.Error(c, e)
+		return true
+	}
+
+	return false
+}
+
+// checkWorkexperiences check the dates and the primary position of the record, the business error is returned if it is invalid
+func (h *workexperiencesHandler) checkWorkexperiences(ctx context.Context, record *model.Workexperiences) (*errcode.Error, error) {
+	if !isValidOngoingDates(record.StartDate, record.EndDate, record.IsCurrent) {
+		return ecode.ErrInvalidDatesWorkexperiences, nil
+	}
+
+	if record.IsPrimary {
+		exists, err := h.iDao.HasPrimary(ctx, record.UserID, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return ecode.ErrPrimaryExistsWorkexperiences, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkBatchWorkexperiences check the record of a batch item, the primary positions of the same user
+// in the batch are also checked, primaryUserIDs is shared by the items of the batch.
+func (h *workexperiencesHandler) checkBatchWorkexperiences(ctx context.Context, record *model.Workexperiences, primaryUserIDs map[int]struct{}) (*errcode.Error, error) {
+	if record.IsPrimary {
+		if _, ok := primaryUserIDs[record.UserID]; ok {
+			return ecode.ErrPrimaryExistsWorkexperiences, nil
+		}
+		primaryUserIDs[record.UserID] = struct{}{}
+	}
+
+	return h.checkWorkexperiences(ctx, record)
+}
+
 func getWorkexperiencesIDFromPath(c *gin.Context) (string, uint64, bool) {
 	idStr := c.Param("id")
 	id, err := utils.StrToUint64E(idStr)
@@ -421,6 +1066,7 @@ func convertWorkexperiences(workexperiences *model.Workexperiences) (*types.Work
 		return nil, err
 	}
 	data.ID = utils.Uint64ToStr(workexperiences.ID)
+	data.DurationMonths = workexperiencesDurationMonths(workexperiences, time.Now())
 	return data, nil
 }
 
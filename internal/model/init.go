@@ -2,16 +2,21 @@
 package model
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/mongo"
 	"gorm.io/gorm"
 
 	"github.com/zhufuyi/sponge/pkg/ggorm"
 	"github.com/zhufuyi/sponge/pkg/goredis"
 	"github.com/zhufuyi/sponge/pkg/logger"
+	"github.com/zhufuyi/sponge/pkg/mgo"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
 	"weaving_net/internal/config"
@@ -21,13 +26,32 @@ var (
 	// ErrCacheNotFound No hit cache
 	ErrCacheNotFound = redis.Nil
 
+	// ErrCacheLocked the record is being loaded by another instance that holds the lock of the cache
+	ErrCacheLocked = errors.New("cache is locked by another instance")
+
 	// ErrRecordNotFound no records found
 	ErrRecordNotFound = gorm.ErrRecordNotFound
+
+	// ErrRecordModified the record has been modified by others since it was read
+	ErrRecordModified = errors.New("record has been modified")
+
+	// ErrInvalidPositions the ids to reorder are not exactly all the records of the user
+	ErrInvalidPositions = errors.New("ids are not all the records of the user")
+
+	// ErrNameExists the name is already used by another record
+	ErrNameExists = errors.New("name already exists")
+
+	// ErrAssessmentCooldown the assessment is retaken before the cooldown is over
+	ErrAssessmentCooldown = errors.New("assessment is in cooldown")
 )
 
 var (
-	db    *gorm.DB
-	once1 sync.Once
+	db            *gorm.DB
+	once1         sync.Once
+	replicaPlugin *ReplicaPlugin // nil if there are no read replicas
+
+	mdb   *mongo.Database // nil if the driver is not mongodb
+	once4 sync.Once
 
 	redisCli *redis.Client
 	once2    sync.Once
@@ -38,8 +62,8 @@ var (
 
 // CacheType cache type
 type CacheType struct {
-	CType string        // cache type  memory or redis
-	Rdb   *redis.Client // if CType=redis, Rdb cannot be empty
+	CType string        // cache type  memory, redis or tiered
+	Rdb   *redis.Client // if CType=redis or tiered, Rdb cannot be empty
 }
 
 // InitCache initial cache
@@ -48,7 +72,7 @@ func InitCache(cType string) {
 		CType: cType,
 	}
 
-	if cType == "redis" {
+	if cType == "redis" || cType == "tiered" {
 		cacheType.Rdb = GetRedisCli()
 	}
 }
@@ -109,11 +133,16 @@ func CloseRedis() error {
 
 // ------------------------------------------------------------------------------------------
 
-// InitDB connect database
+// InitDB connect database, postgresql is a hard dependency of both drivers. if the driver is mongodb, only the
+// profiles, i.e. the users and their records, are stored in mongodb, the skill catalog, the assessments and the
+// revisions are still stored in postgresql, so the service does not start without postgresql either.
 func InitDB() {
 	switch strings.ToLower(config.Get().Database.Driver) {
 	case ggorm.DBDriverPostgresql:
 		InitPostgresql()
+	case mgo.DBDriverName:
+		InitMongodb()
+		InitPostgresql()
 	default:
 		panic("InitDB error, unsupported database driver: " + config.Get().Database.Driver)
 	}
@@ -139,6 +168,18 @@ func InitPostgresql() {
 
 	// add custom gorm plugin
 	//opts = append(opts, ggorm.WithGormPlugin(yourPlugin))
+	opts = append(opts, ggorm.WithGormPlugin(NewRevisionPlugin())) // keep revisions of profile sections
+
+	// read replicas, the plugin of sponge supports only mysql
+	if len(config.Get().Database.Postgresql.SlavesDsn) > 0 {
+		replicas, err := openPostgresqlReplicas(config.Get().Database.Postgresql.SlavesDsn)
+		if err != nil {
+			panic("InitPostgresql error: " + err.Error())
+		}
+		interval := time.Duration(config.Get().Database.Postgresql.ReplicaCheckInterval) * time.Second
+		replicaPlugin = NewReplicaPlugin(replicas, interval)
+		opts = append(opts, ggorm.WithGormPlugin(replicaPlugin))
+	}
 
 	var dsn = utils.AdaptivePostgresqlDsn(config.Get().Database.Postgresql.Dsn)
 	var err error
@@ -148,6 +189,27 @@ func InitPostgresql() {
 	}
 }
 
+// connect the read replicas of postgresql with the settings of the connection pool of the primary
+func openPostgresqlReplicas(dsns []string) ([]*sql.DB, error) {
+	replicas := make([]*sql.DB, 0, len(dsns))
+	for i, dsn := range dsns {
+		replicaDB, err := ggorm.InitPostgresql(utils.AdaptivePostgresqlDsn(dsn),
+			ggorm.WithMaxIdleConns(config.Get().Database.Postgresql.MaxIdleConns),
+			ggorm.WithMaxOpenConns(config.Get().Database.Postgresql.MaxOpenConns),
+			ggorm.WithConnMaxLifetime(time.Duration(config.Get().Database.Postgresql.ConnMaxLifetime)*time.Minute),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("connect to replica %d error: %v", i, err)
+		}
+		sqlDB, err := replicaDB.DB()
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, sqlDB)
+	}
+	return replicas, nil
+}
+
 // GetDB get db
 func GetDB() *gorm.DB {
 	if db == nil {
@@ -159,7 +221,38 @@ func GetDB() *gorm.DB {
 	return db
 }
 
-// CloseDB close db
+// CloseDB close db and its read replicas, and mongodb if it is used
 func CloseDB() error {
+	if replicaPlugin != nil {
+		_ = replicaPlugin.Close()
+	}
+	if mdb != nil {
+		_ = mgo.Close(mdb)
+	}
 	return ggorm.CloseDB(db)
 }
+
+// IsMongodb whether the profiles, i.e. the users and their records, are stored in mongodb
+func IsMongodb() bool {
+	return strings.ToLower(config.Get().Database.Driver) == mgo.DBDriverName
+}
+
+// InitMongodb connect mongodb
+func InitMongodb() {
+	var err error
+	mdb, err = mgo.Init(config.Get().Database.Mongodb.Dsn)
+	if err != nil {
+		panic("InitMongodb error: " + err.Error())
+	}
+}
+
+// GetMongoDB get the mongodb of the profiles
+func GetMongoDB() *mongo.Database {
+	if mdb == nil {
+		once4.Do(func() {
+			InitMongodb()
+		})
+	}
+
+	return mdb
+}
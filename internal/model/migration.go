@@ -0,0 +1,142 @@
+package model
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// a migration of the schema or the data of an existing database, e.g. an index added to the gorm tags of a model
+// after its table was created, or the values of a column whose meaning has changed. the statements must be safe
+// to run again, because all the migrations are run every time the service starts.
+type migration struct {
+	name   string
+	table  string      // the migration is skipped if the table does not exist, e.g. the profiles in mongodb
+	column string      // the migration is skipped if the column already exists, e.g. a column added to a model
+	create interface{} // the model whose table is created with the indexes of its tags if it does not exist
+	stmts  []string
+}
+
+// the columns are added with a default value so that the existing records satisfy NOT NULL, the new positions
+// keep the order of creation in each user.
+func addPositionColumn(table string) *migration {
+	return &migration{
+		name:   table + " position",
+		table:  table,
+		column: "position",
+		stmts: []string{
+			`ALTER TABLE ` + table + ` ADD COLUMN position int4 NOT NULL DEFAULT 0`,
+			`UPDATE ` + table + ` SET position = r.rn FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id) AS rn FROM ` + table + `) AS r WHERE ` + table + `.id = r.id`,
+		},
+	}
+}
+
+func addColumn(table string, column string, definition string) *migration {
+	return &migration{
+		name:   table + " " + column,
+		table:  table,
+		column: column,
+		stmts:  []string{`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition},
+	}
+}
+
+// the schema migrations come first, the data migrations below use the columns and the tables they add
+var migrations = []*migration{
+	{name: "revisions table", create: &Revisions{}},
+	{name: "outbox events table", create: &OutboxEvents{}},
+	{name: "skill categories table", create: &SkillCategories{}},
+	{name: "skill catalogs table", create: &SkillCatalogs{}},
+	{name: "skill synonyms table", create: &SkillSynonyms{}},
+	{name: "skill assessments table", create: &SkillAssessments{}},
+	{name: "skill assessment questions table", create: &SkillAssessmentQuestions{}},
+	{name: "skill assessment attempts table", create: &SkillAssessmentAttempts{}},
+
+	addColumn("users", "version", "int4 NOT NULL DEFAULT 1"),
+	addColumn("educations", "is_current", "bool NOT NULL DEFAULT false"),
+	addColumn("educations", "version", "int4 NOT NULL DEFAULT 1"),
+	addPositionColumn("educations"),
+	addColumn("projects", "version", "int4 NOT NULL DEFAULT 1"),
+	addPositionColumn("projects"),
+	addColumn("skills", "proficiency", "int2 NOT NULL DEFAULT 0"),
+	addColumn("skills", "catalog_id", "int8 DEFAULT 0"),
+	addColumn("skills", "category_id", "int8 DEFAULT 0"),
+	addColumn("skills", "version", "int4 NOT NULL DEFAULT 1"),
+	addColumn("skills", "verified_level", "int2 NOT NULL DEFAULT 0"),
+	addColumn("skills", "verified_at", "timestamp"),
+	addPositionColumn("skills"),
+	{
+		name:  "skills catalog index",
+		table: "skills",
+		stmts: []string{`CREATE INDEX IF NOT EXISTS idx_skills_catalog_id ON skills (catalog_id)`},
+	},
+	addColumn("user_introductions", "version", "int4 NOT NULL DEFAULT 1"),
+	addPositionColumn("user_introductions"),
+	addColumn("workexperiences", "is_current", "bool NOT NULL DEFAULT false"),
+	addColumn("workexperiences", "is_primary", "bool NOT NULL DEFAULT false"),
+	addColumn("workexperiences", "version", "int4 NOT NULL DEFAULT 1"),
+	addPositionColumn("workexperiences"),
+
+	{
+		// the versions written concurrently before the unique index existed are renumbered in the order of writing
+		name:  "unique revision versions",
+		table: "revisions",
+		stmts: []string{
+			`UPDATE revisions SET version = r.rn FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY resource_type, resource_id ORDER BY id) AS rn FROM revisions) AS r WHERE revisions.id = r.id AND revisions.version <> r.rn`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_revisions_version ON revisions (resource_type, resource_id, version)`,
+		},
+	},
+	{
+		// the zero end date meant the record was ongoing before the end date became nullable
+		name:  "ongoing workexperiences",
+		table: "workexperiences",
+		stmts: []string{
+			`UPDATE workexperiences SET end_date = NULL, is_current = true WHERE end_date < '0001-01-02'`,
+		},
+	},
+	{
+		name:  "ongoing educations",
+		table: "educations",
+		stmts: []string{
+			`UPDATE educations SET end_date = NULL, is_current = true WHERE end_date < '0001-01-02'`,
+		},
+	},
+	{
+		// a user has at most one primary record, only the latest of the primary records set concurrently is kept
+		name:  "unique primary workexperiences",
+		table: "workexperiences",
+		stmts: []string{
+			`UPDATE workexperiences SET is_primary = false WHERE is_primary AND deleted_at IS NULL AND id NOT IN (SELECT MAX(id) FROM workexperiences WHERE is_primary AND deleted_at IS NULL GROUP BY user_id)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_workexperiences_primary ON workexperiences (user_id) WHERE is_primary AND deleted_at IS NULL`,
+		},
+	},
+}
+
+// Migrate run the migrations in order, each of them in a transaction, it stops at the first error.
+func Migrate(db *gorm.DB) error {
+	for _, m := range migrations {
+		if m.create != nil {
+			if db.Migrator().HasTable(m.create) {
+				continue
+			}
+			if err := db.Migrator().CreateTable(m.create); err != nil {
+				return fmt.Errorf("migration '%s' error: %v", m.name, err)
+			}
+			continue
+		}
+		if !db.Migrator().HasTable(m.table) || (m.column != "" && db.Migrator().HasColumn(m.table, m.column)) {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range m.stmts {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("migration '%s' error: %v", m.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:migrate?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+
+	// the tables of the profiles that do not exist are skipped, the new tables are created
+	err = Migrate(db)
+	assert.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable(&Workexperiences{}))
+	assert.True(t, db.Migrator().HasTable(&SkillCatalogs{}))
+	assert.True(t, db.Migrator().HasTable(&OutboxEvents{}))
+
+	// the duplicate versions written before the unique index existed
+	err = db.AutoMigrate(&Revisions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Migrator().DropIndex(&Revisions{}, "idx_revisions_version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []int{1, 2, 2, 3} {
+		err = db.Create(&Revisions{ResourceType: "projects", ResourceID: 1, Version: version, Snapshot: "{}"}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = db.Create(&Revisions{ResourceType: "projects", ResourceID: 2, Version: 1, Snapshot: "{}"}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// run twice, the migrations are idempotent
+	for i := 0; i < 2; i++ {
+		err = Migrate(db)
+		assert.NoError(t, err)
+	}
+	versions := []int{}
+	err = db.Model(&Revisions{}).Where("resource_id = ?", 1).Order("id").Pluck("version", &versions).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4}, versions)
+	assert.True(t, db.Migrator().HasIndex(&Revisions{}, "idx_revisions_version"))
+	err = db.Create(&Revisions{ResourceType: "projects", ResourceID: 2, Version: 1, Snapshot: "{}"}).Error
+	assert.Error(t, err)
+}
+
+func TestMigrate_workexperiences(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:migrateWorkexperiences?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+	err = db.AutoMigrate(&Workexperiences{}, &Educations{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the legacy records with a zero end date and the primary records set concurrently
+	zero, endDate := time.Time{}, time.Now()
+	records := []*Workexperiences{
+		{UserID: 1, Company: "a", EndDate: &zero, IsPrimary: true},
+		{UserID: 1, Company: "b", EndDate: &endDate, IsPrimary: true},
+		{UserID: 2, Company: "c", IsPrimary: true},
+	}
+	for _, record := range records {
+		err = db.Create(record).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = db.Create(&Educations{UserID: 1, School: "a", EndDate: &zero}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db)
+	assert.NoError(t, err)
+
+	record := &Workexperiences{}
+	err = db.First(record, records[0].ID).Error
+	assert.NoError(t, err)
+	assert.Nil(t, record.EndDate)
+	assert.True(t, record.IsCurrent)
+	assert.False(t, record.IsPrimary)
+	record = &Workexperiences{}
+	err = db.First(record, records[1].ID).Error
+	assert.NoError(t, err)
+	assert.NotNil(t, record.EndDate)
+	assert.False(t, record.IsCurrent)
+	assert.True(t, record.IsPrimary)
+	education := &Educations{}
+	err = db.First(education).Error
+	assert.NoError(t, err)
+	assert.Nil(t, education.EndDate)
+	assert.True(t, education.IsCurrent)
+
+	// a user can not have two primary records, but the deleted ones and the other users are not counted
+	err = db.Create(&Workexperiences{UserID: 1, Company: "d", IsPrimary: true}).Error
+	assert.Error(t, err)
+	err = db.Delete(&Workexperiences{}, records[1].ID).Error
+	assert.NoError(t, err)
+	err = db.Create(&Workexperiences{UserID: 1, Company: "d", IsPrimary: true}).Error
+	assert.NoError(t, err)
+}
+
+func TestMigrate_columns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:migrateColumns?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+
+	// the tables and the records of a database created before the columns were added
+	stmts := []string{
+		`CREATE TABLE users (id integer PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, first_name varchar(50), last_name varchar(50), profile_picture_url varchar(255), about text)`,
+		`CREATE TABLE workexperiences (id integer PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, user_id int4 NOT NULL, company varchar(100) NOT NULL, title varchar(100), employment_type varchar(50), job_description text, location varchar(100), start_date date, end_date date)`,
+		`CREATE TABLE skills (id integer PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, user_id int4 NOT NULL, skill_type varchar(50) NOT NULL, skill_name varchar(50) NOT NULL, proficiency_level varchar(50))`,
+		`INSERT INTO users (id, first_name) VALUES (1, 'a')`,
+		`INSERT INTO workexperiences (id, user_id, company, end_date) VALUES (1, 1, 'a', '0001-01-01'), (2, 2, 'b', NULL), (3, 1, 'c', NULL)`,
+		`INSERT INTO skills (id, user_id, skill_type, skill_name) VALUES (1, 1, 'a', 'go'), (2, 1, 'a', 'sql')`,
+	}
+	for _, stmt := range stmts {
+		err = db.Exec(stmt).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// run twice, the columns are added once
+	for i := 0; i < 2; i++ {
+		err = Migrate(db)
+		assert.NoError(t, err)
+	}
+	for _, column := range []string{"is_current", "is_primary", "position", "version"} {
+		assert.True(t, db.Migrator().HasColumn(&Workexperiences{}, column), column)
+	}
+	for _, column := range []string{"proficiency", "catalog_id", "category_id", "position", "version", "verified_level", "verified_at"} {
+		assert.True(t, db.Migrator().HasColumn(&Skills{}, column), column)
+	}
+	assert.True(t, db.Migrator().HasIndex(&Skills{}, "idx_skills_catalog_id"))
+
+	user := &Users{}
+	err = db.First(user, 1).Error
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.Version)
+	records := []*Workexperiences{}
+	err = db.Order("id").Find(&records).Error
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, []int{1, 1, 2}, []int{records[0].Position, records[1].Position, records[2].Position})
+	assert.True(t, records[0].IsCurrent)
+	assert.Nil(t, records[0].EndDate)
+	assert.Equal(t, 1, records[2].Version)
+	skills := []*Skills{}
+	err = db.Order("id").Find(&skills).Error
+	assert.NoError(t, err)
+	assert.Len(t, skills, 2)
+	assert.Equal(t, uint64(0), skills[1].CatalogID)
+	assert.Equal(t, 2, skills[1].Position)
+}
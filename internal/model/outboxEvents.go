@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+// the types of the outbox events of the changes of a record, the event type is the table name and the change,
+// e.g. projects.created
+const (
+	OutboxCreated = "created"
+	OutboxUpdated = "updated"
+	OutboxDeleted = "deleted"
+)
+
+type OutboxEvents struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	EventType    string     `gorm:"column:event_type;type:varchar(100);NOT NULL" json:"eventType"`                    // 事件类型，例如projects.created
+	ResourceType string     `gorm:"column:resource_type;type:varchar(50);NOT NULL" json:"resourceType"`               // 资源类型(表名)
+	ResourceID   uint64     `gorm:"column:resource_id;type:int8;NOT NULL" json:"resourceId"`                          // 资源ID
+	Payload      string     `gorm:"column:payload;type:jsonb" json:"payload"`                                         // 事件数据
+	PublishedAt  *time.Time `gorm:"column:published_at;type:timestamp;index:idx_outbox_published" json:"publishedAt"` // 发布时间，为空表示未发布
+}
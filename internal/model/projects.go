@@ -11,4 +11,6 @@ type Projects struct {
 	ProjectName string `gorm:"column:project_name;type:varchar(100);NOT NULL" json:"projectName"` // 项目名称
 	Role        string `gorm:"column:role;type:varchar(50)" json:"role"`                          // 所担任角色
 	Description string `gorm:"column:description;type:text" json:"description"`                   // 项目介绍/成就
+	Position    int    `gorm:"column:position;type:int4;NOT NULL" json:"position"`                // 排序位置，同一用户内从小到大排列
+	Version     int    `gorm:"column:version;type:int4;NOT NULL;default:1" json:"version"`        // 版本号，每次更新加1，用于乐观锁
 }
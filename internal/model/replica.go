@@ -0,0 +1,216 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/zhufuyi/sponge/pkg/logger"
+)
+
+// DefaultReplicaCheckInterval the default interval of the health checks of the read replicas
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+type replicaCtxKey struct{}
+
+type primaryPinCtxKey struct{}
+
+// WithReplica the queries with the returned context read a replica if there is a healthy one, e.g. the queries
+// of the records that may be a little stale, the other queries read the primary. after a write with a context of
+// WithReadYourWrites, the queries with the context read the primary too.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaCtxKey{}, true)
+}
+
+// WithReadYourWrites pin the queries with the returned context, or the contexts derived from it, to the primary
+// after the first write with them, so that a request reads its own writes, e.g. the context of a http request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryPinCtxKey{}, new(atomic.Bool))
+}
+
+// whether the queries with the context can read a replica
+func isReplicaRead(ctx context.Context) bool {
+	if ctx == nil || ctx.Value(replicaCtxKey{}) == nil {
+		return false
+	}
+	pinned, ok := ctx.Value(primaryPinCtxKey{}).(*atomic.Bool)
+	return !ok || !pinned.Load()
+}
+
+type replica struct {
+	db      *sql.DB
+	name    string // the index of the replica in the configuration, the dsn is not logged
+	healthy atomic.Bool
+}
+
+// ReplicaPlugin a gorm plugin of the read replicas by dbresolver, only the reads with the context of WithReplica
+// are routed to the replicas, the writes, the transactions and the other reads use the primary. the replicas are
+// pinged every check interval, the unhealthy ones are not read, and the primary is read if none is healthy.
+type ReplicaPlugin struct {
+	replicas []*replica
+	interval time.Duration
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewReplicaPlugin create a plugin of the connections of the replicas, they are closed by Close
+func NewReplicaPlugin(dbs []*sql.DB, checkInterval time.Duration) *ReplicaPlugin {
+	if checkInterval <= 0 {
+		checkInterval = DefaultReplicaCheckInterval
+	}
+	p := &ReplicaPlugin{interval: checkInterval, done: make(chan struct{})}
+	for i, db := range dbs {
+		r := &replica{db: db, name: fmt.Sprintf("replica-%d", i)}
+		r.healthy.Store(true)
+		p.replicas = append(p.replicas, r)
+	}
+	return p
+}
+
+// Name plugin name
+func (p *ReplicaPlugin) Name() string {
+	return "weaving_net:replica"
+}
+
+// Initialize register dbresolver with the replicas and the callbacks that route the reads and pin the primary
+func (p *ReplicaPlugin) Initialize(db *gorm.DB) error {
+	dialectors := make([]gorm.Dialector, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		dialector, err := replicaDialector(db.Dialector, r.db)
+		if err != nil {
+			return err
+		}
+		dialectors = append(dialectors, dialector)
+	}
+	err := db.Use(dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: p}))
+	if err != nil {
+		return err
+	}
+
+	err = db.Callback().Query().After("gorm:db_resolver").Before("gorm:query").Register(p.Name(), p.routeRead)
+	if err != nil {
+		return err
+	}
+	err = db.Callback().Row().After("gorm:db_resolver").Before("gorm:row").Register(p.Name(), p.routeRead)
+	if err != nil {
+		return err
+	}
+	for _, processor := range []interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}{db.Callback().Create(), db.Callback().Update(), db.Callback().Delete(), db.Callback().Raw()} {
+		err = processor.Register(p.Name()+":pin", pinPrimary)
+		if err != nil {
+			return err
+		}
+	}
+
+	go p.check()
+	return nil
+}
+
+// the dialector of a replica of the same database as the primary
+func replicaDialector(primary gorm.Dialector, conn *sql.DB) (gorm.Dialector, error) {
+	switch primary.Name() {
+	case "postgres":
+		return postgres.New(postgres.Config{Conn: conn}), nil
+	case "mysql":
+		return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), nil
+	}
+	return nil, fmt.Errorf("read replicas are not supported by %s", primary.Name())
+}
+
+// route the reads without WithReplica to the primary, and all the reads if no replica is healthy
+func (p *ReplicaPlugin) routeRead(db *gorm.DB) {
+	if !isReplicaRead(db.Statement.Context) || !p.hasHealthy() {
+		dbresolver.Write.ModifyStatement(db.Statement)
+	}
+}
+
+// pin the queries after a successful write to the primary, see WithReadYourWrites
+func pinPrimary(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+	if pinned, ok := db.Statement.Context.Value(primaryPinCtxKey{}).(*atomic.Bool); ok {
+		pinned.Store(true)
+	}
+}
+
+// Resolve choose a random healthy replica, implements dbresolver.Policy
+func (p *ReplicaPlugin) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]gorm.ConnPool, 0, len(connPools))
+	for _, r := range p.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r.db)
+		}
+	}
+	if len(healthy) == 0 {
+		// the reads have been routed to the primary by routeRead, a replica was down since then
+		return connPools[rand.Intn(len(connPools))] //nolint
+	}
+	return healthy[rand.Intn(len(healthy))] //nolint
+}
+
+func (p *ReplicaPlugin) hasHealthy() bool {
+	for _, r := range p.replicas {
+		if r.healthy.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// ping the replicas every check interval until the plugin is closed
+func (p *ReplicaPlugin) check() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.ping()
+		}
+	}
+}
+
+func (p *ReplicaPlugin) ping() {
+	for _, r := range p.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+		err := r.db.PingContext(ctx)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			logger.Info("read replica is healthy again", logger.String("replica", r.name))
+		} else {
+			logger.Warn("read replica is unhealthy, it is not read until it recovers", logger.Err(err), logger.String("replica", r.name))
+		}
+	}
+}
+
+// Close stop the health checks and close the connections of the replicas
+func (p *ReplicaPlugin) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.done)
+		for _, r := range p.replicas {
+			if e := r.db.Close(); e != nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
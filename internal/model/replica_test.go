@@ -0,0 +1,88 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func newReplicaDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, sqlmock.Sqlmock, *ReplicaPlugin) {
+	primarySQL, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicaSQL, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: primarySQL, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicaMock.ExpectPing() // dbresolver opens the replica
+	p := NewReplicaPlugin([]*sql.DB{replicaSQL}, time.Hour)
+	err = db.Use(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, primaryMock, replicaMock, p
+}
+
+func TestReplicaPlugin(t *testing.T) {
+	db, primaryMock, replicaMock, p := newReplicaDB(t)
+	defer p.Close()
+	ctx := context.Background()
+	rows := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"id"}).AddRow(1) }
+
+	// only the reads with WithReplica read the replica
+	replicaMock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	err := db.WithContext(WithReplica(ctx)).First(&Users{}).Error
+	assert.NoError(t, err)
+	primaryMock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	err = db.WithContext(ctx).First(&Users{}).Error
+	assert.NoError(t, err)
+
+	// the reads of a context are pinned to the primary after it writes
+	pinCtx := WithReadYourWrites(ctx)
+	replicaMock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	err = db.WithContext(WithReplica(pinCtx)).First(&Users{}).Error
+	assert.NoError(t, err)
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	primaryMock.ExpectCommit()
+	err = db.WithContext(pinCtx).Model(&Users{}).Where("id = ?", 1).Update("about", "foo").Error
+	assert.NoError(t, err)
+	primaryMock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	err = db.WithContext(WithReplica(pinCtx)).First(&Users{}).Error
+	assert.NoError(t, err)
+
+	// the primary is read while the replica is unhealthy
+	replicaMock.ExpectPing().WillReturnError(errors.New("mock error"))
+	p.ping()
+	primaryMock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	err = db.WithContext(WithReplica(ctx)).First(&Users{}).Error
+	assert.NoError(t, err)
+	replicaMock.ExpectPing()
+	p.ping()
+	replicaMock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	err = db.WithContext(WithReplica(ctx)).First(&Users{}).Error
+	assert.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicaPlugin_Close(t *testing.T) {
+	_, _, replicaMock, p := newReplicaDB(t)
+	replicaMock.ExpectClose()
+	assert.NoError(t, p.Close())
+	assert.NoError(t, p.Close())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
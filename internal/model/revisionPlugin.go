@@ -0,0 +1,270 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tables that keep revisions before every update, key is table name, value is the json fields
+// kept in the snapshot, if the value is empty, all fields except id and time are kept.
+var revisionTables = map[string][]string{
+	"workexperiences":    nil,
+	"projects":           nil,
+	"user_introductions": nil,
+	"users":              {"about"},
+}
+
+// fields of ggorm.Model, the version and the sort position that are never kept in the snapshot
+var revisionIgnoreFields = []string{"id", "createdAt", "updatedAt", "version", "position"}
+
+// IsRevisionTable determine if the table keeps revisions
+func IsRevisionTable(table string) bool {
+	_, ok := revisionTables[table]
+	return ok
+}
+
+// RevisionSnapshot convert a record of a revision table to a snapshot, only the tracked fields are kept
+func RevisionSnapshot(table string, record interface{}) (map[string]interface{}, error) {
+	fields, ok := revisionTables[table]
+	if !ok {
+		return nil, fmt.Errorf("table '%s' does not keep revisions", table)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		for _, field := range revisionIgnoreFields {
+			delete(values, field)
+		}
+		return values, nil
+	}
+
+	snapshot := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		snapshot[field] = values[field]
+	}
+	return snapshot, nil
+}
+
+// the times that a revision is saved by SaveRevision if its version is taken by another one
+const saveRevisionRetries = 3
+
+// the key of the revision of the record updated by a statement, it is got before the update and saved after it
+const revisionInstanceKey = "weaving_net:revision"
+
+type revisionPlugin struct{}
+
+// NewRevisionPlugin create a gorm plugin that saves a snapshot of the record to the revisions table
+// when it is updated, the snapshot is taken before the update and written after it in the same transaction,
+// only if the update changes the record, e.g. an update with a stale version does not leave a revision.
+func NewRevisionPlugin() gorm.Plugin {
+	return &revisionPlugin{}
+}
+
+// Name plugin name
+func (p *revisionPlugin) Name() string {
+	return "weaving_net:revision"
+}
+
+// Initialize register the callbacks
+func (p *revisionPlugin) Initialize(db *gorm.DB) error {
+	err := db.Callback().Update().After("gorm:before_update").Before("gorm:update").Register(p.Name()+":snapshot", takeRevision)
+	if err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Before("gorm:after_update").Register(p.Name(), saveRevision)
+}
+
+// take the snapshot of the record before it is updated, the record is locked until the transaction ends,
+// so that the concurrent updates of the record are serialized and their revisions are not duplicated.
+func takeRevision(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	table := stmt.Schema.Table
+	fields, ok := revisionTables[table]
+	if !ok || !isUpdateRevisionFields(stmt, fields) {
+		return
+	}
+
+	// only a single record updated by primary key is tracked, e.g. soft deletion by condition is ignored
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil || stmt.ReflectValue.Kind() != reflect.Struct {
+		return
+	}
+	pkValue, isZero := pk.ValueOf(stmt.Context, stmt.ReflectValue)
+	id, ok := pkValue.(uint64)
+	if isZero || !ok {
+		return
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	record := reflect.New(stmt.Schema.ModelType)
+	err := tx.Table(table).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(record.Interface()).Error
+	if err != nil {
+		if err != ErrRecordNotFound {
+			_ = db.AddError(fmt.Errorf("get revision record error: %v", err))
+		}
+		return
+	}
+
+	userID := int(id)
+	if field := stmt.Schema.LookUpField("user_id"); field != nil {
+		v, _ := field.ValueOf(stmt.Context, record.Elem())
+		userID, _ = v.(int)
+	}
+	revision, err := newRevision(table, id, userID, record.Interface())
+	if err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	db.InstanceSet(revisionInstanceKey, revision)
+}
+
+// save the snapshot taken before the update with the next version, only if the update changes the record
+func saveRevision(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	value, ok := db.InstanceGet(revisionInstanceKey)
+	if !ok {
+		return
+	}
+
+	err := createRevision(db.Session(&gorm.Session{NewDB: true}), value.(*Revisions))
+	if err != nil {
+		_ = db.AddError(err)
+	}
+}
+
+// SaveRevision save the revision of a record that is updated without gorm, e.g. the profiles in mongodb, record is
+// the record before the update and columns are the columns of the update, see NewRevisionPlugin. it is skipped
+// if the table does not keep revisions or the update does not change the fields kept in the snapshot.
+// the record is not locked, so a revision saved at the same time may take the version, then it is saved again
+// with the next version.
+func SaveRevision(ctx context.Context, db *gorm.DB, sch *schema.Schema, id uint64, record interface{}, columns map[string]interface{}) error {
+	fields, ok := revisionTables[sch.Table]
+	if !ok || !isRevisionColumns(sch, fields, columns) {
+		return nil
+	}
+
+	userID := int(id)
+	if field := sch.LookUpField("user_id"); field != nil {
+		v, _ := field.ValueOf(ctx, reflect.Indirect(reflect.ValueOf(record)))
+		userID, _ = v.(int)
+	}
+	revision, err := newRevision(sch.Table, id, userID, record)
+	if err != nil {
+		return err
+	}
+	for i := 1; ; i++ {
+		err = createRevision(db.WithContext(ctx), revision)
+		if err == nil || i >= saveRevisionRetries {
+			return err
+		}
+	}
+}
+
+// create the revision of a record of a revision table with its snapshot, the version is set by createRevision
+func newRevision(table string, id uint64, userID int, record interface{}) (*Revisions, error) {
+	snapshot, err := RevisionSnapshot(table, record)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &Revisions{
+		ResourceType: table,
+		ResourceID:   id,
+		UserID:       userID,
+		Snapshot:     string(data),
+	}, nil
+}
+
+// save the revision with the next version of its resource after the resource is updated in the same transaction,
+// the version is unique, see idx_revisions_version.
+func createRevision(tx *gorm.DB, revision *Revisions) error {
+	var version int
+	err := tx.Model(&Revisions{}).Select("COALESCE(MAX(version), 0)").
+		Where("resource_type = ? AND resource_id = ?", revision.ResourceType, revision.ResourceID).Scan(&version).Error
+	if err != nil {
+		return fmt.Errorf("get revision version error: %v", err)
+	}
+
+	revision.Version = version + 1
+	err = tx.Create(revision).Error
+	if err != nil {
+		return fmt.Errorf("save revision error: %v", err)
+	}
+	return nil
+}
+
+// determine if the update statement changes the fields kept in the snapshot
+func isUpdateRevisionFields(stmt *gorm.Statement, fields []string) bool {
+	dest, ok := stmt.Dest.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	return isRevisionColumns(stmt.Schema, fields, dest)
+}
+
+// determine if the columns of an update change the fields kept in the snapshot, if all the fields are kept, an
+// update of only the fields that are never kept, e.g. the positions and the versions rewritten by a reorder,
+// does not change them.
+func isRevisionColumns(sch *schema.Schema, fields []string, columns map[string]interface{}) bool {
+	for column := range columns {
+		field := sch.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		name := jsonFieldName(field)
+		if len(fields) == 0 {
+			if !isRevisionIgnoreField(name) {
+				return true
+			}
+			continue
+		}
+		for _, f := range fields {
+			if name == f {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isRevisionIgnoreField(name string) bool {
+	for _, field := range revisionIgnoreFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonFieldName(field *schema.Field) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
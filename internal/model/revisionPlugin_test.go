@@ -0,0 +1,115 @@
+package model
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestSaveRevision(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:saveRevision?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+	err = db.AutoMigrate(&Revisions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	parse := func(table interface{}) *schema.Schema {
+		sch, err := schema.Parse(table, &sync.Map{}, schema.NamingStrategy{SingularTable: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sch
+	}
+
+	// only the updates of the fields kept in the snapshot are saved
+	user := &Users{FirstName: "Ada", About: "mathematician"}
+	user.ID = 1
+	err = SaveRevision(ctx, db, parse(user), user.ID, user, map[string]interface{}{"last_name": "Byron"})
+	assert.NoError(t, err)
+	for _, about := range []string{"poet", "writer"} {
+		err = SaveRevision(ctx, db, parse(user), user.ID, user, map[string]interface{}{"about": about})
+		assert.NoError(t, err)
+	}
+	revisions := []*Revisions{}
+	err = db.Order("version").Find(&revisions).Error
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, "users", revisions[1].ResourceType)
+		assert.Equal(t, 2, revisions[1].Version)
+		assert.Equal(t, 1, revisions[1].UserID)
+		assert.JSONEq(t, `{"about":"mathematician"}`, revisions[1].Snapshot)
+	}
+
+	// the user of a child record and the tables that do not keep revisions
+	project := &Projects{UserID: 2, ProjectName: "loom"}
+	project.ID = 3
+	err = SaveRevision(ctx, db, parse(project), project.ID, project, map[string]interface{}{"project_name": "weaving"})
+	assert.NoError(t, err)
+	revision := &Revisions{}
+	err = db.Where("resource_type = ?", "projects").First(revision).Error
+	assert.NoError(t, err)
+	assert.Equal(t, 2, revision.UserID)
+	err = SaveRevision(ctx, db, parse(&Skills{}), 4, &Skills{}, map[string]interface{}{"skill_name": "go"})
+	assert.NoError(t, err)
+
+	// the positions and the versions rewritten by a reorder are not kept
+	err = SaveRevision(ctx, db, parse(project), project.ID, project, map[string]interface{}{"position": 2, "version": 2, "updated_at": time.Now()})
+	assert.NoError(t, err)
+	var total int64
+	err = db.Model(&Revisions{}).Count(&total).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+}
+
+func TestNewRevisionPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:revisionPlugin?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+	err = db.Use(NewRevisionPlugin())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&Revisions{}, &Projects{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := &Projects{UserID: 1, ProjectName: "loom", Position: 1, Version: 1}
+	err = db.Create(project).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a reorder does not flood the history
+	err = db.Model(project).Updates(map[string]interface{}{"position": 2, "version": gorm.Expr("version + 1")}).Error
+	assert.NoError(t, err)
+	var total int64
+	err = db.Model(&Revisions{}).Count(&total).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	err = db.Model(project).Updates(map[string]interface{}{"project_name": "weaving", "version": gorm.Expr("version + 1")}).Error
+	assert.NoError(t, err)
+	revision := &Revisions{}
+	err = db.First(revision).Error
+	assert.NoError(t, err)
+	assert.Equal(t, 1, revision.Version)
+	assert.JSONEq(t, `{"userId":1,"projectName":"loom","role":"","description":""}`, revision.Snapshot)
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type Revisions struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	ResourceType string `gorm:"column:resource_type;type:varchar(50);NOT NULL;uniqueIndex:idx_revisions_version" json:"resourceType"` // 资源类型(表名)
+	ResourceID   uint64 `gorm:"column:resource_id;type:int8;NOT NULL;uniqueIndex:idx_revisions_version" json:"resourceId"`            // 资源ID
+	UserID       int    `gorm:"column:user_id;type:int4" json:"userId"`                                                               // 用户ID
+	Version      int    `gorm:"column:version;type:int4;NOT NULL;uniqueIndex:idx_revisions_version" json:"version"`                   // 版本号，同一资源内唯一
+	Snapshot     string `gorm:"column:snapshot;type:jsonb" json:"snapshot"`                                                           // 更新前的数据快照
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type SkillAssessmentAttempts struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	AssessmentID uint64 `gorm:"column:assessment_id;type:int8;NOT NULL;index:idx_attempt_user" json:"assessmentId"` // 测评ID
+	UserID       int    `gorm:"column:user_id;type:int4;NOT NULL;index:idx_attempt_user" json:"userId"`             // 用户ID
+	SkillID      uint64 `gorm:"column:skill_id;type:int8;NOT NULL" json:"skillId"`                                  // 认证的技能ID
+	Score        int    `gorm:"column:score;type:int4;NOT NULL" json:"score"`                                       // 得分，满分100
+	Level        int    `gorm:"column:level;type:int2;NOT NULL" json:"level"`                                       // 得分对应的熟练程度等级
+	Passed       bool   `gorm:"column:passed;type:bool;NOT NULL" json:"passed"`                                     // 是否通过
+}
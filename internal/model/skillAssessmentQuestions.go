@@ -0,0 +1,15 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type SkillAssessmentQuestions struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	AssessmentID uint64 `gorm:"column:assessment_id;type:int8;NOT NULL;index" json:"assessmentId"` // 测评ID
+	Content      string `gorm:"column:content;type:varchar(500);NOT NULL" json:"content"`          // 题目内容
+	Options      string `gorm:"column:options;type:text;NOT NULL" json:"options"`                  // 选项，json字符串数组
+	Answer       int    `gorm:"column:answer;type:int4;NOT NULL" json:"-"`                         // 正确选项的下标，从0开始，不返回给客户端
+	Position     int    `gorm:"column:position;type:int4;NOT NULL" json:"position"`                // 题目顺序
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type SkillAssessments struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	CatalogID     uint64 `gorm:"column:catalog_id;type:int8;NOT NULL;index" json:"catalogId"`   // 测评的标准技能ID
+	Title         string `gorm:"column:title;type:varchar(100);NOT NULL" json:"title"`          // 标题
+	PassScore     int    `gorm:"column:pass_score;type:int4;NOT NULL" json:"passScore"`         // 通过分数，满分100
+	CooldownHours int    `gorm:"column:cooldown_hours;type:int4;NOT NULL" json:"cooldownHours"` // 重新测评的冷却时间，单位小时
+}
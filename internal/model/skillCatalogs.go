@@ -0,0 +1,14 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type SkillCatalogs struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	Name           string `gorm:"column:name;type:varchar(50);NOT NULL" json:"name"`                                  // 标准技能名称
+	NormalizedName string `gorm:"column:normalized_name;type:varchar(50);NOT NULL;uniqueIndex" json:"normalizedName"` // 归一化名称，用于匹配和搜索
+	CategoryID     uint64 `gorm:"column:category_id;type:int8;index" json:"categoryId"`                               // 分类ID
+	ParentID       uint64 `gorm:"column:parent_id;type:int8;index" json:"parentId"`                                   // 父技能ID，0表示顶级技能
+}
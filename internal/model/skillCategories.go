@@ -0,0 +1,12 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type SkillCategories struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	Name        string `gorm:"column:name;type:varchar(50);NOT NULL;uniqueIndex" json:"name"` // 分类名称
+	Description string `gorm:"column:description;type:varchar(255)" json:"description"`       // 分类描述
+}
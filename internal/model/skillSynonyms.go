@@ -0,0 +1,13 @@
+package model
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+)
+
+type SkillSynonyms struct {
+	ggorm.Model `gorm:"embedded"` // embed id and time
+
+	CatalogID      uint64 `gorm:"column:catalog_id;type:int8;NOT NULL;index" json:"catalogId"`                        // 标准技能ID
+	Name           string `gorm:"column:name;type:varchar(50);NOT NULL" json:"name"`                                  // 同义词
+	NormalizedName string `gorm:"column:normalized_name;type:varchar(50);NOT NULL;uniqueIndex" json:"normalizedName"` // 归一化名称，用于匹配和搜索
+}
@@ -1,14 +1,79 @@
 package model
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/zhufuyi/sponge/pkg/ggorm"
 )
 
+// the proficiency scale of the skills, 0 means not set
+const (
+	ProficiencyBeginner     = 1
+	ProficiencyElementary   = 2
+	ProficiencyIntermediate = 3
+	ProficiencyAdvanced     = 4
+	ProficiencyExpert       = 5
+)
+
+var (
+	proficiencyNames = []string{"", "beginner", "elementary", "intermediate", "advanced", "expert"}
+
+	// the legacy free text proficiency levels that are mapped onto the scale
+	proficiencyAliases = map[string]int{
+		"novice": ProficiencyBeginner, "入门": ProficiencyBeginner, "了解": ProficiencyBeginner,
+		"basic": ProficiencyElementary, "junior": ProficiencyElementary, "初级": ProficiencyElementary,
+		"一般": ProficiencyElementary, "medium": ProficiencyIntermediate, "competent": ProficiencyIntermediate,
+		"中级": ProficiencyIntermediate, "熟悉": ProficiencyIntermediate, "熟练": ProficiencyIntermediate,
+		"senior": ProficiencyAdvanced, "proficient": ProficiencyAdvanced, "高级": ProficiencyAdvanced,
+		"精通": ProficiencyExpert, "master": ProficiencyExpert, "专家": ProficiencyExpert,
+	}
+)
+
 type Skills struct {
 	ggorm.Model `gorm:"embedded"` // embed id and time
 
-	UserID           int    `gorm:"column:user_id;type:int4;NOT NULL" json:"userId"`                   // 用户ID
-	SkillType        string `gorm:"column:skill_type;type:varchar(50);NOT NULL" json:"skillType"`      // 技能类型
-	SkillName        string `gorm:"column:skill_name;type:varchar(50);NOT NULL" json:"skillName"`      // 技能名称
-	ProficiencyLevel string `gorm:"column:proficiency_level;type:varchar(50)" json:"proficiencyLevel"` // 熟练程度
+	UserID           int        `gorm:"column:user_id;type:int4;NOT NULL" json:"userId"`                         // 用户ID
+	SkillType        string     `gorm:"column:skill_type;type:varchar(50);NOT NULL" json:"skillType"`            // 技能类型，已由分类替代，保存分类名称
+	SkillName        string     `gorm:"column:skill_name;type:varchar(50);NOT NULL" json:"skillName"`            // 技能名称
+	ProficiencyLevel string     `gorm:"column:proficiency_level;type:varchar(50)" json:"proficiencyLevel"`       // 熟练程度，已由熟练程度等级替代，保存等级名称
+	Proficiency      int        `gorm:"column:proficiency;type:int2;NOT NULL;default:0" json:"proficiency"`      // 熟练程度等级，1入门 2初级 3中级 4高级 5专家，0表示未设置
+	CatalogID        uint64     `gorm:"column:catalog_id;type:int8;index" json:"catalogId"`                      // 标准技能ID，0表示未匹配到技能目录
+	CategoryID       uint64     `gorm:"column:category_id;type:int8" json:"categoryId"`                          // 技能分类ID
+	Position         int        `gorm:"column:position;type:int4;NOT NULL" json:"position"`                      // 排序位置，同一用户内从小到大排列
+	Version          int        `gorm:"column:version;type:int4;NOT NULL;default:1" json:"version"`              // 版本号，每次更新加1，用于乐观锁
+	VerifiedLevel    int        `gorm:"column:verified_level;type:int2;NOT NULL;default:0" json:"verifiedLevel"` // 通过测评认证的熟练程度等级，0表示未认证
+	VerifiedAt       *time.Time `gorm:"column:verified_at;type:timestamp" json:"verifiedAt"`                     // 通过测评认证的时间
+}
+
+// ProficiencyName the name of a proficiency level, e.g. 3 is "intermediate", an empty string is returned if it is out of the scale
+func ProficiencyName(level int) string {
+	if !IsValidProficiency(level) {
+		return ""
+	}
+	return proficiencyNames[level]
+}
+
+// IsValidProficiency determine if the proficiency level is on the scale
+func IsValidProficiency(level int) bool {
+	return level >= ProficiencyBeginner && level <= ProficiencyExpert
+}
+
+// ParseProficiency map a free text proficiency level onto the scale, the names of the scale, their aliases
+// and the numbers 1~5 are accepted case-insensitively, 0 is returned if the text is unknown.
+func ParseProficiency(text string) int {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if n, err := strconv.Atoi(text); err == nil {
+		if IsValidProficiency(n) {
+			return n
+		}
+		return 0
+	}
+	for level, name := range proficiencyNames {
+		if name != "" && name == text {
+			return level
+		}
+	}
+	return proficiencyAliases[text]
 }
@@ -7,7 +7,9 @@ import (
 type UserIntroductions struct {
 	ggorm.Model `gorm:"embedded"` // embed id and time
 
-	UserID  int    `gorm:"column:user_id;type:int4;NOT NULL" json:"userId"`
-	Title   string `gorm:"column:title;type:varchar(100);NOT NULL" json:"title"` // 介绍标题
-	Content string `gorm:"column:content;type:text" json:"content"`              // 介绍内容
+	UserID   int    `gorm:"column:user_id;type:int4;NOT NULL" json:"userId"`
+	Title    string `gorm:"column:title;type:varchar(100);NOT NULL" json:"title"`       // 介绍标题
+	Content  string `gorm:"column:content;type:text" json:"content"`                    // 介绍内容
+	Position int    `gorm:"column:position;type:int4;NOT NULL" json:"position"`         // 排序位置，同一用户内从小到大排列
+	Version  int    `gorm:"column:version;type:int4;NOT NULL;default:1" json:"version"` // 版本号，每次更新加1，用于乐观锁
 }
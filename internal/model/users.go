@@ -11,4 +11,5 @@ type Users struct {
 	LastName          string `gorm:"column:last_name;type:varchar(50);NOT NULL" json:"lastName"`            // 姓氏
 	ProfilePictureUrl string `gorm:"column:profile_picture_url;type:varchar(255)" json:"profilePictureUrl"` // 头像URL
 	About             string `gorm:"column:about;type:text" json:"about"`                                   // 个人简介
+	Version           int    `gorm:"column:version;type:int4;NOT NULL;default:1" json:"version"`            // 版本号，每次更新加1，用于乐观锁
 }
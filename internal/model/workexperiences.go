@@ -8,12 +8,16 @@ import (
 type Workexperiences struct {
 	ggorm.Model `gorm:"embedded"` // embed id and time
 
-	UserID         int       `gorm:"column:user_id;type:int4;NOT NULL" json:"userId"`               // 用户ID
-	Company        string    `gorm:"column:company;type:varchar(100);NOT NULL" json:"company"`      // 公司
-	Title          string    `gorm:"column:title;type:varchar(50)" json:"title"`                    // 职位
-	EmploymentType string    `gorm:"column:employment_type;type:varchar(50)" json:"employmentType"` // 工作类型
-	JobDescription string    `gorm:"column:job_description;type:text" json:"jobDescription"`        // 工作内容
-	Location       string    `gorm:"column:location;type:varchar(100)" json:"location"`             // 地点
-	StartDate      time.Time `gorm:"column:start_date;type:date" json:"startDate"`                  // 开始日期
-	EndDate        time.Time `gorm:"column:end_date;type:date" json:"endDate"`                      // 结束日期
+	UserID         int        `gorm:"column:user_id;type:int4;NOT NULL" json:"userId"`               // 用户ID
+	Company        string     `gorm:"column:company;type:varchar(100);NOT NULL" json:"company"`      // 公司
+	Title          string     `gorm:"column:title;type:varchar(50)" json:"title"`                    // 职位
+	EmploymentType string     `gorm:"column:employment_type;type:varchar(50)" json:"employmentType"` // 工作类型
+	JobDescription string     `gorm:"column:job_description;type:text" json:"jobDescription"`        // 工作内容
+	Location       string     `gorm:"column:location;type:varchar(100)" json:"location"`             // 地点
+	StartDate      time.Time  `gorm:"column:start_date;type:date" json:"startDate"`                  // 开始日期
+	EndDate        *time.Time `gorm:"column:end_date;type:date" json:"endDate"`                      // 结束日期，为空表示至今
+	IsCurrent      bool       `gorm:"column:is_current;type:bool;NOT NULL" json:"isCurrent"`         // 是否在职
+	IsPrimary      bool       `gorm:"column:is_primary;type:bool;NOT NULL" json:"isPrimary"`         // 是否为主要职位，每个用户最多一个，见idx_workexperiences_primary
+	Position       int        `gorm:"column:position;type:int4;NOT NULL" json:"position"`            // 排序位置，同一用户内从小到大排列
+	Version        int        `gorm:"column:version;type:int4;NOT NULL;default:1" json:"version"`    // 版本号，每次更新加1，用于乐观锁
 }
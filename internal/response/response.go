@@ -0,0 +1,202 @@
+// Package response writes all the http responses in the same envelope, the errors have the details of the
+// invalid fields, and all the responses have the request id and the trace id for troubleshooting.
+//
+// the errors are written as RFC 7807 problem details if the client accepts application/problem+json.
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+)
+
+// ProblemContentType the content type of the RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// Result the envelope of the responses
+type Result struct {
+	Code      int         `json:"code"`
+	Msg       string      `json:"msg"`
+	Data      interface{} `json:"data"`
+	Details   []*Detail   `json:"details,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+	TraceID   string      `json:"traceId,omitempty"`
+}
+
+// Detail the error of a field of the request, the field is empty if the error is not caused by a field
+type Detail struct {
+	Field string `json:"field,omitempty"`
+	Msg   string `json:"msg"`
+}
+
+// Problem the RFC 7807 problem details of an error
+type Problem struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Status    int       `json:"status"`
+	Instance  string    `json:"instance,omitempty"`
+	Code      int       `json:"code"`
+	Details   []*Detail `json:"details,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	TraceID   string    `json:"traceId,omitempty"`
+}
+
+// Success respond the data with http status 200
+func Success(c *gin.Context, data ...interface{}) {
+	write(c, http.StatusOK, errcode.Success, nil, data...)
+}
+
+// Error respond the error with http status 200, the code in the body tells the error, it is used by the errors
+// that the handlers find in the requests, e.g. invalid parameters and the business rules.
+func Error(c *gin.Context, err *errcode.Error, data ...interface{}) {
+	statusCode := http.StatusOK
+	if acceptsProblem(c) {
+		statusCode = HTTPStatus(err)
+	}
+	write(c, statusCode, err, nil, data...)
+}
+
+// Out respond the error with its http status, e.g. 404 for not found and 409 for conflict, it is used by the
+// errors of the dao.
+func Out(c *gin.Context, err *errcode.Error, data ...interface{}) {
+	write(c, HTTPStatus(err), err, nil, data...)
+}
+
+// OutWithStatus respond the error with the http status, e.g. 412 and 428 of the preconditions
+func OutWithStatus(c *gin.Context, statusCode int, err *errcode.Error, data ...interface{}) {
+	write(c, statusCode, err, nil, data...)
+}
+
+// InvalidParams respond the invalid parameters error with the details of err, the details of the validation
+// errors are the json names of the fields with the failed rules.
+func InvalidParams(c *gin.Context, err error) {
+	statusCode := http.StatusOK
+	if acceptsProblem(c) {
+		statusCode = http.StatusBadRequest
+	}
+	write(c, statusCode, errcode.InvalidParams, ErrorDetails(err))
+}
+
+// HTTPStatus the http status of the error, the business errors are the errors of the requests
+func HTTPStatus(err *errcode.Error) int {
+	switch err.Code() {
+	case errcode.FailedPrecondition.Code():
+		return http.StatusConflict
+	case errcode.DeadlineExceeded.Code():
+		return http.StatusGatewayTimeout
+	}
+	if err.Code() >= errcode.HCode(1) {
+		return http.StatusBadRequest
+	}
+	return err.ToHTTPCode()
+}
+
+// ErrorDetails the details of the error of a request
+func ErrorDetails(err error) []*Detail {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := []*Detail{}
+		for _, e := range validationErrs {
+			field := e.Namespace()
+			if i := strings.Index(field, "."); i >= 0 { // remove the name of the struct
+				field = field[i+1:]
+			}
+			msg := fmt.Sprintf("failed on the '%s' rule", e.Tag())
+			if e.Param() != "" {
+				msg = fmt.Sprintf("failed on the '%s=%s' rule", e.Tag(), e.Param())
+			}
+			details = append(details, &Detail{Field: field, Msg: msg})
+		}
+		return details
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []*Detail{{Field: typeErr.Field, Msg: "cannot be " + typeErr.Value + ", must be " + typeErr.Type.String()}}
+	}
+
+	return []*Detail{{Msg: err.Error()}}
+}
+
+// JSONFieldName the json name of a struct field, it is registered to the validator so that the fields of the
+// validation errors are named as the clients send them.
+func JSONFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func write(c *gin.Context, statusCode int, err *errcode.Error, details []*Detail, data ...interface{}) {
+	requestID, traceID := middleware.GCtxRequestID(c), traceIDOf(c)
+
+	if err.Code() != errcode.Success.Code() && acceptsProblem(c) {
+		c.Render(statusCode, problemRender{&Problem{
+			Type:      "about:blank",
+			Title:     err.Msg(),
+			Status:    statusCode,
+			Instance:  c.Request.URL.Path,
+			Code:      err.Code(),
+			Details:   details,
+			RequestID: requestID,
+			TraceID:   traceID,
+		}})
+		return
+	}
+
+	result := &Result{
+		Code:      err.Code(),
+		Msg:       err.Msg(),
+		Data:      &struct{}{},
+		Details:   details,
+		RequestID: requestID,
+		TraceID:   traceID,
+	}
+	if len(data) > 0 && data[0] != nil {
+		result.Data = data[0]
+	}
+	c.JSON(statusCode, result)
+}
+
+func traceIDOf(c *gin.Context) string {
+	spanContext := trace.SpanContextFromContext(c.Request.Context())
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+func acceptsProblem(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ProblemContentType)
+}
+
+type problemRender struct {
+	problem *Problem
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ProblemContentType)
+}
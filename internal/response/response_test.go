@@ -0,0 +1,136 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/errcode"
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+)
+
+var errBusiness = errcode.NewError(20101, "business error")
+
+func newContext(header map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.ReleaseMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	for k, v := range header {
+		c.Request.Header.Set(k, v)
+	}
+	return c, w
+}
+
+func TestSuccess(t *testing.T) {
+	c, w := newContext(nil)
+	c.Set(middleware.ContextRequestIDKey, "abc")
+	Success(c, gin.H{"id": 1})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := &Result{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+	assert.Equal(t, 0, result.Code)
+	assert.Equal(t, "abc", result.RequestID)
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, result.Data)
+
+	c, w = newContext(nil)
+	Success(c)
+	assert.JSONEq(t, `{"code":0,"msg":"ok","data":{}}`, w.Body.String())
+}
+
+func TestError(t *testing.T) {
+	c, w := newContext(nil)
+	Error(c, errBusiness)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":20101,"msg":"business error","data":{}}`, w.Body.String())
+
+	// problem details
+	c, w = newContext(map[string]string{"Accept": ProblemContentType})
+	c.Set(middleware.ContextRequestIDKey, "abc")
+	Error(c, errBusiness)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	problem := &Problem{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), problem))
+	assert.Equal(t, &Problem{
+		Type:      "about:blank",
+		Title:     "business error",
+		Status:    http.StatusBadRequest,
+		Instance:  "/api/v1/users/1",
+		Code:      20101,
+		RequestID: "abc",
+	}, problem)
+}
+
+func TestOut(t *testing.T) {
+	c, w := newContext(nil)
+	Out(c, errcode.NotFound)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	result := &Result{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+	assert.Equal(t, errcode.NotFound.Code(), result.Code)
+
+	c, w = newContext(nil)
+	OutWithStatus(c, http.StatusPreconditionFailed, errcode.AlreadyExists)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestInvalidParams(t *testing.T) {
+	c, w := newContext(nil)
+	InvalidParams(c, errors.New("invalid id"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	result := &Result{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+	assert.Equal(t, errcode.InvalidParams.Code(), result.Code)
+	assert.Equal(t, []*Detail{{Msg: "invalid id"}}, result.Details)
+
+	c, w = newContext(map[string]string{"Accept": "application/json, " + ProblemContentType})
+	InvalidParams(c, errors.New("invalid id"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := &Problem{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), problem))
+	assert.Equal(t, []*Detail{{Msg: "invalid id"}}, problem.Details)
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusOK, HTTPStatus(errcode.Success))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(errcode.InvalidParams))
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(errcode.NotFound))
+	assert.Equal(t, http.StatusConflict, HTTPStatus(errcode.AlreadyExists))
+	assert.Equal(t, http.StatusConflict, HTTPStatus(errcode.FailedPrecondition))
+	assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(errcode.DeadlineExceeded))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(errcode.InternalServerError))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(errBusiness))
+}
+
+func TestErrorDetails(t *testing.T) {
+	assert.Nil(t, ErrorDetails(nil))
+
+	type profile struct {
+		Nickname string `json:"nickname" binding:"required"`
+	}
+	type form struct {
+		Email   string  `json:"email" binding:"email"`
+		Age     int     `json:"age" binding:"min=1"`
+		Profile profile `json:"profile"`
+	}
+	validate := validator.New()
+	validate.SetTagName("binding")
+	validate.RegisterTagNameFunc(JSONFieldName)
+	err := validate.Struct(&form{Email: "foo"})
+	assert.Equal(t, []*Detail{
+		{Field: "email", Msg: "failed on the 'email' rule"},
+		{Field: "age", Msg: "failed on the 'min=1' rule"},
+		{Field: "profile.nickname", Msg: "failed on the 'required' rule"},
+	}, ErrorDetails(err))
+
+	err = json.Unmarshal([]byte(`{"age":"1"}`), &form{})
+	assert.Equal(t, []*Detail{{Field: "age", Msg: "cannot be string, must be int"}}, ErrorDetails(err))
+}
@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zhufuyi/sponge/pkg/gin/middleware"
+	"github.com/zhufuyi/sponge/pkg/jwt"
+
+	"weaving_net/internal/config"
+)
+
+// adminAuth the jwt authentication of the admin api, the uid of the token must be one of the admin uids in the
+// configuration, so the admin api is not allowed for anyone if no administrator is configured.
+func adminAuth() gin.HandlerFunc {
+	return middleware.Auth(middleware.WithVerify(verifyAdmin), middleware.WithSwitchHTTPCode())
+}
+
+func verifyAdmin(claims *jwt.Claims, _ string, c *gin.Context) error {
+	for _, uid := range config.Get().Admin.UIDs {
+		if uid != "" && uid == claims.UID {
+			c.Set("uid", claims.UID)
+			c.Set("name", claims.Name)
+			return nil
+		}
+	}
+	return errors.New("not an administrator")
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		cacheAdminRouter(group, handler.NewCacheAdminHandler())
+	})
+}
+
+func cacheAdminRouter(group *gin.RouterGroup, h handler.CacheAdminHandler) {
+	admin := group.Group("/admin/cache", adminAuth()) // only the administrators can warm up and repair the cache
+
+	admin.POST("/warmup", h.Warmup)
+	admin.POST("/verify", h.Verify)
+}
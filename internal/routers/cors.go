@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// corsMiddleware same as middleware.Cors, besides the conditional request headers are allowed and the ETag is exposed
+func corsMiddleware() gin.HandlerFunc {
+	return cors.New(
+		cors.Config{
+			AllowOrigins:     []string{"*"},
+			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
+			AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Accept", "If-Match", "If-None-Match"},
+			ExposeHeaders:    []string{"Content-Length", "text/plain", "Authorization", "Content-Type", "ETag"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		},
+	)
+}
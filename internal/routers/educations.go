@@ -17,12 +17,18 @@ func educationsRouter(group *gin.RouterGroup, h handler.EducationsHandler) {
 	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
 
 	group.POST("/educations", h.Create)
+	group.POST("/educations/batch", h.CreateBatch)
 	group.DELETE("/educations/:id", h.DeleteByID)
 	group.POST("/educations/delete/ids", h.DeleteByIDs)
 	group.PUT("/educations/:id", h.UpdateByID)
+	group.PUT("/educations/batch", h.UpdateBatch)
+	group.PATCH("/educations/:id", h.PatchByID)
 	group.GET("/educations/:id", h.GetByID)
 	group.POST("/educations/condition", h.GetByCondition)
 	group.POST("/educations/list/ids", h.ListByIDs)
 	group.GET("/educations/list", h.ListByLastID)
 	group.POST("/educations/list", h.List)
+	group.POST("/educations/list/cursor", h.ListByCursor)
+	group.GET("/educations/user/:userId", h.ListByUserID)
+	group.PUT("/educations/user/:userId/positions", h.Reorder)
 }
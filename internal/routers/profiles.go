@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		profilesRouter(group, handler.NewProfilesHandler())
+	})
+}
+
+func profilesRouter(group *gin.RouterGroup, h handler.ProfilesHandler) {
+	//group.Use(middleware.Auth()) // all of the following routes use jwt authentication
+	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
+
+	group.PUT("/users/:id/profile", h.Replace)
+	group.POST("/projects/:id/move", h.MoveProject)
+}
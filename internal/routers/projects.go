@@ -17,12 +17,18 @@ func projectsRouter(group *gin.RouterGroup, h handler.ProjectsHandler) {
 	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
 
 	group.POST("/projects", h.Create)
+	group.POST("/projects/batch", h.CreateBatch)
 	group.DELETE("/projects/:id", h.DeleteByID)
 	group.POST("/projects/delete/ids", h.DeleteByIDs)
 	group.PUT("/projects/:id", h.UpdateByID)
+	group.PUT("/projects/batch", h.UpdateBatch)
+	group.PATCH("/projects/:id", h.PatchByID)
 	group.GET("/projects/:id", h.GetByID)
 	group.POST("/projects/condition", h.GetByCondition)
 	group.POST("/projects/list/ids", h.ListByIDs)
 	group.GET("/projects/list", h.ListByLastID)
 	group.POST("/projects/list", h.List)
+	group.POST("/projects/list/cursor", h.ListByCursor)
+	group.GET("/projects/user/:userId", h.ListByUserID)
+	group.PUT("/projects/user/:userId/positions", h.Reorder)
 }
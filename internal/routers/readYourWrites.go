@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/model"
+)
+
+// readYourWritesMiddleware the reads of a request use the primary after the request writes, so that it reads
+// its own writes from the database instead of a read replica that may lag behind, see model.WithReadYourWrites
+func readYourWritesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(model.WithReadYourWrites(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		revisionsRouter(group, handler.NewRevisionsHandler())
+	})
+}
+
+func revisionsRouter(group *gin.RouterGroup, h handler.RevisionsHandler) {
+	//group.Use(middleware.Auth()) // all of the following routes use jwt authentication
+	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
+
+	group.GET("/revisions/:resource/:id", h.List)
+	group.GET("/revisions/:resource/:id/diff", h.Diff)
+	group.POST("/revisions/:resource/:id/restore", h.Restore)
+}
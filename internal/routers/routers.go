@@ -22,6 +22,7 @@ import (
 
 	"weaving_net/docs"
 	"weaving_net/internal/config"
+	"weaving_net/internal/response"
 )
 
 var (
@@ -36,7 +37,7 @@ func NewRouter() *gin.Engine {
 	r := gin.New()
 
 	r.Use(gin.Recovery())
-	r.Use(middleware.Cors())
+	r.Use(corsMiddleware())
 
 	if config.Get().HTTP.Timeout > 0 {
 		// if you need more fine-grained control over your routes, set the timeout in your routes, unsetting the timeout globally here.
@@ -46,6 +47,11 @@ func NewRouter() *gin.Engine {
 	// request id middleware
 	r.Use(middleware.RequestID())
 
+	// read your writes middleware, effective when there are read replicas
+	if config.Get().Database.Postgresql.ReadYourWrites && len(config.Get().Database.Postgresql.SlavesDsn) > 0 {
+		r.Use(readYourWritesMiddleware())
+	}
+
 	// logger middleware, to print simple messages, replace middleware.Logging with middleware.SimpleLog
 	r.Use(middleware.Logging(
 		middleware.WithLog(logger.Get()),
@@ -89,7 +95,9 @@ func NewRouter() *gin.Engine {
 	}
 
 	// validator
-	binding.Validator = validator.Init()
+	customValidator := validator.Init()
+	customValidator.Validate.RegisterTagNameFunc(response.JSONFieldName) // the error details are named by json
+	binding.Validator = customValidator
 
 	r.GET("/health", handlerfunc.CheckHealth)
 	r.GET("/ping", handlerfunc.Ping)
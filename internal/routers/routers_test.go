@@ -2,12 +2,15 @@ package routers
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/zhufuyi/sponge/pkg/jwt"
 	"github.com/zhufuyi/sponge/pkg/utils"
 
 	"weaving_net/configs"
@@ -52,18 +55,74 @@ func TestNewRouter2(t *testing.T) {
 
 type mock struct{}
 
-func (u mock) Create(c *gin.Context)         { return }
-func (u mock) DeleteByID(c *gin.Context)     { return }
-func (u mock) DeleteByIDs(c *gin.Context)    { return }
-func (u mock) UpdateByID(c *gin.Context)     { return }
-func (u mock) GetByID(c *gin.Context)        { return }
-func (u mock) GetByCondition(c *gin.Context) { return }
-func (u mock) ListByIDs(c *gin.Context)      { return }
-func (u mock) ListByLastID(c *gin.Context)   { return }
-func (u mock) List(c *gin.Context)           { return }
+func (u mock) Create(c *gin.Context)          { return }
+func (u mock) CreateBatch(c *gin.Context)     { return }
+func (u mock) DeleteByID(c *gin.Context)      { return }
+func (u mock) DeleteByIDs(c *gin.Context)     { return }
+func (u mock) UpdateByID(c *gin.Context)      { return }
+func (u mock) UpdateBatch(c *gin.Context)     { return }
+func (u mock) PatchByID(c *gin.Context)       { return }
+func (u mock) GetByID(c *gin.Context)         { return }
+func (u mock) GetByCondition(c *gin.Context)  { return }
+func (u mock) ListByIDs(c *gin.Context)       { return }
+func (u mock) ListByLastID(c *gin.Context)    { return }
+func (u mock) ListByCursor(c *gin.Context)    { return }
+func (u mock) List(c *gin.Context)            { return }
+func (u mock) ListByUserID(c *gin.Context)    { return }
+func (u mock) Reorder(c *gin.Context)         { return }
+func (u mock) AddSynonyms(c *gin.Context)     { return }
+func (u mock) Suggest(c *gin.Context)         { return }
+func (u mock) CreateCategory(c *gin.Context)  { return }
+func (u mock) ListCategories(c *gin.Context)  { return }
+func (u mock) ListByCatalogID(c *gin.Context) { return }
+func (u mock) Submit(c *gin.Context)          { return }
+func (u mock) Warmup(c *gin.Context)          { return }
+func (u mock) Verify(c *gin.Context)          { return }
 
 func Test_educationsRouter(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 	educationsRouter(r.Group("/"), &mock{})
 }
+
+func Test_skillCatalogsRouter(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.Default()
+	// the catalog routes share the prefix with the skills routes
+	skillsRouter(r.Group("/"), &mock{})
+	skillCatalogsRouter(r.Group("/"), &mock{})
+}
+
+func Test_skillAssessmentsRouter(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.Default()
+	skillsRouter(r.Group("/"), &mock{})
+	skillCatalogsRouter(r.Group("/"), &mock{})
+	skillAssessmentsRouter(r.Group("/"), &mock{})
+}
+
+func Test_cacheAdminRouter(t *testing.T) {
+	config.Set(&config.Config{Admin: config.Admin{UIDs: []string{"1"}}})
+	defer config.Set(nil)
+	jwt.Init()
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	cacheAdminRouter(r.Group("/"), &mock{})
+
+	warmup := func(uid string) int {
+		req := httptest.NewRequest(http.MethodPost, "/admin/cache/warmup", nil)
+		if uid != "" {
+			token, err := jwt.GenerateToken(uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, warmup(""))
+	assert.Equal(t, http.StatusUnauthorized, warmup("2"))
+	assert.Equal(t, http.StatusOK, warmup("1"))
+}
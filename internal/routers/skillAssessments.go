@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		skillAssessmentsRouter(group, handler.NewSkillAssessmentsHandler())
+	})
+}
+
+func skillAssessmentsRouter(group *gin.RouterGroup, h handler.SkillAssessmentsHandler) {
+	//group.Use(middleware.Auth()) // all of the following routes use jwt authentication
+	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
+
+	group.POST("/skills/assessments", h.Create)
+	group.GET("/skills/assessments/catalog/:catalogId", h.ListByCatalogID)
+	group.GET("/skills/assessments/:id", h.GetByID)
+	group.POST("/skills/assessments/:id/attempts", h.Submit)
+}
@@ -0,0 +1,25 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		skillCatalogsRouter(group, handler.NewSkillCatalogsHandler())
+	})
+}
+
+func skillCatalogsRouter(group *gin.RouterGroup, h handler.SkillCatalogsHandler) {
+	//group.Use(middleware.Auth()) // all of the following routes use jwt authentication
+	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
+
+	group.POST("/skills/catalog", h.Create)
+	group.GET("/skills/catalog/suggest", h.Suggest)
+	group.POST("/skills/catalog/categories", h.CreateCategory)
+	group.GET("/skills/catalog/categories", h.ListCategories)
+	group.GET("/skills/catalog/:id", h.GetByID)
+	group.POST("/skills/catalog/:id/synonyms", h.AddSynonyms)
+}
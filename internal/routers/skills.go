@@ -17,12 +17,18 @@ func skillsRouter(group *gin.RouterGroup, h handler.SkillsHandler) {
 	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
 
 	group.POST("/skills", h.Create)
+	group.POST("/skills/batch", h.CreateBatch)
 	group.DELETE("/skills/:id", h.DeleteByID)
 	group.POST("/skills/delete/ids", h.DeleteByIDs)
 	group.PUT("/skills/:id", h.UpdateByID)
+	group.PUT("/skills/batch", h.UpdateBatch)
+	group.PATCH("/skills/:id", h.PatchByID)
 	group.GET("/skills/:id", h.GetByID)
 	group.POST("/skills/condition", h.GetByCondition)
 	group.POST("/skills/list/ids", h.ListByIDs)
 	group.GET("/skills/list", h.ListByLastID)
 	group.POST("/skills/list", h.List)
+	group.POST("/skills/list/cursor", h.ListByCursor)
+	group.GET("/skills/user/:userId", h.ListByUserID)
+	group.PUT("/skills/user/:userId/positions", h.Reorder)
 }
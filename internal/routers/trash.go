@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"weaving_net/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		trashRouter(group, handler.NewTrashHandler())
+	})
+}
+
+func trashRouter(group *gin.RouterGroup, h handler.TrashHandler) {
+	//group.Use(middleware.Auth()) // all of the following routes use jwt authentication
+	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
+
+	group.GET("/trash/:resource", h.List)
+	group.POST("/trash/:resource/:id/restore", h.RestoreByID)
+	group.POST("/trash/:resource/restore/ids", h.RestoreByIDs)
+	group.DELETE("/trash/:resource/:id", h.PurgeByID)
+	group.POST("/trash/:resource/purge/ids", h.PurgeByIDs)
+}
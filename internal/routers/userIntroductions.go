@@ -17,12 +17,18 @@ func userIntroductionsRouter(group *gin.RouterGroup, h handler.UserIntroductions
 	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
 
 	group.POST("/userIntroductions", h.Create)
+	group.POST("/userIntroductions/batch", h.CreateBatch)
 	group.DELETE("/userIntroductions/:id", h.DeleteByID)
 	group.POST("/userIntroductions/delete/ids", h.DeleteByIDs)
 	group.PUT("/userIntroductions/:id", h.UpdateByID)
+	group.PUT("/userIntroductions/batch", h.UpdateBatch)
+	group.PATCH("/userIntroductions/:id", h.PatchByID)
 	group.GET("/userIntroductions/:id", h.GetByID)
 	group.POST("/userIntroductions/condition", h.GetByCondition)
 	group.POST("/userIntroductions/list/ids", h.ListByIDs)
 	group.GET("/userIntroductions/list", h.ListByLastID)
 	group.POST("/userIntroductions/list", h.List)
+	group.POST("/userIntroductions/list/cursor", h.ListByCursor)
+	group.GET("/userIntroductions/user/:userId", h.ListByUserID)
+	group.PUT("/userIntroductions/user/:userId/positions", h.Reorder)
 }
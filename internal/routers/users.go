@@ -17,12 +17,16 @@ func usersRouter(group *gin.RouterGroup, h handler.UsersHandler) {
 	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
 
 	group.POST("/users", h.Create)
+	group.POST("/users/batch", h.CreateBatch)
 	group.DELETE("/users/:id", h.DeleteByID)
 	group.POST("/users/delete/ids", h.DeleteByIDs)
 	group.PUT("/users/:id", h.UpdateByID)
+	group.PUT("/users/batch", h.UpdateBatch)
+	group.PATCH("/users/:id", h.PatchByID)
 	group.GET("/users/:id", h.GetByID)
 	group.POST("/users/condition", h.GetByCondition)
 	group.POST("/users/list/ids", h.ListByIDs)
 	group.GET("/users/list", h.ListByLastID)
 	group.POST("/users/list", h.List)
+	group.POST("/users/list/cursor", h.ListByCursor)
 }
@@ -17,12 +17,18 @@ func workexperiencesRouter(group *gin.RouterGroup, h handler.WorkexperiencesHand
 	// or group.Use(middleware.Auth(middleware.WithVerify(verify))) // token authentication
 
 	group.POST("/workexperiences", h.Create)
+	group.POST("/workexperiences/batch", h.CreateBatch)
 	group.DELETE("/workexperiences/:id", h.DeleteByID)
 	group.POST("/workexperiences/delete/ids", h.DeleteByIDs)
 	group.PUT("/workexperiences/:id", h.UpdateByID)
+	group.PUT("/workexperiences/batch", h.UpdateBatch)
+	group.PATCH("/workexperiences/:id", h.PatchByID)
 	group.GET("/workexperiences/:id", h.GetByID)
 	group.POST("/workexperiences/condition", h.GetByCondition)
 	group.POST("/workexperiences/list/ids", h.ListByIDs)
 	group.GET("/workexperiences/list", h.ListByLastID)
 	group.POST("/workexperiences/list", h.List)
+	group.POST("/workexperiences/list/cursor", h.ListByCursor)
+	group.GET("/workexperiences/user/:userId", h.ListByUserID)
+	group.PUT("/workexperiences/user/:userId/positions", h.Reorder)
 }
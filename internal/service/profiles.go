@@ -0,0 +1,225 @@
+// Package service is the business operations that change the records of several tables atomically,
+// the operations run in the units of work of the daos, see dao.UnitOfWork.
+package service
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/zhufuyi/sponge/pkg/ggorm"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+// ErrUserNotFound the user that the records are moved to does not exist
+var ErrUserNotFound = errors.New("user not found")
+
+var _ ProfilesService = (*profilesService)(nil)
+
+// Profile the user and all the records of the user
+type Profile struct {
+	User              *model.Users
+	Educations        []*model.Educations
+	Projects          []*model.Projects
+	Skills            []*model.Skills
+	UserIntroductions []*model.UserIntroductions
+	Workexperiences   []*model.Workexperiences
+}
+
+// ProfilesService defining the service interface of the profiles
+type ProfilesService interface {
+	ReplaceProfile(ctx context.Context, profile *Profile) error
+	MoveProject(ctx context.Context, id uint64, version int, userID int) (*model.Projects, error)
+}
+
+type profilesService struct {
+	uow                  dao.UnitOfWork
+	usersDao             dao.UsersDao
+	educationsDao        dao.EducationsDao
+	projectsDao          dao.ProjectsDao
+	skillsDao            dao.SkillsDao
+	userIntroductionsDao dao.UserIntroductionsDao
+	workexperiencesDao   dao.WorkexperiencesDao
+}
+
+// NewProfilesService creating the service interface of the profiles in the database of database.driver
+func NewProfilesService() ProfilesService {
+	return NewProfilesServiceByDao(
+		dao.NewUnitOfWorkByDriver(),
+		dao.NewUsersDaoByDriver(cache.NewUsersCache(model.GetCacheType())),
+		dao.NewEducationsDaoByDriver(cache.NewEducationsCache(model.GetCacheType())),
+		dao.NewProjectsDaoByDriver(cache.NewProjectsCache(model.GetCacheType())),
+		dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType())),
+		dao.NewUserIntroductionsDaoByDriver(cache.NewUserIntroductionsCache(model.GetCacheType())),
+		dao.NewWorkexperiencesDaoByDriver(cache.NewWorkexperiencesCache(model.GetCacheType())),
+	)
+}
+
+// NewProfilesServiceByDao creating the service interface of the profiles with the daos, the daos must be
+// of the database of uow.
+func NewProfilesServiceByDao(
+	uow dao.UnitOfWork,
+	usersDao dao.UsersDao,
+	educationsDao dao.EducationsDao,
+	projectsDao dao.ProjectsDao,
+	skillsDao dao.SkillsDao,
+	userIntroductionsDao dao.UserIntroductionsDao,
+	workexperiencesDao dao.WorkexperiencesDao,
+) ProfilesService {
+	return &profilesService{
+		uow:                  uow,
+		usersDao:             usersDao,
+		educationsDao:        educationsDao,
+		projectsDao:          projectsDao,
+		skillsDao:            skillsDao,
+		userIntroductionsDao: userIntroductionsDao,
+		workexperiencesDao:   workexperiencesDao,
+	}
+}
+
+// ReplaceProfile replace the user and all the records of the user in one transaction, the records of the user
+// are deleted and the records of the profile are created in their order, the ids are written back to them.
+// the user is only replaced when its version has not changed if the version of profile.User is not 0,
+// otherwise model.ErrRecordModified is returned, model.ErrRecordNotFound is returned if the user does not exist.
+func (s *profilesService) ReplaceProfile(ctx context.Context, profile *Profile) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		userID := int(profile.User.ID)
+		err := s.usersDao.ReplaceByID(ctx, profile.User)
+		if err != nil {
+			return err
+		}
+
+		err = replaceRecords[model.Educations](ctx, s.educationsDao, userID, profile.Educations, func(r *model.Educations) (*ggorm.Model, *int) {
+			return &r.Model, &r.UserID
+		})
+		if err != nil {
+			return err
+		}
+		err = replaceRecords[model.Projects](ctx, s.projectsDao, userID, profile.Projects, func(r *model.Projects) (*ggorm.Model, *int) {
+			return &r.Model, &r.UserID
+		})
+		if err != nil {
+			return err
+		}
+		err = replaceRecords[model.Skills](ctx, s.skillsDao, userID, profile.Skills, func(r *model.Skills) (*ggorm.Model, *int) {
+			return &r.Model, &r.UserID
+		})
+		if err != nil {
+			return err
+		}
+		err = replaceRecords[model.UserIntroductions](ctx, s.userIntroductionsDao, userID, profile.UserIntroductions, func(r *model.UserIntroductions) (*ggorm.Model, *int) {
+			return &r.Model, &r.UserID
+		})
+		if err != nil {
+			return err
+		}
+		return replaceRecords[model.Workexperiences](ctx, s.workexperiencesDao, userID, profile.Workexperiences, func(r *model.Workexperiences) (*ggorm.Model, *int) {
+			return &r.Model, &r.UserID
+		})
+	})
+}
+
+// MoveProject move a project to the end of the projects of another user in one transaction, and the positions
+// of the projects left by it are rewritten to close the gap. the project is only moved when its version has not
+// changed if version is not 0, otherwise model.ErrRecordModified is returned. ErrUserNotFound is returned if
+// the user does not exist.
+func (s *profilesService) MoveProject(ctx context.Context, id uint64, version int, userID int) (*model.Projects, error) {
+	var project *model.Projects
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		record, err := s.projectsDao.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version > 0 && record.Version != version {
+			return model.ErrRecordModified
+		}
+		fromUserID := record.UserID
+		if fromUserID == userID {
+			project = record
+			return nil
+		}
+
+		_, err = s.usersDao.GetByID(ctx, uint64(userID))
+		if err != nil {
+			if errors.Is(err, model.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		records, err := s.projectsDao.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		position := 1
+		if len(records) > 0 {
+			position = records[len(records)-1].Position + 1
+		}
+
+		// the version read above is compared, so that a concurrent change is not lost
+		err = s.projectsDao.PatchByID(ctx, id, record.Version, map[string]interface{}{"user_id": userID, "position": position})
+		if err != nil {
+			return err
+		}
+
+		records, err = s.projectsDao.GetByUserID(ctx, fromUserID)
+		if err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			ids := make([]uint64, 0, len(records))
+			for _, r := range records {
+				ids = append(ids, r.ID)
+			}
+			err = s.projectsDao.Reorder(ctx, fromUserID, ids)
+			if err != nil {
+				return err
+			}
+		}
+
+		project, err = s.projectsDao.GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// the dao of the records of each user that replaceRecords uses
+type userRecordsDao[T any] interface {
+	GetByUserID(ctx context.Context, userID int) ([]*T, error)
+	CreateByTx(ctx context.Context, tx *gorm.DB, table *T) (uint64, error)
+	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
+}
+
+// delete all the records of the user and create the records in their order in the unit of work of ctx, fields
+// are the model and the user id of a record, the new records are created with the user and new ids.
+func replaceRecords[T any](ctx context.Context, d userRecordsDao[T], userID int, records []*T, fields func(*T) (*ggorm.Model, *int)) error {
+	tx := dao.TxFromContext(ctx)
+	oldRecords, err := d.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, record := range oldRecords {
+		m, _ := fields(record)
+		err = d.DeleteByTx(ctx, tx, m.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		m, recordUserID := fields(record)
+		*m = ggorm.Model{}
+		*recordUserID = userID
+		_, err = d.CreateByTx(ctx, tx, record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
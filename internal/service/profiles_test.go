@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+var testDBSeq int64
+
+type profilesTest struct {
+	ProfilesService
+	users      dao.UsersDao
+	projects   dao.ProjectsDao
+	skills     dao.SkillsDao
+	educations dao.EducationsDao
+}
+
+func newProfilesTest(t *testing.T) *profilesTest {
+	dsn := fmt.Sprintf("file:profiles%d?mode=memory&cache=shared", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&model.Users{}, &model.Educations{}, &model.Projects{}, &model.Skills{},
+		&model.UserIntroductions{}, &model.Workexperiences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+
+	cacheType := &model.CacheType{CType: "memory"}
+	p := &profilesTest{
+		users:      dao.NewUsersDao(db, cache.NewUsersCache(cacheType)),
+		projects:   dao.NewProjectsDao(db, cache.NewProjectsCache(cacheType)),
+		skills:     dao.NewSkillsDao(db, cache.NewSkillsCache(cacheType)),
+		educations: dao.NewEducationsDao(db, cache.NewEducationsCache(cacheType)),
+	}
+	p.ProfilesService = NewProfilesServiceByDao(
+		dao.NewUnitOfWork(db),
+		p.users,
+		p.educations,
+		p.projects,
+		p.skills,
+		dao.NewUserIntroductionsDao(db, cache.NewUserIntroductionsCache(cacheType)),
+		dao.NewWorkexperiencesDao(db, cache.NewWorkexperiencesCache(cacheType)),
+	)
+	return p
+}
+
+func (p *profilesTest) createUser(t *testing.T, firstName string) *model.Users {
+	user := &model.Users{FirstName: firstName, LastName: "test"}
+	err := p.users.Create(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func (p *profilesTest) createProjects(t *testing.T, userID int, names ...string) []*model.Projects {
+	records := []*model.Projects{}
+	for _, name := range names {
+		record := &model.Projects{UserID: userID, ProjectName: name}
+		err := p.projects.Create(context.Background(), record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func projectNames(records []*model.Projects) []string {
+	names := []string{}
+	for _, record := range records {
+		names = append(names, fmt.Sprintf("%s:%d", record.ProjectName, record.Position))
+	}
+	return names
+}
+
+func Test_profilesService_ReplaceProfile(t *testing.T) {
+	p := newProfilesTest(t)
+	ctx := context.Background()
+	user := p.createUser(t, "foo")
+	userID := int(user.ID)
+	p.createProjects(t, userID, "a", "b")
+	_, err := p.projects.GetByUserID(ctx, userID) // cached
+	assert.NoError(t, err)
+
+	profile := &Profile{
+		User:       &model.Users{Model: user.Model, FirstName: "bar", Version: user.Version},
+		Projects:   []*model.Projects{{ProjectName: "c"}, {ProjectName: "d", UserID: 100}},
+		Skills:     []*model.Skills{{SkillName: "go", Proficiency: 4}},
+		Educations: []*model.Educations{{School: "school", StartDate: time.Now()}},
+	}
+	err = p.ReplaceProfile(ctx, profile)
+	assert.NoError(t, err)
+	assert.NotZero(t, profile.Projects[0].ID)
+	assert.NotZero(t, profile.Skills[0].ID)
+
+	record, err := p.users.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", record.FirstName)
+	projects, err := p.projects.GetByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c:1", "d:2"}, projectNames(projects))
+	skills, err := p.skills.GetByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, skills, 1)
+
+	// the version has changed, nothing is replaced
+	profile = &Profile{
+		User:     &model.Users{Model: user.Model, FirstName: "baz", Version: user.Version},
+		Projects: []*model.Projects{{ProjectName: "e"}},
+	}
+	err = p.ReplaceProfile(ctx, profile)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+	projects, err = p.projects.GetByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c:1", "d:2"}, projectNames(projects))
+
+	// the user does not exist
+	missing := &model.Users{FirstName: "qux"}
+	missing.ID = 100
+	err = p.ReplaceProfile(ctx, &Profile{User: missing})
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
+
+func Test_profilesService_MoveProject(t *testing.T) {
+	p := newProfilesTest(t)
+	ctx := context.Background()
+	from := int(p.createUser(t, "foo").ID)
+	to := int(p.createUser(t, "bar").ID)
+	records := p.createProjects(t, from, "a", "b", "c")
+	p.createProjects(t, to, "d")
+	_, err := p.projects.GetByUserID(ctx, from) // cached
+	assert.NoError(t, err)
+
+	project, err := p.MoveProject(ctx, records[0].ID, records[0].Version, to)
+	assert.NoError(t, err)
+	assert.Equal(t, to, project.UserID)
+	assert.Equal(t, 2, project.Position)
+
+	projects, err := p.projects.GetByUserID(ctx, from)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b:1", "c:2"}, projectNames(projects))
+	projects, err = p.projects.GetByUserID(ctx, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d:1", "a:2"}, projectNames(projects))
+
+	// the version has changed
+	_, err = p.MoveProject(ctx, records[0].ID, records[0].Version, from)
+	assert.ErrorIs(t, err, model.ErrRecordModified)
+
+	// the user does not exist, nothing is moved
+	_, err = p.MoveProject(ctx, records[1].ID, 0, 100)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	projects, err = p.projects.GetByUserID(ctx, from)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b:1", "c:2"}, projectNames(projects))
+
+	// the project does not exist
+	_, err = p.MoveProject(ctx, 100, 0, to)
+	assert.ErrorIs(t, err, model.ErrRecordNotFound)
+}
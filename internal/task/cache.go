@@ -0,0 +1,189 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+const (
+	// DefaultWarmupSize the default number of the most viewed profiles that are preloaded
+	DefaultWarmupSize = 100
+	// DefaultVerifySampleSize the default number of the cached values of each table that are verified
+	DefaultVerifySampleSize = 100
+)
+
+// cacheVerifier verify the cache of a table
+type cacheVerifier interface {
+	VerifyCache(ctx context.Context, n int, repair bool) (*dao.CacheReport, error)
+}
+
+// profileCollection a child table of the profiles whose records are cached by user
+type profileCollection struct {
+	name   string
+	dao    cacheVerifier
+	list   func(ctx context.Context, userID int) error                             // get the records of a user through the cache
+	verify func(ctx context.Context, n int, repair bool) (*dao.CacheReport, error) // VerifyCollections of the dao
+}
+
+// collectionDao the dao of a child table of the profiles
+type collectionDao[T any] interface {
+	cacheVerifier
+	ListByUserID(ctx context.Context, userID int) ([]*T, error)
+	VerifyCollections(ctx context.Context, n int, repair bool) (*dao.CacheReport, error)
+}
+
+func newProfileCollection[T any](name string, d collectionDao[T]) *profileCollection {
+	return &profileCollection{
+		name: name,
+		dao:  d,
+		list: func(ctx context.Context, userID int) error {
+			_, err := d.ListByUserID(ctx, userID)
+			return err
+		},
+		verify: d.VerifyCollections,
+	}
+}
+
+// CacheJobs the warmup and the verification of the caches of the profiles, i.e. the users and their records
+type CacheJobs struct {
+	views       cache.Views
+	usersDao    dao.UsersDao
+	collections []*profileCollection
+}
+
+// WarmupReport the result of the cache warmup
+type WarmupReport struct {
+	Users       int            `json:"users"`       // the number of the preloaded profiles
+	Collections map[string]int `json:"collections"` // the number of the users whose records are preloaded, the key is the table name
+}
+
+// CacheVerification the result of the verification of the cache of a table, or of the records of its users
+type CacheVerification struct {
+	Name string `json:"name"` // the table name, e.g. skills, the records of the users are named like skills:user
+	*dao.CacheReport
+	Error string `json:"error,omitempty"` // the cache of the table is not verified if there is an error
+}
+
+// NewCacheJobs create the cache jobs of the profiles with the database and the cache of the service
+func NewCacheJobs() *CacheJobs {
+	return newCacheJobs(
+		cache.NewViews(model.GetCacheType()),
+		dao.NewUsersDaoByDriver(cache.NewUsersCache(model.GetCacheType())),
+		dao.NewEducationsDaoByDriver(cache.NewEducationsCache(model.GetCacheType())),
+		dao.NewProjectsDaoByDriver(cache.NewProjectsCache(model.GetCacheType())),
+		dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType())),
+		dao.NewUserIntroductionsDaoByDriver(cache.NewUserIntroductionsCache(model.GetCacheType())),
+		dao.NewWorkexperiencesDaoByDriver(cache.NewWorkexperiencesCache(model.GetCacheType())),
+	)
+}
+
+func newCacheJobs(
+	views cache.Views,
+	usersDao dao.UsersDao,
+	educationsDao dao.EducationsDao,
+	projectsDao dao.ProjectsDao,
+	skillsDao dao.SkillsDao,
+	userIntroductionsDao dao.UserIntroductionsDao,
+	workexperiencesDao dao.WorkexperiencesDao,
+) *CacheJobs {
+	return &CacheJobs{
+		views:    views,
+		usersDao: usersDao,
+		collections: []*profileCollection{
+			newProfileCollection[model.Educations]("educations", educationsDao),
+			newProfileCollection[model.Projects]("projects", projectsDao),
+			newProfileCollection[model.Skills]("skills", skillsDao),
+			newProfileCollection[model.UserIntroductions]("userIntroductions", userIntroductionsDao),
+			newProfileCollection[model.Workexperiences]("workexperiences", workexperiencesDao),
+		},
+	}
+}
+
+// Warmup preload the n most viewed profiles and the records of the users to the cache, so that the requests
+// after a redis flush or a deploy do not all miss the cache. an error of the records of one user does not
+// stop the others, an error is returned only if the profiles can not be loaded.
+func (j *CacheJobs) Warmup(ctx context.Context, n int) (*WarmupReport, error) {
+	if n <= 0 {
+		n = DefaultWarmupSize
+	}
+	report := &WarmupReport{Collections: make(map[string]int, len(j.collections))}
+	userIDs, err := j.views.Top(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return report, nil
+	}
+
+	users, err := j.usersDao.GetByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	report.Users = len(users)
+
+	for _, userID := range userIDs {
+		if _, ok := users[userID]; !ok {
+			continue // deleted
+		}
+		for _, c := range j.collections {
+			err = c.list(ctx, int(userID))
+			if err != nil {
+				logger.Warn("warmup cache error", logger.Err(err), logger.String("table", c.name), logger.Uint64("userID", userID))
+				continue
+			}
+			report.Collections[c.name]++
+		}
+	}
+
+	logger.Info("warmup cache succeeded", logger.Int("users", report.Users), logger.Any("collections", report.Collections))
+	return report, nil
+}
+
+// Verify compare n random cached values of each table with database, and delete the divergent ones from the
+// cache if repair is true, see dao.CacheReport. an error of one table does not stop the others.
+func (j *CacheJobs) Verify(ctx context.Context, n int, repair bool) []*CacheVerification {
+	if n <= 0 {
+		n = DefaultVerifySampleSize
+	}
+
+	verifiers := []*CacheVerification{{Name: "users"}}
+	verifyFns := []func(ctx context.Context, n int, repair bool) (*dao.CacheReport, error){j.usersDao.VerifyCache}
+	for _, c := range j.collections {
+		verifiers = append(verifiers, &CacheVerification{Name: c.name}, &CacheVerification{Name: c.name + ":user"})
+		verifyFns = append(verifyFns, c.dao.VerifyCache, c.verify)
+	}
+
+	for i, v := range verifiers {
+		report, err := verifyFns[i](ctx, n, repair)
+		if err != nil {
+			logger.Error("verify cache error", logger.Err(err), logger.String("name", v.Name))
+			v.Error = err.Error()
+			continue
+		}
+		v.CacheReport = report
+		if len(report.Divergences) > 0 {
+			logger.Warn("the cache is different from database", logger.String("name", v.Name),
+				logger.Int("divergences", len(report.Divergences)), logger.Any("repaired", repair))
+		}
+	}
+	return verifiers
+}
+
+// RunWarmup warm up the cache in the background after the service starts, see CacheJobs.Warmup
+func RunWarmup(n int, timeout time.Duration) {
+	jobs := NewCacheJobs()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, err := jobs.Warmup(ctx, n)
+		if err != nil {
+			logger.Error("warmup cache error", logger.Err(err), logger.Int("n", n))
+		}
+	}()
+}
@@ -0,0 +1,90 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+func newTestCacheJobs(views cache.Views, d *gotest.Dao) *CacheJobs {
+	// the resources are not cached, the warmup only loads them
+	return newCacheJobs(
+		views,
+		dao.NewUsersDao(d.DB, nil),
+		dao.NewEducationsDao(d.DB, nil),
+		dao.NewProjectsDao(d.DB, nil),
+		dao.NewSkillsDao(d.DB, nil),
+		dao.NewUserIntroductionsDao(d.DB, nil),
+		dao.NewWorkexperiencesDao(d.DB, nil),
+	)
+}
+
+type errViews struct{}
+
+func (errViews) Incr(context.Context, uint64) error { return nil }
+
+func (errViews) Top(context.Context, int) ([]uint64, error) { return nil, errors.New("mock error") }
+
+func Test_CacheJobs_Warmup(t *testing.T) {
+	c := gotest.NewCache(map[string]interface{}{"1": &model.Users{}})
+	defer c.Close()
+	d := gotest.NewDao(nil, &model.Users{})
+	defer d.Close()
+	views := cache.NewViews(&model.CacheType{CType: "redis", Rdb: c.RedisClient})
+	jobs := newTestCacheJobs(views, d)
+
+	// no views
+	report, err := jobs.Warmup(c.Ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Users)
+
+	// user 2 is deleted
+	assert.NoError(t, c.RedisClient.ZIncrBy(c.Ctx, cache.ViewsKey, 2, "1").Err())
+	assert.NoError(t, c.RedisClient.ZIncrBy(c.Ctx, cache.ViewsKey, 1, "2").Err())
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	for i := 0; i < 4; i++ {
+		d.SQLMock.ExpectQuery("SELECT .*").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 1))
+	}
+	report, err = jobs.Warmup(c.Ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Users)
+	// the records of the last table are not loaded because of the error
+	assert.Equal(t, map[string]int{"educations": 1, "projects": 1, "skills": 1, "userIntroductions": 1}, report.Collections)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+
+	_, err = newTestCacheJobs(errViews{}, d).Warmup(c.Ctx, 10)
+	assert.Error(t, err)
+}
+
+func Test_CacheJobs_Verify(t *testing.T) {
+	d := gotest.NewDao(nil, &model.Users{})
+	defer d.Close()
+
+	reports := newTestCacheJobs(errViews{}, d).Verify(context.Background(), 0, true)
+	assert.Len(t, reports, 11)
+	assert.Equal(t, "users", reports[0].Name)
+	assert.Equal(t, "skills:user", reports[6].Name)
+	for _, report := range reports {
+		assert.Empty(t, report.Error)
+		assert.Equal(t, 0, report.Checked)
+		assert.True(t, report.Repaired)
+	}
+}
+
+func TestRunWarmup(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	RunWarmup(10, 0)
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhufuyi/sponge/pkg/gocron"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+const (
+	// DefaultOutboxRelaySpec the default cron spec of the outbox relay task, every minute
+	DefaultOutboxRelaySpec = "0 * * * * *"
+
+	// the events of the units of work are published right after the commit, only the events that are still
+	// not published after the delay are relayed, so that they are not published twice.
+	outboxRelayDelay     = time.Minute
+	outboxRelayBatchSize = 100
+)
+
+// NewOutboxRelayTask create a scheduled task that publishes the outbox events left unpublished, e.g. by an instance
+// that stopped before publishing the events of a committed transaction, and permanently deletes the events
+// published more than retentionDays ago, if retentionDays is 0, the published events are kept.
+func NewOutboxRelayTask(spec string, retentionDays int, publisher dao.OutboxPublisher) *gocron.Task {
+	if spec == "" {
+		spec = DefaultOutboxRelaySpec
+	}
+	d := dao.NewOutboxDao(model.GetDB())
+
+	return &gocron.Task{
+		TimeSpec: spec,
+		Name:     "relayOutbox",
+		Fn: func() {
+			ctx := context.Background()
+			_, _ = relayOutbox(ctx, d, publisher, time.Now().Add(-outboxRelayDelay))
+			if retentionDays > 0 {
+				before := time.Now().AddDate(0, 0, -retentionDays)
+				rows, err := d.PurgePublishedBefore(ctx, before)
+				if err != nil {
+					logger.Error("PurgePublishedBefore error", logger.Err(err), logger.Any("before", before))
+				} else if rows > 0 {
+					logger.Info("purge outbox events succeeded", logger.Int64("rows", rows), logger.Any("before", before))
+				}
+			}
+		},
+	}
+}
+
+// publish the unpublished events created before the time in batches in order, return the number of the published
+// events, it stops at the first error so that the order of the events is kept.
+func relayOutbox(ctx context.Context, d dao.OutboxDao, publisher dao.OutboxPublisher, before time.Time) (int, error) {
+	total := 0
+	for {
+		events, err := d.ListUnpublished(ctx, before, outboxRelayBatchSize)
+		if err != nil {
+			logger.Error("ListUnpublished error", logger.Err(err), logger.Any("before", before))
+			return total, err
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		err = publisher.Publish(ctx, events)
+		if err != nil {
+			logger.Error("publish outbox events error", logger.Err(err), logger.Int("events", len(events)))
+			return total, err
+		}
+		ids := make([]uint64, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		err = d.MarkPublished(ctx, ids)
+		if err != nil {
+			logger.Error("MarkPublished error", logger.Err(err), logger.Any("ids", ids))
+			return total, err
+		}
+
+		total += len(events)
+		if len(events) < outboxRelayBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		logger.Info("relay outbox events succeeded", logger.Int("events", total))
+	}
+	return total, nil
+}
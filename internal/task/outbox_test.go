@@ -0,0 +1,68 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+type outboxPublisher struct {
+	events []*model.OutboxEvents
+	err    error
+}
+
+func (p *outboxPublisher) Publish(ctx context.Context, events []*model.OutboxEvents) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func Test_relayOutbox(t *testing.T) {
+	d := gotest.NewDao(nil, &model.OutboxEvents{})
+	defer d.Close()
+	outboxDao := dao.NewOutboxDao(d.DB)
+	before := time.Now().Add(-time.Minute)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "resource_type", "resource_id"}).
+			AddRow(1, "skills.created", "skills", 1).
+			AddRow(2, "skills.deleted", "skills", 1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	d.SQLMock.ExpectCommit()
+
+	publisher := &outboxPublisher{}
+	total, err := relayOutbox(context.Background(), outboxDao, publisher, before)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, publisher.events, 2)
+
+	// the events are not marked if they fail to be published
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "resource_type", "resource_id"}).
+			AddRow(3, "skills.created", "skills", 2))
+	publisher.err = errors.New("publish error")
+	total, err = relayOutbox(context.Background(), outboxDao, publisher, before)
+	assert.Error(t, err)
+	assert.Equal(t, 0, total)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+}
+
+func TestNewOutboxRelayTask(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewOutboxRelayTask("", 7, &outboxPublisher{})
+}
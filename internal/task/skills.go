@@ -0,0 +1,132 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhufuyi/sponge/pkg/gocron"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+// the number of skills normalized in a batch
+const normalizeSkillsBatchSize = 100
+
+// NewNormalizeSkillsTask create a scheduled task that maps the skills which are not matched to the
+// skill catalog yet onto the canonical skills, e.g. the skills named "golang" and "Go lang" become "Go".
+func NewNormalizeSkillsTask(spec string) *gocron.Task {
+	skillsDao := dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType()))
+	catalogDao := dao.NewSkillCatalogsDao(model.GetDB())
+
+	return &gocron.Task{
+		TimeSpec: spec,
+		Name:     "normalizeSkills",
+		Fn: func() {
+			normalizeSkills(context.Background(), skillsDao, catalogDao, normalizeSkillsBatchSize)
+		},
+	}
+}
+
+// RunMigrateProficiency migrate the legacy proficiency level texts onto the proficiency scale in the background
+// after the service starts, it does not depend on the skill catalog. the skills saved later with a text only are
+// leveled when they are written, so a run at each start is enough.
+func RunMigrateProficiency(timeout time.Duration) {
+	skillsDao := dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType()))
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		migrateProficiency(ctx, skillsDao, normalizeSkillsBatchSize)
+	}()
+}
+
+// normalize the unmatched skills batch by batch, the skills that still have no match are skipped and tried
+// again in the next run, an error of one skill does not stop the others. the number of matched skills is returned.
+func normalizeSkills(ctx context.Context, skillsDao dao.SkillsDao, catalogDao dao.SkillCatalogsDao, batchSize int) int {
+	matched := 0
+	lastID := uint64(0)
+	for {
+		records, err := skillsDao.GetUnmatched(ctx, lastID, batchSize)
+		if err != nil {
+			logger.Error("GetUnmatched error", logger.Err(err), logger.Uint64("lastID", lastID))
+			break
+		}
+
+		for _, record := range records {
+			lastID = record.ID
+			isMatched, err := catalogDao.Normalize(ctx, record)
+			if err != nil {
+				logger.Error("Normalize error", logger.Err(err), logger.Uint64("id", record.ID))
+				continue
+			}
+			if !isMatched {
+				continue
+			}
+
+			err = skillsDao.PatchByID(ctx, record.ID, 0, map[string]interface{}{
+				"catalog_id":  record.CatalogID,
+				"category_id": record.CategoryID,
+				"skill_name":  record.SkillName,
+				"skill_type":  record.SkillType,
+			})
+			if err != nil {
+				logger.Error("PatchByID error", logger.Err(err), logger.Uint64("id", record.ID))
+				continue
+			}
+			matched++
+		}
+
+		if len(records) < batchSize {
+			break
+		}
+	}
+
+	if matched > 0 {
+		logger.Info("normalize skills succeeded", logger.Int("matched", matched))
+	}
+	return matched
+}
+
+// map the legacy proficiency level texts onto the proficiency scale batch by batch, the unknown texts are
+// kept as they are and skipped. the number of migrated skills is returned.
+func migrateProficiency(ctx context.Context, skillsDao dao.SkillsDao, batchSize int) int {
+	migrated, unknown := 0, 0
+	lastID := uint64(0)
+	for {
+		records, err := skillsDao.GetUnleveled(ctx, lastID, batchSize)
+		if err != nil {
+			logger.Error("GetUnleveled error", logger.Err(err), logger.Uint64("lastID", lastID))
+			break
+		}
+
+		for _, record := range records {
+			lastID = record.ID
+			level := model.ParseProficiency(record.ProficiencyLevel)
+			if level == 0 {
+				unknown++
+				continue
+			}
+
+			err = skillsDao.PatchByID(ctx, record.ID, 0, map[string]interface{}{
+				"proficiency":       level,
+				"proficiency_level": model.ProficiencyName(level),
+			})
+			if err != nil {
+				logger.Error("PatchByID error", logger.Err(err), logger.Uint64("id", record.ID))
+				continue
+			}
+			migrated++
+		}
+
+		if len(records) < batchSize {
+			break
+		}
+	}
+
+	if migrated > 0 || unknown > 0 {
+		logger.Info("migrate proficiency finished", logger.Int("migrated", migrated), logger.Int("unknown", unknown))
+	}
+	return migrated
+}
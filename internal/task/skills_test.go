@@ -0,0 +1,101 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+func Test_normalizeSkills(t *testing.T) {
+	c := gotest.NewCache(map[string]interface{}{})
+	c.ICache = cache.NewSkillsCache(&model.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	d := gotest.NewDao(c, &model.Skills{})
+	defer d.Close()
+	skillsDao := dao.NewSkillsDao(d.DB, c.ICache.(cache.SkillsCache))
+	catalogDao := dao.NewSkillCatalogsDao(d.DB)
+
+	// the first batch is full, one skill is matched and the other is not
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skills`").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "skill_name"}).AddRow(1, "golang").AddRow(2, "cobol"))
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs("golang", "golang").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(10, "Go"))
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE `skills` SET .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skill_catalogs`").
+		WithArgs("cobol", "cobol").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// the second batch is the last one
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skills`").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	matched := normalizeSkills(context.Background(), skillsDao, catalogDao, 2)
+	assert.Equal(t, 1, matched)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+
+	// get unmatched error test
+	matched = normalizeSkills(context.Background(), skillsDao, catalogDao, 2)
+	assert.Equal(t, 0, matched)
+}
+
+func Test_migrateProficiency(t *testing.T) {
+	c := gotest.NewCache(map[string]interface{}{})
+	c.ICache = cache.NewSkillsCache(&model.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	d := gotest.NewDao(c, &model.Skills{})
+	defer d.Close()
+	skillsDao := dao.NewSkillsDao(d.DB, c.ICache.(cache.SkillsCache))
+
+	// the unknown text is skipped
+	d.SQLMock.ExpectQuery("SELECT .* FROM `skills`").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "proficiency_level"}).AddRow(1, "精通").AddRow(2, "so-so"))
+	d.SQLMock.ExpectQuery("SELECT DISTINCT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE `skills` SET .*").
+		WithArgs(5, "expert", d.AnyTime, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	migrated := migrateProficiency(context.Background(), skillsDao, 10)
+	assert.Equal(t, 1, migrated)
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+
+	// get unleveled error test
+	migrated = migrateProficiency(context.Background(), skillsDao, 10)
+	assert.Equal(t, 0, migrated)
+}
+
+func TestNewNormalizeSkillsTask(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewNormalizeSkillsTask("")
+}
+
+func TestRunMigrateProficiency(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	RunMigrateProficiency(0)
+}
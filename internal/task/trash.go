@@ -0,0 +1,64 @@
+// Package task is the scheduled tasks of the service.
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhufuyi/sponge/pkg/gocron"
+	"github.com/zhufuyi/sponge/pkg/logger"
+
+	"weaving_net/internal/cache"
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+// DefaultPurgeTrashSpec the default cron spec of the purge trash task, 3 a.m. every day
+const DefaultPurgeTrashSpec = "0 0 3 * * *"
+
+// trashPurger permanently delete the soft deleted records of a resource
+type trashPurger interface {
+	PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// NewPurgeTrashTask create a scheduled task that permanently deletes the records of all
+// resources which have been soft deleted for more than retentionDays.
+func NewPurgeTrashTask(spec string, retentionDays int) *gocron.Task {
+	if spec == "" {
+		spec = DefaultPurgeTrashSpec
+	}
+
+	purgers := map[string]trashPurger{
+		"users":             dao.NewUsersDaoByDriver(cache.NewUsersCache(model.GetCacheType())),
+		"educations":        dao.NewEducationsDaoByDriver(cache.NewEducationsCache(model.GetCacheType())),
+		"projects":          dao.NewProjectsDaoByDriver(cache.NewProjectsCache(model.GetCacheType())),
+		"skills":            dao.NewSkillsDaoByDriver(cache.NewSkillsCache(model.GetCacheType())),
+		"userIntroductions": dao.NewUserIntroductionsDaoByDriver(cache.NewUserIntroductionsCache(model.GetCacheType())),
+		"workexperiences":   dao.NewWorkexperiencesDaoByDriver(cache.NewWorkexperiencesCache(model.GetCacheType())),
+	}
+
+	return &gocron.Task{
+		TimeSpec: spec,
+		Name:     "purgeTrash",
+		Fn: func() {
+			purgeTrash(context.Background(), purgers, time.Now().AddDate(0, 0, -retentionDays))
+		},
+	}
+}
+
+// purge the records soft deleted before the time, an error of one resource does not stop the others
+func purgeTrash(ctx context.Context, purgers map[string]trashPurger, before time.Time) map[string]int64 {
+	result := make(map[string]int64, len(purgers))
+	for name, purger := range purgers {
+		rows, err := purger.PurgeDeletedBefore(ctx, before)
+		if err != nil {
+			logger.Error("PurgeDeletedBefore error", logger.Err(err), logger.String("resource", name), logger.Any("before", before))
+			continue
+		}
+		result[name] = rows
+		if rows > 0 {
+			logger.Info("purge trash succeeded", logger.String("resource", name), logger.Int64("rows", rows), logger.Any("before", before))
+		}
+	}
+	return result
+}
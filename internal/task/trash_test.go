@@ -0,0 +1,47 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhufuyi/sponge/pkg/gotest"
+
+	"weaving_net/internal/dao"
+	"weaving_net/internal/model"
+)
+
+type errPurger struct{}
+
+func (p errPurger) PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	return 0, errors.New("mock error")
+}
+
+func Test_purgeTrash(t *testing.T) {
+	d := gotest.NewDao(nil, &model.Skills{})
+	defer d.Close()
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(d.AnyTime).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	d.SQLMock.ExpectCommit()
+
+	result := purgeTrash(context.Background(), map[string]trashPurger{
+		"skills":   dao.NewSkillsDao(d.DB, nil),
+		"projects": errPurger{},
+	}, time.Now().AddDate(0, 0, -30))
+
+	assert.Equal(t, map[string]int64{"skills": 2}, result)
+}
+
+func TestNewPurgeTrashTask(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewPurgeTrashTask("", 30)
+}
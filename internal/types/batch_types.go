@@ -0,0 +1,27 @@
+package types
+
+const (
+	// BatchModeAtomic all items succeed or the whole batch is rolled back, it is the default mode
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort the successful items are committed even if some items fail
+	BatchModeBestEffort = "bestEffort"
+)
+
+// BatchItemResult the result of an item in the batch, in the same order as the request items
+type BatchItemResult struct {
+	Index int    `json:"index"`        // index of the item in the request
+	ID    uint64 `json:"id,omitempty"` // id of the record, the created id for batch create
+	Code  int    `json:"code"`         // return code of the item, 0 means success
+	Msg   string `json:"msg"`          // return information description of the item
+}
+
+// BatchRespond only for api docs
+type BatchRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Results   []BatchItemResult `json:"results"`
+		Succeeded int               `json:"succeeded"` // number of the successful items
+		Failed    int               `json:"failed"`    // number of the failed items
+	} `json:"data"` // return data
+}
@@ -0,0 +1,44 @@
+package types
+
+// WarmupCacheRequest request params
+type WarmupCacheRequest struct {
+	Limit int `json:"limit" binding:"min=0,max=10000"` // the number of the most viewed profiles that are preloaded, default is 100
+}
+
+// WarmupCacheRespond only for api docs
+type WarmupCacheRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Users       int            `json:"users"`       // the number of the preloaded profiles
+		Collections map[string]int `json:"collections"` // the number of the users whose records are preloaded, the key is the table name
+	} `json:"data"` // return data
+}
+
+// VerifyCacheRequest request params
+type VerifyCacheRequest struct {
+	Sample int  `json:"sample" binding:"min=0,max=10000"` // the number of the sampled cached values of each table, default is 100
+	Repair bool `json:"repair" binding:""`                // whether the divergent values are deleted from the cache
+}
+
+// CacheDivergence a cached value that is not the same as database
+type CacheDivergence struct {
+	ID     uint64 `json:"id,omitempty"`     // the id of the record
+	UserID int    `json:"userId,omitempty"` // the user id of the records cached by user
+	Reason string `json:"reason"`
+}
+
+// VerifyCacheRespond only for api docs
+type VerifyCacheRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Reports []struct {
+			Name        string             `json:"name"` // the table name, the records of the users are named like skills:user
+			Checked     int                `json:"checked"`
+			Divergences []*CacheDivergence `json:"divergences"`
+			Repaired    bool               `json:"repaired"`
+			Error       string             `json:"error"`
+		} `json:"reports"`
+	} `json:"data"` // return data
+}
@@ -0,0 +1,15 @@
+package types
+
+import (
+	"github.com/zhufuyi/sponge/pkg/ggorm/query"
+)
+
+// ListByCursorRequest request params of a keyset page, the first page is requested without cursor, the other pages
+// are requested with the nextCursor or prevCursor of the last page and the same columns as the first page.
+type ListByCursorRequest struct {
+	Cursor  string         `json:"cursor" binding:""`             // nextCursor or prevCursor of the last page, empty means the first page
+	Limit   int            `json:"limit" binding:"min=0,max=100"` // size of the page, default is 10
+	Sort    string         `json:"sort" binding:""`               // sort of the first page, the same as the sort of list, id is added as the last sort column, default is -id
+	Columns []query.Column `json:"columns" binding:""`            // filters, the same as the columns of list
+	Filter  *Filter        `json:"filter" binding:""`             // conditions, the same as the filter of list
+}
@@ -12,44 +12,71 @@ var _ time.Time
 
 // CreateEducationsRequest request params
 type CreateEducationsRequest struct {
-	UserID       int       `json:"userId" binding:""`       // 用户ID
-	School       string    `json:"school" binding:""`       // 学校
-	Degree       string    `json:"degree" binding:""`       // 学位
-	FieldOfStudy string    `json:"fieldOfStudy" binding:""` // 专业
-	StartDate    time.Time `json:"startDate" binding:""`    // 开始日期
-	EndDate      time.Time `json:"endDate" binding:""`      // 结束日期
-	Gpa          string    `json:"gpa" binding:""`          // 平均成绩
-	Activities   string    `json:"activities" binding:""`   // 活动/社团
+	UserID       int        `json:"userId" binding:""`       // 用户ID
+	School       string     `json:"school" binding:""`       // 学校
+	Degree       string     `json:"degree" binding:""`       // 学位
+	FieldOfStudy string     `json:"fieldOfStudy" binding:""` // 专业
+	StartDate    time.Time  `json:"startDate" binding:""`    // 开始日期
+	EndDate      *time.Time `json:"endDate" binding:""`      // 结束日期，为空表示至今
+	IsCurrent    bool       `json:"isCurrent" binding:""`    // 是否在读，为true时结束日期必须为空
+	Gpa          string     `json:"gpa" binding:""`          // 平均成绩
+	Activities   string     `json:"activities" binding:""`   // 活动/社团
+	Position     int        `json:"position" binding:""`     // 排序位置，同一用户内从小到大排列，创建时为0表示排在最后
 }
 
 // UpdateEducationsByIDRequest request params
 type UpdateEducationsByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 
-	UserID       int       `json:"userId" binding:""`       // 用户ID
-	School       string    `json:"school" binding:""`       // 学校
-	Degree       string    `json:"degree" binding:""`       // 学位
-	FieldOfStudy string    `json:"fieldOfStudy" binding:""` // 专业
-	StartDate    time.Time `json:"startDate" binding:""`    // 开始日期
-	EndDate      time.Time `json:"endDate" binding:""`      // 结束日期
-	Gpa          string    `json:"gpa" binding:""`          // 平均成绩
-	Activities   string    `json:"activities" binding:""`   // 活动/社团
+	UserID       int        `json:"userId" binding:""`       // 用户ID
+	School       string     `json:"school" binding:""`       // 学校
+	Degree       string     `json:"degree" binding:""`       // 学位
+	FieldOfStudy string     `json:"fieldOfStudy" binding:""` // 专业
+	StartDate    time.Time  `json:"startDate" binding:""`    // 开始日期
+	EndDate      *time.Time `json:"endDate" binding:""`      // 结束日期，为空表示至今
+	IsCurrent    bool       `json:"isCurrent" binding:""`    // 是否在读，为true时结束日期必须为空
+	Gpa          string     `json:"gpa" binding:""`          // 平均成绩
+	Activities   string     `json:"activities" binding:""`   // 活动/社团
+	Position     int        `json:"position" binding:""`     // 排序位置，同一用户内从小到大排列，为0时不修改
+}
+
+// CreateEducationssBatchRequest request params
+type CreateEducationssBatchRequest struct {
+	Mode  string                    `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []CreateEducationsRequest `json:"items" binding:"required,min=1,max=100,dive"`      // items to be created
+}
+
+// UpdateEducationsBatchItem an item of the batch update, all fields of the record are replaced as UpdateByID does
+type UpdateEducationsBatchItem struct {
+	UpdateEducationsByIDRequest
+	Version int `json:"version" binding:"required"` // 版本号，GetByID返回的version，记录已被修改时该项失败
+}
+
+// UpdateEducationssBatchRequest request params, the id and the version of each item are required
+type UpdateEducationssBatchRequest struct {
+	Mode  string                      `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []UpdateEducationsBatchItem `json:"items" binding:"required,min=1,max=100,dive"`      // items to be updated
 }
 
 // EducationsObjDetail detail
 type EducationsObjDetail struct {
 	ID string `json:"id"` // convert to string id
 
-	UserID       int       `json:"userId"`       // 用户ID
-	School       string    `json:"school"`       // 学校
-	Degree       string    `json:"degree"`       // 学位
-	FieldOfStudy string    `json:"fieldOfStudy"` // 专业
-	StartDate    time.Time `json:"startDate"`    // 开始日期
-	EndDate      time.Time `json:"endDate"`      // 结束日期
-	Gpa          string    `json:"gpa"`          // 平均成绩
-	Activities   string    `json:"activities"`   // 活动/社团
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	UserID       int        `json:"userId"`       // 用户ID
+	School       string     `json:"school"`       // 学校
+	Degree       string     `json:"degree"`       // 学位
+	FieldOfStudy string     `json:"fieldOfStudy"` // 专业
+	StartDate    time.Time  `json:"startDate"`    // 开始日期
+	EndDate      *time.Time `json:"endDate"`      // 结束日期，为空表示至今
+	IsCurrent    bool       `json:"isCurrent"`    // 是否在读
+	Gpa          string     `json:"gpa"`          // 平均成绩
+	Activities   string     `json:"activities"`   // 活动/社团
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	Position     int        `json:"position"` // 排序位置，同一用户内从小到大排列
+	Version      int        `json:"version"`
+
+	User *UsersObjDetail `json:"user,omitempty"` // 所属用户，expand=user时返回
 }
 
 // CreateEducationsRespond only for api docs
@@ -66,6 +93,14 @@ type UpdateEducationsByIDRespond struct {
 	Result
 }
 
+// PatchEducationsByIDRequest request params, only the fields present are changed, null clears a field
+type PatchEducationsByIDRequest map[string]interface{}
+
+// PatchEducationsByIDRespond only for api docs
+type PatchEducationsByIDRespond struct {
+	Result
+}
+
 // GetEducationsByIDRespond only for api docs
 type GetEducationsByIDRespond struct {
 	Code int    `json:"code"` // return code
@@ -121,6 +156,7 @@ type ListEducationssByIDsRespond struct {
 // ListEducationssRequest request params
 type ListEducationssRequest struct {
 	query.Params
+	Filter *Filter `json:"filter" binding:""` // conditions with all the operators and groups, and-ed with the columns
 }
 
 // ListEducationssRespond only for api docs
@@ -131,3 +167,33 @@ type ListEducationssRespond struct {
 		Educationss []EducationsObjDetail `json:"educationss"`
 	} `json:"data"` // return data
 }
+
+// ListEducationssByUserIDRespond only for api docs
+type ListEducationssByUserIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Educationss []EducationsObjDetail `json:"educationss"`
+	} `json:"data"` // return data
+}
+
+// ReorderEducationssRequest request params
+type ReorderEducationssRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // all the ids of the user in the new order
+}
+
+// ReorderEducationssRespond only for api docs
+type ReorderEducationssRespond struct {
+	Result
+}
+
+// ListEducationssByCursorRespond only for api docs
+type ListEducationssByCursorRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Educationss []EducationsObjDetail `json:"educationss"`
+		NextCursor  string                `json:"nextCursor"` // cursor of the next page, empty means there is no next page
+		PrevCursor  string                `json:"prevCursor"` // cursor of the previous page, empty means there is no previous page
+	} `json:"data"` // return data
+}
@@ -0,0 +1,18 @@
+package types
+
+// Filter a condition of a list, it is either a column condition or a group of conditions joined by and/or.
+//
+// the operators of the column conditions are eq, neq, gt, gte, lt, lte, like, ilike, starts_with, in, between,
+// isnull and notnull, the value of in and between is an array, the value of isnull and notnull is ignored.
+// the value of a time column can be relative to now, e.g. now-5y, now+1M, now-7d, the units are y, M, w, d, h, m, s.
+//
+// example:
+//
+//	{"or": [{"name": "startDate", "exp": "gte", "value": "now-5y"}, {"name": "endDate", "exp": "isnull"}]}
+type Filter struct {
+	Name  string      `json:"name,omitempty"`  // column name
+	Exp   string      `json:"exp,omitempty"`   // operator, default is eq
+	Value interface{} `json:"value,omitempty"` // column value
+	And   []*Filter   `json:"and,omitempty"`   // all the conditions are true
+	Or    []*Filter   `json:"or,omitempty"`    // any of the conditions is true
+}
@@ -0,0 +1,42 @@
+package types
+
+// ReplaceProfileRequest request params, the records of the user are replaced by the records in their order,
+// the userId of the records is ignored.
+type ReplaceProfileRequest struct {
+	User              CreateUsersRequest               `json:"user" binding:"required"`                  // 用户
+	Educations        []CreateEducationsRequest        `json:"educations" binding:"max=100,dive"`        // 教育经历列表
+	Projects          []CreateProjectsRequest          `json:"projects" binding:"max=100,dive"`          // 项目列表
+	Skills            []CreateSkillsRequest            `json:"skills" binding:"max=100,dive"`            // 技能列表
+	UserIntroductions []CreateUserIntroductionsRequest `json:"userIntroductions" binding:"max=100,dive"` // 个人介绍列表
+	Workexperiences   []CreateWorkexperiencesRequest   `json:"workexperiences" binding:"max=100,dive"`   // 工作经历列表
+}
+
+// ProfileIDs the ids of the records of the replaced profile, in the order of the request
+type ProfileIDs struct {
+	Educations        []uint64 `json:"educations"`
+	Projects          []uint64 `json:"projects"`
+	Skills            []uint64 `json:"skills"`
+	UserIntroductions []uint64 `json:"userIntroductions"`
+	Workexperiences   []uint64 `json:"workexperiences"`
+}
+
+// ReplaceProfileRespond only for api docs
+type ReplaceProfileRespond struct {
+	Code int        `json:"code"` // return code
+	Msg  string     `json:"msg"`  // return information description
+	Data ProfileIDs `json:"data"` // return data
+}
+
+// MoveProjectRequest request params
+type MoveProjectRequest struct {
+	UserID int `json:"userId" binding:"gt=0"` // the user that the project is moved to
+}
+
+// MoveProjectRespond only for api docs
+type MoveProjectRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Projects ProjectsObjDetail `json:"projects"`
+	} `json:"data"` // return data
+}
@@ -16,6 +16,7 @@ type CreateProjectsRequest struct {
 	ProjectName string `json:"projectName" binding:""` // 项目名称
 	Role        string `json:"role" binding:""`        // 所担任角色
 	Description string `json:"description" binding:""` // 项目介绍/成就
+	Position    int    `json:"position" binding:""`    // 排序位置，同一用户内从小到大排列，创建时为0表示排在最后
 }
 
 // UpdateProjectsByIDRequest request params
@@ -26,6 +27,25 @@ type UpdateProjectsByIDRequest struct {
 	ProjectName string `json:"projectName" binding:""` // 项目名称
 	Role        string `json:"role" binding:""`        // 所担任角色
 	Description string `json:"description" binding:""` // 项目介绍/成就
+	Position    int    `json:"position" binding:""`    // 排序位置，同一用户内从小到大排列，为0时不修改
+}
+
+// CreateProjectssBatchRequest request params
+type CreateProjectssBatchRequest struct {
+	Mode  string                  `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []CreateProjectsRequest `json:"items" binding:"required,min=1,max=100,dive"`      // items to be created
+}
+
+// UpdateProjectsBatchItem an item of the batch update, all fields of the record are replaced as UpdateByID does
+type UpdateProjectsBatchItem struct {
+	UpdateProjectsByIDRequest
+	Version int `json:"version" binding:"required"` // 版本号，GetByID返回的version，记录已被修改时该项失败
+}
+
+// UpdateProjectssBatchRequest request params, the id and the version of each item are required
+type UpdateProjectssBatchRequest struct {
+	Mode  string                    `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []UpdateProjectsBatchItem `json:"items" binding:"required,min=1,max=100,dive"`      // items to be updated
 }
 
 // ProjectsObjDetail detail
@@ -38,6 +58,10 @@ type ProjectsObjDetail struct {
 	Description string    `json:"description"` // 项目介绍/成就
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+	Position    int       `json:"position"` // 排序位置，同一用户内从小到大排列
+	Version     int       `json:"version"`
+
+	User *UsersObjDetail `json:"user,omitempty"` // 所属用户，expand=user时返回
 }
 
 // CreateProjectsRespond only for api docs
@@ -54,6 +78,14 @@ type UpdateProjectsByIDRespond struct {
 	Result
 }
 
+// PatchProjectsByIDRequest request params, only the fields present are changed, null clears a field
+type PatchProjectsByIDRequest map[string]interface{}
+
+// PatchProjectsByIDRespond only for api docs
+type PatchProjectsByIDRespond struct {
+	Result
+}
+
 // GetProjectsByIDRespond only for api docs
 type GetProjectsByIDRespond struct {
 	Code int    `json:"code"` // return code
@@ -109,6 +141,7 @@ type ListProjectssByIDsRespond struct {
 // ListProjectssRequest request params
 type ListProjectssRequest struct {
 	query.Params
+	Filter *Filter `json:"filter" binding:""` // conditions with all the operators and groups, and-ed with the columns
 }
 
 // ListProjectssRespond only for api docs
@@ -119,3 +152,33 @@ type ListProjectssRespond struct {
 		Projectss []ProjectsObjDetail `json:"projectss"`
 	} `json:"data"` // return data
 }
+
+// ReorderProjectssRequest request params
+type ReorderProjectssRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // all the ids of the user in the new order
+}
+
+// ReorderProjectssRespond only for api docs
+type ReorderProjectssRespond struct {
+	Result
+}
+
+// ListProjectssByUserIDRespond only for api docs
+type ListProjectssByUserIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Projectss []ProjectsObjDetail `json:"projectss"`
+	} `json:"data"` // return data
+}
+
+// ListProjectssByCursorRespond only for api docs
+type ListProjectssByCursorRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Projectss  []ProjectsObjDetail `json:"projectss"`
+		NextCursor string              `json:"nextCursor"` // cursor of the next page, empty means there is no next page
+		PrevCursor string              `json:"prevCursor"` // cursor of the previous page, empty means there is no previous page
+	} `json:"data"` // return data
+}
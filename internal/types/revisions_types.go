@@ -0,0 +1,55 @@
+package types
+
+import (
+	"time"
+)
+
+// RevisionsObjDetail detail
+type RevisionsObjDetail struct {
+	ID string `json:"id"` // convert to string id
+
+	ResourceType string                 `json:"resourceType"` // resource type, e.g. workexperiences
+	ResourceID   uint64                 `json:"resourceId"`   // resource id
+	UserID       int                    `json:"userId"`       // user id
+	Version      int                    `json:"version"`      // version, starting from 1
+	Snapshot     map[string]interface{} `json:"snapshot"`     // the data of the resource before it was updated
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// RevisionFieldDiff a field that differs between two revisions
+type RevisionFieldDiff struct {
+	Field string      `json:"field"` // field name
+	From  interface{} `json:"from"`  // value in the from revision
+	To    interface{} `json:"to"`    // value in the to revision
+}
+
+// ListRevisionsRespond only for api docs
+type ListRevisionsRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Revisions []RevisionsObjDetail `json:"revisions"`
+		Total     int64                `json:"total"`
+	} `json:"data"` // return data
+}
+
+// DiffRevisionsRespond only for api docs
+type DiffRevisionsRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		From  int                 `json:"from"`  // from version
+		To    int                 `json:"to"`    // to version, 0 means the current data
+		Diffs []RevisionFieldDiff `json:"diffs"` // changed fields
+	} `json:"data"` // return data
+}
+
+// RestoreRevisionRequest request params
+type RestoreRevisionRequest struct {
+	Version int `json:"version" binding:"gt=0"` // the version to restore
+}
+
+// RestoreRevisionRespond only for api docs
+type RestoreRevisionRespond struct {
+	Result
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"time"
+)
+
+// CreateSkillAssessmentsRequest request params
+type CreateSkillAssessmentsRequest struct {
+	CatalogID     uint64                                  `json:"catalogId" binding:"required"`                    // canonical skill id
+	Title         string                                  `json:"title" binding:"required,max=100"`                // title
+	PassScore     int                                     `json:"passScore" binding:"required,min=1,max=100"`      // pass score, the full score is 100
+	CooldownHours int                                     `json:"cooldownHours" binding:"min=0,max=8760"`          // hours to wait before retaking, 0 means no cooldown
+	Questions     []CreateSkillAssessmentQuestionsRequest `json:"questions" binding:"required,min=1,max=100,dive"` // questions in order
+}
+
+// CreateSkillAssessmentQuestionsRequest request params
+type CreateSkillAssessmentQuestionsRequest struct {
+	Content string   `json:"content" binding:"required,max=500"`                   // question
+	Options []string `json:"options" binding:"min=2,max=10,dive,required,max=200"` // options
+	Answer  int      `json:"answer" binding:"min=0"`                               // index of the right option, starting from 0
+}
+
+// CreateSkillAssessmentsRespond only for api docs
+type CreateSkillAssessmentsRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		ID uint64 `json:"id"` // id
+	} `json:"data"` // return data
+}
+
+// SkillAssessmentsObjDetail detail
+type SkillAssessmentsObjDetail struct {
+	ID string `json:"id"` // convert to string id
+
+	CatalogID     uint64    `json:"catalogId"`     // canonical skill id
+	Title         string    `json:"title"`         // title
+	PassScore     int       `json:"passScore"`     // pass score, the full score is 100
+	CooldownHours int       `json:"cooldownHours"` // hours to wait before retaking
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// SkillAssessmentQuestionsObjDetail detail, the answer is not included
+type SkillAssessmentQuestionsObjDetail struct {
+	ID string `json:"id"` // convert to string id
+
+	Content string   `json:"content"` // question
+	Options []string `json:"options"` // options
+}
+
+// GetSkillAssessmentsByIDRespond only for api docs
+type GetSkillAssessmentsByIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SkillAssessments SkillAssessmentsObjDetail           `json:"skillAssessments"`
+		Questions        []SkillAssessmentQuestionsObjDetail `json:"questions"` // questions in order
+	} `json:"data"` // return data
+}
+
+// ListSkillAssessmentsByCatalogIDRespond only for api docs
+type ListSkillAssessmentsByCatalogIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SkillAssessmentss []SkillAssessmentsObjDetail `json:"skillAssessmentss"`
+	} `json:"data"` // return data
+}
+
+// SubmitSkillAssessmentsRequest request params
+type SubmitSkillAssessmentsRequest struct {
+	SkillID uint64                  `json:"skillId" binding:"required"`                    // id of the skill to be verified
+	Answers []SkillAssessmentAnswer `json:"answers" binding:"required,min=1,max=100,dive"` // answers, the unanswered questions are wrong
+}
+
+// SkillAssessmentAnswer answer of a question
+type SkillAssessmentAnswer struct {
+	QuestionID uint64 `json:"questionId" binding:"required"` // question id
+	Option     int    `json:"option" binding:"min=0"`        // index of the chosen option, starting from 0
+}
+
+// SubmitSkillAssessmentsRespond only for api docs
+type SubmitSkillAssessmentsRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Score     int  `json:"score"`     // score, the full score is 100
+		Level     int  `json:"level"`     // proficiency level of the score
+		Passed    bool `json:"passed"`    // whether the skill is verified
+		PassScore int  `json:"passScore"` // pass score
+	} `json:"data"` // return data
+}
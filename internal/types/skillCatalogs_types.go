@@ -0,0 +1,97 @@
+package types
+
+import (
+	"time"
+)
+
+// CreateSkillCatalogsRequest request params
+type CreateSkillCatalogsRequest struct {
+	Name       string   `json:"name" binding:"required,max=50"`        // canonical name, e.g. Go
+	CategoryID uint64   `json:"categoryId" binding:""`                 // category id, 0 means no category
+	ParentID   uint64   `json:"parentId" binding:""`                   // parent skill id, 0 means a top level skill
+	Synonyms   []string `json:"synonyms" binding:"max=50,dive,max=50"` // synonyms, e.g. golang
+}
+
+// CreateSkillCatalogsRespond only for api docs
+type CreateSkillCatalogsRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		ID uint64 `json:"id"` // id
+	} `json:"data"` // return data
+}
+
+// SkillCatalogsObjDetail detail
+type SkillCatalogsObjDetail struct {
+	ID string `json:"id"` // convert to string id
+
+	Name       string    `json:"name"`       // canonical name
+	CategoryID uint64    `json:"categoryId"` // category id
+	ParentID   uint64    `json:"parentId"`   // parent skill id
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// GetSkillCatalogsByIDRespond only for api docs
+type GetSkillCatalogsByIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SkillCatalogs SkillCatalogsObjDetail   `json:"skillCatalogs"`
+		Synonyms      []string                 `json:"synonyms"` // synonyms of the skill
+		Children      []SkillCatalogsObjDetail `json:"children"` // child skills
+	} `json:"data"` // return data
+}
+
+// AddSkillCatalogsSynonymsRequest request params
+type AddSkillCatalogsSynonymsRequest struct {
+	Names []string `json:"names" binding:"min=1,max=50,dive,required,max=50"` // synonyms to be added
+}
+
+// AddSkillCatalogsSynonymsRespond only for api docs
+type AddSkillCatalogsSynonymsRespond struct {
+	Result
+}
+
+// SuggestSkillCatalogsRespond only for api docs
+type SuggestSkillCatalogsRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SkillCatalogss []SkillCatalogsObjDetail `json:"skillCatalogss"`
+	} `json:"data"` // return data
+}
+
+// CreateSkillCategoriesRequest request params
+type CreateSkillCategoriesRequest struct {
+	Name        string `json:"name" binding:"required,max=50"` // category name, e.g. Programming Languages
+	Description string `json:"description" binding:"max=255"`  // description
+}
+
+// CreateSkillCategoriesRespond only for api docs
+type CreateSkillCategoriesRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		ID uint64 `json:"id"` // id
+	} `json:"data"` // return data
+}
+
+// SkillCategoriesObjDetail detail
+type SkillCategoriesObjDetail struct {
+	ID string `json:"id"` // convert to string id
+
+	Name        string    `json:"name"`        // category name
+	Description string    `json:"description"` // description
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ListSkillCategoriesRespond only for api docs
+type ListSkillCategoriesRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SkillCategoriess []SkillCategoriesObjDetail `json:"skillCategoriess"`
+	} `json:"data"` // return data
+}
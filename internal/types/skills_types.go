@@ -13,9 +13,13 @@ var _ time.Time
 // CreateSkillsRequest request params
 type CreateSkillsRequest struct {
 	UserID           int    `json:"userId" binding:""`           // 用户ID
-	SkillType        string `json:"skillType" binding:""`        // 技能类型
+	SkillType        string `json:"skillType" binding:""`        // 技能类型，已废弃，使用categoryId，匹配到技能目录时为分类名称
 	SkillName        string `json:"skillName" binding:""`        // 技能名称
-	ProficiencyLevel string `json:"proficiencyLevel" binding:""` // 熟练程度
+	ProficiencyLevel string `json:"proficiencyLevel" binding:""` // 熟练程度，已废弃，使用proficiency，未设置proficiency时按文本映射到等级
+	Proficiency      int    `json:"proficiency" binding:"max=5"` // 熟练程度等级，1入门 2初级 3中级 4高级 5专家
+	CatalogID        uint64 `json:"catalogId" binding:""`        // 标准技能ID，为0时按技能名称匹配技能目录
+	CategoryID       uint64 `json:"categoryId" binding:""`       // 技能分类ID
+	Position         int    `json:"position" binding:""`         // 排序位置，同一用户内从小到大排列，创建时为0表示排在最后
 }
 
 // UpdateSkillsByIDRequest request params
@@ -23,21 +27,52 @@ type UpdateSkillsByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 
 	UserID           int    `json:"userId" binding:""`           // 用户ID
-	SkillType        string `json:"skillType" binding:""`        // 技能类型
+	SkillType        string `json:"skillType" binding:""`        // 技能类型，已废弃，使用categoryId，匹配到技能目录时为分类名称
 	SkillName        string `json:"skillName" binding:""`        // 技能名称
-	ProficiencyLevel string `json:"proficiencyLevel" binding:""` // 熟练程度
+	ProficiencyLevel string `json:"proficiencyLevel" binding:""` // 熟练程度，已废弃，使用proficiency，未设置proficiency时按文本映射到等级
+	Proficiency      int    `json:"proficiency" binding:"max=5"` // 熟练程度等级，1入门 2初级 3中级 4高级 5专家
+	CatalogID        uint64 `json:"catalogId" binding:""`        // 标准技能ID，为0时按技能名称匹配技能目录
+	CategoryID       uint64 `json:"categoryId" binding:""`       // 技能分类ID
+	Position         int    `json:"position" binding:""`         // 排序位置，同一用户内从小到大排列，为0时不修改
+}
+
+// CreateSkillssBatchRequest request params
+type CreateSkillssBatchRequest struct {
+	Mode  string                `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []CreateSkillsRequest `json:"items" binding:"required,min=1,max=100,dive"`      // items to be created
+}
+
+// UpdateSkillsBatchItem an item of the batch update, all fields of the record are replaced as UpdateByID does
+type UpdateSkillsBatchItem struct {
+	UpdateSkillsByIDRequest
+	Version int `json:"version" binding:"required"` // 版本号，GetByID返回的version，记录已被修改时该项失败
+}
+
+// UpdateSkillssBatchRequest request params, the id and the version of each item are required
+type UpdateSkillssBatchRequest struct {
+	Mode  string                  `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []UpdateSkillsBatchItem `json:"items" binding:"required,min=1,max=100,dive"`      // items to be updated
 }
 
 // SkillsObjDetail detail
 type SkillsObjDetail struct {
 	ID string `json:"id"` // convert to string id
 
-	UserID           int       `json:"userId"`           // 用户ID
-	SkillType        string    `json:"skillType"`        // 技能类型
-	SkillName        string    `json:"skillName"`        // 技能名称
-	ProficiencyLevel string    `json:"proficiencyLevel"` // 熟练程度
-	CreatedAt        time.Time `json:"createdAt"`
-	UpdatedAt        time.Time `json:"updatedAt"`
+	UserID           int        `json:"userId"`           // 用户ID
+	SkillType        string     `json:"skillType"`        // 技能类型，已废弃，使用categoryId
+	SkillName        string     `json:"skillName"`        // 技能名称
+	ProficiencyLevel string     `json:"proficiencyLevel"` // 熟练程度等级名称，已废弃，使用proficiency
+	Proficiency      int        `json:"proficiency"`      // 熟练程度等级，1入门 2初级 3中级 4高级 5专家，0表示未设置
+	CatalogID        uint64     `json:"catalogId"`        // 标准技能ID，0表示未匹配到技能目录
+	CategoryID       uint64     `json:"categoryId"`       // 技能分类ID
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	Position         int        `json:"position"` // 排序位置，同一用户内从小到大排列
+	Version          int        `json:"version"`
+	VerifiedLevel    int        `json:"verifiedLevel"` // 通过测评认证的熟练程度等级，0表示未认证
+	VerifiedAt       *time.Time `json:"verifiedAt"`    // 通过测评认证的时间
+
+	User *UsersObjDetail `json:"user,omitempty"` // 所属用户，expand=user时返回
 }
 
 // CreateSkillsRespond only for api docs
@@ -54,6 +89,14 @@ type UpdateSkillsByIDRespond struct {
 	Result
 }
 
+// PatchSkillsByIDRequest request params, only the fields present are changed, null clears a field
+type PatchSkillsByIDRequest map[string]interface{}
+
+// PatchSkillsByIDRespond only for api docs
+type PatchSkillsByIDRespond struct {
+	Result
+}
+
 // GetSkillsByIDRespond only for api docs
 type GetSkillsByIDRespond struct {
 	Code int    `json:"code"` // return code
@@ -109,6 +152,7 @@ type ListSkillssByIDsRespond struct {
 // ListSkillssRequest request params
 type ListSkillssRequest struct {
 	query.Params
+	Filter *Filter `json:"filter" binding:""` // conditions with all the operators and groups, and-ed with the columns
 }
 
 // ListSkillssRespond only for api docs
@@ -119,3 +163,33 @@ type ListSkillssRespond struct {
 		Skillss []SkillsObjDetail `json:"skillss"`
 	} `json:"data"` // return data
 }
+
+// ReorderSkillssRequest request params
+type ReorderSkillssRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // all the ids of the user in the new order
+}
+
+// ReorderSkillssRespond only for api docs
+type ReorderSkillssRespond struct {
+	Result
+}
+
+// ListSkillssByUserIDRespond only for api docs
+type ListSkillssByUserIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Skillss []SkillsObjDetail `json:"skillss"`
+	} `json:"data"` // return data
+}
+
+// ListSkillssByCursorRespond only for api docs
+type ListSkillssByCursorRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Skillss    []SkillsObjDetail `json:"skillss"`
+		NextCursor string            `json:"nextCursor"` // cursor of the next page, empty means there is no next page
+		PrevCursor string            `json:"prevCursor"` // cursor of the previous page, empty means there is no previous page
+	} `json:"data"` // return data
+}
@@ -0,0 +1,31 @@
+package types
+
+// ListTrashRespond only for api docs
+type ListTrashRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Records []interface{} `json:"records"` // the detail of the resource, e.g. WorkexperiencesObjDetail
+		Total   int64         `json:"total"`
+	} `json:"data"` // return data
+}
+
+// RestoreTrashByIDsRequest request params
+type RestoreTrashByIDsRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // id list
+}
+
+// RestoreTrashRespond only for api docs
+type RestoreTrashRespond struct {
+	Result
+}
+
+// PurgeTrashByIDsRequest request params
+type PurgeTrashByIDsRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // id list
+}
+
+// PurgeTrashRespond only for api docs
+type PurgeTrashRespond struct {
+	Result
+}
@@ -12,18 +12,38 @@ var _ time.Time
 
 // CreateUserIntroductionsRequest request params
 type CreateUserIntroductionsRequest struct {
-	UserID  int    `json:"userId" binding:""`
-	Title   string `json:"title" binding:""`   // 介绍标题
-	Content string `json:"content" binding:""` // 介绍内容
+	UserID   int    `json:"userId" binding:""`
+	Title    string `json:"title" binding:""`    // 介绍标题
+	Content  string `json:"content" binding:""`  // 介绍内容
+	Position int    `json:"position" binding:""` // 排序位置，同一用户内从小到大排列，创建时为0表示排在最后
 }
 
 // UpdateUserIntroductionsByIDRequest request params
 type UpdateUserIntroductionsByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 
-	UserID  int    `json:"userId" binding:""`
-	Title   string `json:"title" binding:""`   // 介绍标题
-	Content string `json:"content" binding:""` // 介绍内容
+	UserID   int    `json:"userId" binding:""`
+	Title    string `json:"title" binding:""`    // 介绍标题
+	Content  string `json:"content" binding:""`  // 介绍内容
+	Position int    `json:"position" binding:""` // 排序位置，同一用户内从小到大排列，为0时不修改
+}
+
+// CreateUserIntroductionssBatchRequest request params
+type CreateUserIntroductionssBatchRequest struct {
+	Mode  string                           `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []CreateUserIntroductionsRequest `json:"items" binding:"required,min=1,max=100,dive"`      // items to be created
+}
+
+// UpdateUserIntroductionsBatchItem an item of the batch update, all fields of the record are replaced as UpdateByID does
+type UpdateUserIntroductionsBatchItem struct {
+	UpdateUserIntroductionsByIDRequest
+	Version int `json:"version" binding:"required"` // 版本号，GetByID返回的version，记录已被修改时该项失败
+}
+
+// UpdateUserIntroductionssBatchRequest request params, the id and the version of each item are required
+type UpdateUserIntroductionssBatchRequest struct {
+	Mode  string                             `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []UpdateUserIntroductionsBatchItem `json:"items" binding:"required,min=1,max=100,dive"`      // items to be updated
 }
 
 // UserIntroductionsObjDetail detail
@@ -35,6 +55,10 @@ type UserIntroductionsObjDetail struct {
 	Content   string    `json:"content"` // 介绍内容
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	Position  int       `json:"position"` // 排序位置，同一用户内从小到大排列
+	Version   int       `json:"version"`
+
+	User *UsersObjDetail `json:"user,omitempty"` // 所属用户，expand=user时返回
 }
 
 // CreateUserIntroductionsRespond only for api docs
@@ -51,6 +75,14 @@ type UpdateUserIntroductionsByIDRespond struct {
 	Result
 }
 
+// PatchUserIntroductionsByIDRequest request params, only the fields present are changed, null clears a field
+type PatchUserIntroductionsByIDRequest map[string]interface{}
+
+// PatchUserIntroductionsByIDRespond only for api docs
+type PatchUserIntroductionsByIDRespond struct {
+	Result
+}
+
 // GetUserIntroductionsByIDRespond only for api docs
 type GetUserIntroductionsByIDRespond struct {
 	Code int    `json:"code"` // return code
@@ -106,6 +138,7 @@ type ListUserIntroductionssByIDsRespond struct {
 // ListUserIntroductionssRequest request params
 type ListUserIntroductionssRequest struct {
 	query.Params
+	Filter *Filter `json:"filter" binding:""` // conditions with all the operators and groups, and-ed with the columns
 }
 
 // ListUserIntroductionssRespond only for api docs
@@ -116,3 +149,33 @@ type ListUserIntroductionssRespond struct {
 		UserIntroductionss []UserIntroductionsObjDetail `json:"userIntroductionss"`
 	} `json:"data"` // return data
 }
+
+// ReorderUserIntroductionssRequest request params
+type ReorderUserIntroductionssRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // all the ids of the user in the new order
+}
+
+// ReorderUserIntroductionssRespond only for api docs
+type ReorderUserIntroductionssRespond struct {
+	Result
+}
+
+// ListUserIntroductionssByUserIDRespond only for api docs
+type ListUserIntroductionssByUserIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		UserIntroductionss []UserIntroductionsObjDetail `json:"userIntroductionss"`
+	} `json:"data"` // return data
+}
+
+// ListUserIntroductionssByCursorRespond only for api docs
+type ListUserIntroductionssByCursorRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		UserIntroductionss []UserIntroductionsObjDetail `json:"userIntroductionss"`
+		NextCursor         string                       `json:"nextCursor"` // cursor of the next page, empty means there is no next page
+		PrevCursor         string                       `json:"prevCursor"` // cursor of the previous page, empty means there is no previous page
+	} `json:"data"` // return data
+}
@@ -28,6 +28,24 @@ type UpdateUsersByIDRequest struct {
 	About             string `json:"about" binding:""`             // 个人简介
 }
 
+// CreateUserssBatchRequest request params
+type CreateUserssBatchRequest struct {
+	Mode  string               `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []CreateUsersRequest `json:"items" binding:"required,min=1,max=100,dive"`      // items to be created
+}
+
+// UpdateUsersBatchItem an item of the batch update, all fields of the record are replaced as UpdateByID does
+type UpdateUsersBatchItem struct {
+	UpdateUsersByIDRequest
+	Version int `json:"version" binding:"required"` // 版本号，GetByID返回的version，记录已被修改时该项失败
+}
+
+// UpdateUserssBatchRequest request params, the id and the version of each item are required
+type UpdateUserssBatchRequest struct {
+	Mode  string                 `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []UpdateUsersBatchItem `json:"items" binding:"required,min=1,max=100,dive"`      // items to be updated
+}
+
 // UsersObjDetail detail
 type UsersObjDetail struct {
 	ID string `json:"id"` // convert to string id
@@ -38,6 +56,10 @@ type UsersObjDetail struct {
 	About             string    `json:"about"`             // 个人简介
 	CreatedAt         time.Time `json:"createdAt"`
 	UpdatedAt         time.Time `json:"updatedAt"`
+	Version           int       `json:"version"`
+
+	Skills   []*SkillsObjDetail   `json:"skills,omitempty"`   // 技能列表，expand=skills时返回
+	Projects []*ProjectsObjDetail `json:"projects,omitempty"` // 项目列表，expand=projects时返回
 }
 
 // CreateUsersRespond only for api docs
@@ -54,6 +76,14 @@ type UpdateUsersByIDRespond struct {
 	Result
 }
 
+// PatchUsersByIDRequest request params, only the fields present are changed, null clears a field
+type PatchUsersByIDRequest map[string]interface{}
+
+// PatchUsersByIDRespond only for api docs
+type PatchUsersByIDRespond struct {
+	Result
+}
+
 // GetUsersByIDRespond only for api docs
 type GetUsersByIDRespond struct {
 	Code int    `json:"code"` // return code
@@ -109,6 +139,7 @@ type ListUserssByIDsRespond struct {
 // ListUserssRequest request params
 type ListUserssRequest struct {
 	query.Params
+	Filter *Filter `json:"filter" binding:""` // conditions with all the operators and groups, and-ed with the columns
 }
 
 // ListUserssRespond only for api docs
@@ -119,3 +150,14 @@ type ListUserssRespond struct {
 		Userss []UsersObjDetail `json:"userss"`
 	} `json:"data"` // return data
 }
+
+// ListUserssByCursorRespond only for api docs
+type ListUserssByCursorRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Userss     []UsersObjDetail `json:"userss"`
+		NextCursor string           `json:"nextCursor"` // cursor of the next page, empty means there is no next page
+		PrevCursor string           `json:"prevCursor"` // cursor of the previous page, empty means there is no previous page
+	} `json:"data"` // return data
+}
@@ -12,44 +12,75 @@ var _ time.Time
 
 // CreateWorkexperiencesRequest request params
 type CreateWorkexperiencesRequest struct {
-	UserID         int       `json:"userId" binding:""`         // 用户ID
-	Company        string    `json:"company" binding:""`        // 公司
-	Title          string    `json:"title" binding:""`          // 职位
-	EmploymentType string    `json:"employmentType" binding:""` // 工作类型
-	JobDescription string    `json:"jobDescription" binding:""` // 工作内容
-	Location       string    `json:"location" binding:""`       // 地点
-	StartDate      time.Time `json:"startDate" binding:""`      // 开始日期
-	EndDate        time.Time `json:"endDate" binding:""`        // 结束日期
+	UserID         int        `json:"userId" binding:""`         // 用户ID
+	Company        string     `json:"company" binding:""`        // 公司
+	Title          string     `json:"title" binding:""`          // 职位
+	EmploymentType string     `json:"employmentType" binding:""` // 工作类型
+	JobDescription string     `json:"jobDescription" binding:""` // 工作内容
+	Location       string     `json:"location" binding:""`       // 地点
+	StartDate      time.Time  `json:"startDate" binding:""`      // 开始日期
+	EndDate        *time.Time `json:"endDate" binding:""`        // 结束日期，为空表示至今
+	IsCurrent      bool       `json:"isCurrent" binding:""`      // 是否在职，为true时结束日期必须为空
+	IsPrimary      bool       `json:"isPrimary" binding:""`      // 是否为主要职位，每个用户最多一个
+	Position       int        `json:"position" binding:""`       // 排序位置，同一用户内从小到大排列，创建时为0表示排在最后
 }
 
 // UpdateWorkexperiencesByIDRequest request params
 type UpdateWorkexperiencesByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 
-	UserID         int       `json:"userId" binding:""`         // 用户ID
-	Company        string    `json:"company" binding:""`        // 公司
-	Title          string    `json:"title" binding:""`          // 职位
-	EmploymentType string    `json:"employmentType" binding:""` // 工作类型
-	JobDescription string    `json:"jobDescription" binding:""` // 工作内容
-	Location       string    `json:"location" binding:""`       // 地点
-	StartDate      time.Time `json:"startDate" binding:""`      // 开始日期
-	EndDate        time.Time `json:"endDate" binding:""`        // 结束日期
+	UserID         int        `json:"userId" binding:""`         // 用户ID
+	Company        string     `json:"company" binding:""`        // 公司
+	Title          string     `json:"title" binding:""`          // 职位
+	EmploymentType string     `json:"employmentType" binding:""` // 工作类型
+	JobDescription string     `json:"jobDescription" binding:""` // 工作内容
+	Location       string     `json:"location" binding:""`       // 地点
+	StartDate      time.Time  `json:"startDate" binding:""`      // 开始日期
+	EndDate        *time.Time `json:"endDate" binding:""`        // 结束日期，为空表示至今
+	IsCurrent      bool       `json:"isCurrent" binding:""`      // 是否在职，为true时结束日期必须为空
+	IsPrimary      bool       `json:"isPrimary" binding:""`      // 是否为主要职位，每个用户最多一个
+	Position       int        `json:"position" binding:""`       // 排序位置，同一用户内从小到大排列，为0时不修改
+}
+
+// CreateWorkexperiencessBatchRequest request params
+type CreateWorkexperiencessBatchRequest struct {
+	Mode  string                         `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []CreateWorkexperiencesRequest `json:"items" binding:"required,min=1,max=100,dive"`      // items to be created
+}
+
+// UpdateWorkexperiencesBatchItem an item of the batch update, all fields of the record are replaced as UpdateByID does
+type UpdateWorkexperiencesBatchItem struct {
+	UpdateWorkexperiencesByIDRequest
+	Version int `json:"version" binding:"required"` // 版本号，GetByID返回的version，记录已被修改时该项失败
+}
+
+// UpdateWorkexperiencessBatchRequest request params, the id and the version of each item are required
+type UpdateWorkexperiencessBatchRequest struct {
+	Mode  string                           `json:"mode" binding:"omitempty,oneof=atomic bestEffort"` // atomic(default) or bestEffort
+	Items []UpdateWorkexperiencesBatchItem `json:"items" binding:"required,min=1,max=100,dive"`      // items to be updated
 }
 
 // WorkexperiencesObjDetail detail
 type WorkexperiencesObjDetail struct {
 	ID string `json:"id"` // convert to string id
 
-	UserID         int       `json:"userId"`         // 用户ID
-	Company        string    `json:"company"`        // 公司
-	Title          string    `json:"title"`          // 职位
-	EmploymentType string    `json:"employmentType"` // 工作类型
-	JobDescription string    `json:"jobDescription"` // 工作内容
-	Location       string    `json:"location"`       // 地点
-	StartDate      time.Time `json:"startDate"`      // 开始日期
-	EndDate        time.Time `json:"endDate"`        // 结束日期
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+	UserID         int        `json:"userId"`         // 用户ID
+	Company        string     `json:"company"`        // 公司
+	Title          string     `json:"title"`          // 职位
+	EmploymentType string     `json:"employmentType"` // 工作类型
+	JobDescription string     `json:"jobDescription"` // 工作内容
+	Location       string     `json:"location"`       // 地点
+	StartDate      time.Time  `json:"startDate"`      // 开始日期
+	EndDate        *time.Time `json:"endDate"`        // 结束日期，为空表示至今
+	IsCurrent      bool       `json:"isCurrent"`      // 是否在职
+	IsPrimary      bool       `json:"isPrimary"`      // 是否为主要职位
+	DurationMonths int        `json:"durationMonths"` // 任职时长(月)，在职的计算到当前
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	Position       int        `json:"position"` // 排序位置，同一用户内从小到大排列
+	Version        int        `json:"version"`
+
+	User *UsersObjDetail `json:"user,omitempty"` // 所属用户，expand=user时返回
 }
 
 // CreateWorkexperiencesRespond only for api docs
@@ -66,6 +97,14 @@ type UpdateWorkexperiencesByIDRespond struct {
 	Result
 }
 
+// PatchWorkexperiencesByIDRequest request params, only the fields present are changed, null clears a field
+type PatchWorkexperiencesByIDRequest map[string]interface{}
+
+// PatchWorkexperiencesByIDRespond only for api docs
+type PatchWorkexperiencesByIDRespond struct {
+	Result
+}
+
 // GetWorkexperiencesByIDRespond only for api docs
 type GetWorkexperiencesByIDRespond struct {
 	Code int    `json:"code"` // return code
@@ -121,6 +160,7 @@ type ListWorkexperiencessByIDsRespond struct {
 // ListWorkexperiencessRequest request params
 type ListWorkexperiencessRequest struct {
 	query.Params
+	Filter *Filter `json:"filter" binding:""` // conditions with all the operators and groups, and-ed with the columns
 }
 
 // ListWorkexperiencessRespond only for api docs
@@ -131,3 +171,34 @@ type ListWorkexperiencessRespond struct {
 		Workexperiencess []WorkexperiencesObjDetail `json:"workexperiencess"`
 	} `json:"data"` // return data
 }
+
+// ListWorkexperiencessByUserIDRespond only for api docs
+type ListWorkexperiencessByUserIDRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Workexperiencess []WorkexperiencesObjDetail `json:"workexperiencess"`
+		TotalYears       float64                    `json:"totalYears"` // 总工作年限，重叠的时间只计算一次
+	} `json:"data"` // return data
+}
+
+// ReorderWorkexperiencessRequest request params
+type ReorderWorkexperiencessRequest struct {
+	IDs []uint64 `json:"ids" binding:"min=1"` // all the ids of the user in the new order
+}
+
+// ReorderWorkexperiencessRespond only for api docs
+type ReorderWorkexperiencessRespond struct {
+	Result
+}
+
+// ListWorkexperiencessByCursorRespond only for api docs
+type ListWorkexperiencessByCursorRespond struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Workexperiencess []WorkexperiencesObjDetail `json:"workexperiencess"`
+		NextCursor       string                     `json:"nextCursor"` // cursor of the next page, empty means there is no next page
+		PrevCursor       string                     `json:"prevCursor"` // cursor of the previous page, empty means there is no previous page
+	} `json:"data"` // return data
+}
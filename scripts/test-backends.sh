@@ -0,0 +1,49 @@
+#!/bin/bash
+
+# run the behavioral tests of the daos against mongodb and postgresql in docker, the tests fail instead of
+# being skipped if a database is missing.
+
+mongoName="weaving_net-test-mongodb"
+postgresName="weaving_net-test-postgres"
+mongoPort=27018
+postgresPort=5433
+
+function stopDatabases() {
+    docker rm -f ${mongoName} ${postgresName} > /dev/null 2>&1
+}
+
+function checkResult() {
+    result=$1
+    if [ ${result} -ne 0 ]; then
+        exit ${result}
+    fi
+}
+
+stopDatabases
+trap stopDatabases EXIT
+
+# the transactions of the unit of work need a replica set
+docker run -d --name ${mongoName} -p ${mongoPort}:${mongoPort} mongo:6.0 --replSet rs0 --port ${mongoPort} --bind_ip_all
+checkResult $?
+docker run -d --name ${postgresName} -p ${postgresPort}:5432 -e POSTGRES_PASSWORD=123456 -e POSTGRES_DB=weaving_net postgres:14
+checkResult $?
+
+ready=false
+for i in $(seq 1 60); do
+    if docker exec ${mongoName} mongosh --port ${mongoPort} --quiet --eval \
+        "try { rs.status().ok } catch (e) { rs.initiate({_id: 'rs0', members: [{_id: 0, host: 'localhost:${mongoPort}'}]}).ok }" 2>/dev/null | grep -q 1 \
+        && docker exec ${postgresName} pg_isready -U postgres > /dev/null 2>&1; then
+        ready=true
+        break
+    fi
+    sleep 2
+done
+if [ "${ready}" != "true" ]; then
+    echo "the databases are not ready"
+    exit 1
+fi
+
+export WEAVING_NET_TEST_REQUIRE_BACKENDS=true
+export WEAVING_NET_TEST_MONGODB_DSN="mongodb://localhost:${mongoPort}/weaving_net"
+export WEAVING_NET_TEST_POSTGRES_DSN="host=localhost port=${postgresPort} user=postgres password=123456 dbname=weaving_net sslmode=disable"
+go test -count=1 -cover ./internal/dao/ ./internal/handler/